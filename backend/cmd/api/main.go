@@ -1,15 +1,18 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"fullstack-backend/internal/api/handlers"
 	"fullstack-backend/internal/api/routes"
 	"fullstack-backend/internal/config"
 	"fullstack-backend/internal/database"
+	"fullstack-backend/internal/models"
 	"fullstack-backend/internal/repository"
 	"fullstack-backend/internal/service"
 	"fullstack-backend/pkg/email"
+	"fullstack-backend/pkg/secret"
 
 	"github.com/gin-gonic/gin"
 )
@@ -46,6 +49,16 @@ func main() {
 	depositRepo := repository.NewDepositRepository(db)
 	withdrawalRepo := repository.NewWithdrawalRepository(db)
 	historyRepo := repository.NewBetReceiptHistoryRepository(db)
+	credentialAccessRepo := repository.NewCredentialAccessRepository(db)
+	feeScheduleRepo := repository.NewFeeScheduleRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	claimRepo := repository.NewClaimRepository(db)
+	disputeRepo := repository.NewDisputeRepository(db)
+	taskCodeRepo := repository.NewTaskCodeRepository(db)
+	periodRepo := repository.NewPeriodRepository(db)
+	withdrawalRequestRepo := repository.NewWithdrawalRequestRepository(db)
 
 	// Initialize email service
 	emailService := email.NewEmailService(
@@ -61,21 +74,83 @@ func main() {
 		log.Println("⚠️  Email service not configured - emails will be logged to console only")
 	}
 
+	// Keyring mã hóa tài khoản/mật khẩu đơn hàng (key mặc định chỉ được dùng khi APP_ENV=development)
+	if err := cfg.ValidateCredentialKeys(); err != nil {
+		log.Fatal("❌ ", err)
+	}
+	keyring, err := secret.ParseKeyring(cfg.CredentialKeys)
+	if err != nil {
+		log.Fatal("❌ Invalid CREDENTIAL_KEYS: ", err)
+	}
+	if cfg.CredentialKeys == config.DefaultCredentialKeys {
+		log.Println("⚠️  CREDENTIAL_KEYS not set - using development key (APP_ENV=development)")
+	}
+
+	if err := cfg.ValidateCredentialIndexKey(); err != nil {
+		log.Fatal("❌ ", err)
+	}
+	accountIndex, err := secret.ParseBlindIndex(cfg.CredentialIndexKey)
+	if err != nil {
+		log.Fatal("❌ Invalid CREDENTIAL_INDEX_KEY: ", err)
+	}
+	if cfg.CredentialIndexKey == config.DefaultCredentialIndexKey {
+		log.Println("⚠️  CREDENTIAL_INDEX_KEY not set - using development key (APP_ENV=development)")
+	}
+
+	sttScheme, err := models.ParseSTTScheme(cfg.STTScheme)
+	if err != nil {
+		log.Fatal("❌ Invalid STT_SCHEME: ", err)
+	}
+
+	duplicateRules, err := models.ParseDuplicateRules(cfg.DuplicateRules)
+	if err != nil {
+		log.Fatal("❌ Invalid DUPLICATE_RULES: ", err)
+	}
+
 	authService := service.NewAuthService(userRepo, passwordResetRepo, cfg.JWTSecret, emailService)
-	betReceiptService := service.NewBetReceiptService(betReceiptRepo, userRepo, walletRepo, historyRepo)
-	walletService := service.NewWalletService(walletRepo)
+	betReceiptService := service.NewBetReceiptService(betReceiptRepo, userRepo, walletRepo, historyRepo, credentialAccessRepo, feeScheduleRepo, commentRepo, taskCodeRepo, keyring, accountIndex, sttScheme, duplicateRules)
+	walletService := service.NewWalletService(walletRepo, userRepo)
 	depositService := service.NewDepositService(depositRepo, userRepo, walletRepo)
-	withdrawalService := service.NewWithdrawalService(withdrawalRepo, userRepo, walletRepo)
+	withdrawalService := service.NewWithdrawalService(withdrawalRepo, withdrawalRequestRepo, userRepo, walletRepo, notificationRepo)
 	historyService := service.NewBetReceiptHistoryService(historyRepo)
+	notificationService := service.NewNotificationService(notificationRepo)
+	feeScheduleService := service.NewFeeScheduleService(feeScheduleRepo)
+	attachmentService := service.NewAttachmentService(attachmentRepo, betReceiptRepo, historyRepo, cfg.AttachmentDir)
+	claimService := service.NewClaimService(betReceiptService, betReceiptRepo, userRepo, walletRepo, historyRepo, claimRepo, cfg.MaxOpenClaims)
+	taskCodeService := service.NewTaskCodeService(taskCodeRepo)
+	periodService := service.NewPeriodService(periodRepo)
+	disputeService := service.NewDisputeService(disputeRepo, betReceiptService, betReceiptRepo, userRepo, attachmentRepo, notificationRepo)
+	commentService := service.NewCommentService(commentRepo, betReceiptRepo, userRepo, notificationRepo, cfg.CommentEditWindow)
 
 	authHandler := handlers.NewAuthHandler(authService, cfg.JWTSecret)
 	betReceiptHandler := handlers.NewBetReceiptHandler(betReceiptService, cfg.JWTSecret)
-	walletHandler := handlers.NewWalletHandler(walletService)
+	walletHandler := handlers.NewWalletHandler(walletService, cfg.JWTSecret)
 	depositHandler := handlers.NewDepositHandler(depositService, cfg.JWTSecret)
 	withdrawalHandler := handlers.NewWithdrawalHandler(withdrawalService, cfg.JWTSecret)
 	historyHandler := handlers.NewBetReceiptHistoryHandler(historyService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService, cfg.JWTSecret)
+	feeScheduleHandler := handlers.NewFeeScheduleHandler(feeScheduleService, cfg.JWTSecret)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService, cfg.JWTSecret)
+	commentHandler := handlers.NewCommentHandler(commentService, cfg.JWTSecret)
+	claimHandler := handlers.NewClaimHandler(claimService, cfg.JWTSecret)
+	disputeHandler := handlers.NewDisputeHandler(disputeService, cfg.JWTSecret)
+	taskCodeHandler := handlers.NewTaskCodeHandler(taskCodeService, cfg.JWTSecret)
+	periodHandler := handlers.NewPeriodHandler(periodService, cfg.JWTSecret)
 	log.Println("✅ Layers initialized")
 
+	// Mã hóa tài khoản/mật khẩu còn plaintext hoặc đang dùng master key cũ
+	if _, err := betReceiptService.RotateCredentials(); err != nil {
+		log.Printf("❌ Failed to rotate bet receipt credentials: %v", err)
+	}
+	// Chỉ mục tài khoản (phát hiện đơn hàng trùng) cho đơn hàng tạo trước khi có chỉ mục
+	if _, err := betReceiptService.BackfillAccountHashes(); err != nil {
+		log.Printf("❌ Failed to backfill bet receipt account hashes: %v", err)
+	}
+
+	// 3.1. Job nền kiểm tra đơn hàng quá hạn (ghi lịch sử + gửi thông báo)
+	overdueChecker := service.NewOverdueChecker(betReceiptRepo, historyRepo, notificationRepo, userRepo, cfg.OverdueCheckInterval)
+	overdueChecker.Start(context.Background())
+
 	// 4. Setup router
 	router := gin.Default()
 
@@ -124,7 +199,7 @@ func main() {
 	router.Static("/uploads", "./uploads")
 	log.Println("✅ Static file serving enabled for /uploads")
 
-	routes.SetupRoutes(router, authHandler, betReceiptHandler, walletHandler, depositHandler, withdrawalHandler, historyHandler)
+	routes.SetupRoutes(router, authHandler, betReceiptHandler, walletHandler, depositHandler, withdrawalHandler, historyHandler, notificationHandler, feeScheduleHandler, attachmentHandler, commentHandler, claimHandler, disputeHandler, taskCodeHandler, periodHandler)
 	log.Println("✅ Routes configured")
 
 	// 5. Start server
@@ -136,17 +211,79 @@ func main() {
 	log.Println("   GET  http://localhost:" + cfg.Port + "/api/auth/users")
 	log.Println("   POST   http://localhost:" + cfg.Port + "/api/bet-receipts")
 	log.Println("   GET    http://localhost:" + cfg.Port + "/api/bet-receipts")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/bet-receipts?overdue=true&deadline_from=&deadline_to=")
 	log.Println("   GET    http://localhost:" + cfg.Port + "/api/bet-receipts/:id")
 	log.Println("   PUT    http://localhost:" + cfg.Port + "/api/bet-receipts/:id")
 	log.Println("   DELETE http://localhost:" + cfg.Port + "/api/bet-receipts/:id")
+	log.Println("   POST   http://localhost:" + cfg.Port + "/api/bet-receipts/:id/restore")
+	log.Println("   POST   http://localhost:" + cfg.Port + "/api/bet-receipts/:id/revert/:historyId")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/bet-receipts/trash")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/bet-receipts/duplicates?rule=")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/bet-receipts/pool")
+	log.Println("   POST   http://localhost:" + cfg.Port + "/api/bet-receipts/:id/claim")
+	log.Println("   POST   http://localhost:" + cfg.Port + "/api/bet-receipts/:id/release")
+	log.Println("   POST   http://localhost:" + cfg.Port + "/api/bet-receipts/:id/assign")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/bet-receipts/:id/claims")
 	log.Println("   PATCH  http://localhost:" + cfg.Port + "/api/bet-receipts/:id/status")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/bet-receipts/:id/allowed-transitions")
+	log.Println("   POST   http://localhost:" + cfg.Port + "/api/bet-receipts/:id/credentials/reveal")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/bet-receipts/:id/credentials/access-log")
+	log.Println("   POST   http://localhost:" + cfg.Port + "/api/bet-receipts/:id/attachments")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/bet-receipts/:id/attachments")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/bet-receipts/:id/attachments/:attachmentId")
+	log.Println("   DELETE http://localhost:" + cfg.Port + "/api/bet-receipts/:id/attachments/:attachmentId")
+	log.Println("   POST   http://localhost:" + cfg.Port + "/api/bet-receipts/:id/disputes")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/bet-receipts/:id/disputes")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/disputes?open=true&referee_id=&worker_id=")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/disputes/report?from=&to=")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/disputes/:id")
+	log.Println("   PUT    http://localhost:" + cfg.Port + "/api/disputes/:id/referee")
+	log.Println("   POST   http://localhost:" + cfg.Port + "/api/disputes/:id/resolve")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/task-codes?active=true")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/task-codes/stats?from=&to=")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/task-codes/:id")
+	log.Println("   POST   http://localhost:" + cfg.Port + "/api/task-codes")
+	log.Println("   PUT    http://localhost:" + cfg.Port + "/api/task-codes/:id")
+	log.Println("   DELETE http://localhost:" + cfg.Port + "/api/task-codes/:id")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/periods")
+	log.Println("   POST   http://localhost:" + cfg.Port + "/api/periods/close")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/periods/:month/snapshots")
+	log.Println("   POST   http://localhost:" + cfg.Port + "/api/bet-receipts/:id/comments")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/bet-receipts/:id/comments")
+	log.Println("   PUT    http://localhost:" + cfg.Port + "/api/bet-receipts/:id/comments/:commentId")
+	log.Println("   DELETE http://localhost:" + cfg.Port + "/api/bet-receipts/:id/comments/:commentId")
+	log.Println("   POST   http://localhost:" + cfg.Port + "/api/bet-receipts/bulk-status")
+	log.Println("   POST   http://localhost:" + cfg.Port + "/api/bet-receipts/quote")
+	log.Println("   POST   http://localhost:" + cfg.Port + "/api/bet-receipts/import?dry_run=true")
+	log.Println("   GET    http://localhost:" + cfg.Port + "/api/bet-receipts/export?format=xlsx|csv&group_by=month")
 	log.Println("   GET  http://localhost:" + cfg.Port + "/api/wallets")
+	log.Println("   GET  http://localhost:" + cfg.Port + "/api/wallets/export?format=xlsx|csv")
 	log.Println("   POST http://localhost:" + cfg.Port + "/api/wallets/recalculate-all")
 	log.Println("   POST http://localhost:" + cfg.Port + "/api/wallets/:user_id/recalculate")
+	log.Println("   GET  http://localhost:" + cfg.Port + "/api/wallets/:user_id/ledger?entry_type=...&from=...&to=...")
+	log.Println("   POST http://localhost:" + cfg.Port + "/api/wallets/:user_id/ledger/adjustments (admin)")
+	log.Println("   GET  http://localhost:" + cfg.Port + "/api/wallets/:user_id/statement?month=YYYY-MM")
 	log.Println("   POST http://localhost:" + cfg.Port + "/api/deposits")
+	log.Println("   GET  http://localhost:" + cfg.Port + "/api/deposits/export?format=xlsx|csv&group_by=month")
 	log.Println("   POST http://localhost:" + cfg.Port + "/api/withdrawals")
+	log.Println("   GET  http://localhost:" + cfg.Port + "/api/withdrawals/export?format=xlsx|csv&group_by=month")
+	log.Println("   POST http://localhost:" + cfg.Port + "/api/withdrawals/requests")
+	log.Println("   GET  http://localhost:" + cfg.Port + "/api/withdrawals/requests?status=pending&user_id=")
+	log.Println("   GET  http://localhost:" + cfg.Port + "/api/withdrawals/requests/:id")
+	log.Println("   POST http://localhost:" + cfg.Port + "/api/withdrawals/requests/:id/approve (admin)")
+	log.Println("   POST http://localhost:" + cfg.Port + "/api/withdrawals/requests/:id/reject (admin)")
+	log.Println("   POST http://localhost:" + cfg.Port + "/api/withdrawals/requests/:id/pay (admin)")
 	log.Println("   GET  http://localhost:" + cfg.Port + "/api/bet-receipt-history")
 	log.Println("   GET  http://localhost:" + cfg.Port + "/api/bet-receipt-history/:id")
+	log.Println("   GET   http://localhost:" + cfg.Port + "/api/notifications?unread=true")
+	log.Println("   POST  http://localhost:" + cfg.Port + "/api/notifications/read-all")
+	log.Println("   PATCH http://localhost:" + cfg.Port + "/api/notifications/:id/read")
+	log.Println("   GET   http://localhost:" + cfg.Port + "/api/fee-schedules")
+	log.Println("   GET   http://localhost:" + cfg.Port + "/api/fee-schedules/current")
+	log.Println("   GET   http://localhost:" + cfg.Port + "/api/fee-schedules/:version")
+	log.Println("   POST  http://localhost:" + cfg.Port + "/api/fee-schedules")
+	log.Println("   PUT   http://localhost:" + cfg.Port + "/api/fee-schedules/:version")
+	log.Println("   DELETE http://localhost:" + cfg.Port + "/api/fee-schedules/:version")
 
 	if err := router.Run(":" + cfg.Port); err != nil {
 		log.Fatal("❌ Failed to start server:", err)
@@ -0,0 +1,130 @@
+package main
+
+// CLI import đơn hàng từ file CSV/XLSX (cùng logic với POST /api/bet-receipts/import)
+//
+// Cách dùng (chạy từ thư mục backend/):
+//   go run ./cmd/import -file orders.xlsx                      # dry-run: chỉ kiểm tra, in lỗi theo dòng
+//   go run ./cmd/import -file orders.xlsx -commit -by admin@example.com
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"fullstack-backend/internal/config"
+	"fullstack-backend/internal/database"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"fullstack-backend/internal/service"
+	"fullstack-backend/pkg/secret"
+	"fullstack-backend/pkg/spreadsheet"
+)
+
+func main() {
+	filePath := flag.String("file", "", "Đường dẫn file .csv hoặc .xlsx")
+	commit := flag.Bool("commit", false, "Tạo đơn hàng (mặc định chỉ dry-run)")
+	performedByEmail := flag.String("by", "", "Email người thực hiện import (ghi vào lịch sử)")
+	flag.Parse()
+
+	if *filePath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	format, err := spreadsheet.DetectFormat(*filePath)
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+
+	file, err := os.Open(*filePath)
+	if err != nil {
+		log.Fatal("❌ Không mở được file: ", err)
+	}
+	rows, err := spreadsheet.ReadRows(file, format)
+	file.Close()
+	if err != nil {
+		log.Fatal("❌ Không đọc được file: ", err)
+	}
+
+	cfg := config.Load()
+	db, err := database.NewPostgresDB(cfg)
+	if err != nil {
+		log.Fatal("❌ Failed to connect database:", err)
+	}
+	defer db.Close()
+
+	if err := database.RunMigrations(db, "migrations"); err != nil {
+		log.Fatal("❌ Failed to run migrations: ", err)
+	}
+
+	if err := cfg.ValidateCredentialKeys(); err != nil {
+		log.Fatal("❌ ", err)
+	}
+	keyring, err := secret.ParseKeyring(cfg.CredentialKeys)
+	if err != nil {
+		log.Fatal("❌ CREDENTIAL_KEYS không hợp lệ: ", err)
+	}
+
+	if err := cfg.ValidateCredentialIndexKey(); err != nil {
+		log.Fatal("❌ ", err)
+	}
+	accountIndex, err := secret.ParseBlindIndex(cfg.CredentialIndexKey)
+	if err != nil {
+		log.Fatal("❌ CREDENTIAL_INDEX_KEY không hợp lệ: ", err)
+	}
+
+	sttScheme, err := models.ParseSTTScheme(cfg.STTScheme)
+	if err != nil {
+		log.Fatal("❌ STT_SCHEME không hợp lệ: ", err)
+	}
+
+	duplicateRules, err := models.ParseDuplicateRules(cfg.DuplicateRules)
+	if err != nil {
+		log.Fatal("❌ DUPLICATE_RULES không hợp lệ: ", err)
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	betReceiptService := service.NewBetReceiptService(
+		repository.NewBetReceiptRepository(db),
+		userRepo,
+		repository.NewWalletRepository(db),
+		repository.NewBetReceiptHistoryRepository(db),
+		repository.NewCredentialAccessRepository(db),
+		repository.NewFeeScheduleRepository(db),
+		nil,
+		repository.NewTaskCodeRepository(db),
+		keyring,
+		accountIndex,
+		sttScheme,
+		duplicateRules,
+	)
+
+	var performedBy *string
+	if *performedByEmail != "" {
+		user, err := userRepo.FindByEmail(*performedByEmail)
+		if err != nil || user == nil {
+			log.Fatal("❌ Không tìm thấy người dùng với email: ", *performedByEmail)
+		}
+		performedBy = &user.ID
+	}
+
+	report, importErr := betReceiptService.ImportBetReceipts(rows, !*commit, performedBy)
+	if report != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	}
+	if importErr != nil {
+		log.Fatal("❌ Import thất bại: ", importErr)
+	}
+	if report.DryRun && len(report.Errors) > 0 {
+		log.Printf("⚠️  Có %d lỗi trong file", len(report.Errors))
+		os.Exit(1)
+	}
+
+	if report.Committed {
+		log.Printf("✅ Đã import %d đơn hàng", len(report.Created))
+	} else {
+		log.Printf("✅ Dry-run: %d/%d dòng hợp lệ (chạy lại với -commit để tạo đơn hàng)", report.ValidRows, report.TotalRows)
+	}
+}
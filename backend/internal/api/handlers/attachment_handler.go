@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AttachmentHandler struct {
+	attachmentService *service.AttachmentService
+	jwtSecret         string
+}
+
+func NewAttachmentHandler(attachmentService *service.AttachmentService, jwtSecret string) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+		jwtSecret:         jwtSecret,
+	}
+}
+
+// UploadAttachment upload tệp đính kèm cho đơn hàng (admin hoặc người nhận kèo)
+// multipart/form-data: file (ảnh hoặc PDF), kind (proof|dispute|other, mặc định proof)
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Không tìm thấy file",
+		})
+		return
+	}
+
+	attachment, err := h.attachmentService.AddAttachment(c.Param("id"), claims.UserID, claims.Role, c.PostForm("kind"), file)
+	if err != nil {
+		log.Printf("❌ UPLOAD TỆP ĐÍNH KÈM THẤT BẠI: %v", err)
+		writeAttachmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Đã thêm tệp đính kèm",
+		"data":    attachment,
+	})
+}
+
+// GetAttachments lấy danh sách tệp đính kèm của đơn hàng (admin hoặc người nhận kèo)
+func (h *AttachmentHandler) GetAttachments(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.attachmentService.GetAttachments(c.Param("id"), claims.UserID, claims.Role)
+	if err != nil {
+		log.Printf("❌ LỖI LẤY TỆP ĐÍNH KÈM: %v", err)
+		writeAttachmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    attachments,
+	})
+}
+
+// DownloadAttachment tải tệp đính kèm (admin hoặc người nhận kèo)
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	attachment, path, err := h.attachmentService.GetAttachmentFile(c.Param("id"), c.Param("attachmentId"), claims.UserID, claims.Role)
+	if err != nil {
+		log.Printf("❌ LỖI TẢI TỆP ĐÍNH KÈM: %v", err)
+		writeAttachmentError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.FileAttachment(path, attachment.FileName)
+}
+
+// DeleteAttachment xóa tệp đính kèm (admin hoặc người đã upload)
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	if err := h.attachmentService.DeleteAttachment(c.Param("id"), c.Param("attachmentId"), claims.UserID, claims.Role); err != nil {
+		log.Printf("❌ XÓA TỆP ĐÍNH KÈM THẤT BẠI: %v", err)
+		writeAttachmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã xóa tệp đính kèm",
+	})
+}
+
+func writeAttachmentError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		status = http.StatusBadRequest
+	case errors.Is(err, service.ErrAttachmentAccessDenied):
+		status = http.StatusForbidden
+	case errors.Is(err, service.ErrBetReceiptNotFound), errors.Is(err, service.ErrAttachmentNotFound):
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
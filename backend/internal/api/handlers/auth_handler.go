@@ -2,20 +2,31 @@ package handlers
 
 // Xử lí đăng nhập đăng kí  trả về Json response
 import (
+	"errors"
 	"fullstack-backend/internal/models"
 	"fullstack-backend/internal/service"
+	"fullstack-backend/pkg/upload"
 	"fullstack-backend/pkg/utils"
 	"log"
 	"net/http"
 	"os"
-	"path/filepath"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// avatarUploadPolicy - Ảnh đại diện: JPEG, PNG, GIF tối đa 5MB, lưu trong thư mục public /uploads/avatars
+var avatarUploadPolicy = upload.Policy{
+	Dir:     "uploads/avatars",
+	MaxSize: 5 * 1024 * 1024,
+	AllowedTypes: map[string]string{
+		"image/jpeg": ".jpg",
+		"image/png":  ".png",
+		"image/gif":  ".gif",
+	},
+	TypeLabel: "ảnh (JPEG, PNG, GIF)",
+}
+
 type AuthHandler struct {
 	authService *service.AuthService
 	jwtSecret   string
@@ -169,32 +180,20 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 
 // GetAllUsers lấy danh sách tất cả users (chỉ role = 'user')
 func (h *AuthHandler) GetAllUsers(c *gin.Context) {
-	limitStr := c.DefaultQuery("limit", "1000")
-	offsetStr := c.DefaultQuery("offset", "0")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit < 1 {
-		limit = 1000
-	}
+	limit, offset, cursor := parsePageQuery(c, 1000)
 
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
-	}
-
-	users, err := h.authService.GetAllUsers(limit, offset)
+	users, page, err := h.authService.GetAllUsers(limit, offset, cursor)
 	if err != nil {
 		log.Printf("❌ Lỗi khi lấy danh sách users: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "Lỗi khi lấy danh sách users",
-		})
+		respondListError(c, err, "Lỗi khi lấy danh sách users")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    users,
+		"success":     true,
+		"data":        users,
+		"next_cursor": page.NextCursor,
+		"prev_cursor": page.PrevCursor,
 	})
 }
 
@@ -381,51 +380,18 @@ func (h *AuthHandler) UploadAvatar(c *gin.Context) {
 		return
 	}
 
-	// 3. Validate file type
-	allowedTypes := map[string]bool{
-		"image/jpeg": true,
-		"image/jpg":  true,
-		"image/png": true,
-		"image/gif": true,
-	}
-	if !allowedTypes[file.Header.Get("Content-Type")] {
-		log.Printf("❌ File type không hợp lệ: %s", file.Header.Get("Content-Type"))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "Chỉ chấp nhận file ảnh (JPEG, PNG, GIF)",
-		})
-		return
-	}
-
-	// 4. Validate file size (max 5MB)
-	maxSize := int64(5 * 1024 * 1024) // 5MB
-	if file.Size > maxSize {
-		log.Printf("❌ File quá lớn: %d bytes", file.Size)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "File ảnh không được vượt quá 5MB",
-		})
-		return
-	}
-
-	// 5. Tạo tên file unique (userID_timestamp.extension)
-	ext := filepath.Ext(file.Filename)
-	filename := claims.UserID + "_" + strconv.FormatInt(time.Now().Unix(), 10) + ext
-	uploadPath := "uploads/avatars"
-	
-	// Tạo thư mục nếu chưa tồn tại
-	if err := os.MkdirAll(uploadPath, 0755); err != nil {
-		log.Printf("❌ Lỗi tạo thư mục: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "Lỗi khi tạo thư mục lưu ảnh",
-		})
-		return
-	}
-
-	// 6. Lưu file
-	filePath := filepath.Join(uploadPath, filename)
-	if err := c.SaveUploadedFile(file, filePath); err != nil {
+	// 3-6. Kiểm tra loại file (JPEG, PNG, GIF), dung lượng (tối đa 5MB) và lưu file (userID_timestamp_ngẫu nhiên.extension)
+	saved, err := upload.Save(file, avatarUploadPolicy, claims.UserID)
+	if err != nil {
+		var uploadErr *upload.Error
+		if errors.As(err, &uploadErr) {
+			log.Printf("❌ File ảnh không hợp lệ: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   uploadErr.Message,
+			})
+			return
+		}
 		log.Printf("❌ Lỗi lưu file: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -435,13 +401,13 @@ func (h *AuthHandler) UploadAvatar(c *gin.Context) {
 	}
 
 	// 7. Tạo URL để trả về (relative path)
-	avatarURL := "/uploads/avatars/" + filename
+	avatarURL := "/uploads/avatars/" + saved.Name
 
 	// 8. Cập nhật avatar URL trong database
 	updatedUser, err := h.authService.UpdateAvatar(claims.UserID, avatarURL)
 	if err != nil {
 		// Xóa file nếu cập nhật database thất bại
-		os.Remove(filePath)
+		os.Remove(saved.Path)
 		errorMsg := err.Error()
 		log.Printf("❌ CẬP NHẬT AVATAR THẤT BẠI: %s", errorMsg)
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RevealCredentials trả về tài khoản/mật khẩu đã giải mã của đơn hàng
+// Chỉ admin hoặc người nhận kèo được xem, mọi lần yêu cầu đều được ghi nhật ký
+// Body (tùy chọn): {"reason": "..."}
+func (h *BetReceiptHandler) RevealCredentials(c *gin.Context) {
+	id := c.Param("id")
+
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	var req models.RevealCredentialsRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	credentials, err := h.betReceiptService.RevealCredentials(id, claims.UserID, claims.Role, &req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrBetReceiptNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrCredentialAccessDenied):
+			status = http.StatusForbidden
+		default:
+			log.Printf("❌ LỖI XEM TÀI KHOẢN/MẬT KHẨU ĐƠN HÀNG %s: %v", id, err)
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// Không cho trình duyệt/proxy lưu cache response chứa mật khẩu
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    credentials,
+	})
+}
+
+// GetCredentialAccessLog lấy nhật ký xem tài khoản/mật khẩu của đơn hàng (chỉ admin)
+func (h *BetReceiptHandler) GetCredentialAccessLog(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.jwtSecret); !ok {
+		return
+	}
+
+	entries, err := h.betReceiptService.GetCredentialAccessLog(c.Param("id"))
+	if err != nil {
+		log.Printf("❌ LỖI LẤY NHẬT KÝ XEM TÀI KHOẢN: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Lỗi khi lấy nhật ký xem tài khoản",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entries,
+	})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/service"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseForceQuery đọc query parameter force (true = vẫn lưu đơn hàng nghi trùng theo quy tắc cảnh báo)
+func parseForceQuery(c *gin.Context) (bool, error) {
+	force, err := strconv.ParseBool(c.DefaultQuery("force", "false"))
+	if err != nil {
+		return false, errors.New("Tham số force không hợp lệ (true hoặc false)")
+	}
+	return force, nil
+}
+
+// writeDuplicateError trả về 409 kèm danh sách đơn hàng trùng nếu err là *service.DuplicateError
+// requires_force = true: gửi lại với ?force=true để vẫn lưu
+func writeDuplicateError(c *gin.Context, err error) bool {
+	var dupErr *service.DuplicateError
+	if !errors.As(err, &dupErr) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"success":        false,
+		"error":          err.Error(),
+		"requires_force": dupErr.RequiresForce,
+		"duplicates":     dupErr.Matches,
+	})
+	return true
+}
+
+// GetDuplicateReport báo cáo các nhóm đơn hàng đang nghi trùng nhau (admin)
+// Query: rule (order_code | task_account_day, mặc định tất cả quy tắc đang bật)
+func (h *BetReceiptHandler) GetDuplicateReport(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.jwtSecret); !ok {
+		return
+	}
+
+	groups, err := h.betReceiptService.GetDuplicateReport(strings.TrimSpace(c.Query("rule")))
+	if err != nil {
+		log.Printf("❌ LỖI LẤY BÁO CÁO ĐƠN HÀNG TRÙNG: %v", err)
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Lỗi khi lấy báo cáo đơn hàng trùng",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    groups,
+		"total":   len(groups),
+	})
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseTimeQuery parse thời gian từ query parameter
+// Hỗ trợ định dạng "YYYY-MM-DD" và RFC3339
+// Với endOfDay = true và định dạng ngày, trả về 00:00 ngày hôm sau (dùng làm cận trên không bao gồm)
+func parseTimeQuery(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return &t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.New("Thời gian '" + value + "' không hợp lệ (định dạng YYYY-MM-DD hoặc RFC3339)")
+	}
+	return &t, nil
+}
+
+// parseBetReceiptFilter đọc các query parameter của GET /api/bet-receipts thành BetReceiptFilter
+// status: có thể lặp lại (?status=DONE&status=ĐỀN) hoặc phân tách bằng dấu phẩy
+// received_from/received_to, completed_from/completed_to, deadline_from/deadline_to: YYYY-MM-DD hoặc RFC3339
+// overdue: true = chỉ đơn quá hạn, false = chỉ đơn chưa quá hạn
+// task_code, order_code, q: tìm theo tiền tố (q áp dụng cho cả mã nhiệm vụ và mã đơn hàng)
+// sort_by: tên trường JSON, sort_order: asc | desc
+// cursor: next_cursor/prev_cursor từ response trước (thay cho offset)
+func parseBetReceiptFilter(c *gin.Context) (*models.BetReceiptFilter, error) {
+	filter := &models.BetReceiptFilter{
+		BetType:         c.Query("bet_type"),
+		Region:          strings.TrimSpace(c.Query("region")),
+		TaskCodePrefix:  strings.TrimSpace(c.Query("task_code")),
+		OrderCodePrefix: strings.TrimSpace(c.Query("order_code")),
+		CodePrefix:      strings.TrimSpace(c.Query("q")),
+		SortBy:          c.Query("sort_by"),
+	}
+
+	for _, value := range c.QueryArray("status") {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				filter.Statuses = append(filter.Statuses, status)
+			}
+		}
+	}
+
+	if userID := c.Query("user_id"); userID != "" {
+		filter.UserID = &userID
+	}
+
+	switch strings.ToLower(c.DefaultQuery("sort_order", "asc")) {
+	case "asc":
+		filter.SortDesc = false
+	case "desc":
+		filter.SortDesc = true
+	default:
+		return nil, errors.New("sort_order phải là 'asc' hoặc 'desc'")
+	}
+
+	var err error
+	if filter.ReceivedFrom, err = parseTimeQuery(c.Query("received_from"), false); err != nil {
+		return nil, err
+	}
+	if filter.ReceivedTo, err = parseTimeQuery(c.Query("received_to"), true); err != nil {
+		return nil, err
+	}
+	if filter.CompletedFrom, err = parseTimeQuery(c.Query("completed_from"), false); err != nil {
+		return nil, err
+	}
+	if filter.CompletedTo, err = parseTimeQuery(c.Query("completed_to"), true); err != nil {
+		return nil, err
+	}
+	if filter.DeadlineFrom, err = parseTimeQuery(c.Query("deadline_from"), false); err != nil {
+		return nil, err
+	}
+	if filter.DeadlineTo, err = parseTimeQuery(c.Query("deadline_to"), true); err != nil {
+		return nil, err
+	}
+
+	if overdueStr := c.Query("overdue"); overdueStr != "" {
+		overdue, err := strconv.ParseBool(overdueStr)
+		if err != nil {
+			return nil, errors.New("overdue phải là 'true' hoặc 'false'")
+		}
+		filter.Overdue = &overdue
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 {
+		limit = 100
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	filter.Limit = limit
+	filter.Offset = offset
+	filter.CursorToken = c.Query("cursor")
+
+	return filter, nil
+}
@@ -4,7 +4,6 @@ import (
 	"fullstack-backend/internal/service"
 	"log"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,33 +20,21 @@ func NewBetReceiptHistoryHandler(historyService *service.BetReceiptHistoryServic
 
 // GetAllHistories lấy tất cả lịch sử
 func (h *BetReceiptHistoryHandler) GetAllHistories(c *gin.Context) {
-	// Parse pagination parameters
-	limitStr := c.DefaultQuery("limit", "100")
-	offsetStr := c.DefaultQuery("offset", "0")
+	// Parse pagination parameters (limit, offset hoặc cursor)
+	limit, offset, cursor := parsePageQuery(c, 100)
 
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit < 1 {
-		limit = 100
-	}
-
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
-	}
-
-	histories, err := h.historyService.GetAllHistories(limit, offset)
+	histories, page, err := h.historyService.GetAllHistories(limit, offset, cursor)
 	if err != nil {
 		log.Printf("Handler - ❌ Lỗi lấy danh sách lịch sử: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "Lỗi khi lấy danh sách lịch sử: " + err.Error(),
-		})
+		respondListError(c, err, "Lỗi khi lấy danh sách lịch sử: "+err.Error())
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    histories,
+		"success":     true,
+		"data":        histories,
+		"next_cursor": page.NextCursor,
+		"prev_cursor": page.PrevCursor,
 	})
 }
 
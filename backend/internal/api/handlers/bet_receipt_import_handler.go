@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/service"
+	"fullstack-backend/pkg/spreadsheet"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportFileSize - Kích thước file import tối đa (10MB)
+const maxImportFileSize = 10 * 1024 * 1024
+
+// ImportBetReceipts import đơn hàng từ file CSV/XLSX (multipart field "file")
+// Mặc định dry_run=true: chỉ kiểm tra và trả về lỗi theo dòng
+// dry_run=false: tạo tất cả đơn hàng trong một transaction (có lỗi thì không tạo đơn nào)
+func (h *BetReceiptHandler) ImportBetReceipts(c *gin.Context) {
+	log.Println("=== BẮT ĐẦU IMPORT ĐƠN HÀNG ===")
+
+	claims, ok := requireAdmin(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "true"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "dry_run phải là true hoặc false",
+		})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Vui lòng chọn file để import (field 'file')",
+		})
+		return
+	}
+
+	if file.Size > maxImportFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "File quá lớn. Kích thước tối đa là 10MB",
+		})
+		return
+	}
+
+	format, err := spreadsheet.DetectFormat(file.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		log.Printf("❌ Lỗi mở file import: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Lỗi khi đọc file",
+		})
+		return
+	}
+	defer src.Close()
+
+	rows, err := spreadsheet.ReadRows(src, format)
+	if err != nil {
+		log.Printf("❌ Lỗi đọc file %s: %v", file.Filename, err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Không đọc được file: " + err.Error(),
+		})
+		return
+	}
+
+	log.Printf("📝 Import file %s (%s) - %d dòng, dry_run=%v, người import: %s", file.Filename, format, len(rows), dryRun, claims.UserID)
+
+	report, err := h.betReceiptService.ImportBetReceipts(rows, dryRun, &claims.UserID)
+	if err != nil {
+		log.Printf("❌ IMPORT ĐƠN HÀNG THẤT BẠI: %v", err)
+		status := http.StatusInternalServerError
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"data":    report,
+		})
+		return
+	}
+
+	log.Printf("✅ IMPORT ĐƠN HÀNG - %d/%d dòng hợp lệ, committed=%v", report.ValidRows, report.TotalRows, report.Committed)
+	log.Println("=== KẾT THÚC IMPORT ĐƠN HÀNG ===")
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QuoteBetReceipt báo giá "Công thực nhận" trước khi tạo đơn hàng (người dùng đã đăng nhập)
+// Body: bet_type, web_bet_amount_cny, status (DONE mặc định | HỦY BỎ), actual_received_cny (khi HỦY BỎ)
+// Trả về phí web, phí rút tiền, phí trung gian, công thực nhận (CNY) và quy đổi VND theo tỷ giá hiện tại
+func (h *BetReceiptHandler) QuoteBetReceipt(c *gin.Context) {
+	if _, ok := requireClaims(c, h.jwtSecret); !ok {
+		return
+	}
+
+	var req models.BetReceiptQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+		})
+		return
+	}
+
+	quote, err := h.betReceiptService.QuoteBetReceipt(&req)
+	if err != nil {
+		log.Printf("❌ BÁO GIÁ THẤT BẠI: %v", err)
+		var fieldErr *service.StatusFieldRequiredError
+		if errors.As(err, &fieldErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+				"field":   fieldErr.Field,
+			})
+			return
+		}
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Lỗi khi báo giá: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    quote,
+	})
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RevertBetReceipt hoàn tác đơn hàng về một phiên bản trong lịch sử (admin)
+// Query parameter "snapshot": "old" (mặc định, trạng thái trước thay đổi) hoặc "new" (trạng thái sau thay đổi)
+func (h *BetReceiptHandler) RevertBetReceipt(c *gin.Context) {
+	claims, ok := requireAdmin(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	betReceipt, err := h.betReceiptService.RevertBetReceipt(c.Param("id"), c.Param("historyId"), c.Query("snapshot"), &claims.UserID)
+	if err != nil {
+		log.Printf("❌ HOÀN TÁC ĐƠN HÀNG THẤT BẠI: %v", err)
+		var validationErr *service.ValidationError
+		switch {
+		case errors.Is(err, service.ErrBetReceiptNotFound), errors.Is(err, service.ErrHistoryNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		case errors.As(err, &validationErr):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã hoàn tác đơn hàng",
+		"data":    betReceipt,
+	})
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDeletedBetReceipts lấy danh sách đơn hàng trong thùng rác (admin)
+// Cùng query parameter (filter, sắp xếp, phân trang) với GET /api/bet-receipts
+func (h *BetReceiptHandler) GetDeletedBetReceipts(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.jwtSecret); !ok {
+		return
+	}
+
+	filter, err := parseBetReceiptFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	betReceipts, total, page, err := h.betReceiptService.GetDeletedBetReceipts(filter)
+	if err != nil {
+		log.Printf("❌ LỖI LẤY THÙNG RÁC ĐƠN HÀNG: %v", err)
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Lỗi khi lấy danh sách đơn hàng đã xóa",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"data":        betReceipts,
+		"total":       total,
+		"limit":       filter.Limit,
+		"offset":      filter.Offset,
+		"next_cursor": page.NextCursor,
+		"prev_cursor": page.PrevCursor,
+	})
+}
+
+// RestoreBetReceipt khôi phục đơn hàng từ thùng rác (admin), wallet được tính lại như trước khi xóa
+func (h *BetReceiptHandler) RestoreBetReceipt(c *gin.Context) {
+	claims, ok := requireAdmin(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	betReceipt, err := h.betReceiptService.RestoreBetReceipt(c.Param("id"), &claims.UserID)
+	if err != nil {
+		log.Printf("❌ KHÔI PHỤC ĐƠN HÀNG THẤT BẠI: %v", err)
+		if errors.Is(err, service.ErrBetReceiptNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Không tìm thấy đơn hàng trong thùng rác",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã khôi phục đơn hàng",
+		"data":    betReceipt,
+	})
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ClaimHandler struct {
+	claimService *service.ClaimService
+	jwtSecret    string
+}
+
+func NewClaimHandler(claimService *service.ClaimService, jwtSecret string) *ClaimHandler {
+	return &ClaimHandler{
+		claimService: claimService,
+		jwtSecret:    jwtSecret,
+	}
+}
+
+// GetPool lấy danh sách đơn hàng mới chưa có người nhận (pool)
+// Cùng query parameter (filter, sắp xếp, phân trang) với GET /api/bet-receipts
+func (h *ClaimHandler) GetPool(c *gin.Context) {
+	if _, ok := requireClaims(c, h.jwtSecret); !ok {
+		return
+	}
+
+	filter, err := parseBetReceiptFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	betReceipts, total, page, err := h.claimService.GetPool(filter)
+	if err != nil {
+		log.Printf("❌ LỖI LẤY POOL ĐƠN HÀNG: %v", err)
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Lỗi khi lấy danh sách đơn hàng chưa có người nhận",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"data":        betReceipts,
+		"total":       total,
+		"limit":       filter.Limit,
+		"offset":      filter.Offset,
+		"next_cursor": page.NextCursor,
+		"prev_cursor": page.PrevCursor,
+	})
+}
+
+// ClaimBetReceipt người nhận kèo tự nhận đơn hàng trong pool (ai nhận trước được trước)
+func (h *ClaimHandler) ClaimBetReceipt(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	betReceipt, err := h.claimService.ClaimBetReceipt(c.Param("id"), claims.UserID, claims.Role)
+	if err != nil {
+		log.Printf("❌ NHẬN ĐƠN HÀNG THẤT BẠI: %v", err)
+		writeClaimError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã nhận đơn hàng",
+		"data":    betReceipt,
+	})
+}
+
+// ReleaseBetReceipt người nhận kèo trả đơn hàng đã nhận về pool (chỉ khi còn "Đơn hàng mới")
+func (h *ClaimHandler) ReleaseBetReceipt(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	betReceipt, err := h.claimService.ReleaseBetReceipt(c.Param("id"), claims.UserID)
+	if err != nil {
+		log.Printf("❌ TRẢ ĐƠN HÀNG THẤT BẠI: %v", err)
+		writeClaimError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã trả đơn hàng về pool",
+		"data":    betReceipt,
+	})
+}
+
+// AssignBetReceipt admin giao/giao lại đơn hàng (body: user_id, null = thu hồi về pool)
+func (h *ClaimHandler) AssignBetReceipt(c *gin.Context) {
+	claims, ok := requireAdmin(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	var req models.AssignBetReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+		})
+		return
+	}
+	userID := ""
+	if req.UserID != nil {
+		userID = *req.UserID
+	}
+
+	betReceipt, err := h.claimService.AssignBetReceipt(c.Param("id"), userID, &claims.UserID)
+	if err != nil {
+		log.Printf("❌ GIAO ĐƠN HÀNG THẤT BẠI: %v", err)
+		writeClaimError(c, err)
+		return
+	}
+
+	message := "Đã giao đơn hàng"
+	if userID == "" {
+		message = "Đã thu hồi đơn hàng về pool"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    betReceipt,
+	})
+}
+
+// GetClaimHistory lấy lịch sử nhận/trả/giao của đơn hàng (admin hoặc người đang nhận đơn)
+func (h *ClaimHandler) GetClaimHistory(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	history, err := h.claimService.GetClaimHistory(c.Param("id"), claims.UserID, claims.Role)
+	if err != nil {
+		log.Printf("❌ LỖI LẤY LỊCH SỬ NHẬN ĐƠN: %v", err)
+		writeClaimError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    history,
+	})
+}
+
+func writeClaimError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		status = http.StatusBadRequest
+	case errors.Is(err, service.ErrClaimAccessDenied):
+		status = http.StatusForbidden
+	case errors.Is(err, service.ErrBetReceiptNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrBetReceiptAlreadyClaimed):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommentHandler struct {
+	commentService *service.CommentService
+	jwtSecret      string
+}
+
+func NewCommentHandler(commentService *service.CommentService, jwtSecret string) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+		jwtSecret:      jwtSecret,
+	}
+}
+
+// AddComment thêm bình luận vào đơn hàng (admin hoặc người nhận kèo)
+// Body: content, mentioned_user_id (tùy chọn), internal (ghi chú nội bộ, chỉ admin)
+func (h *CommentHandler) AddComment(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	var req models.CreateBetReceiptCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+		})
+		return
+	}
+
+	comment, err := h.commentService.AddComment(c.Param("id"), claims.UserID, claims.Role, &req)
+	if err != nil {
+		log.Printf("❌ THÊM BÌNH LUẬN THẤT BẠI: %v", err)
+		writeCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Đã thêm bình luận",
+		"data":    comment,
+	})
+}
+
+// GetComments lấy bình luận của đơn hàng (admin hoặc người nhận kèo)
+func (h *CommentHandler) GetComments(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	comments, err := h.commentService.GetComments(c.Param("id"), claims.UserID, claims.Role)
+	if err != nil {
+		log.Printf("❌ LỖI LẤY BÌNH LUẬN: %v", err)
+		writeCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    comments,
+	})
+}
+
+// UpdateComment sửa bình luận (chỉ người viết, trong thời hạn sửa)
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateBetReceiptCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+		})
+		return
+	}
+
+	comment, err := h.commentService.UpdateComment(c.Param("id"), c.Param("commentId"), claims.UserID, claims.Role, &req)
+	if err != nil {
+		log.Printf("❌ SỬA BÌNH LUẬN THẤT BẠI: %v", err)
+		writeCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã sửa bình luận",
+		"data":    comment,
+	})
+}
+
+// DeleteComment xóa bình luận (admin, hoặc người viết trong thời hạn sửa)
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	if err := h.commentService.DeleteComment(c.Param("id"), c.Param("commentId"), claims.UserID, claims.Role); err != nil {
+		log.Printf("❌ XÓA BÌNH LUẬN THẤT BẠI: %v", err)
+		writeCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã xóa bình luận",
+	})
+}
+
+func writeCommentError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		status = http.StatusBadRequest
+	case errors.Is(err, service.ErrCommentAccessDenied):
+		status = http.StatusForbidden
+	case errors.Is(err, service.ErrBetReceiptNotFound), errors.Is(err, service.ErrCommentNotFound):
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
@@ -39,7 +39,7 @@ func (h *DepositHandler) CreateDeposit(c *gin.Context) {
 		return
 	}
 
-	log.Printf("📝 Thông tin nạp tiền - Tên người dùng: %s, Số tiền VND: %.2f", req.UserName, req.AmountVND)
+	log.Printf("📝 Thông tin nạp tiền - Tên người dùng: %s, Số tiền VND: %s", req.UserName, req.AmountVND)
 
 	// Kiểm tra quyền admin (từ JWT token)
 	authHeader := c.GetHeader("Authorization")
@@ -84,7 +84,7 @@ func (h *DepositHandler) CreateDeposit(c *gin.Context) {
 		return
 	}
 
-	log.Printf("✅ NẠP TIỀN THÀNH CÔNG - ID: %s, UserID: %s, AmountVND: %.2f",
+	log.Printf("✅ NẠP TIỀN THÀNH CÔNG - ID: %s, UserID: %s, AmountVND: %s",
 		deposit.ID, deposit.UserID, deposit.AmountVND)
 	log.Println("=== KẾT THÚC XỬ LÝ NẠP TIỀN ===\n")
 
@@ -127,14 +127,12 @@ func (h *DepositHandler) GetAllDeposits(c *gin.Context) {
 		return
 	}
 
-	// Gọi service để lấy danh sách
-	deposits, err := h.depositService.GetAllDeposits()
+	// Gọi service để lấy danh sách (không truyền limit/cursor thì lấy tất cả)
+	limit, offset, cursor := parsePageQuery(c, 0)
+	deposits, page, err := h.depositService.GetAllDeposits(limit, offset, cursor)
 	if err != nil {
 		log.Printf("❌ LỖI LẤY DANH SÁCH NẠP TIỀN: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "Lỗi khi lấy danh sách lịch sử nạp tiền",
-		})
+		respondListError(c, err, "Lỗi khi lấy danh sách lịch sử nạp tiền")
 		return
 	}
 
@@ -142,7 +140,9 @@ func (h *DepositHandler) GetAllDeposits(c *gin.Context) {
 	log.Println("=== KẾT THÚC LẤY DANH SÁCH LỊCH SỬ NẠP TIỀN ===\n")
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    deposits,
+		"success":     true,
+		"data":        deposits,
+		"next_cursor": page.NextCursor,
+		"prev_cursor": page.PrevCursor,
 	})
 }
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/service"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DisputeHandler struct {
+	disputeService *service.DisputeService
+	jwtSecret      string
+}
+
+func NewDisputeHandler(disputeService *service.DisputeService, jwtSecret string) *DisputeHandler {
+	return &DisputeHandler{
+		disputeService: disputeService,
+		jwtSecret:      jwtSecret,
+	}
+}
+
+// OpenDispute mở tranh chấp và chuyển đơn hàng sang "CHỜ TRỌNG TÀI" (admin hoặc người nhận kèo)
+func (h *DisputeHandler) OpenDispute(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	var req models.OpenDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+		})
+		return
+	}
+
+	dispute, err := h.disputeService.OpenDispute(c.Param("id"), claims.UserID, claims.Role, &req)
+	if err != nil {
+		log.Printf("❌ MỞ TRANH CHẤP THẤT BẠI: %v", err)
+		writeDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Đã mở tranh chấp, đơn hàng chuyển sang " + models.BetReceiptStatusWaitingRef,
+		"data":    dispute,
+	})
+}
+
+// GetBetReceiptDisputes lấy các tranh chấp của đơn hàng (admin hoặc người nhận kèo)
+func (h *DisputeHandler) GetBetReceiptDisputes(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	disputes, err := h.disputeService.GetBetReceiptDisputes(c.Param("id"), claims.UserID, claims.Role)
+	if err != nil {
+		log.Printf("❌ LỖI LẤY TRANH CHẤP CỦA ĐƠN HÀNG: %v", err)
+		writeDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    disputes,
+	})
+}
+
+// GetDisputes lấy danh sách tranh chấp (admin)
+// Query: open (true = đang mở, false = đã quyết định), referee_id, worker_id
+func (h *DisputeHandler) GetDisputes(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.jwtSecret); !ok {
+		return
+	}
+
+	filter := &models.DisputeFilter{}
+	if openStr := c.Query("open"); openStr != "" {
+		open, err := strconv.ParseBool(openStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Tham số open không hợp lệ (true hoặc false)",
+			})
+			return
+		}
+		filter.Open = &open
+	}
+	if refereeID := strings.TrimSpace(c.Query("referee_id")); refereeID != "" {
+		filter.RefereeID = &refereeID
+	}
+	if workerID := strings.TrimSpace(c.Query("worker_id")); workerID != "" {
+		filter.WorkerID = &workerID
+	}
+
+	disputes, err := h.disputeService.GetDisputes(filter)
+	if err != nil {
+		log.Printf("❌ LỖI LẤY DANH SÁCH TRANH CHẤP: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Lỗi khi lấy danh sách tranh chấp",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    disputes,
+		"total":   len(disputes),
+	})
+}
+
+// GetWorkerReport thống kê tranh chấp theo người nhận kèo và thời gian xử lý trung bình (admin)
+// Query: from, to (YYYY-MM-DD hoặc RFC3339, lọc theo thời điểm mở tranh chấp)
+func (h *DisputeHandler) GetWorkerReport(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.jwtSecret); !ok {
+		return
+	}
+
+	from, err := parseTimeQuery(c.Query("from"), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	to, err := parseTimeQuery(c.Query("to"), true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	report, err := h.disputeService.GetWorkerReport(from, to)
+	if err != nil {
+		log.Printf("❌ LỖI THỐNG KÊ TRANH CHẤP: %v", err)
+		writeDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// GetDispute lấy chi tiết tranh chấp kèm tệp bằng chứng (admin hoặc người nhận kèo của tranh chấp)
+func (h *DisputeHandler) GetDispute(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	dispute, err := h.disputeService.GetDispute(c.Param("id"), claims.UserID, claims.Role)
+	if err != nil {
+		log.Printf("❌ LỖI LẤY TRANH CHẤP: %v", err)
+		writeDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dispute,
+	})
+}
+
+// AssignReferee giao trọng tài cho tranh chấp đang mở (admin)
+func (h *DisputeHandler) AssignReferee(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.jwtSecret); !ok {
+		return
+	}
+
+	var req models.AssignRefereeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+		})
+		return
+	}
+
+	dispute, err := h.disputeService.AssignReferee(c.Param("id"), req.RefereeID)
+	if err != nil {
+		log.Printf("❌ GIAO TRỌNG TÀI THẤT BẠI: %v", err)
+		writeDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã giao trọng tài",
+		"data":    dispute,
+	})
+}
+
+// ResolveDispute trọng tài quyết định tranh chấp, đơn hàng chuyển sang status theo quyết định
+func (h *DisputeHandler) ResolveDispute(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	var req models.ResolveDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+		})
+		return
+	}
+
+	dispute, err := h.disputeService.ResolveDispute(c.Param("id"), claims.UserID, claims.Role, &req)
+	if err != nil {
+		log.Printf("❌ QUYẾT ĐỊNH TRANH CHẤP THẤT BẠI: %v", err)
+		writeDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã quyết định tranh chấp: " + req.Decision,
+		"data":    dispute,
+	})
+}
+
+func writeDisputeError(c *gin.Context, err error) {
+	// Chuyển status không hợp lệ -> 409, kèm danh sách status được phép (giống PUT /bet-receipts/:id/status)
+	var transitionErr *service.StatusTransitionError
+	if errors.As(err, &transitionErr) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"from":    transitionErr.From,
+			"to":      transitionErr.To,
+			"allowed": models.GetAllowedStatusTransitions(transitionErr.From),
+		})
+		return
+	}
+	var fieldErr *service.StatusFieldRequiredError
+	if errors.As(err, &fieldErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"field":   fieldErr.Field,
+		})
+		return
+	}
+
+	status := http.StatusInternalServerError
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		status = http.StatusBadRequest
+	case errors.Is(err, service.ErrDisputeAccessDenied), errors.Is(err, service.ErrDisputeFinalStatusAdminOnly):
+		status = http.StatusForbidden
+	case errors.Is(err, service.ErrDisputeNotFound), errors.Is(err, service.ErrBetReceiptNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrDisputeAlreadyOpen), errors.Is(err, service.ErrDisputeClosed):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
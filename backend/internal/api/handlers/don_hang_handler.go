@@ -2,12 +2,13 @@ package handlers
 
 // Xử lý các request liên quan đến đơn hàng (thông tin nhận kèo)
 import (
+	"errors"
 	"fullstack-backend/internal/models"
 	"fullstack-backend/internal/service"
+	"fullstack-backend/pkg/money"
 	"fullstack-backend/pkg/utils"
 	"log"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
@@ -75,12 +76,26 @@ func (h *BetReceiptHandler) CreateBetReceipt(c *gin.Context) {
 	// TODO: Kiểm tra role là admin (có thể cần thêm middleware)
 	log.Printf("🔍 Người tạo đơn hàng - User ID: %s", claims.UserID)
 
+	force, err := parseForceQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
 	// Gọi service để xử lý logic
-	betReceipt, err := h.betReceiptService.CreateBetReceipt(&req)
+	betReceipt, err := h.betReceiptService.CreateBetReceipt(&req, force)
 	if err != nil {
 		errorMsg := err.Error()
 		log.Printf("❌ TẠO ĐƠN HÀNG THẤT BẠI: %s", errorMsg)
 
+		// Đơn hàng trùng -> 409, kèm danh sách đơn hàng trùng
+		if writeDuplicateError(c, err) {
+			return
+		}
+
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   errorMsg,
@@ -98,36 +113,33 @@ func (h *BetReceiptHandler) CreateBetReceipt(c *gin.Context) {
 	})
 }
 
-// GetAllBetReceipts lấy danh sách tất cả đơn hàng
+// GetAllBetReceipts lấy danh sách đơn hàng
+// Hỗ trợ lọc (status, bet_type, user_id, region, khoảng thời gian nhận/hoàn thành, tiền tố mã),
+// sắp xếp (sort_by, sort_order) và trả về tổng số đơn hàng khớp filter (total)
 func (h *BetReceiptHandler) GetAllBetReceipts(c *gin.Context) {
 	log.Println("=== BẮT ĐẦU LẤY DANH SÁCH ĐƠN HÀNG ===")
 
 	// Parse query parameters
-	limitStr := c.DefaultQuery("limit", "100")
-	offsetStr := c.DefaultQuery("offset", "0")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit < 1 {
-		limit = 100
-	}
-
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
+	filter, err := parseBetReceiptFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
 	}
 
 	// Lấy user_id từ JWT token
-	var userID *string
 	authHeader := c.GetHeader("Authorization")
 	if authHeader != "" {
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString != authHeader {
 			claims, err := utils.ValidateJWT(tokenString, h.jwtSecret)
 			if err == nil {
-				// Nếu role là "admin", không filter (userID = nil) để thấy tất cả
-				// Nếu role là "user", filter theo user_id để chỉ thấy của mình
+				// Nếu role là "admin", không ép filter (có thể lọc theo user_id trong query) để thấy tất cả
+				// Nếu role là "user", luôn filter theo user_id để chỉ thấy của mình
 				if claims.Role != "admin" {
-					userID = &claims.UserID
+					filter.UserID = &claims.UserID
 					log.Printf("🔍 User role - Filtering by user_id: %s (role: %s)", claims.UserID, claims.Role)
 				} else {
 					log.Printf("🔍 Admin role - Showing all receipts (user_id: %s, role: %s)", claims.UserID, claims.Role)
@@ -143,9 +155,17 @@ func (h *BetReceiptHandler) GetAllBetReceipts(c *gin.Context) {
 	}
 
 	// Gọi service
-	betReceipts, err := h.betReceiptService.GetAllBetReceipts(limit, offset, userID)
+	betReceipts, total, page, err := h.betReceiptService.GetAllBetReceipts(filter)
 	if err != nil {
 		log.Printf("❌ LỖI LẤY DANH SÁCH ĐƠN HÀNG: %v", err)
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Lỗi khi lấy danh sách đơn hàng",
@@ -153,7 +173,7 @@ func (h *BetReceiptHandler) GetAllBetReceipts(c *gin.Context) {
 		return
 	}
 
-	log.Printf("✅ LẤY DANH SÁCH ĐƠN HÀNG THÀNH CÔNG - Số lượng: %d", len(betReceipts))
+	log.Printf("✅ LẤY DANH SÁCH ĐƠN HÀNG THÀNH CÔNG - Số lượng: %d / %d", len(betReceipts), total)
 	if len(betReceipts) > 0 {
 		log.Printf("🔍 Mẫu dữ liệu đầu tiên - ID: %s, STT: %d, UserID: %s, UserName: %s",
 			betReceipts[0].ID, betReceipts[0].STT, betReceipts[0].UserID, betReceipts[0].UserName)
@@ -162,16 +182,23 @@ func (h *BetReceiptHandler) GetAllBetReceipts(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    betReceipts,
+		"data":        betReceipts,
+		"total":       total,
+		"limit":       filter.Limit,
+		"offset":      filter.Offset,
+		"next_cursor": page.NextCursor,
+		"prev_cursor": page.PrevCursor,
 	})
 }
 
 // GetBetReceiptByID lấy thông tin đơn hàng theo ID
+// Có JWT token: kèm bình luận (ghi chú nội bộ chỉ trả về cho admin)
 func (h *BetReceiptHandler) GetBetReceiptByID(c *gin.Context) {
 	id := c.Param("id")
 	log.Printf("=== BẮT ĐẦU LẤY ĐƠN HÀNG THEO ID: %s ===", id)
 
-	betReceipt, err := h.betReceiptService.GetBetReceiptByID(id)
+	claims := optionalClaims(c, h.jwtSecret)
+	betReceipt, err := h.betReceiptService.GetBetReceiptByID(id, claims != nil, claims != nil && claims.Role == "admin")
 	if err != nil {
 		log.Printf("❌ LỖI LẤY ĐƠN HÀNG: %v", err)
 		c.JSON(http.StatusNotFound, gin.H{
@@ -246,6 +273,30 @@ func (h *BetReceiptHandler) UpdateBetReceiptStatus(c *gin.Context) {
 		errorMsg := err.Error()
 		log.Printf("❌ CẬP NHẬT STATUS THẤT BẠI: %s", errorMsg)
 
+		// Chuyển status không hợp lệ -> 409, kèm danh sách status được phép
+		var transitionErr *service.StatusTransitionError
+		if errors.As(err, &transitionErr) && models.IsValidBetReceiptStatus(transitionErr.To) {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   errorMsg,
+				"from":    transitionErr.From,
+				"to":      transitionErr.To,
+				"allowed": models.GetAllowedStatusTransitions(transitionErr.From),
+			})
+			return
+		}
+
+		// Thiếu trường bắt buộc -> 400, kèm tên trường
+		var fieldErr *service.StatusFieldRequiredError
+		if errors.As(err, &fieldErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   errorMsg,
+				"field":   fieldErr.Field,
+			})
+			return
+		}
+
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   errorMsg,
@@ -253,7 +304,7 @@ func (h *BetReceiptHandler) UpdateBetReceiptStatus(c *gin.Context) {
 		return
 	}
 
-	log.Printf("✅ CẬP NHẬT STATUS THÀNH CÔNG - ID: %s, Status: %s, Công thực nhận: %.2f",
+	log.Printf("✅ CẬP NHẬT STATUS THÀNH CÔNG - ID: %s, Status: %s, Công thực nhận: %s",
 		betReceipt.ID, betReceipt.Status, betReceipt.ActualAmountCNY)
 	log.Println("=== KẾT THÚC CẬP NHẬT STATUS ===\n")
 
@@ -313,12 +364,26 @@ func (h *BetReceiptHandler) UpdateBetReceipt(c *gin.Context) {
 
 	log.Printf("🔍 Người cập nhật đơn hàng - User ID: %s", claims.UserID)
 
+	force, err := parseForceQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
 	// Gọi service để xử lý logic (truyền userID để ghi log)
-	betReceipt, err := h.betReceiptService.UpdateBetReceipt(id, &req, &claims.UserID)
+	betReceipt, err := h.betReceiptService.UpdateBetReceipt(id, &req, &claims.UserID, force)
 	if err != nil {
 		errorMsg := err.Error()
 		log.Printf("❌ CẬP NHẬT ĐƠN HÀNG THẤT BẠI: %s", errorMsg)
 
+		// Đơn hàng trùng -> 409, kèm danh sách đơn hàng trùng
+		if writeDuplicateError(c, err) {
+			return
+		}
+
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   errorMsg,
@@ -377,7 +442,11 @@ func (h *BetReceiptHandler) DeleteBetReceipt(c *gin.Context) {
 		errorMsg := err.Error()
 		log.Printf("❌ XÓA ĐƠN HÀNG THẤT BẠI: %s", errorMsg)
 
-		c.JSON(http.StatusBadRequest, gin.H{
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, service.ErrBetReceiptNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{
 			"success": false,
 			"error":   errorMsg,
 		})
@@ -390,7 +459,7 @@ func (h *BetReceiptHandler) DeleteBetReceipt(c *gin.Context) {
 	// Trả response thành công
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Đã xóa đơn hàng thành công",
+		"message": "Đã chuyển đơn hàng vào thùng rác (có thể khôi phục)",
 	})
 }
 
@@ -431,7 +500,7 @@ func (h *BetReceiptHandler) UpdateExchangeRateForProcessedOrders(c *gin.Context)
 
 	// Parse request body
 	var req struct {
-		ExchangeRate float64 `json:"exchange_rate" binding:"required"`
+		ExchangeRate money.Rate `json:"exchange_rate" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -452,7 +521,7 @@ func (h *BetReceiptHandler) UpdateExchangeRateForProcessedOrders(c *gin.Context)
 		return
 	}
 
-	log.Printf("📝 Tỷ giá mới: %.2f", req.ExchangeRate)
+	log.Printf("📝 Tỷ giá mới: %s", req.ExchangeRate)
 
 	// Gọi service để cập nhật tỷ giá
 	if err := h.betReceiptService.UpdateExchangeRateForProcessedOrders(req.ExchangeRate); err != nil {
@@ -518,7 +587,7 @@ func (h *BetReceiptHandler) GetCurrentExchangeRate(c *gin.Context) {
 		return
 	}
 
-	log.Printf("✅ LẤY TỶ GIÁ THÀNH CÔNG: %.2f", exchangeRate)
+	log.Printf("✅ LẤY TỶ GIÁ THÀNH CÔNG: %s", exchangeRate)
 	log.Println("=== KẾT THÚC LẤY TỶ GIÁ ===\n")
 
 	c.JSON(http.StatusOK, gin.H{
@@ -563,7 +632,7 @@ func (h *BetReceiptHandler) RecalculateActualAmountCNY(c *gin.Context) {
 	log.Printf("🔍 Người tính lại tệ - User ID: %s", claims.UserID)
 
 	// Gọi service để tính lại tệ
-	betReceipt, err := h.betReceiptService.RecalculateActualAmountCNY(id)
+	betReceipt, err := h.betReceiptService.RecalculateActualAmountCNY(id, &claims.UserID)
 	if err != nil {
 		errorMsg := err.Error()
 		log.Printf("❌ TÍNH LẠI TỆ THẤT BẠI: %s", errorMsg)
@@ -575,7 +644,7 @@ func (h *BetReceiptHandler) RecalculateActualAmountCNY(c *gin.Context) {
 		return
 	}
 
-	log.Printf("✅ TÍNH LẠI TỆ THÀNH CÔNG - ID: %s, Công thực nhận: %.2f",
+	log.Printf("✅ TÍNH LẠI TỆ THÀNH CÔNG - ID: %s, Công thực nhận: %s",
 		betReceipt.ID, betReceipt.ActualAmountCNY)
 	log.Println("=== KẾT THÚC TÍNH LẠI TỆ ===\n")
 
@@ -667,7 +736,7 @@ func (h *BetReceiptHandler) GetMonthlyTotalByUserID(c *gin.Context) {
 		return
 	}
 
-	log.Printf("✅ TÍNH TỔNG THEO THÁNG THÀNH CÔNG - User: %s, Tháng: %s, Tổng: %.2f ¥", userID, month, total)
+	log.Printf("✅ TÍNH TỔNG THEO THÁNG THÀNH CÔNG - User: %s, Tháng: %s, Tổng: %s ¥", userID, month, total)
 	log.Println("=== KẾT THÚC TÍNH TỔNG THEO THÁNG ===\n")
 
 	c.JSON(http.StatusOK, gin.H{
@@ -679,3 +748,79 @@ func (h *BetReceiptHandler) GetMonthlyTotalByUserID(c *gin.Context) {
 		},
 	})
 }
+
+// BulkUpdateBetReceiptStatus cập nhật status cho nhiều đơn hàng cùng lúc (một transaction)
+// Trả về báo cáo từng đơn hàng; nếu có đơn hàng không hợp lệ thì không đơn hàng nào được cập nhật
+func (h *BetReceiptHandler) BulkUpdateBetReceiptStatus(c *gin.Context) {
+	log.Println("=== BẮT ĐẦU CẬP NHẬT STATUS HÀNG LOẠT ===")
+
+	var req models.BulkUpdateBetReceiptStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("❌ VALIDATION LỖI: Dữ liệu không hợp lệ - %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+		})
+		return
+	}
+
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	log.Printf("📝 Cập nhật status hàng loạt - Số đơn: %d, Status mới: %s, Người cập nhật: %s", len(req.IDs), req.Status, claims.UserID)
+
+	report, err := h.betReceiptService.BulkUpdateBetReceiptStatus(&req, &claims.UserID)
+	if err != nil {
+		log.Printf("❌ CẬP NHẬT STATUS HÀNG LOẠT THẤT BẠI: %v", err)
+		status := http.StatusInternalServerError
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"data":    report,
+		})
+		return
+	}
+
+	log.Printf("✅ CẬP NHẬT STATUS HÀNG LOẠT THÀNH CÔNG - Số đơn: %d", report.Updated)
+	log.Println("=== KẾT THÚC CẬP NHẬT STATUS HÀNG LOẠT ===")
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// GetAllowedTransitions trả về status hiện tại và các status có thể chuyển tới của đơn hàng
+// (kèm các trường bắt buộc cho từng status) để frontend hiển thị đúng lựa chọn
+func (h *BetReceiptHandler) GetAllowedTransitions(c *gin.Context) {
+	id := c.Param("id")
+
+	if _, ok := requireClaims(c, h.jwtSecret); !ok {
+		return
+	}
+
+	currentStatus, transitions, err := h.betReceiptService.GetAllowedTransitions(id)
+	if err != nil {
+		log.Printf("❌ LỖI LẤY DANH SÁCH STATUS HỢP LỆ CHO ĐƠN HÀNG %s: %v", id, err)
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Không tìm thấy đơn hàng",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"id":             id,
+			"current_status": currentStatus,
+			"transitions":    transitions,
+		},
+	})
+}
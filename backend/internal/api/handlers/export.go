@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/service"
+	"fullstack-backend/pkg/spreadsheet"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseExportOptions đọc format (xlsx | csv, mặc định xlsx) và group_by (month) từ query parameter
+// Nếu không hợp lệ, trả về response 400 và ok = false
+func parseExportOptions(c *gin.Context) (format string, byMonth bool, ok bool) {
+	format = strings.ToLower(c.DefaultQuery("format", spreadsheet.FormatXLSX))
+	if format != spreadsheet.FormatXLSX && format != spreadsheet.FormatCSV {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "format phải là 'xlsx' hoặc 'csv'",
+		})
+		return "", false, false
+	}
+
+	switch c.Query("group_by") {
+	case "":
+	case "month":
+		if format == spreadsheet.FormatCSV {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   spreadsheet.ErrMultipleSheets.Error(),
+			})
+			return "", false, false
+		}
+		byMonth = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "group_by chỉ hỗ trợ 'month'",
+		})
+		return "", false, false
+	}
+
+	return format, byMonth, true
+}
+
+// streamExport ghi file export trực tiếp vào response (không tạo file tạm trong bộ nhớ)
+// name: tên file không có đuôi, sẽ được thêm ngày export và đuôi theo format
+// Nếu lỗi xảy ra trước khi ghi dữ liệu thì trả về JSON lỗi, sau đó chỉ có thể ngắt response
+func streamExport(c *gin.Context, name, format string, fn func(spreadsheet.Writer) error) {
+	filename := name + "_" + time.Now().Format("20060102") + "." + format
+
+	c.Header("Content-Type", spreadsheet.ContentType(format))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	writer, err := spreadsheet.NewWriter(c.Writer, format)
+	if err == nil {
+		if err = fn(writer); err == nil {
+			err = writer.Close()
+		}
+	}
+	if err == nil {
+		log.Printf("✅ Export thành công: %s", filename)
+		return
+	}
+
+	log.Printf("❌ LỖI EXPORT %s: %v", filename, err)
+	if c.Writer.Written() {
+		// Đã gửi một phần file, không thể đổi status code
+		c.Abort()
+		return
+	}
+
+	c.Header("Content-Disposition", "")
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"success": false,
+		"error":   "Lỗi khi export dữ liệu",
+	})
+}
+
+// ExportBetReceipts export danh sách đơn hàng ra file XLSX/CSV
+// Nhận cùng các query parameter lọc/sắp xếp như GET /api/bet-receipts (bỏ qua limit/offset/cursor)
+// User thường chỉ export được đơn hàng của mình
+func (h *BetReceiptHandler) ExportBetReceipts(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	format, byMonth, ok := parseExportOptions(c)
+	if !ok {
+		return
+	}
+
+	filter, err := parseBetReceiptFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if claims.Role != "admin" {
+		filter.UserID = &claims.UserID
+	}
+
+	streamExport(c, "don_hang", format, func(w spreadsheet.Writer) error {
+		return h.betReceiptService.ExportBetReceipts(filter, byMonth, w)
+	})
+}
+
+// ExportWallets export tổng hợp tài chính của tất cả users ra file XLSX/CSV (chỉ admin)
+func (h *WalletHandler) ExportWallets(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.jwtSecret); !ok {
+		return
+	}
+
+	format, byMonth, ok := parseExportOptions(c)
+	if !ok {
+		return
+	}
+	if byMonth {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Tổng hợp tài chính không hỗ trợ group_by=month",
+		})
+		return
+	}
+
+	streamExport(c, "tong_hop_tai_chinh", format, h.walletService.ExportWallets)
+}
+
+// ExportDeposits export lịch sử nạp tiền ra file XLSX/CSV (chỉ admin)
+func (h *DepositHandler) ExportDeposits(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.jwtSecret); !ok {
+		return
+	}
+
+	format, byMonth, ok := parseExportOptions(c)
+	if !ok {
+		return
+	}
+
+	streamExport(c, "lich_su_nop_tien", format, func(w spreadsheet.Writer) error {
+		return h.depositService.ExportDeposits(byMonth, w)
+	})
+}
+
+// ExportWithdrawals export lịch sử rút tiền ra file XLSX/CSV (chỉ admin)
+func (h *WithdrawalHandler) ExportWithdrawals(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.jwtSecret); !ok {
+		return
+	}
+
+	format, byMonth, ok := parseExportOptions(c)
+	if !ok {
+		return
+	}
+
+	streamExport(c, "lich_su_rut_tien", format, func(w spreadsheet.Writer) error {
+		return h.withdrawalService.ExportWithdrawals(byMonth, w)
+	})
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/service"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FeeScheduleHandler struct {
+	feeScheduleService *service.FeeScheduleService
+	jwtSecret          string
+}
+
+func NewFeeScheduleHandler(feeScheduleService *service.FeeScheduleService, jwtSecret string) *FeeScheduleHandler {
+	return &FeeScheduleHandler{
+		feeScheduleService: feeScheduleService,
+		jwtSecret:          jwtSecret,
+	}
+}
+
+// GetFeeSchedules lấy tất cả biểu phí (hiệu lực mới nhất trước)
+func (h *FeeScheduleHandler) GetFeeSchedules(c *gin.Context) {
+	if _, ok := requireClaims(c, h.jwtSecret); !ok {
+		return
+	}
+
+	schedules, err := h.feeScheduleService.GetFeeSchedules()
+	if err != nil {
+		log.Printf("Handler - ❌ Lỗi lấy danh sách biểu phí: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Lỗi khi lấy danh sách biểu phí",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    schedules,
+	})
+}
+
+// GetCurrentFeeSchedule lấy biểu phí đang có hiệu lực
+// Query: at=RFC3339 để lấy biểu phí có hiệu lực tại một thời điểm khác
+func (h *FeeScheduleHandler) GetCurrentFeeSchedule(c *gin.Context) {
+	if _, ok := requireClaims(c, h.jwtSecret); !ok {
+		return
+	}
+
+	at := time.Now()
+	if value := c.Query("at"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "at phải có định dạng RFC3339 (vd: 2026-01-01T00:00:00Z)",
+			})
+			return
+		}
+		at = parsed
+	}
+
+	schedule, err := h.feeScheduleService.GetEffectiveFeeSchedule(at)
+	if err != nil {
+		respondFeeScheduleError(c, err, "Lỗi khi lấy biểu phí")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    schedule,
+	})
+}
+
+// GetFeeSchedule lấy biểu phí theo version
+func (h *FeeScheduleHandler) GetFeeSchedule(c *gin.Context) {
+	if _, ok := requireClaims(c, h.jwtSecret); !ok {
+		return
+	}
+
+	version, ok := parseFeeScheduleVersion(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.feeScheduleService.GetFeeSchedule(version)
+	if err != nil {
+		respondFeeScheduleError(c, err, "Lỗi khi lấy biểu phí")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    schedule,
+	})
+}
+
+// CreateFeeSchedule tạo biểu phí mới (chỉ admin)
+// Biểu phí mới được áp dụng cho các đơn hàng xử lý từ effective_from trở đi
+func (h *FeeScheduleHandler) CreateFeeSchedule(c *gin.Context) {
+	claims, ok := requireAdmin(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	var req models.FeeScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+		})
+		return
+	}
+
+	schedule, err := h.feeScheduleService.CreateFeeSchedule(&req, claims.UserID)
+	if err != nil {
+		respondFeeScheduleError(c, err, "Lỗi khi tạo biểu phí")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    schedule,
+		"message": "Đã tạo biểu phí thành công",
+	})
+}
+
+// UpdateFeeSchedule sửa biểu phí chưa được đơn hàng nào sử dụng (chỉ admin)
+func (h *FeeScheduleHandler) UpdateFeeSchedule(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.jwtSecret); !ok {
+		return
+	}
+
+	version, ok := parseFeeScheduleVersion(c)
+	if !ok {
+		return
+	}
+
+	var req models.FeeScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+		})
+		return
+	}
+
+	schedule, err := h.feeScheduleService.UpdateFeeSchedule(version, &req)
+	if err != nil {
+		respondFeeScheduleError(c, err, "Lỗi khi cập nhật biểu phí")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    schedule,
+		"message": "Đã cập nhật biểu phí thành công",
+	})
+}
+
+// DeleteFeeSchedule xóa biểu phí chưa được đơn hàng nào sử dụng (chỉ admin)
+func (h *FeeScheduleHandler) DeleteFeeSchedule(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.jwtSecret); !ok {
+		return
+	}
+
+	version, ok := parseFeeScheduleVersion(c)
+	if !ok {
+		return
+	}
+
+	if err := h.feeScheduleService.DeleteFeeSchedule(version); err != nil {
+		respondFeeScheduleError(c, err, "Lỗi khi xóa biểu phí")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã xóa biểu phí thành công",
+	})
+}
+
+func parseFeeScheduleVersion(c *gin.Context) (int, bool) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Version biểu phí không hợp lệ",
+		})
+		return 0, false
+	}
+	return version, true
+}
+
+// respondFeeScheduleError: không tìm thấy -> 404, đã được sử dụng -> 409, dữ liệu không hợp lệ -> 400, còn lại 500
+func respondFeeScheduleError(c *gin.Context, err error, message string) {
+	status := http.StatusInternalServerError
+	var validationErr *service.ValidationError
+	switch {
+	case errors.Is(err, service.ErrFeeScheduleNotFound), errors.Is(err, service.ErrNoEffectiveFeeSchedule):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrFeeScheduleInUse):
+		status = http.StatusConflict
+	case errors.As(err, &validationErr):
+		status = http.StatusBadRequest
+	default:
+		log.Printf("Handler - ❌ %s: %v", message, err)
+		err = errors.New(message)
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"fullstack-backend/pkg/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireClaims lấy và xác thực JWT token từ header Authorization
+// Nếu token không hợp lệ, trả về response 401 và ok = false (handler chỉ cần return)
+func requireClaims(c *gin.Context, jwtSecret string) (*utils.Claims, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Yêu cầu xác thực",
+		})
+		return nil, false
+	}
+
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if tokenString == authHeader {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Định dạng token không hợp lệ",
+		})
+		return nil, false
+	}
+
+	claims, err := utils.ValidateJWT(tokenString, jwtSecret)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Token không hợp lệ hoặc đã hết hạn",
+		})
+		return nil, false
+	}
+
+	return claims, true
+}
+
+// optionalClaims lấy claims nếu request có JWT token hợp lệ, không trả về lỗi (nil nếu không có/không hợp lệ)
+func optionalClaims(c *gin.Context, jwtSecret string) *utils.Claims {
+	tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if tokenString == "" {
+		return nil
+	}
+	claims, err := utils.ValidateJWT(tokenString, jwtSecret)
+	if err != nil {
+		return nil
+	}
+	return claims
+}
+
+// requireAdmin giống requireClaims nhưng chỉ cho phép role = "admin" (trả về 403 nếu không phải admin)
+func requireAdmin(c *gin.Context, jwtSecret string) (*utils.Claims, bool) {
+	claims, ok := requireClaims(c, jwtSecret)
+	if !ok {
+		return nil, false
+	}
+
+	if claims.Role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Chỉ admin mới có quyền thực hiện thao tác này",
+		})
+		return nil, false
+	}
+
+	return claims, true
+}
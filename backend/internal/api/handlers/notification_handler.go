@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+	jwtSecret           string
+}
+
+func NewNotificationHandler(notificationService *service.NotificationService, jwtSecret string) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		jwtSecret:           jwtSecret,
+	}
+}
+
+// GetNotifications lấy thông báo của user hiện tại (mới nhất trước)
+// Query: unread=true để chỉ lấy thông báo chưa đọc, limit/offset hoặc cursor để phân trang
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	unreadOnly := c.Query("unread") == "true"
+	limit, offset, cursor := parsePageQuery(c, 50)
+
+	notifications, unread, page, err := h.notificationService.GetNotifications(claims.UserID, unreadOnly, limit, offset, cursor)
+	if err != nil {
+		log.Printf("Handler - ❌ Lỗi lấy danh sách thông báo: %v", err)
+		respondListError(c, err, "Lỗi khi lấy danh sách thông báo")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"data":         notifications,
+		"unread_count": unread,
+		"next_cursor":  page.NextCursor,
+		"prev_cursor":  page.PrevCursor,
+	})
+}
+
+// MarkNotificationRead đánh dấu đã đọc một thông báo của user hiện tại
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	if err := h.notificationService.MarkRead(c.Param("id"), claims.UserID); err != nil {
+		if errors.Is(err, service.ErrNotificationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		log.Printf("Handler - ❌ Lỗi đánh dấu đã đọc thông báo: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Lỗi khi cập nhật thông báo",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã đánh dấu đã đọc",
+	})
+}
+
+// MarkAllNotificationsRead đánh dấu đã đọc tất cả thông báo của user hiện tại
+func (h *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	count, err := h.notificationService.MarkAllRead(claims.UserID)
+	if err != nil {
+		log.Printf("Handler - ❌ Lỗi đánh dấu đã đọc tất cả thông báo: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Lỗi khi cập nhật thông báo",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"updated": count,
+		},
+	})
+}
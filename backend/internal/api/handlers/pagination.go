@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPageLimit - Số dòng tối đa mỗi trang
+const maxPageLimit = 1000
+
+// parsePageQuery đọc limit, offset, cursor từ query parameter
+// defaultLimit = 0 nghĩa là mặc định lấy tất cả (chỉ phân trang khi client truyền limit hoặc cursor)
+func parsePageQuery(c *gin.Context, defaultLimit int) (int, int, string) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if err != nil || limit < 0 || (limit == 0 && defaultLimit > 0) {
+		limit = defaultLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	return limit, offset, c.Query("cursor")
+}
+
+// respondListError trả về 400 nếu là ValidationError (vd: cursor không hợp lệ), ngược lại 500 với message
+func respondListError(c *gin.Context, err error, message string) {
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"success": false,
+		"error":   message,
+	})
+}
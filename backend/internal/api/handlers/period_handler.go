@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PeriodHandler struct {
+	periodService *service.PeriodService
+	jwtSecret     string
+}
+
+func NewPeriodHandler(periodService *service.PeriodService, jwtSecret string) *PeriodHandler {
+	return &PeriodHandler{
+		periodService: periodService,
+		jwtSecret:     jwtSecret,
+	}
+}
+
+// GetClosedPeriods lấy danh sách tháng đã khóa sổ (chỉ admin)
+func (h *PeriodHandler) GetClosedPeriods(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.jwtSecret); !ok {
+		return
+	}
+
+	periods, err := h.periodService.GetClosedPeriods()
+	if err != nil {
+		respondPeriodError(c, err, "Lỗi khi lấy danh sách kỳ khóa sổ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    periods,
+		"total":   len(periods),
+	})
+}
+
+// ClosePeriod khóa sổ một tháng đã qua (chỉ admin)
+// Chụp số dư mọi ví rồi khóa đơn hàng hoàn thành, nộp cọc, rút tiền trong tháng
+func (h *PeriodHandler) ClosePeriod(c *gin.Context) {
+	claims, ok := requireAdmin(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	var req models.ClosePeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+		})
+		return
+	}
+
+	period, err := h.periodService.CloseMonth(&req, claims.UserID)
+	if err != nil {
+		respondPeriodError(c, err, "Lỗi khi khóa sổ")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Đã khóa sổ tháng " + period.Month,
+		"data":    period,
+	})
+}
+
+// GetPeriodSnapshots lấy số dư ví đã chụp khi khóa sổ tháng :month (chỉ admin)
+func (h *PeriodHandler) GetPeriodSnapshots(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.jwtSecret); !ok {
+		return
+	}
+
+	period, snapshots, err := h.periodService.GetSnapshots(c.Param("month"))
+	if err != nil {
+		respondPeriodError(c, err, "Lỗi khi lấy số dư ví khóa sổ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"period":    period,
+			"snapshots": snapshots,
+		},
+	})
+}
+
+// respondPeriodError: tháng chưa khóa sổ -> 404, đã khóa sổ -> 409, dữ liệu không hợp lệ -> 400, còn lại 500
+func respondPeriodError(c *gin.Context, err error, message string) {
+	status := http.StatusInternalServerError
+	var validationErr *service.ValidationError
+	switch {
+	case errors.Is(err, service.ErrPeriodNotClosed):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrPeriodAlreadyClosed):
+		status = http.StatusConflict
+	case errors.As(err, &validationErr):
+		status = http.StatusBadRequest
+	default:
+		log.Printf("Handler - ❌ %s: %v", message, err)
+		err = errors.New(message)
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/service"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaskCodeHandler struct {
+	taskCodeService *service.TaskCodeService
+	jwtSecret       string
+}
+
+func NewTaskCodeHandler(taskCodeService *service.TaskCodeService, jwtSecret string) *TaskCodeHandler {
+	return &TaskCodeHandler{
+		taskCodeService: taskCodeService,
+		jwtSecret:       jwtSecret,
+	}
+}
+
+// GetTaskCodes lấy danh mục mã nhiệm vụ (để chọn khi tạo đơn hàng)
+// Query: active (true = đang dùng, false = ngừng sử dụng)
+func (h *TaskCodeHandler) GetTaskCodes(c *gin.Context) {
+	if _, ok := requireClaims(c, h.jwtSecret); !ok {
+		return
+	}
+
+	var active *bool
+	if activeStr := c.Query("active"); activeStr != "" {
+		value, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Tham số active không hợp lệ (true hoặc false)",
+			})
+			return
+		}
+		active = &value
+	}
+
+	taskCodes, err := h.taskCodeService.GetTaskCodes(active)
+	if err != nil {
+		respondTaskCodeError(c, err, "Lỗi khi lấy danh mục mã nhiệm vụ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    taskCodes,
+		"total":   len(taskCodes),
+	})
+}
+
+// GetTaskCodeStats thống kê đơn hàng theo mã nhiệm vụ (chỉ admin)
+// Query: from, to (YYYY-MM-DD hoặc RFC3339, lọc theo thời gian nhận kèo)
+func (h *TaskCodeHandler) GetTaskCodeStats(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.jwtSecret); !ok {
+		return
+	}
+
+	from, err := parseTimeQuery(c.Query("from"), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	to, err := parseTimeQuery(c.Query("to"), true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	stats, err := h.taskCodeService.GetStats(from, to)
+	if err != nil {
+		respondTaskCodeError(c, err, "Lỗi khi thống kê theo mã nhiệm vụ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    stats,
+	})
+}
+
+// GetTaskCode lấy mã nhiệm vụ theo ID
+func (h *TaskCodeHandler) GetTaskCode(c *gin.Context) {
+	if _, ok := requireClaims(c, h.jwtSecret); !ok {
+		return
+	}
+
+	taskCode, err := h.taskCodeService.GetTaskCode(c.Param("id"))
+	if err != nil {
+		respondTaskCodeError(c, err, "Lỗi khi lấy mã nhiệm vụ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    taskCode,
+	})
+}
+
+// CreateTaskCode thêm mã nhiệm vụ vào danh mục (chỉ admin)
+func (h *TaskCodeHandler) CreateTaskCode(c *gin.Context) {
+	claims, ok := requireAdmin(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	var req models.TaskCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+		})
+		return
+	}
+
+	taskCode, err := h.taskCodeService.CreateTaskCode(&req, claims.UserID)
+	if err != nil {
+		respondTaskCodeError(c, err, "Lỗi khi tạo mã nhiệm vụ")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    taskCode,
+		"message": "Đã thêm mã nhiệm vụ vào danh mục",
+	})
+}
+
+// UpdateTaskCode sửa mã nhiệm vụ, active = false để ngừng sử dụng (chỉ admin)
+func (h *TaskCodeHandler) UpdateTaskCode(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.jwtSecret); !ok {
+		return
+	}
+
+	var req models.TaskCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+		})
+		return
+	}
+
+	taskCode, err := h.taskCodeService.UpdateTaskCode(c.Param("id"), &req)
+	if err != nil {
+		respondTaskCodeError(c, err, "Lỗi khi cập nhật mã nhiệm vụ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    taskCode,
+		"message": "Đã cập nhật mã nhiệm vụ",
+	})
+}
+
+// DeleteTaskCode xóa mã nhiệm vụ chưa có đơn hàng nào sử dụng (chỉ admin)
+func (h *TaskCodeHandler) DeleteTaskCode(c *gin.Context) {
+	if _, ok := requireAdmin(c, h.jwtSecret); !ok {
+		return
+	}
+
+	if err := h.taskCodeService.DeleteTaskCode(c.Param("id")); err != nil {
+		respondTaskCodeError(c, err, "Lỗi khi xóa mã nhiệm vụ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã xóa mã nhiệm vụ khỏi danh mục",
+	})
+}
+
+// respondTaskCodeError: không tìm thấy -> 404, trùng mã / đã được sử dụng -> 409, dữ liệu không hợp lệ -> 400, còn lại 500
+func respondTaskCodeError(c *gin.Context, err error, message string) {
+	status := http.StatusInternalServerError
+	var validationErr *service.ValidationError
+	switch {
+	case errors.Is(err, service.ErrTaskCodeNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrTaskCodeExists), errors.Is(err, service.ErrTaskCodeInUse):
+		status = http.StatusConflict
+	case errors.As(err, &validationErr):
+		status = http.StatusBadRequest
+	default:
+		log.Printf("Handler - ❌ %s: %v", message, err)
+		err = errors.New(message)
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
@@ -1,21 +1,24 @@
 package handlers
 
 import (
+	"fullstack-backend/internal/models"
 	"fullstack-backend/internal/service"
+	"fullstack-backend/pkg/money"
 	"log"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type WalletHandler struct {
 	walletService *service.WalletService
+	jwtSecret     string
 }
 
-func NewWalletHandler(walletService *service.WalletService) *WalletHandler {
+func NewWalletHandler(walletService *service.WalletService, jwtSecret string) *WalletHandler {
 	return &WalletHandler{
 		walletService: walletService,
+		jwtSecret:     jwtSecret,
 	}
 }
 
@@ -24,27 +27,13 @@ func NewWalletHandler(walletService *service.WalletService) *WalletHandler {
 func (h *WalletHandler) GetAllWallets(c *gin.Context) {
 	log.Println("=== BẮT ĐẦU LẤY DANH SÁCH WALLETS ===")
 
-	// Parse query parameters
-	limitStr := c.DefaultQuery("limit", "100")
-	offsetStr := c.DefaultQuery("offset", "0")
+	// Parse query parameters (limit, offset hoặc cursor)
+	limit, offset, cursor := parsePageQuery(c, 100)
 
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit < 1 {
-		limit = 100
-	}
-
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
-	}
-
-	results, err := h.walletService.GetAllWallets(limit, offset)
+	results, page, err := h.walletService.GetAllWallets(limit, offset, cursor)
 	if err != nil {
 		log.Printf("❌ LỖI LẤY DANH SÁCH WALLETS: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "Lỗi khi lấy danh sách wallets",
-		})
+		respondListError(c, err, "Lỗi khi lấy danh sách wallets")
 		return
 	}
 
@@ -60,13 +49,15 @@ func (h *WalletHandler) GetAllWallets(c *gin.Context) {
 	if len(results) > 0 {
 		log.Printf("👤 Tên người dùng đầu tiên (từ nd.ten): %s", results[0].User.Name)
 	}
-	log.Printf("💰 Tổng SD hiện tại: %.2f VND", totalCurrentBalanceVND)
+	log.Printf("💰 Tổng SD hiện tại: %s VND", totalCurrentBalanceVND)
 	log.Println("=== KẾT THÚC LẤY DANH SÁCH WALLETS ===")
 
 	c.JSON(http.StatusOK, gin.H{
 		"success":                true,
 		"data":                   results,
 		"total_current_balance_vnd": totalCurrentBalanceVND,
+		"next_cursor":            page.NextCursor,
+		"prev_cursor":            page.PrevCursor,
 	})
 }
 
@@ -83,14 +74,14 @@ func (h *WalletHandler) RecalculateWallet(c *gin.Context) {
 	}
 
 	// Tỷ giá mặc định: 3550 VND = 1 CNY
-	exchangeRate := 3550.0
+	exchangeRate := models.DefaultExchangeRate
 	if rateStr := c.Query("exchange_rate"); rateStr != "" {
-		if rate, err := strconv.ParseFloat(rateStr, 64); err == nil && rate > 0 {
+		if rate, err := money.ParseRate(rateStr); err == nil && rate > 0 {
 			exchangeRate = rate
 		}
 	}
 
-	log.Printf("=== BẮT ĐẦU RECALCULATE WALLET - UserID: %s, ExchangeRate: %.2f ===", userID, exchangeRate)
+	log.Printf("=== BẮT ĐẦU RECALCULATE WALLET - UserID: %s, ExchangeRate: %s ===", userID, exchangeRate)
 
 	err := h.walletService.RecalculateWallet(userID, exchangeRate)
 	if err != nil {
@@ -115,14 +106,14 @@ func (h *WalletHandler) RecalculateWallet(c *gin.Context) {
 // Dùng khi đã xóa/sửa trực tiếp trong database và cần đồng bộ lại tất cả wallets
 func (h *WalletHandler) RecalculateAllWallets(c *gin.Context) {
 	// Tỷ giá mặc định: 3550 VND = 1 CNY
-	exchangeRate := 3550.0
+	exchangeRate := models.DefaultExchangeRate
 	if rateStr := c.Query("exchange_rate"); rateStr != "" {
-		if rate, err := strconv.ParseFloat(rateStr, 64); err == nil && rate > 0 {
+		if rate, err := money.ParseRate(rateStr); err == nil && rate > 0 {
 			exchangeRate = rate
 		}
 	}
 
-	log.Printf("=== BẮT ĐẦU RECALCULATE TẤT CẢ WALLETS - ExchangeRate: %.2f ===", exchangeRate)
+	log.Printf("=== BẮT ĐẦU RECALCULATE TẤT CẢ WALLETS - ExchangeRate: %s ===", exchangeRate)
 
 	err := h.walletService.RecalculateAllWallets(exchangeRate)
 	if err != nil {
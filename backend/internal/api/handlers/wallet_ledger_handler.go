@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/service"
+	"fullstack-backend/pkg/utils"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondWalletLedgerError trả lỗi của sổ cái ví: 404 nếu không có người dùng, 400 nếu dữ liệu không hợp lệ
+func respondWalletLedgerError(c *gin.Context, err error, message string) {
+	if errors.Is(err, service.ErrWalletUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	respondListError(c, err, message)
+}
+
+// requireWalletOwner chỉ cho phép admin hoặc chính chủ ví (trả về 403 nếu không phải)
+func requireWalletOwner(c *gin.Context, claims *utils.Claims, userID string) bool {
+	if claims.Role == "admin" || claims.UserID == userID {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"success": false,
+		"error":   "Bạn chỉ được xem ví của chính mình",
+	})
+	return false
+}
+
+// GetLedgerEntries lấy sổ cái ví của một user (mới nhất trước, kèm số dư sau mỗi bút toán)
+// User thường chỉ xem được sổ cái của chính mình, admin xem được của mọi user
+// Query: entry_type, from, to (YYYY-MM-DD hoặc RFC3339), limit/offset hoặc cursor
+func (h *WalletHandler) GetLedgerEntries(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	userID := c.Param("user_id")
+	if !requireWalletOwner(c, claims, userID) {
+		return
+	}
+
+	filter := models.WalletLedgerFilter{EntryType: c.Query("entry_type")}
+	var err error
+	if filter.From, err = parseTimeQuery(c.Query("from"), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if filter.To, err = parseTimeQuery(c.Query("to"), true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	limit, offset, cursor := parsePageQuery(c, 100)
+
+	entries, page, err := h.walletService.GetLedgerEntries(userID, filter, limit, offset, cursor)
+	if err != nil {
+		log.Printf("❌ LỖI LẤY SỔ CÁI VÍ - UserID: %s: %v", userID, err)
+		respondWalletLedgerError(c, err, "Lỗi khi lấy sổ cái ví")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"data":        entries,
+		"next_cursor": page.NextCursor,
+		"prev_cursor": page.PrevCursor,
+	})
+}
+
+// GetStatement lấy sao kê ví theo tháng: số dư đầu kỳ, công thực nhận (kèm tỷ giá), tiền đền, nộp cọc, rút tiền,
+// điều chỉnh và số dư cuối kỳ, mỗi nhóm có cộng phát sinh VND và CNY
+// User thường chỉ xem được sao kê của chính mình, admin xem được của mọi user
+// Query: month (YYYY-MM, mặc định tháng hiện tại)
+func (h *WalletHandler) GetStatement(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	userID := c.Param("user_id")
+	if !requireWalletOwner(c, claims, userID) {
+		return
+	}
+
+	month := c.Query("month")
+	statement, err := h.walletService.GetStatement(userID, month)
+	if err != nil {
+		log.Printf("❌ LỖI LẤY SAO KÊ VÍ - UserID: %s, Tháng: %s: %v", userID, month, err)
+		respondWalletLedgerError(c, err, "Lỗi khi lấy sao kê ví")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    statement,
+	})
+}
+
+// CreateLedgerAdjustment ghi bút toán điều chỉnh thủ công vào sổ cái ví (chỉ admin)
+// Body: amount_vnd (dương = cộng, âm = trừ), description (lý do)
+func (h *WalletHandler) CreateLedgerAdjustment(c *gin.Context) {
+	claims, ok := requireAdmin(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	var req models.WalletAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+		})
+		return
+	}
+
+	userID := c.Param("user_id")
+	entry, err := h.walletService.CreateAdjustment(userID, &req, claims.UserID)
+	if err != nil {
+		log.Printf("❌ LỖI ĐIỀU CHỈNH VÍ - UserID: %s: %v", userID, err)
+		respondWalletLedgerError(c, err, "Lỗi khi điều chỉnh ví")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Đã ghi bút toán điều chỉnh",
+		"data":    entry,
+	})
+}
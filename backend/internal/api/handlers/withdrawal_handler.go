@@ -23,7 +23,8 @@ func NewWithdrawalHandler(withdrawalService *service.WithdrawalService, jwtSecre
 	}
 }
 
-// CreateWithdrawal xử lý rút tiền
+// CreateWithdrawal ghi nhận lần chi tiền admin thực hiện trực tiếp (chỉ admin, không vượt số dư khả dụng)
+// Được ghi thành yêu cầu rút tiền đã duyệt và đã chi (tạo, duyệt, chi trong một transaction)
 func (h *WithdrawalHandler) CreateWithdrawal(c *gin.Context) {
 	var req models.CreateWithdrawalRequest
 
@@ -39,40 +40,18 @@ func (h *WithdrawalHandler) CreateWithdrawal(c *gin.Context) {
 		return
 	}
 
-	log.Printf("📝 Thông tin rút tiền - Tên người dùng: %s, Số tiền VND: %.2f", req.UserName, req.AmountVND)
+	log.Printf("📝 Thông tin rút tiền - Tên người dùng: %s, Số tiền VND: %s", req.UserName, req.AmountVND)
 
-	// Kiểm tra quyền admin (từ JWT token)
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   "Yêu cầu xác thực",
-		})
+	// Kiểm tra quyền admin (từ JWT token), user thường gửi yêu cầu rút tiền (CreateWithdrawalRequest)
+	claims, ok := requireAdmin(c, h.jwtSecret)
+	if !ok {
 		return
 	}
 
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	if tokenString == authHeader {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   "Định dạng token không hợp lệ",
-		})
-		return
-	}
-
-	claims, err := utils.ValidateJWT(tokenString, h.jwtSecret)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   "Token không hợp lệ hoặc đã hết hạn",
-		})
-		return
-	}
-
-	log.Printf("🔍 Người rút tiền - User ID: %s", claims.UserID)
+	log.Printf("🔍 Admin ghi nhận rút tiền - User ID: %s", claims.UserID)
 
 	// Gọi service để xử lý logic
-	withdrawal, err := h.withdrawalService.CreateWithdrawal(&req)
+	withdrawal, err := h.withdrawalService.CreateWithdrawal(&req, claims.UserID)
 	if err != nil {
 		errorMsg := err.Error()
 		log.Printf("❌ RÚT TIỀN THẤT BẠI: %s", errorMsg)
@@ -84,7 +63,7 @@ func (h *WithdrawalHandler) CreateWithdrawal(c *gin.Context) {
 		return
 	}
 
-	log.Printf("✅ RÚT TIỀN THÀNH CÔNG - ID: %s, UserID: %s, AmountVND: %.2f",
+	log.Printf("✅ RÚT TIỀN THÀNH CÔNG - ID: %s, UserID: %s, AmountVND: %s",
 		withdrawal.ID, withdrawal.UserID, withdrawal.AmountVND)
 	log.Println("=== KẾT THÚC XỬ LÝ RÚT TIỀN ===\n")
 
@@ -127,14 +106,12 @@ func (h *WithdrawalHandler) GetAllWithdrawals(c *gin.Context) {
 		return
 	}
 
-	// Gọi service để lấy danh sách
-	withdrawals, err := h.withdrawalService.GetAllWithdrawals()
+	// Gọi service để lấy danh sách (không truyền limit/cursor thì lấy tất cả)
+	limit, offset, cursor := parsePageQuery(c, 0)
+	withdrawals, page, err := h.withdrawalService.GetAllWithdrawals(limit, offset, cursor)
 	if err != nil {
 		log.Printf("❌ LỖI LẤY DANH SÁCH RÚT TIỀN: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "Lỗi khi lấy danh sách lịch sử rút tiền",
-		})
+		respondListError(c, err, "Lỗi khi lấy danh sách lịch sử rút tiền")
 		return
 	}
 
@@ -142,8 +119,10 @@ func (h *WithdrawalHandler) GetAllWithdrawals(c *gin.Context) {
 	log.Println("=== KẾT THÚC LẤY DANH SÁCH LỊCH SỬ RÚT TIỀN ===\n")
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    withdrawals,
+		"success":     true,
+		"data":        withdrawals,
+		"next_cursor": page.NextCursor,
+		"prev_cursor": page.PrevCursor,
 	})
 }
 
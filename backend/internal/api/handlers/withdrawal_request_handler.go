@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"errors"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/service"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateWithdrawalRequest user gửi yêu cầu rút tiền cho chính mình (không vượt số dư khả dụng)
+func (h *WithdrawalHandler) CreateWithdrawalRequest(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	var req models.CreateWithdrawalRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+		})
+		return
+	}
+
+	wr, err := h.withdrawalService.CreateWithdrawalRequest(claims.UserID, &req)
+	if err != nil {
+		respondWithdrawalRequestError(c, err, "Lỗi khi tạo yêu cầu rút tiền")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    wr,
+	})
+}
+
+// GetWithdrawalRequests lấy yêu cầu rút tiền (cũ nhất trước)
+// Admin xem tất cả (hàng đợi duyệt: status=pending), user thường chỉ xem yêu cầu của mình
+// Query: status (pending, approved, rejected, paid), user_id (chỉ admin)
+func (h *WithdrawalHandler) GetWithdrawalRequests(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	filter := &models.WithdrawalRequestFilter{Status: strings.TrimSpace(c.Query("status"))}
+	if claims.Role != "admin" {
+		filter.UserID = &claims.UserID
+	} else if userID := strings.TrimSpace(c.Query("user_id")); userID != "" {
+		filter.UserID = &userID
+	}
+
+	requests, err := h.withdrawalService.GetWithdrawalRequests(filter)
+	if err != nil {
+		respondWithdrawalRequestError(c, err, "Lỗi khi lấy danh sách yêu cầu rút tiền")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    requests,
+		"total":   len(requests),
+	})
+}
+
+// GetWithdrawalRequest lấy một yêu cầu rút tiền (admin hoặc chủ yêu cầu)
+func (h *WithdrawalHandler) GetWithdrawalRequest(c *gin.Context) {
+	claims, ok := requireClaims(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	wr, err := h.withdrawalService.GetWithdrawalRequest(c.Param("id"))
+	if err != nil {
+		respondWithdrawalRequestError(c, err, "Lỗi khi lấy yêu cầu rút tiền")
+		return
+	}
+	if claims.Role != "admin" && wr.UserID != claims.UserID {
+		// Không để lộ yêu cầu của người khác
+		respondWithdrawalRequestError(c, service.ErrWithdrawalRequestNotFound, "")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    wr,
+	})
+}
+
+// ApproveWithdrawalRequest duyệt yêu cầu rút tiền đang chờ (chỉ admin)
+func (h *WithdrawalHandler) ApproveWithdrawalRequest(c *gin.Context) {
+	claims, ok := requireAdmin(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	wr, err := h.withdrawalService.ApproveWithdrawalRequest(c.Param("id"), claims.UserID)
+	if err != nil {
+		respondWithdrawalRequestError(c, err, "Lỗi khi duyệt yêu cầu rút tiền")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã duyệt yêu cầu rút tiền",
+		"data":    wr,
+	})
+}
+
+// RejectWithdrawalRequest từ chối yêu cầu rút tiền chưa chi, bắt buộc có lý do (chỉ admin)
+func (h *WithdrawalHandler) RejectWithdrawalRequest(c *gin.Context) {
+	claims, ok := requireAdmin(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	var req models.RejectWithdrawalRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+		})
+		return
+	}
+
+	wr, err := h.withdrawalService.RejectWithdrawalRequest(c.Param("id"), req.Reason, claims.UserID)
+	if err != nil {
+		respondWithdrawalRequestError(c, err, "Lỗi khi từ chối yêu cầu rút tiền")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã từ chối yêu cầu rút tiền",
+		"data":    wr,
+	})
+}
+
+// PayWithdrawalRequest ghi nhận đã chi tiền cho yêu cầu đã duyệt, ghi lịch sử rút tiền và trừ ví (chỉ admin)
+func (h *WithdrawalHandler) PayWithdrawalRequest(c *gin.Context) {
+	claims, ok := requireAdmin(c, h.jwtSecret)
+	if !ok {
+		return
+	}
+
+	var req models.PayWithdrawalRequestRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Dữ liệu không hợp lệ: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	wr, err := h.withdrawalService.PayWithdrawalRequest(c.Param("id"), &req, claims.UserID)
+	if err != nil {
+		respondWithdrawalRequestError(c, err, "Lỗi khi chi tiền cho yêu cầu rút tiền")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã chi tiền cho yêu cầu rút tiền",
+		"data":    wr,
+	})
+}
+
+// respondWithdrawalRequestError: không tìm thấy -> 404, sai trạng thái -> 409, dữ liệu / số dư không hợp lệ -> 400, còn lại 500
+func respondWithdrawalRequestError(c *gin.Context, err error, message string) {
+	status := http.StatusInternalServerError
+	var validationErr *service.ValidationError
+	switch {
+	case errors.Is(err, service.ErrWithdrawalRequestNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrWithdrawalRequestState):
+		status = http.StatusConflict
+	case errors.As(err, &validationErr):
+		status = http.StatusBadRequest
+	default:
+		log.Printf("Handler - ❌ %s: %v", message, err)
+		err = errors.New(message)
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"fullstack-backend/internal/api/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupAttachmentRoutes thiết lập các routes tệp đính kèm (ảnh/PDF bằng chứng) của đơn hàng
+func setupAttachmentRoutes(api *gin.RouterGroup, handler *handlers.AttachmentHandler) {
+	attachments := api.Group("/bet-receipts/:id/attachments")
+	{
+		// Protected routes - cần JWT token (admin hoặc người nhận kèo)
+		attachments.POST("", handler.UploadAttachment)                 // Upload tệp đính kèm (multipart: file, kind)
+		attachments.GET("", handler.GetAttachments)                    // Danh sách tệp đính kèm
+		attachments.GET("/:attachmentId", handler.DownloadAttachment)  // Tải tệp đính kèm
+		attachments.DELETE("/:attachmentId", handler.DeleteAttachment) // Xóa tệp đính kèm (admin hoặc người upload)
+	}
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"fullstack-backend/internal/api/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupClaimRoutes thiết lập các routes pool đơn hàng mới (tự nhận đơn) và giao đơn
+func setupClaimRoutes(api *gin.RouterGroup, handler *handlers.ClaimHandler) {
+	betReceipts := api.Group("/bet-receipts")
+	{
+		// Protected routes - cần JWT token
+		betReceipts.GET("/pool", handler.GetPool)                   // Đơn hàng mới chưa có người nhận
+		betReceipts.POST("/:id/claim", handler.ClaimBetReceipt)     // Người nhận kèo tự nhận đơn (ai nhận trước được trước)
+		betReceipts.POST("/:id/release", handler.ReleaseBetReceipt) // Người nhận kèo trả đơn về pool
+		betReceipts.POST("/:id/assign", handler.AssignBetReceipt)   // Giao/giao lại/thu hồi đơn (admin)
+		betReceipts.GET("/:id/claims", handler.GetClaimHistory)     // Lịch sử nhận/trả/giao đơn
+	}
+}
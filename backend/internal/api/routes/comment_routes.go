@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"fullstack-backend/internal/api/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupCommentRoutes thiết lập các routes bình luận của đơn hàng
+func setupCommentRoutes(api *gin.RouterGroup, handler *handlers.CommentHandler) {
+	comments := api.Group("/bet-receipts/:id/comments")
+	{
+		// Protected routes - cần JWT token (admin hoặc người nhận kèo)
+		comments.POST("", handler.AddComment)                 // Thêm bình luận (@mention, ghi chú nội bộ chỉ admin)
+		comments.GET("", handler.GetComments)                 // Danh sách bình luận (ghi chú nội bộ chỉ admin xem)
+		comments.PUT("/:commentId", handler.UpdateComment)    // Sửa bình luận (người viết, trong thời hạn sửa)
+		comments.DELETE("/:commentId", handler.DeleteComment) // Xóa bình luận (admin, hoặc người viết trong thời hạn sửa)
+	}
+}
@@ -13,6 +13,7 @@ func setupDepositRoutes(api *gin.RouterGroup, handler *handlers.DepositHandler)
 		// Protected routes - cần JWT token
 		deposits.POST("", handler.CreateDeposit)        // Nạp tiền
 		deposits.GET("", handler.GetAllDeposits)        // Lấy tất cả lịch sử nạp tiền
+		deposits.GET("/export", handler.ExportDeposits) // Export lịch sử nạp tiền ra XLSX/CSV (admin)
 	}
 }
 
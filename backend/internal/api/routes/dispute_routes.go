@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"fullstack-backend/internal/api/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupDisputeRoutes thiết lập các routes tranh chấp (trọng tài) của đơn hàng "CHỜ TRỌNG TÀI"
+func setupDisputeRoutes(api *gin.RouterGroup, handler *handlers.DisputeHandler) {
+	// Protected routes - cần JWT token
+	api.POST("/bet-receipts/:id/disputes", handler.OpenDispute)          // Mở tranh chấp, chuyển đơn hàng sang CHỜ TRỌNG TÀI (admin hoặc người nhận kèo)
+	api.GET("/bet-receipts/:id/disputes", handler.GetBetReceiptDisputes) // Tranh chấp của đơn hàng
+
+	disputes := api.Group("/disputes")
+	{
+		disputes.GET("", handler.GetDisputes)                 // Danh sách tranh chấp (admin)
+		disputes.GET("/report", handler.GetWorkerReport)      // Thống kê tranh chấp theo người nhận kèo (admin)
+		disputes.GET("/:id", handler.GetDispute)              // Chi tiết tranh chấp kèm tệp bằng chứng
+		disputes.PUT("/:id/referee", handler.AssignReferee)   // Giao trọng tài (admin)
+		disputes.POST("/:id/resolve", handler.ResolveDispute) // Trọng tài quyết định DONE / HỦY BỎ / ĐỀN
+	}
+}
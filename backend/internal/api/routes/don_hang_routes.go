@@ -11,15 +11,26 @@ func setupDonHangRoutes(api *gin.RouterGroup, handler *handlers.BetReceiptHandle
 	betReceipts := api.Group("/bet-receipts")
 	{
 		// Protected routes - cần JWT token
-		betReceipts.POST("", handler.CreateBetReceipt)                   // Tạo đơn hàng mới
+		betReceipts.POST("", handler.CreateBetReceipt)                   // Tạo đơn hàng mới (đơn hàng nghi trùng cần ?force=true)
 		betReceipts.GET("", handler.GetAllBetReceipts)                   // Lấy danh sách đơn hàng
+		betReceipts.POST("/import", handler.ImportBetReceipts)          // Import đơn hàng từ file CSV/XLSX (admin, mặc định dry_run=true)
+		betReceipts.GET("/export", handler.ExportBetReceipts)                // Export đơn hàng ra XLSX/CSV (cùng filter với GET, group_by=month: mỗi tháng một sheet)
+		betReceipts.POST("/bulk-status", handler.BulkUpdateBetReceiptStatus) // Cập nhật status hàng loạt (một transaction, trả về kết quả từng đơn)
+		betReceipts.POST("/quote", handler.QuoteBetReceipt)                  // Báo giá Công thực nhận trước khi tạo đơn hàng (DONE hoặc HỦY BỎ)
 		betReceipts.GET("/current-exchange-rate", handler.GetCurrentExchangeRate) // Lấy tỷ giá hiện tại
+		betReceipts.GET("/trash", handler.GetDeletedBetReceipts)                 // Thùng rác: đơn hàng đã xóa (admin, phải đặt trước /:id)
+		betReceipts.GET("/duplicates", handler.GetDuplicateReport)               // Báo cáo nhóm đơn hàng nghi trùng (admin, ?rule=order_code|task_account_day)
 		betReceipts.GET("/top-5-monthly", handler.GetTop5UsersByMonthlyReceivedAmount) // Lấy top 5 users theo số tiền đã nhận trong tháng (phải đặt trước /:id)
 		betReceipts.GET("/monthly-total", handler.GetMonthlyTotalByUserID)              // Tính tổng số tiền đã nhận theo tháng cho user hiện tại (phải đặt trước /:id)
 		betReceipts.GET("/:id", handler.GetBetReceiptByID)               // Lấy thông tin đơn hàng theo ID
 		betReceipts.PATCH("/:id/status", handler.UpdateBetReceiptStatus) // Cập nhật status đơn hàng (tự động tính Công thực nhận khi DONE)
-		betReceipts.PUT("/:id", handler.UpdateBetReceipt)                // Cập nhật các trường thông thường của đơn hàng (không phải status)
-		betReceipts.DELETE("/:id", handler.DeleteBetReceipt)             // Xóa đơn hàng
+		betReceipts.GET("/:id/allowed-transitions", handler.GetAllowedTransitions) // Lấy các status có thể chuyển tới (kèm trường bắt buộc)
+		betReceipts.POST("/:id/credentials/reveal", handler.RevealCredentials)        // Xem tài khoản/mật khẩu đã giải mã (admin hoặc người nhận kèo, có ghi nhật ký)
+		betReceipts.GET("/:id/credentials/access-log", handler.GetCredentialAccessLog) // Nhật ký xem tài khoản/mật khẩu (admin)
+		betReceipts.PUT("/:id", handler.UpdateBetReceipt)                // Cập nhật các trường thông thường của đơn hàng (không phải status, nghi trùng cần ?force=true)
+		betReceipts.DELETE("/:id", handler.DeleteBetReceipt)             // Xóa mềm đơn hàng (chuyển vào thùng rác)
+		betReceipts.POST("/:id/restore", handler.RestoreBetReceipt)      // Khôi phục đơn hàng từ thùng rác (admin, tính lại wallet)
+		betReceipts.POST("/:id/revert/:historyId", handler.RevertBetReceipt) // Hoàn tác về phiên bản trong lịch sử (admin, ?snapshot=old|new)
 		betReceipts.POST("/update-exchange-rate", handler.UpdateExchangeRateForProcessedOrders) // Cập nhật tỷ giá cho các đơn hàng đã xử lí
 		betReceipts.POST("/:id/recalculate-amount", handler.RecalculateActualAmountCNY) // Tính lại tệ cho đơn hàng đã xử lý
 	}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"fullstack-backend/internal/api/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupFeeScheduleRoutes thiết lập các routes quản lý biểu phí
+func setupFeeScheduleRoutes(api *gin.RouterGroup, handler *handlers.FeeScheduleHandler) {
+	feeSchedules := api.Group("/fee-schedules")
+	{
+		// Protected routes - cần JWT token (tạo/sửa/xóa chỉ admin)
+		feeSchedules.GET("", handler.GetFeeSchedules)               // Lấy tất cả biểu phí
+		feeSchedules.GET("/current", handler.GetCurrentFeeSchedule) // Biểu phí đang có hiệu lực (at=RFC3339: tại thời điểm khác)
+		feeSchedules.GET("/:version", handler.GetFeeSchedule)       // Lấy biểu phí theo version
+		feeSchedules.POST("", handler.CreateFeeSchedule)            // Tạo biểu phí mới (version mới)
+		feeSchedules.PUT("/:version", handler.UpdateFeeSchedule)    // Sửa biểu phí chưa được sử dụng
+		feeSchedules.DELETE("/:version", handler.DeleteFeeSchedule) // Xóa biểu phí chưa được sử dụng
+	}
+}
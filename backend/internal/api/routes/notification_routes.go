@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"fullstack-backend/internal/api/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupNotificationRoutes thiết lập các routes liên quan đến thông báo của user hiện tại
+func setupNotificationRoutes(api *gin.RouterGroup, handler *handlers.NotificationHandler) {
+	notifications := api.Group("/notifications")
+	{
+		// Protected routes - cần JWT token
+		notifications.GET("", handler.GetNotifications)                   // Lấy thông báo (unread=true: chỉ chưa đọc)
+		notifications.POST("/read-all", handler.MarkAllNotificationsRead) // Đánh dấu đã đọc tất cả
+		notifications.PATCH("/:id/read", handler.MarkNotificationRead)    // Đánh dấu đã đọc một thông báo
+	}
+}
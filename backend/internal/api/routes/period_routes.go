@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"fullstack-backend/internal/api/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupPeriodRoutes thiết lập các routes khóa sổ theo tháng
+func setupPeriodRoutes(api *gin.RouterGroup, handler *handlers.PeriodHandler) {
+	periods := api.Group("/periods")
+	{
+		// Protected routes - chỉ admin
+		periods.GET("", handler.GetClosedPeriods)                    // Danh sách tháng đã khóa sổ
+		periods.POST("/close", handler.ClosePeriod)                  // Khóa sổ tháng đã qua (month, note)
+		periods.GET("/:month/snapshots", handler.GetPeriodSnapshots) // Số dư ví đã chụp khi khóa sổ
+	}
+}
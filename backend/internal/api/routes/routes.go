@@ -15,6 +15,14 @@ func SetupRoutes(
 	depositHandler *handlers.DepositHandler,
 	withdrawalHandler *handlers.WithdrawalHandler,
 	historyHandler *handlers.BetReceiptHistoryHandler,
+	notificationHandler *handlers.NotificationHandler,
+	feeScheduleHandler *handlers.FeeScheduleHandler,
+	attachmentHandler *handlers.AttachmentHandler,
+	commentHandler *handlers.CommentHandler,
+	claimHandler *handlers.ClaimHandler,
+	disputeHandler *handlers.DisputeHandler,
+	taskCodeHandler *handlers.TaskCodeHandler,
+	periodHandler *handlers.PeriodHandler,
 ) {
 	// API group - prefix /api cho tất cả endpoints
 	api := router.Group("/api")
@@ -34,6 +42,14 @@ func SetupRoutes(
 	setupDepositRoutes(api, depositHandler)
 	setupWithdrawalRoutes(api, withdrawalHandler)
 	SetupBetReceiptHistoryRoutes(api, historyHandler)
+	setupNotificationRoutes(api, notificationHandler)
+	setupFeeScheduleRoutes(api, feeScheduleHandler)
+	setupAttachmentRoutes(api, attachmentHandler)
+	setupCommentRoutes(api, commentHandler)
+	setupClaimRoutes(api, claimHandler)
+	setupDisputeRoutes(api, disputeHandler)
+	setupTaskCodeRoutes(api, taskCodeHandler)
+	setupPeriodRoutes(api, periodHandler)
 
 	// TODO: Thêm các routes khác ở đây khi phát triển
 	// setupUserRoutes(api, userHandler)
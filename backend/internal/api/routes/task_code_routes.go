@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"fullstack-backend/internal/api/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupTaskCodeRoutes thiết lập các routes quản lý danh mục mã nhiệm vụ
+func setupTaskCodeRoutes(api *gin.RouterGroup, handler *handlers.TaskCodeHandler) {
+	taskCodes := api.Group("/task-codes")
+	{
+		// Protected routes - cần JWT token (tạo/sửa/xóa và thống kê chỉ admin)
+		taskCodes.GET("", handler.GetTaskCodes)           // Danh mục mã nhiệm vụ (active=true|false)
+		taskCodes.GET("/stats", handler.GetTaskCodeStats) // Thống kê theo mã nhiệm vụ (from, to)
+		taskCodes.GET("/:id", handler.GetTaskCode)        // Lấy mã nhiệm vụ theo ID
+		taskCodes.POST("", handler.CreateTaskCode)        // Thêm mã nhiệm vụ
+		taskCodes.PUT("/:id", handler.UpdateTaskCode)     // Sửa mã nhiệm vụ / ngừng sử dụng
+		taskCodes.DELETE("/:id", handler.DeleteTaskCode)  // Xóa mã nhiệm vụ chưa được sử dụng
+	}
+}
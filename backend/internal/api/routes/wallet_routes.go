@@ -11,8 +11,12 @@ func setupWalletRoutes(api *gin.RouterGroup, handler *handlers.WalletHandler) {
 	wallets := api.Group("/wallets")
 	{
 		// Protected routes - cần JWT token
-		wallets.GET("", handler.GetAllWallets)                           // Lấy danh sách tất cả wallets
-		wallets.POST("/recalculate-all", handler.RecalculateAllWallets)  // Tính toán lại tất cả wallets từ dữ liệu thực tế
-		wallets.POST("/:user_id/recalculate", handler.RecalculateWallet) // Tính toán lại wallet cho một user cụ thể
+		wallets.GET("", handler.GetAllWallets)                                       // Lấy danh sách tất cả wallets
+		wallets.GET("/export", handler.ExportWallets)                                // Export tổng hợp tài chính ra XLSX/CSV (admin)
+		wallets.POST("/recalculate-all", handler.RecalculateAllWallets)              // Tính toán lại tất cả wallets từ dữ liệu thực tế
+		wallets.POST("/:user_id/recalculate", handler.RecalculateWallet)             // Tính toán lại wallet cho một user cụ thể
+		wallets.GET("/:user_id/ledger", handler.GetLedgerEntries)                    // Sổ cái ví của user (user thường chỉ xem của mình)
+		wallets.POST("/:user_id/ledger/adjustments", handler.CreateLedgerAdjustment) // Ghi bút toán điều chỉnh thủ công (admin)
+		wallets.GET("/:user_id/statement", handler.GetStatement)                     // Sao kê ví theo tháng (month=YYYY-MM)
 	}
 }
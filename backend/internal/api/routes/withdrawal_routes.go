@@ -11,8 +11,17 @@ func setupWithdrawalRoutes(api *gin.RouterGroup, handler *handlers.WithdrawalHan
 	withdrawals := api.Group("/withdrawals")
 	{
 		// Protected routes - cần JWT token
-		withdrawals.POST("", handler.CreateWithdrawal)  // Rút tiền
-		withdrawals.GET("", handler.GetAllWithdrawals)  // Lấy tất cả lịch sử rút tiền
+		withdrawals.POST("", handler.CreateWithdrawal)  // Admin chi trực tiếp: tạo + duyệt + chi yêu cầu rút tiền trong một transaction
+		withdrawals.GET("", handler.GetAllWithdrawals)  // Lấy tất cả lịch sử rút tiền (chỉ các lần đã chi)
+		withdrawals.GET("/export", handler.ExportWithdrawals) // Export lịch sử rút tiền ra XLSX/CSV (admin)
+
+		// Yêu cầu rút tiền: pending -> approved/rejected -> paid, chỉ paid mới trừ ví
+		withdrawals.POST("/requests", handler.CreateWithdrawalRequest)              // User gửi yêu cầu rút tiền
+		withdrawals.GET("/requests", handler.GetWithdrawalRequests)                 // Hàng đợi (admin) / yêu cầu của mình (status)
+		withdrawals.GET("/requests/:id", handler.GetWithdrawalRequest)              // Chi tiết yêu cầu
+		withdrawals.POST("/requests/:id/approve", handler.ApproveWithdrawalRequest) // Duyệt (admin)
+		withdrawals.POST("/requests/:id/reject", handler.RejectWithdrawalRequest)   // Từ chối, kèm lý do (admin)
+		withdrawals.POST("/requests/:id/pay", handler.PayWithdrawalRequest)         // Chi tiền, ghi lịch sử rút tiền (admin)
 	}
 }
 
@@ -1,11 +1,24 @@
 package config
 
 import (
+	"fmt"
+	"fullstack-backend/pkg/money"
 	"os"
 	"strconv"
+	"time"
 )
 
+// AppEnvDevelopment - Giá trị APP_ENV của môi trường dev (cho phép chạy với key mặc định)
+const AppEnvDevelopment = "development"
+
+// DefaultCredentialKeys - Key mặc định cho môi trường dev (APP_ENV=development), server không khởi động với key này ở môi trường khác
+const DefaultCredentialKeys = "dev:ZGV2LWNyZWRlbnRpYWwta2V5LWNoYW5nZS1tZS0hISE="
+
+// DefaultCredentialIndexKey - Key chỉ mục tài khoản mặc định cho môi trường dev (APP_ENV=development), server không khởi động với key này ở môi trường khác
+const DefaultCredentialIndexKey = "ZGV2LWNyZWRlbnRpYWwtaW5kZXgta2V5LWNoYW5nZSE="
+
 type Config struct {
+	AppEnv       string // "development" cho phép dùng key mặc định, mặc định "production"
 	Port         string
 	DBHost       string
 	DBPort       string
@@ -13,7 +26,33 @@ type Config struct {
 	DBPassword   string
 	DBName       string
 	JWTSecret    string
-	ExchangeRate float64 // Tỷ giá VND/CNY mặc định
+	ExchangeRate money.Rate // Tỷ giá VND/CNY mặc định
+
+	// Chu kỳ job kiểm tra đơn hàng quá hạn (0 = tắt)
+	OverdueCheckInterval time.Duration
+
+	// Master key mã hóa tài khoản/mật khẩu đơn hàng: "id1:base64key1,id2:base64key2" (key đầu tiên dùng để mã hóa)
+	// Đổi key: thêm key mới vào đầu danh sách, khởi động lại server (dữ liệu được mã hóa lại), sau đó có thể bỏ key cũ
+	CredentialKeys string
+
+	// Key (base64, 32 byte) tính chỉ mục mù của tài khoản đơn hàng để phát hiện đơn hàng trùng
+	// Đổi key: xóa tai_khoan_hash (UPDATE thong_tin_nhan_keo SET tai_khoan_hash = NULL) rồi khởi động lại server
+	CredentialIndexKey string
+
+	// Quy tắc phát hiện đơn hàng trùng: "order_code=reject,task_account_day=warn" (reject, warn hoặc off)
+	DuplicateRules string
+
+	// Thư mục lưu tệp đính kèm đơn hàng (ảnh/PDF bằng chứng) - KHÔNG nằm trong thư mục public /uploads
+	AttachmentDir string
+
+	// Thời hạn người viết được sửa/xóa bình luận đơn hàng
+	CommentEditWindow time.Duration
+
+	// Số đơn hàng đang mở tối đa của một người nhận kèo khi tự nhận đơn từ pool (0 = không giới hạn)
+	MaxOpenClaims int
+
+	// Cách đánh mã STT đơn hàng: "global" (mặc định), "monthly" (2026-10-0001) hoặc "user"
+	STTScheme string
 	
 	// Email configuration
 	SMTPHost     string
@@ -25,12 +64,28 @@ type Config struct {
 
 func Load() *Config {
 	exchangeRateStr := getEnv("EXCHANGE_RATE", "3550.0")
-	exchangeRate := 3550.0
-	if rate, err := strconv.ParseFloat(exchangeRateStr, 64); err == nil {
+	exchangeRate := money.RateFromInt(3550)
+	if rate, err := money.ParseRate(exchangeRateStr); err == nil {
 		exchangeRate = rate
 	}
 
+	overdueCheckInterval, err := time.ParseDuration(getEnv("OVERDUE_CHECK_INTERVAL", "1m"))
+	if err != nil {
+		overdueCheckInterval = time.Minute
+	}
+
+	commentEditWindow, err := time.ParseDuration(getEnv("COMMENT_EDIT_WINDOW", "15m"))
+	if err != nil {
+		commentEditWindow = 15 * time.Minute
+	}
+
+	maxOpenClaims, err := strconv.Atoi(getEnv("MAX_OPEN_CLAIMS", "3"))
+	if err != nil || maxOpenClaims < 0 {
+		maxOpenClaims = 3
+	}
+
 	return &Config{
+		AppEnv:       getEnv("APP_ENV", "production"),
 		Port:         getEnv("PORT", "8080"),
 		DBHost:       getEnv("DB_HOST", "localhost"),
 		DBPort:       getEnv("DB_PORT", "5432"),
@@ -39,6 +94,15 @@ func Load() *Config {
 		DBName:       getEnv("DB_NAME", "hst_db"),
 		JWTSecret:    getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
 		ExchangeRate: exchangeRate,
+
+		OverdueCheckInterval: overdueCheckInterval,
+		CredentialKeys:       getEnv("CREDENTIAL_KEYS", DefaultCredentialKeys),
+		CredentialIndexKey:   getEnv("CREDENTIAL_INDEX_KEY", DefaultCredentialIndexKey),
+		DuplicateRules:       getEnv("DUPLICATE_RULES", "order_code=reject,task_account_day=warn"),
+		STTScheme:            getEnv("STT_SCHEME", "global"),
+		AttachmentDir:        getEnv("ATTACHMENT_DIR", "storage/attachments"),
+		CommentEditWindow:    commentEditWindow,
+		MaxOpenClaims:        maxOpenClaims,
 		
 		// Email configuration
 		SMTPHost:     getEnv("SMTP_HOST", "smtp.gmail.com"),
@@ -49,6 +113,32 @@ func Load() *Config {
 	}
 }
 
+// IsDevelopment kiểm tra server chạy ở môi trường dev (APP_ENV=development)
+func (c *Config) IsDevelopment() bool {
+	return c.AppEnv == AppEnvDevelopment
+}
+
+// ValidateCredentialKeys trả về lỗi nếu CREDENTIAL_KEYS chưa set (đang dùng key mặc định công khai trong repo)
+// mà server không chạy ở môi trường dev
+func (c *Config) ValidateCredentialKeys() error {
+	return c.requireNonDefault("CREDENTIAL_KEYS", c.CredentialKeys, DefaultCredentialKeys)
+}
+
+// ValidateCredentialIndexKey trả về lỗi nếu CREDENTIAL_INDEX_KEY chưa set (key HMAC công khai trong repo cho phép
+// dò ngược tai_khoan_hash từ bản dump DB) mà server không chạy ở môi trường dev
+func (c *Config) ValidateCredentialIndexKey() error {
+	return c.requireNonDefault("CREDENTIAL_INDEX_KEY", c.CredentialIndexKey, DefaultCredentialIndexKey)
+}
+
+// requireNonDefault trả về lỗi nếu value là giá trị mặc định (biến môi trường name chưa set hoặc set bằng mặc định)
+// ngoài môi trường dev
+func (c *Config) requireNonDefault(name, value, defaultValue string) error {
+	if value != defaultValue || c.IsDevelopment() {
+		return nil
+	}
+	return fmt.Errorf("%s chưa được cấu hình (đang dùng key mặc định của môi trường dev); đặt %s hoặc APP_ENV=%s khi chạy dev", name, name, AppEnvDevelopment)
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
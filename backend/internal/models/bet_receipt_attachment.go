@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// BetReceiptAttachment - Tệp đính kèm (ảnh/PDF bằng chứng) của đơn hàng
+type BetReceiptAttachment struct {
+	ID             string    `json:"id" db:"id"`
+	BetReceiptID   string    `json:"bet_receipt_id" db:"bet_receipt_id"`
+	Kind           string    `json:"kind" db:"kind"`                         // proof, dispute, other
+	FileName       string    `json:"file_name" db:"file_name"`               // Tên file gốc
+	StoredName     string    `json:"-" db:"stored_name"`                     // Tên file lưu trên đĩa (không trả về client)
+	ContentType    string    `json:"content_type" db:"content_type"`         // Nhận diện từ nội dung file
+	SizeBytes      int64     `json:"size_bytes" db:"size_bytes"`             // Dung lượng (bytes)
+	UploadedBy     *string   `json:"uploaded_by,omitempty" db:"uploaded_by"` // ID người upload
+	UploadedByName string    `json:"uploaded_by_name,omitempty" db:"-"`      // Tên người upload (join)
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`             // Thời gian upload
+}
+
+// Loại tệp đính kèm
+const (
+	AttachmentKindProof   = "proof"   // Bằng chứng hoàn thành/thanh toán
+	AttachmentKindDispute = "dispute" // Bằng chứng tranh chấp (CHỜ TRỌNG TÀI, ĐỀN)
+	AttachmentKindOther   = "other"
+)
+
+// IsValidAttachmentKind kiểm tra loại tệp đính kèm hợp lệ
+func IsValidAttachmentKind(kind string) bool {
+	return kind == AttachmentKindProof || kind == AttachmentKindDispute || kind == AttachmentKindOther
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// BetReceiptClaim - Một lần nhận/trả/giao đơn hàng (bảng bet_receipt_claims)
+type BetReceiptClaim struct {
+	ID              string    `json:"id" db:"id"`
+	BetReceiptID    string    `json:"bet_receipt_id" db:"bet_receipt_id"`
+	Action          string    `json:"action" db:"action"`                       // CLAIM, RELEASE, ASSIGN, UNASSIGN
+	FromUserID      *string   `json:"from_user_id,omitempty" db:"from_user_id"` // Người nhận trước đó (nil = pool)
+	FromUserName    string    `json:"from_user_name,omitempty" db:"-"`
+	ToUserID        *string   `json:"to_user_id,omitempty" db:"to_user_id"` // Người nhận mới (nil = trả về pool)
+	ToUserName      string    `json:"to_user_name,omitempty" db:"-"`
+	PerformedBy     *string   `json:"performed_by,omitempty" db:"performed_by"`
+	PerformedByName string    `json:"performed_by_name,omitempty" db:"-"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// Loại thao tác nhận/giao đơn hàng
+const (
+	ClaimActionClaim    = "CLAIM"    // Người nhận kèo tự nhận đơn từ pool
+	ClaimActionRelease  = "RELEASE"  // Người nhận kèo trả đơn về pool
+	ClaimActionAssign   = "ASSIGN"   // Admin giao/giao lại đơn cho người dùng
+	ClaimActionUnassign = "UNASSIGN" // Admin thu hồi đơn về pool
+)
+
+// AssignBetReceiptRequest - Request body admin giao đơn hàng (user_id = null: thu hồi về pool)
+type AssignBetReceiptRequest struct {
+	UserID *string `json:"user_id"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// BetReceiptComment - Bình luận của đơn hàng
+type BetReceiptComment struct {
+	ID                string     `json:"id" db:"id"`
+	BetReceiptID      string     `json:"bet_receipt_id" db:"bet_receipt_id"`
+	AuthorID          *string    `json:"author_id,omitempty" db:"author_id"`                 // Người viết (nil nếu tài khoản đã bị xóa)
+	AuthorName        string     `json:"author_name,omitempty" db:"-"`                       // Tên người viết (join)
+	Content           string     `json:"content" db:"content"`                               // Nội dung
+	MentionedUserID   *string    `json:"mentioned_user_id,omitempty" db:"mentioned_user_id"` // Người được @mention
+	MentionedUserName string     `json:"mentioned_user_name,omitempty" db:"-"`               // Tên người được @mention (join)
+	Internal          bool       `json:"internal" db:"internal"`                             // Ghi chú nội bộ (chỉ admin xem)
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	EditedAt          *time.Time `json:"edited_at,omitempty" db:"edited_at"` // Lần sửa cuối (nil = chưa sửa)
+}
+
+// CreateBetReceiptCommentRequest - Request body thêm bình luận
+type CreateBetReceiptCommentRequest struct {
+	Content         string  `json:"content" binding:"required"`
+	MentionedUserID *string `json:"mentioned_user_id"` // @mention một người dùng (nhận thông báo)
+	Internal        bool    `json:"internal"`          // Ghi chú nội bộ (chỉ admin)
+}
+
+// UpdateBetReceiptCommentRequest - Request body sửa bình luận
+type UpdateBetReceiptCommentRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// CommentMaxLength - Độ dài tối đa của nội dung bình luận (ký tự)
+const CommentMaxLength = 2000
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// BetReceiptCredentials - Tài khoản/mật khẩu đã giải mã của đơn hàng (chỉ trả về qua API reveal)
+type BetReceiptCredentials struct {
+	BetReceiptID string `json:"bet_receipt_id"`
+	Account      string `json:"account"`
+	Password     string `json:"password"`
+}
+
+// RevealCredentialsRequest - Yêu cầu xem tài khoản/mật khẩu (POST /api/bet-receipts/:id/credentials/reveal)
+type RevealCredentialsRequest struct {
+	Reason string `json:"reason"` // Lý do xem (được ghi vào nhật ký)
+}
+
+// CredentialAccessLog - Nhật ký xem tài khoản/mật khẩu (bảng bet_receipt_credential_access)
+// Ghi cả lần được phép và lần bị từ chối
+type CredentialAccessLog struct {
+	ID           string    `json:"id" db:"id"`
+	BetReceiptID string    `json:"bet_receipt_id" db:"bet_receipt_id"`
+	UserID       *string   `json:"user_id,omitempty" db:"user_id"`
+	UserName     string    `json:"user_name,omitempty" db:"-"` // Tên người xem (join)
+	Allowed      bool      `json:"allowed" db:"allowed"`
+	Reason       string    `json:"reason,omitempty" db:"reason"`
+	IPAddress    string    `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent    string    `json:"user_agent,omitempty" db:"user_agent"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"fullstack-backend/pkg/money"
+	"time"
+)
+
+// BetReceiptDispute - Tranh chấp (trọng tài) của đơn hàng "CHỜ TRỌNG TÀI"
+type BetReceiptDispute struct {
+	ID                string     `json:"id" db:"id"`
+	BetReceiptID      string     `json:"bet_receipt_id" db:"bet_receipt_id"`
+	STTCode           string     `json:"stt_code,omitempty" db:"-"`                              // Mã STT của đơn hàng (join)
+	TaskCode          string     `json:"task_code,omitempty" db:"-"`                             // Mã nhiệm vụ của đơn hàng (join)
+	WorkerID          *string    `json:"worker_id,omitempty" db:"worker_id"`                     // Người nhận kèo lúc mở tranh chấp
+	WorkerName        string     `json:"worker_name,omitempty" db:"-"`                           // Tên người nhận kèo (join)
+	PreviousStatus    string     `json:"previous_status" db:"previous_status"`                   // Status trước khi mở tranh chấp
+	OpenedBy          *string    `json:"opened_by,omitempty" db:"opened_by"`                     // Người mở tranh chấp
+	OpenedByName      string     `json:"opened_by_name,omitempty" db:"-"`                        // Tên người mở (join)
+	Reason            string     `json:"reason" db:"reason"`                                     // Lý do tranh chấp
+	Evidence          string     `json:"evidence,omitempty" db:"evidence"`                       // Mô tả bằng chứng
+	RefereeID         *string    `json:"referee_id,omitempty" db:"referee_id"`                   // Trọng tài được giao
+	RefereeName       string     `json:"referee_name,omitempty" db:"-"`                          // Tên trọng tài (join)
+	Decision          *string    `json:"decision,omitempty" db:"decision"`                       // DONE, HỦY BỎ, ĐỀN (nil = đang mở)
+	DecisionAmountCNY *money.CNY `json:"decision_amount_cny,omitempty" db:"decision_amount_cny"` // HỦY BỎ: tiền thực nhận, ĐỀN: tiền đền
+	DecisionNote      string     `json:"decision_note,omitempty" db:"decision_note"`
+	DecidedBy         *string    `json:"decided_by,omitempty" db:"decided_by"`
+	DecidedAt         *time.Time `json:"decided_at,omitempty" db:"decided_at"` // Thời điểm quyết định (nil = đang mở)
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+
+	// Bằng chứng dạng file (tệp đính kèm kind = "dispute" của đơn hàng), chỉ có khi lấy chi tiết
+	Attachments []*BetReceiptAttachment `json:"attachments,omitempty" db:"-"`
+}
+
+// IsOpen - Tranh chấp chưa có quyết định
+func (d *BetReceiptDispute) IsOpen() bool {
+	return d.DecidedAt == nil
+}
+
+// OpenDisputeRequest - Request body mở tranh chấp
+type OpenDisputeRequest struct {
+	Reason   string `json:"reason" binding:"required"`
+	Evidence string `json:"evidence"`
+}
+
+// AssignRefereeRequest - Request body giao trọng tài
+type AssignRefereeRequest struct {
+	RefereeID string `json:"referee_id" binding:"required"`
+}
+
+// ResolveDisputeRequest - Request body trọng tài quyết định
+// decision = DONE; HỦY BỎ (amount_cny = tiền thực nhận); ĐỀN (amount_cny = tiền đền, note = lý do đền)
+type ResolveDisputeRequest struct {
+	Decision  string     `json:"decision" binding:"required"`
+	AmountCNY *money.CNY `json:"amount_cny"`
+	Note      string     `json:"note"`
+}
+
+// DisputeFilter - Bộ lọc danh sách tranh chấp (GET /api/disputes)
+type DisputeFilter struct {
+	Open      *bool   // true = đang mở, false = đã quyết định
+	RefereeID *string // Lọc theo trọng tài
+	WorkerID  *string // Lọc theo người nhận kèo
+}
+
+// DisputeWorkerReport - Thống kê tranh chấp theo người nhận kèo
+type DisputeWorkerReport struct {
+	WorkerID            string   `json:"worker_id"`
+	WorkerName          string   `json:"worker_name"`
+	Total               int      `json:"total"`                          // Tổng số tranh chấp
+	Open                int      `json:"open"`                           // Đang mở
+	Resolved            int      `json:"resolved"`                       // Đã quyết định
+	DecidedDone         int      `json:"decided_done"`                   // Quyết định DONE
+	DecidedCancelled    int      `json:"decided_cancelled"`              // Quyết định HỦY BỎ
+	DecidedCompensation int      `json:"decided_compensation"`           // Quyết định ĐỀN
+	AvgResolutionHours  *float64 `json:"avg_resolution_hours,omitempty"` // Thời gian xử lý trung bình (giờ, tranh chấp đã quyết định)
+}
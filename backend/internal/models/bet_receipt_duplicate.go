@@ -0,0 +1,84 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Quy tắc phát hiện đơn hàng trùng
+const (
+	DuplicateRuleOrderCode      = "order_code"       // Cùng mã đơn hàng (không phân biệt hoa thường)
+	DuplicateRuleTaskAccountDay = "task_account_day" // Cùng mã nhiệm vụ + tài khoản trong cùng ngày nhận kèo
+)
+
+// DuplicateMode - Cách xử lý khi một quy tắc phát hiện đơn hàng trùng
+type DuplicateMode string
+
+const (
+	DuplicateModeReject DuplicateMode = "reject" // Từ chối tạo/sửa
+	DuplicateModeWarn   DuplicateMode = "warn"   // Cảnh báo, chỉ tạo/sửa khi gửi force=true
+	DuplicateModeOff    DuplicateMode = "off"    // Không kiểm tra
+)
+
+// DuplicateRules - Cách xử lý của từng quy tắc trùng, chọn qua config DUPLICATE_RULES
+type DuplicateRules map[string]DuplicateMode
+
+// ParseDuplicateRules đọc quy tắc trùng từ config dạng "order_code=reject,task_account_day=warn"
+// Quy tắc không được nhắc đến dùng cách xử lý mặc định: trùng mã đơn hàng bị từ chối,
+// trùng nhiệm vụ + tài khoản trong ngày chỉ cảnh báo
+func ParseDuplicateRules(spec string) (DuplicateRules, error) {
+	rules := DuplicateRules{
+		DuplicateRuleOrderCode:      DuplicateModeReject,
+		DuplicateRuleTaskAccountDay: DuplicateModeWarn,
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		rule, mode, ok := strings.Cut(entry, "=")
+		rule = strings.ToLower(strings.TrimSpace(rule))
+		if !ok {
+			return nil, fmt.Errorf("quy tắc trùng '%s' phải có dạng rule=mode", entry)
+		}
+		if _, known := rules[rule]; !known {
+			return nil, fmt.Errorf("quy tắc trùng không hợp lệ: %q (chỉ chấp nhận %s, %s)", rule, DuplicateRuleOrderCode, DuplicateRuleTaskAccountDay)
+		}
+		switch m := DuplicateMode(strings.ToLower(strings.TrimSpace(mode))); m {
+		case DuplicateModeReject, DuplicateModeWarn, DuplicateModeOff:
+			rules[rule] = m
+		default:
+			return nil, fmt.Errorf("cách xử lý quy tắc %s không hợp lệ: %q (chỉ chấp nhận reject, warn, off)", rule, mode)
+		}
+	}
+	return rules, nil
+}
+
+// Enabled kiểm tra quy tắc có được bật không
+func (r DuplicateRules) Enabled(rule string) bool {
+	mode, ok := r[rule]
+	return ok && mode != DuplicateModeOff
+}
+
+// DuplicateMatch - Đơn hàng đã có bị nghi trùng với đơn hàng đang tạo/sửa
+type DuplicateMatch struct {
+	Rule         string        `json:"rule"` // Quy tắc phát hiện
+	Mode         DuplicateMode `json:"mode"` // reject hoặc warn (chỉ có khi tạo/sửa)
+	BetReceiptID string        `json:"bet_receipt_id"`
+	STTCode      string        `json:"stt_code"`
+	TaskCode     string        `json:"task_code"`
+	OrderCode    string        `json:"order_code"`
+	UserID       string        `json:"user_id,omitempty"`
+	UserName     string        `json:"user_name,omitempty"`
+	Account      string        `json:"account,omitempty"` // Tài khoản đã che
+	Status       string        `json:"status"`
+	ReceivedAt   time.Time     `json:"received_at"`
+}
+
+// DuplicateGroup - Nhóm đơn hàng nghi trùng nhau (báo cáo GET /api/bet-receipts/duplicates)
+type DuplicateGroup struct {
+	Rule        string            `json:"rule"`
+	Key         string            `json:"key"` // Mã đơn hàng, hoặc "mã nhiệm vụ / tài khoản đã che / ngày"
+	BetReceipts []*DuplicateMatch `json:"bet_receipts"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import "testing"
+
+func TestParseDuplicateRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    DuplicateRules
+		wantErr bool
+	}{
+		{
+			name: "mặc định",
+			spec: "",
+			want: DuplicateRules{DuplicateRuleOrderCode: DuplicateModeReject, DuplicateRuleTaskAccountDay: DuplicateModeWarn},
+		},
+		{
+			name: "ghi đè một quy tắc",
+			spec: " TASK_ACCOUNT_DAY = Reject ",
+			want: DuplicateRules{DuplicateRuleOrderCode: DuplicateModeReject, DuplicateRuleTaskAccountDay: DuplicateModeReject},
+		},
+		{
+			name: "tắt cả hai quy tắc",
+			spec: "order_code=off,task_account_day=off,",
+			want: DuplicateRules{DuplicateRuleOrderCode: DuplicateModeOff, DuplicateRuleTaskAccountDay: DuplicateModeOff},
+		},
+		{name: "thiếu dấu =", spec: "order_code", wantErr: true},
+		{name: "quy tắc không hợp lệ", spec: "phone=warn", wantErr: true},
+		{name: "cách xử lý không hợp lệ", spec: "order_code=block", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDuplicateRules(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDuplicateRules(%q) = %v, muốn lỗi", tt.spec, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDuplicateRules(%q) error = %v", tt.spec, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseDuplicateRules(%q) = %v, muốn %v", tt.spec, got, tt.want)
+			}
+			for rule, mode := range tt.want {
+				if got[rule] != mode {
+					t.Errorf("ParseDuplicateRules(%q)[%s] = %s, muốn %s", tt.spec, rule, got[rule], mode)
+				}
+			}
+		})
+	}
+}
+
+func TestDuplicateRulesEnabled(t *testing.T) {
+	rules := DuplicateRules{DuplicateRuleOrderCode: DuplicateModeWarn, DuplicateRuleTaskAccountDay: DuplicateModeOff}
+	if !rules.Enabled(DuplicateRuleOrderCode) {
+		t.Errorf("Enabled(%s) = false, muốn true", DuplicateRuleOrderCode)
+	}
+	if rules.Enabled(DuplicateRuleTaskAccountDay) {
+		t.Errorf("Enabled(%s) = true, muốn false", DuplicateRuleTaskAccountDay)
+	}
+	if rules.Enabled("phone") {
+		t.Error("Enabled(phone) = true, muốn false")
+	}
+}
@@ -6,7 +6,7 @@ import "time"
 type BetReceiptHistory struct {
 	ID              string    `json:"id" db:"id"`
 	BetReceiptID    string    `json:"bet_receipt_id" db:"bet_receipt_id"`
-	Action          string    `json:"action" db:"action"`                           // CREATE, UPDATE, DELETE
+	Action          string    `json:"action" db:"action"`                           // CREATE, UPDATE, DELETE, RESTORE, REVERT, ATTACHMENT, OVERDUE
 	PerformedBy     *string   `json:"performed_by,omitempty" db:"performed_by"`     // ID người thực hiện
 	PerformedByName string    `json:"performed_by_name,omitempty" db:"-"`           // Tên người thực hiện (join)
 	OldData         string    `json:"old_data,omitempty" db:"old_data"`             // JSON string
@@ -22,8 +22,13 @@ type BetReceiptHistory struct {
 
 // HistoryAction constants
 const (
-	HistoryActionUpdate = "UPDATE"
-	HistoryActionDelete = "DELETE"
+	HistoryActionCreate  = "CREATE"
+	HistoryActionUpdate  = "UPDATE"
+	HistoryActionDelete  = "DELETE"
+	HistoryActionRestore = "RESTORE"    // Khôi phục đơn hàng đã xóa (từ thùng rác)
+	HistoryActionRevert  = "REVERT"     // Hoàn tác đơn hàng về một phiên bản trong lịch sử
+	HistoryActionAttach  = "ATTACHMENT" // Thêm/xóa tệp đính kèm (bằng chứng)
+	HistoryActionOverdue = "OVERDUE"    // Job kiểm tra deadline đánh dấu đơn hàng quá hạn
 )
 
 // CreateHistoryRequest - Request để tạo lịch sử
@@ -36,3 +41,9 @@ type CreateHistoryRequest struct {
 	ChangedFields map[string]interface{} `json:"changed_fields,omitempty"`
 	Description   string                 `json:"description,omitempty"`
 }
+
+// Phiên bản dùng để hoàn tác (query parameter "snapshot" của API revert)
+const (
+	HistorySnapshotOld = "old" // old_data: trạng thái TRƯỚC thay đổi (hoàn tác thay đổi đó) - mặc định
+	HistorySnapshotNew = "new" // new_data: trạng thái SAU thay đổi (quay về đúng phiên bản đó)
+)
@@ -0,0 +1,57 @@
+package models
+
+// Cột trong file bảng tính (CSV/XLSX) khi import đơn hàng
+// Tiêu đề theo mẫu Excel "Bảng 1", chấp nhận cả tên JSON (user_name, task_code, ...)
+const (
+	ImportColumnUserName        = "user_name"
+	ImportColumnTaskCode        = "task_code"
+	ImportColumnBetType         = "bet_type"
+	ImportColumnWebBetAmountCNY = "web_bet_amount_cny"
+	ImportColumnOrderCode       = "order_code"
+	ImportColumnNotes           = "notes"
+	ImportColumnAccount         = "account"
+	ImportColumnPassword        = "password"
+	ImportColumnRegion          = "region"
+	ImportColumnCompletedHours  = "completed_hours"
+)
+
+// BetReceiptImportColumns - Tên cột (tên JSON) -> các tiêu đề tiếng Việt được chấp nhận (không phân biệt hoa thường)
+var BetReceiptImportColumns = map[string][]string{
+	ImportColumnUserName:        {"tên", "tên người dùng", "người dùng", "tên nhân viên"},
+	ImportColumnTaskCode:        {"mã nhiệm vụ", "nhiệm vụ"},
+	ImportColumnBetType:         {"loại kèo"},
+	ImportColumnWebBetAmountCNY: {"tiền kèo web", "tiền kèo web (tệ)", "tiền kèo"},
+	ImportColumnOrderCode:       {"mã đơn hàng", "mã đơn"},
+	ImportColumnNotes:           {"ghi chú"},
+	ImportColumnAccount:         {"tài khoản"},
+	ImportColumnPassword:        {"mật khẩu"},
+	ImportColumnRegion:          {"khu vực"},
+	ImportColumnCompletedHours:  {"thời gian hoàn thành (giờ)", "số giờ"},
+}
+
+// BetReceiptImportRequiredColumns - Các cột bắt buộc phải có trong dòng tiêu đề
+var BetReceiptImportRequiredColumns = []string{
+	ImportColumnUserName,
+	ImportColumnTaskCode,
+	ImportColumnBetType,
+	ImportColumnWebBetAmountCNY,
+}
+
+// BetReceiptImportError - Lỗi của một dòng trong file import
+type BetReceiptImportError struct {
+	Row     int    `json:"row"`              // Số dòng trong file (dòng tiêu đề = 1)
+	Column  string `json:"column,omitempty"` // Tên cột (tên JSON) bị lỗi
+	Message string `json:"message"`
+}
+
+// BetReceiptImportReport - Kết quả import
+// DryRun = true: chỉ kiểm tra, không ghi DB
+// Committed = true: tất cả dòng đã được tạo trong một transaction (có lỗi thì không dòng nào được tạo)
+type BetReceiptImportReport struct {
+	DryRun    bool                     `json:"dry_run"`
+	Committed bool                     `json:"committed"`
+	TotalRows int                      `json:"total_rows"` // Số dòng dữ liệu (không tính tiêu đề và dòng trống)
+	ValidRows int                      `json:"valid_rows"`
+	Errors    []*BetReceiptImportError `json:"errors"`
+	Created   []*BetReceipt            `json:"created,omitempty"` // Đơn hàng đã tạo (theo thứ tự trong file)
+}
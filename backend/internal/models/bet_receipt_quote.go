@@ -0,0 +1,32 @@
+package models
+
+import "fullstack-backend/pkg/money"
+
+// FeeBreakdown - Chi tiết "Công thực nhận": số tiền tính phí trừ các khoản phí theo biểu phí
+type FeeBreakdown struct {
+	AmountCNY          money.CNY `json:"amount_cny"`           // Số tiền tính phí (giá kèo khi DONE, tiền thực nhận khi HỦY BỎ)
+	WebFeeCNY          money.CNY `json:"web_fee_cny"`          // Phí web (theo bảng phí web, chỉ kèo web)
+	WithdrawalFeeCNY   money.CNY `json:"withdrawal_fee_cny"`   // Phí rút tiền
+	IntermediaryFeeCNY money.CNY `json:"intermediary_fee_cny"` // Phí trung gian
+	NetCNY             money.CNY `json:"net_cny"`              // Công thực nhận = số tiền tính phí - các khoản phí
+}
+
+// BetReceiptQuoteRequest - Báo giá "Công thực nhận" trước khi tạo đơn hàng (POST /api/bet-receipts/quote)
+type BetReceiptQuoteRequest struct {
+	BetType           string     `json:"bet_type" binding:"required"`
+	WebBetAmountCNY   money.CNY  `json:"web_bet_amount_cny" binding:"required"`
+	Status            string     `json:"status"`              // Kịch bản: DONE (mặc định) hoặc HỦY BỎ
+	ActualReceivedCNY *money.CNY `json:"actual_received_cny"` // Tiền thực nhận, bắt buộc khi status = HỦY BỎ
+}
+
+// BetReceiptQuote - Kết quả báo giá: chi tiết phí theo biểu phí đang có hiệu lực và quy đổi VND theo tỷ giá hiện tại
+type BetReceiptQuote struct {
+	BetType            string    `json:"bet_type"`
+	Status             string    `json:"status"`
+	WebBetAmountCNY    money.CNY `json:"web_bet_amount_cny"`
+	FeeScheduleVersion *int      `json:"fee_schedule_version,omitempty"` // nil: không tính phí (HỦY BỎ không có tiền thực nhận)
+	FeeScheduleName    string    `json:"fee_schedule_name,omitempty"`
+	FeeBreakdown
+	ExchangeRate money.Rate `json:"exchange_rate"` // Tỷ giá VND/CNY hiện tại (current_exchange_rate)
+	NetVND       money.VND  `json:"net_vnd"`       // Công thực nhận quy đổi VND
+}
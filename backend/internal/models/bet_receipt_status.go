@@ -0,0 +1,96 @@
+package models
+
+// Bảng chuyển trạng thái (state machine) của đơn hàng
+// Luồng chính: "Đơn hàng mới" -> "ĐANG THỰC HIỆN" -> "CHỜ CHẤP NHẬN" -> "DONE"
+// Các trạng thái đã xử lí (DONE, HỦY BỎ, ĐỀN) chỉ có thể mở lại qua "CHỜ TRỌNG TÀI"
+// để không xóa mất các trường tài chính (tiền thực nhận, tiền đền, thời gian hoàn thành)
+var BetReceiptStatusTransitions = map[string][]string{
+	BetReceiptStatusNew: {
+		BetReceiptStatusInProgress,
+		BetReceiptStatusScanning,
+		BetReceiptStatusCancelled,
+	},
+	BetReceiptStatusInProgress: {
+		BetReceiptStatusScanning,
+		BetReceiptStatusPending,
+		BetReceiptStatusWaitingRef,
+		BetReceiptStatusDone,
+		BetReceiptStatusCancelled,
+		BetReceiptStatusCompensation,
+	},
+	BetReceiptStatusScanning: {
+		BetReceiptStatusInProgress,
+		BetReceiptStatusPending,
+		BetReceiptStatusCancelled,
+	},
+	BetReceiptStatusPending: {
+		BetReceiptStatusInProgress,
+		BetReceiptStatusWaitingRef,
+		BetReceiptStatusDone,
+		BetReceiptStatusCancelled,
+		BetReceiptStatusCompensation,
+	},
+	BetReceiptStatusWaitingRef: {
+		BetReceiptStatusPending,
+		BetReceiptStatusDone,
+		BetReceiptStatusCancelled,
+		BetReceiptStatusCompensation,
+	},
+	BetReceiptStatusDone: {
+		BetReceiptStatusWaitingRef,
+	},
+	// HỦY BỎ và ĐỀN cho phép chuyển sang chính nó để sửa lại số tiền
+	BetReceiptStatusCancelled: {
+		BetReceiptStatusCancelled,
+		BetReceiptStatusWaitingRef,
+	},
+	BetReceiptStatusCompensation: {
+		BetReceiptStatusCompensation,
+		BetReceiptStatusWaitingRef,
+	},
+}
+
+// BetReceiptStatusRequiredFields - Các trường bắt buộc trong UpdateBetReceiptStatusRequest theo status đích
+// Tên trường là tên JSON để frontend hiển thị form tương ứng
+var BetReceiptStatusRequiredFields = map[string][]string{
+	BetReceiptStatusCancelled:    {"actual_received_cny"},
+	BetReceiptStatusCompensation: {"compensation_cny", "cancel_reason"},
+}
+
+// AllowedStatusTransition - Một bước chuyển trạng thái hợp lệ kèm các trường bắt buộc
+type AllowedStatusTransition struct {
+	Status         string   `json:"status"`
+	RequiredFields []string `json:"required_fields"`
+}
+
+// IsValidBetReceiptStatus kiểm tra status có thuộc các BetReceiptStatus* constants không
+func IsValidBetReceiptStatus(status string) bool {
+	_, ok := BetReceiptStatusTransitions[status]
+	return ok
+}
+
+// CanTransitionBetReceiptStatus kiểm tra có được phép chuyển từ status from sang status to không
+func CanTransitionBetReceiptStatus(from, to string) bool {
+	for _, next := range BetReceiptStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAllowedStatusTransitions trả về danh sách các status có thể chuyển tới từ status hiện tại
+func GetAllowedStatusTransitions(from string) []AllowedStatusTransition {
+	transitions := []AllowedStatusTransition{}
+	for _, next := range BetReceiptStatusTransitions[from] {
+		requiredFields := BetReceiptStatusRequiredFields[next]
+		if requiredFields == nil {
+			requiredFields = []string{}
+		}
+		transitions = append(transitions, AllowedStatusTransition{
+			Status:         next,
+			RequiredFields: requiredFields,
+		})
+	}
+	return transitions
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"fullstack-backend/pkg/money"
+	"time"
+)
+
+// ClosedPeriod - Tháng đã khóa sổ (bảng closed_periods)
+// Đơn hàng hoàn thành, nộp cọc và rút tiền trong tháng bị khóa, không sửa/xóa được nữa
+type ClosedPeriod struct {
+	Month             string    `json:"month"` // YYYY-MM
+	Note              string    `json:"note"`
+	LockedReceipts    int       `json:"locked_receipts"`    // Số đơn hàng bị khóa
+	LockedDeposits    int       `json:"locked_deposits"`    // Số lần nộp cọc bị khóa
+	LockedWithdrawals int       `json:"locked_withdrawals"` // Số lần rút tiền bị khóa
+	WalletCount       int       `json:"wallet_count"`       // Số ví đã chụp số dư
+	ClosedBy          *string   `json:"closed_by"`
+	ClosedByName      string    `json:"closed_by_name,omitempty"`
+	ClosedAt          time.Time `json:"closed_at"`
+}
+
+// WalletPeriodSnapshot - Số dư ví của một user tại thời điểm khóa sổ tháng (bảng wallet_period_snapshots)
+// Cùng cách tính với sao kê ví (WalletStatement) nhưng được lưu lại, không đổi theo bút toán ghi sau
+type WalletPeriodSnapshot struct {
+	Month             string    `json:"month"`
+	UserID            string    `json:"user_id"`
+	UserName          string    `json:"user_name"`
+	OpeningBalanceVND money.VND `json:"opening_balance_vnd"`
+	ReceivedCNY       money.CNY `json:"received_cny"` // receipt_settlement + revaluation
+	ReceivedVND       money.VND `json:"received_vnd"`
+	CompensationCNY   money.CNY `json:"compensation_cny"`
+	CompensationVND   money.VND `json:"compensation_vnd"`
+	DepositVND        money.VND `json:"deposit_vnd"`
+	WithdrawnCNY      money.CNY `json:"withdrawn_cny"`
+	WithdrawnVND      money.VND `json:"withdrawn_vnd"`
+	AdjustmentVND     money.VND `json:"adjustment_vnd"`
+	ClosingBalanceVND money.VND `json:"closing_balance_vnd"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ClosePeriodRequest - Khóa sổ một tháng đã qua (admin)
+type ClosePeriodRequest struct {
+	Month string `json:"month" binding:"required"` // YYYY-MM
+	Note  string `json:"note"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"fullstack-backend/pkg/money"
+	"time"
+)
+
+// FeeSchedule - Biểu phí dùng để tính "Công thực nhận" (bảng fee_schedules)
+// Mỗi lần thay đổi phí là một version mới có thời điểm hiệu lực riêng,
+// đơn hàng lưu version đã dùng (BetReceipt.FeeScheduleVersion) để tính lại cho ra cùng kết quả
+type FeeSchedule struct {
+	Version                   int          `json:"version" db:"version"`
+	Name                      string       `json:"name" db:"name"`
+	EffectiveFrom             time.Time    `json:"effective_from" db:"effective_from"`                             // Thời điểm bắt đầu có hiệu lực
+	WebFeeBrackets            []FeeBracket `json:"web_fee_brackets" db:"web_fee_brackets"`                         // Bảng phí web theo giá kèo (min tăng dần)
+	WebWithdrawalFeeRate      money.Rate   `json:"web_withdrawal_fee_rate" db:"web_withdrawal_fee_rate"`           // Phí rút tiền kèo web (vd: 0.02 = 2%)
+	ExternalWithdrawalFeeRate money.Rate   `json:"external_withdrawal_fee_rate" db:"external_withdrawal_fee_rate"` // Phí rút tiền kèo ngoài (vd: 0.01 = 1%)
+	IntermediaryFeeRate       money.Rate   `json:"intermediary_fee_rate" db:"intermediary_fee_rate"`               // Phí trung gian (vd: 0.06 = 6%)
+	CreatedBy                 *string      `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt                 time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt                 time.Time    `json:"updated_at" db:"updated_at"`
+	InUse                     bool         `json:"in_use" db:"-"` // Đã có đơn hàng tính theo biểu phí này (không được sửa/xóa)
+}
+
+// FeeBracket - Một mức phí web: áp dụng khi giá kèo >= MinCNY (đến mức tiếp theo)
+type FeeBracket struct {
+	MinCNY money.CNY `json:"min_cny"`
+	FeeCNY money.CNY `json:"fee_cny"`
+}
+
+// WebFee tra cứu phí web theo giá kèo (tệ): mức có MinCNY lớn nhất mà <= giá kèo
+func (s *FeeSchedule) WebFee(amountCNY money.CNY) money.CNY {
+	var fee money.CNY
+	for _, bracket := range s.WebFeeBrackets {
+		if amountCNY < bracket.MinCNY {
+			break
+		}
+		fee = bracket.FeeCNY
+	}
+	return fee
+}
+
+// FeeScheduleRequest - Tạo/sửa biểu phí (POST /api/fee-schedules, PUT /api/fee-schedules/:version)
+type FeeScheduleRequest struct {
+	Name                      string       `json:"name" binding:"required"`
+	EffectiveFrom             time.Time    `json:"effective_from" binding:"required"`
+	WebFeeBrackets            []FeeBracket `json:"web_fee_brackets" binding:"required,min=1"`
+	WebWithdrawalFeeRate      *money.Rate  `json:"web_withdrawal_fee_rate" binding:"required"`
+	ExternalWithdrawalFeeRate *money.Rate  `json:"external_withdrawal_fee_rate" binding:"required"`
+	IntermediaryFeeRate       *money.Rate  `json:"intermediary_fee_rate" binding:"required"`
+}
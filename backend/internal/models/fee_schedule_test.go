@@ -0,0 +1,53 @@
+package models
+
+import (
+	"fullstack-backend/pkg/money"
+	"testing"
+)
+
+func TestFeeScheduleWebFee(t *testing.T) {
+	// Giá kèo < 100 tệ: 0; 100-199.99: 5; 200-499.99: 8; >= 500: 12
+	schedule := &FeeSchedule{WebFeeBrackets: []FeeBracket{
+		{MinCNY: money.CNYFromInt(0), FeeCNY: money.CNYFromInt(0)},
+		{MinCNY: money.CNYFromInt(10000), FeeCNY: money.CNYFromInt(500)},
+		{MinCNY: money.CNYFromInt(20000), FeeCNY: money.CNYFromInt(800)},
+		{MinCNY: money.CNYFromInt(50000), FeeCNY: money.CNYFromInt(1200)},
+	}}
+
+	tests := []struct {
+		name   string
+		amount int64 // minor units (0.01 tệ)
+		want   int64
+	}{
+		{name: "mức đầu tiên", amount: 5000, want: 0},
+		{name: "ngay dưới mức 100", amount: 9999, want: 0},
+		{name: "đúng mức 100", amount: 10000, want: 500},
+		{name: "giữa mức 100 và 200", amount: 15050, want: 500},
+		{name: "đúng mức 200", amount: 20000, want: 800},
+		{name: "ngay dưới mức 500", amount: 49999, want: 800},
+		{name: "đúng mức cao nhất", amount: 50000, want: 1200},
+		{name: "vượt mức cao nhất", amount: 10000000, want: 1200},
+		{name: "giá kèo âm", amount: -100, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schedule.WebFee(money.CNYFromInt(tt.amount)); got != money.CNYFromInt(tt.want) {
+				t.Errorf("WebFee(%s) = %s, muốn %s", money.CNYFromInt(tt.amount), got, money.CNYFromInt(tt.want))
+			}
+		})
+	}
+}
+
+func TestFeeScheduleWebFeeNoBrackets(t *testing.T) {
+	schedule := &FeeSchedule{}
+	if got := schedule.WebFee(money.CNYFromInt(10000)); got != 0 {
+		t.Errorf("WebFee() không có mức phí = %s, muốn 0", got)
+	}
+
+	// Mức đầu tiên lớn hơn 0: giá kèo dưới mức đầu tiên không tính phí
+	schedule = &FeeSchedule{WebFeeBrackets: []FeeBracket{{MinCNY: money.CNYFromInt(10000), FeeCNY: money.CNYFromInt(500)}}}
+	if got := schedule.WebFee(money.CNYFromInt(9999)); got != 0 {
+		t.Errorf("WebFee() dưới mức đầu tiên = %s, muốn 0", got)
+	}
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// STTScheme - Cách đánh mã STT hiển thị (ma_stt) của đơn hàng, chọn qua config STT_SCHEME
+// Cột stt luôn là số thứ tự toàn cục (dùng để sắp xếp), ma_stt được đánh theo scheme
+type STTScheme string
+
+const (
+	STTSchemeGlobal  STTScheme = "global"  // Một dãy số chung: "1", "2", ...
+	STTSchemeMonthly STTScheme = "monthly" // Đánh lại từ 1 mỗi tháng: "2026-10-0001"
+	STTSchemeUser    STTScheme = "user"    // Mỗi người dùng một dãy số theo mã ngắn của người dùng: "U001-0001"
+)
+
+// STTScopeGlobal - Bộ đếm của cột stt (số thứ tự toàn cục)
+const STTScopeGlobal = "global"
+
+// STTPoolCode - Thay cho mã người dùng trong scheme "user" khi đơn hàng tạo ra chưa có người nhận (pool): "POOL-0001"
+// Đơn hàng được cấp lại mã theo người nhận khi được nhận/giao lần đầu (xem IsPoolCode)
+const STTPoolCode = "POOL"
+
+// ParseSTTScheme đọc scheme từ config (rỗng = global)
+func ParseSTTScheme(value string) (STTScheme, error) {
+	switch scheme := STTScheme(strings.ToLower(strings.TrimSpace(value))); scheme {
+	case "", STTSchemeGlobal:
+		return STTSchemeGlobal, nil
+	case STTSchemeMonthly, STTSchemeUser:
+		return scheme, nil
+	default:
+		return "", fmt.Errorf("STT scheme không hợp lệ: %q (chỉ chấp nhận global, monthly, user)", value)
+	}
+}
+
+// Scope trả về tên bộ đếm (stt_counters.scope) của đơn hàng tạo lúc at cho userID
+func (s STTScheme) Scope(userID string, at time.Time) string {
+	switch s {
+	case STTSchemeMonthly:
+		return "month:" + at.Format("2006-01")
+	case STTSchemeUser:
+		if userID == "" {
+			return "user:" + STTPoolCode
+		}
+		return "user:" + userID
+	default:
+		return STTScopeGlobal
+	}
+}
+
+// Code tạo mã STT hiển thị từ số thứ tự n trong bộ đếm của scheme
+// userCode: mã ngắn duy nhất của người nhận (nguoi_dung.ma_nguoi_dung, vd: "U001"), "" = đơn hàng trong pool
+func (s STTScheme) Code(userCode string, at time.Time, n int) string {
+	switch s {
+	case STTSchemeMonthly:
+		return fmt.Sprintf("%s-%04d", at.Format("2006-01"), n)
+	case STTSchemeUser:
+		if userCode == "" {
+			userCode = STTPoolCode
+		}
+		return fmt.Sprintf("%s-%04d", userCode, n)
+	default:
+		return strconv.Itoa(n)
+	}
+}
+
+// IsPoolCode kiểm tra mã STT được cấp trong pool của scheme "user" (cần cấp lại theo người nhận)
+func (s STTScheme) IsPoolCode(code string) bool {
+	return s == STTSchemeUser && strings.HasPrefix(code, STTPoolCode+"-")
+}
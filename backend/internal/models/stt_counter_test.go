@@ -0,0 +1,83 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSTTScheme(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    STTScheme
+		wantErr bool
+	}{
+		{value: "", want: STTSchemeGlobal},
+		{value: "global", want: STTSchemeGlobal},
+		{value: " Monthly ", want: STTSchemeMonthly},
+		{value: "USER", want: STTSchemeUser},
+		{value: "yearly", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSTTScheme(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseSTTScheme(%q) = %q, muốn lỗi", tt.value, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSTTScheme(%q) = %q, %v, muốn %q", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestSTTSchemeScopeAndCode(t *testing.T) {
+	at := time.Date(2026, 10, 5, 12, 0, 0, 0, time.UTC)
+	userID := "0b6f3c2e-5a7d-4e0b-9c1a-2f3e4d5c6b7a"
+
+	tests := []struct {
+		name      string
+		scheme    STTScheme
+		userID    string
+		userCode  string
+		n         int
+		wantScope string
+		wantCode  string
+	}{
+		{name: "global", scheme: STTSchemeGlobal, userID: userID, userCode: "U001", n: 42, wantScope: STTScopeGlobal, wantCode: "42"},
+		{name: "monthly", scheme: STTSchemeMonthly, userID: userID, n: 7, wantScope: "month:2026-10", wantCode: "2026-10-0007"},
+		{name: "monthly quá 4 chữ số", scheme: STTSchemeMonthly, n: 12345, wantScope: "month:2026-10", wantCode: "2026-10-12345"},
+		{name: "user dùng mã ngắn", scheme: STTSchemeUser, userID: userID, userCode: "U001", n: 3, wantScope: "user:" + userID, wantCode: "U001-0003"},
+		{name: "user trong pool", scheme: STTSchemeUser, n: 1, wantScope: "user:POOL", wantCode: "POOL-0001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.scheme.Scope(tt.userID, at); got != tt.wantScope {
+				t.Errorf("Scope() = %q, muốn %q", got, tt.wantScope)
+			}
+			if got := tt.scheme.Code(tt.userCode, at, tt.n); got != tt.wantCode {
+				t.Errorf("Code() = %q, muốn %q", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestSTTSchemeIsPoolCode(t *testing.T) {
+	tests := []struct {
+		scheme STTScheme
+		code   string
+		want   bool
+	}{
+		{STTSchemeUser, "POOL-0001", true},
+		{STTSchemeUser, "U001-0001", false},
+		{STTSchemeUser, "POOLX-0001", false},
+		{STTSchemeGlobal, "POOL-0001", false},
+	}
+	for _, tt := range tests {
+		if got := tt.scheme.IsPoolCode(tt.code); got != tt.want {
+			t.Errorf("%s.IsPoolCode(%q) = %v, muốn %v", tt.scheme, tt.code, got, tt.want)
+		}
+	}
+}
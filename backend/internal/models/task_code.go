@@ -0,0 +1,49 @@
+package models
+
+import (
+	"fullstack-backend/pkg/money"
+	"time"
+)
+
+// TaskCode - Mã nhiệm vụ trong danh mục (bảng task_codes)
+// Tạo đơn hàng với mã đang dùng thì các trường bỏ trống được điền từ giá trị mặc định
+type TaskCode struct {
+	ID                     string     `json:"id" db:"id"`
+	Code                   string     `json:"code" db:"code"`
+	Description            string     `json:"description" db:"description"`
+	DefaultBetType         *string    `json:"default_bet_type,omitempty" db:"default_bet_type"`                     // web hoặc Kèo ngoài
+	DefaultWebBetAmountCNY *money.CNY `json:"default_web_bet_amount_cny,omitempty" db:"default_web_bet_amount_cny"` // Tiền kèo web mặc định (tệ)
+	DefaultCompletedHours  *int       `json:"default_completed_hours,omitempty" db:"default_completed_hours"`       // Thời gian hoàn thành mặc định (số giờ)
+	Active                 bool       `json:"active" db:"active"`                                                   // false = ngừng sử dụng, không nhận đơn hàng mới
+	CreatedBy              *string    `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt              time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt              time.Time  `json:"updated_at" db:"updated_at"`
+	BetReceiptCount        int        `json:"bet_receipt_count" db:"-"` // Số đơn hàng (chưa xóa) có mã nhiệm vụ này
+}
+
+// TaskCodeRequest - Tạo/sửa mã nhiệm vụ (POST /api/task-codes, PUT /api/task-codes/:id)
+// Active = nil: giữ nguyên khi sửa, đang dùng khi tạo
+type TaskCodeRequest struct {
+	Code                   string     `json:"code" binding:"required"`
+	Description            string     `json:"description"`
+	DefaultBetType         *string    `json:"default_bet_type"`
+	DefaultWebBetAmountCNY *money.CNY `json:"default_web_bet_amount_cny"`
+	DefaultCompletedHours  *int       `json:"default_completed_hours"`
+	Active                 *bool      `json:"active"`
+}
+
+// TaskCodeStats - Thống kê đơn hàng theo mã nhiệm vụ (GET /api/task-codes/stats)
+// Gồm cả mã nhiệm vụ chưa có trong danh mục (InCatalog = false)
+type TaskCodeStats struct {
+	TaskCode           string   `json:"task_code"`
+	InCatalog          bool     `json:"in_catalog"`
+	TaskCodeID         *string  `json:"task_code_id,omitempty"`
+	Description        string   `json:"description,omitempty"`
+	Total              int      `json:"total"`                          // Tổng số đơn hàng
+	Processed          int      `json:"processed"`                      // Đã xử lý (DONE, HỦY BỎ, ĐỀN)
+	Done               int      `json:"done"`                           // DONE
+	Cancelled          int      `json:"cancelled"`                      // HỦY BỎ
+	Compensation       int      `json:"compensation"`                   // ĐỀN
+	AvgCompletionHours *float64 `json:"avg_completion_hours,omitempty"` // Thời gian hoàn thành trung bình (giờ, từ nhận kèo đến hoàn thành, đơn DONE)
+	CompensationRate   *float64 `json:"compensation_rate,omitempty"`    // Tỷ lệ ĐỀN trên số đơn đã xử lý (0-1)
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Notification - Thông báo cho người dùng (bảng thong_bao)
+type Notification struct {
+	ID           string    `json:"id" db:"id"`
+	UserID       string    `json:"user_id" db:"id_nguoi_dung"`                // Người nhận
+	Type         string    `json:"type" db:"loai"`                            // Loại thông báo (NotificationType*)
+	Title        string    `json:"title" db:"tieu_de"`                        // Tiêu đề
+	Content      string    `json:"content" db:"noi_dung"`                     // Nội dung
+	BetReceiptID *string   `json:"bet_receipt_id,omitempty" db:"id_don_hang"` // Đơn hàng liên quan (nullable)
+	Read         bool      `json:"read" db:"da_doc"`                          // Đã đọc chưa
+	CreatedAt    time.Time `json:"created_at" db:"thoi_gian_tao"`
+}
+
+// NotificationType constants
+const (
+	NotificationTypeBetReceiptOverdue  = "BET_RECEIPT_OVERDUE"          // Đơn hàng quá deadline mà chưa xử lý
+	NotificationTypeCommentMention     = "BET_RECEIPT_COMMENT_MENTION"  // Được @mention trong bình luận đơn hàng
+	NotificationTypeDisputeAssigned    = "BET_RECEIPT_DISPUTE_ASSIGNED" // Được giao làm trọng tài một tranh chấp
+	NotificationTypeDisputeResolved    = "BET_RECEIPT_DISPUTE_RESOLVED" // Tranh chấp của đơn hàng đã có quyết định
+	NotificationTypeWithdrawalApproved = "WITHDRAWAL_REQUEST_APPROVED"  // Yêu cầu rút tiền đã được duyệt
+	NotificationTypeWithdrawalRejected = "WITHDRAWAL_REQUEST_REJECTED"  // Yêu cầu rút tiền bị từ chối
+	NotificationTypeWithdrawalPaid     = "WITHDRAWAL_REQUEST_PAID"      // Yêu cầu rút tiền đã được chi
+)
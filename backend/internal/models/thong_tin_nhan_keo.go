@@ -1,40 +1,81 @@
 package models
 
-import "time"
+import (
+	"fullstack-backend/pkg/money"
+	"fullstack-backend/pkg/pagination"
+	"time"
+)
 
 // BetReceipt - Bảng thông tin nhận kèo (Bảng 1)
 type BetReceipt struct {
-	ID                string  `json:"id" db:"id"`
-	STT               int     `json:"stt" db:"stt"`                                       // Số thứ tự
-	UserID            string  `json:"user_id" db:"id_nguoi_dung"`                         // FK -> nguoi_dung.id
-	UserName          string  `json:"user_name" db:"-"`                                   // Tên người dùng (join từ nguoi_dung.ten, không map từ DB)
-	TaskCode          string  `json:"task_code" db:"ma_nhiem_vu"`                         // Mã nhiệm vụ (vd: "lb3-kc1", "kc4-96-ct")
-	BetType           string  `json:"bet_type" db:"loai_keo"`                             // Loại kèo: "web" hoặc "Kèo ngoài"
-	WebBetAmountCNY   float64 `json:"web_bet_amount_cny" db:"tien_keo_web_te"`            // Tiền kèo web (tệ)
-	OrderCode         string  `json:"order_code" db:"ma_don_hang"`                        // Mã đơn hàng
-	Notes             string  `json:"notes" db:"ghi_chu"`                                 // Ghi chú
-	Status            string  `json:"status" db:"tien_do_hoan_thanh"`                     // Tiến độ: "ĐANG THỰC HIỆN", "DONE", "CHỜ CHẤP NHẬN", "HỦY BỎ", "ĐỀN", "ĐANG QUÉT MÃ", "CHỜ TRỌNG TÀI"
-	CancelReason      string  `json:"cancel_reason" db:"ly_do_huy"`                       // Lý do hủy bỏ (chỉ có giá trị khi status = HỦY BỎ)
-	ActualReceivedCNY float64 `json:"actual_received_cny" db:"tien_keo_web_thuc_nhan_te"` // Tiền kèo Web thực nhận (tệ)
-	CompensationCNY   float64 `json:"compensation_cny" db:"tien_den_te"`                  // Tiền đền (tệ)
+	ID                string    `json:"id" db:"id"`
+	STT               int       `json:"stt" db:"stt"`                                       // Số thứ tự (toàn cục, dùng để sắp xếp)
+	STTCode           string    `json:"stt_code" db:"ma_stt"`                               // Mã STT hiển thị theo STT_SCHEME (vd: "2026-10-0001")
+	UserID            string    `json:"user_id" db:"id_nguoi_dung"`                         // FK -> nguoi_dung.id ("" = chưa có người nhận, đơn nằm trong pool)
+	UserName          string    `json:"user_name" db:"-"`                                   // Tên người dùng (join từ nguoi_dung.ten, không map từ DB)
+	TaskCode          string    `json:"task_code" db:"ma_nhiem_vu"`                         // Mã nhiệm vụ (vd: "lb3-kc1", "kc4-96-ct")
+	BetType           string    `json:"bet_type" db:"loai_keo"`                             // Loại kèo: "web" hoặc "Kèo ngoài"
+	WebBetAmountCNY   money.CNY `json:"web_bet_amount_cny" db:"tien_keo_web_te"`            // Tiền kèo web (tệ)
+	OrderCode         string    `json:"order_code" db:"ma_don_hang"`                        // Mã đơn hàng
+	Notes             string    `json:"notes" db:"ghi_chu"`                                 // Ghi chú
+	Status            string    `json:"status" db:"tien_do_hoan_thanh"`                     // Tiến độ: "ĐANG THỰC HIỆN", "DONE", "CHỜ CHẤP NHẬN", "HỦY BỎ", "ĐỀN", "ĐANG QUÉT MÃ", "CHỜ TRỌNG TÀI"
+	CancelReason      string    `json:"cancel_reason" db:"ly_do_huy"`                       // Lý do hủy bỏ (chỉ có giá trị khi status = HỦY BỎ)
+	ActualReceivedCNY money.CNY `json:"actual_received_cny" db:"tien_keo_web_thuc_nhan_te"` // Tiền kèo Web thực nhận (tệ)
+	CompensationCNY   money.CNY `json:"compensation_cny" db:"tien_den_te"`                  // Tiền đền (tệ)
 
 	// TODO: Tính toán công thức cong_thuc_nhan_te
 	// Công thức tính: cong_thuc_nhan_te = f(tien_keo_web_thuc_nhan_te, tien_den_te, ...)
 	// Ví dụ có thể là: tien_keo_web_thuc_nhan_te - tien_den_te hoặc công thức phức tạp hơn
-	ActualAmountCNY float64 `json:"actual_amount_cny" db:"cong_thuc_nhan_te"` // Công thực nhận (tệ) - TÍNH TOÁN SAU
-	ExchangeRate    float64 `json:"exchange_rate" db:"exchange_rate"`         // Tỷ giá VND/CNY tại thời điểm đơn hàng được xử lí
+	ActualAmountCNY money.CNY  `json:"actual_amount_cny" db:"cong_thuc_nhan_te"` // Công thực nhận (tệ) - TÍNH TOÁN SAU
+	ExchangeRate    money.Rate `json:"exchange_rate" db:"exchange_rate"`         // Tỷ giá VND/CNY tại thời điểm đơn hàng được xử lí
+	// Version biểu phí đã dùng để tính ActualAmountCNY (nil nếu không tính theo công thức, vd: ĐỀN)
+	FeeScheduleVersion *int `json:"fee_schedule_version,omitempty" db:"fee_schedule_version"`
 
-	Account  string `json:"account" db:"tai_khoan"` // Tài khoản
-	Password string `json:"password" db:"mat_khau"` // Mật khẩu
-	Region   string `json:"region" db:"khu_vuc"`    // Khu vực
+	// Tài khoản/mật khẩu được mã hóa trong DB, response chỉ trả về giá trị đã che
+	// Giá trị thật chỉ lấy được qua API reveal (có ghi nhật ký)
+	Account           string `json:"account" db:"-"`        // Tài khoản đã che (vd: "ab****yz")
+	Password          string `json:"password" db:"-"`       // Mật khẩu đã che ("********" nếu có)
+	AccountEncrypted  string `json:"-" db:"tai_khoan"`      // Tài khoản đã mã hóa (như lưu trong DB)
+	PasswordEncrypted string `json:"-" db:"mat_khau"`       // Mật khẩu đã mã hóa (như lưu trong DB)
+	AccountHash       string `json:"-" db:"tai_khoan_hash"` // Chỉ mục mù của tài khoản (phát hiện đơn hàng trùng)
+	Region            string `json:"region" db:"khu_vuc"`   // Khu vực
 
 	ReceivedAt             time.Time  `json:"received_at" db:"thoi_gian_nhan_keo"`                       // Thời gian nhận kèo (cũng chính là thời gian tạo)
 	CompletedAt            *time.Time `json:"completed_at,omitempty" db:"thoi_gian_hoan_thanh"`          // Thời gian hoàn thành thực tế (nullable)
 	CompletedHours         *int       `json:"completed_hours,omitempty" db:"-"`                          // Thời gian hoàn thành (số giờ) - tính từ time_remaining_hours ban đầu
 	TimeRemainingHours     *int       `json:"time_remaining_hours,omitempty" db:"thoi_gian_con_lai_gio"` // Thời gian còn lại (giờ) - nullable
 	TimeRemainingFormatted string     `json:"time_remaining_formatted,omitempty" db:"-"`                 // Thời gian còn lại đã format (giờ:phút) - tính toán từ completed_hours và received_at
+	DeadlineAt             *time.Time `json:"deadline_at,omitempty" db:"han_hoan_thanh"`                 // Deadline = received_at + time_remaining_hours (cột tính toán trong DB)
+	Overdue                bool       `json:"overdue" db:"-"`                                            // Đã quá deadline mà vẫn đang ở trạng thái chưa xử lý (xem IsOverdue)
+	OverdueFlaggedAt       *time.Time `json:"overdue_flagged_at,omitempty" db:"thoi_gian_bao_qua_han"`   // Thời điểm job kiểm tra đánh dấu quá hạn (đã gửi thông báo)
 
 	UpdatedAt time.Time `json:"updated_at" db:"thoi_gian_cap_nhat"` // Thời gian cập nhật
+
+	// Xóa mềm: đơn hàng đã xóa nằm trong thùng rác, không tính vào danh sách/tổng/ví, có thể khôi phục
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"` // Thời gian xóa (nil = chưa xóa)
+	DeletedBy *string    `json:"deleted_by,omitempty" db:"deleted_by"` // ID người xóa
+
+	// Khóa sổ: đơn hàng hoàn thành trong tháng đã khóa sổ không được sửa/xóa nữa
+	LockedPeriod *string `json:"locked_period,omitempty" db:"locked_period"` // Tháng đã khóa sổ (YYYY-MM), nil = chưa khóa
+
+	// Bình luận (chỉ có khi lấy chi tiết đơn hàng, ghi chú nội bộ chỉ trả về cho admin)
+	Comments []*BetReceiptComment `json:"comments,omitempty" db:"-"`
+}
+
+// BetReceiptDeadlineStatuses - Các status còn chịu deadline (quá deadline ở các status này = quá hạn)
+var BetReceiptDeadlineStatuses = []string{BetReceiptStatusNew, BetReceiptStatusInProgress}
+
+// IsOverdue kiểm tra đơn hàng đã quá deadline tại thời điểm now mà vẫn chưa được xử lý
+func (b *BetReceipt) IsOverdue(now time.Time) bool {
+	if b.DeadlineAt == nil || !now.After(*b.DeadlineAt) {
+		return false
+	}
+	for _, status := range BetReceiptDeadlineStatuses {
+		if b.Status == status {
+			return true
+		}
+	}
+	return false
 }
 
 // BetReceiptStatus constants
@@ -57,37 +98,92 @@ const (
 
 // Request DTOs
 type CreateBetReceiptRequest struct {
-	UserName        string  `json:"user_name" binding:"required"` // Tên người dùng (từ cột ten trong nguoi_dung)
-	TaskCode        string  `json:"task_code" binding:"required"`
-	BetType         string  `json:"bet_type" binding:"required"`
-	WebBetAmountCNY float64 `json:"web_bet_amount_cny" binding:"required"`
-	OrderCode       string  `json:"order_code"`
-	Notes           string  `json:"notes"`
-	Account         string  `json:"account"`         // Tài khoản
-	Password        string  `json:"password"`        // Mật khẩu
-	Region          string  `json:"region"`          // Khu vực
-	CompletedHours  *int    `json:"completed_hours"` // Thời gian hoàn thành (số giờ) - dùng để tính thời gian còn lại
+	UserName        string    `json:"user_name"` // Tên người dùng (từ cột ten trong nguoi_dung), để trống = đưa vào pool cho người nhận kèo tự nhận
+	TaskCode        string    `json:"task_code" binding:"required"`
+	BetType         string    `json:"bet_type"`           // Để trống = lấy mặc định của mã nhiệm vụ trong danh mục
+	WebBetAmountCNY money.CNY `json:"web_bet_amount_cny"` // Để trống = lấy mặc định của mã nhiệm vụ trong danh mục
+	OrderCode       string    `json:"order_code"`
+	Notes           string    `json:"notes"`
+	Account         string    `json:"account"`         // Tài khoản
+	Password        string    `json:"password"`        // Mật khẩu
+	Region          string    `json:"region"`          // Khu vực
+	CompletedHours  *int      `json:"completed_hours"` // Thời gian hoàn thành (số giờ) - dùng để tính thời gian còn lại, để trống = lấy mặc định của mã nhiệm vụ
 }
 
 type UpdateBetReceiptStatusRequest struct {
 	Status            string     `json:"status" binding:"required"`
-	ActualReceivedCNY *float64   `json:"actual_received_cny"`
-	CompensationCNY   *float64   `json:"compensation_cny"`
+	ActualReceivedCNY *money.CNY `json:"actual_received_cny"`
+	CompensationCNY   *money.CNY `json:"compensation_cny"`
 	CancelReason      *string    `json:"cancel_reason"` // Lý do hủy bỏ hoặc lý do đền (bắt buộc khi status = ĐỀN)
 	CompletedAt       *time.Time `json:"completed_at"`
 	// TODO: Khi update tien_do_hoan_thanh sang "DONE", cần tính cong_thuc_nhan_te
 	// cong_thuc_nhan_te sẽ được tính tự động dựa trên công thức
 }
 
+// BulkUpdateBetReceiptStatusRequest - Cập nhật status cho nhiều đơn hàng cùng lúc (POST /api/bet-receipts/bulk-status)
+// Các trường status giống UpdateBetReceiptStatusRequest và áp dụng cho tất cả đơn hàng trong IDs
+type BulkUpdateBetReceiptStatusRequest struct {
+	IDs []string `json:"ids" binding:"required,min=1"`
+	UpdateBetReceiptStatusRequest
+}
+
+// BulkStatusItemResult - Kết quả cập nhật status của một đơn hàng trong bulk update
+type BulkStatusItemResult struct {
+	ID        string   `json:"id"`
+	Success   bool     `json:"success"`
+	OldStatus string   `json:"old_status,omitempty"`
+	NewStatus string   `json:"new_status,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Field     string   `json:"field,omitempty"`   // Trường bắt buộc bị thiếu (nếu có)
+	Allowed   []string `json:"allowed,omitempty"` // Các status được phép từ status hiện tại (khi chuyển không hợp lệ)
+}
+
+// BulkStatusReport - Báo cáo kết quả bulk update status
+// Tất cả đơn hàng được cập nhật trong một transaction: chỉ cần một đơn lỗi thì không đơn nào được cập nhật
+type BulkStatusReport struct {
+	Applied bool                    `json:"applied"` // true nếu transaction đã commit
+	Total   int                     `json:"total"`
+	Updated int                     `json:"updated"`
+	Failed  int                     `json:"failed"`
+	Results []*BulkStatusItemResult `json:"results"`
+}
+
 type UpdateBetReceiptRequest struct {
-	UserName        *string  `json:"user_name"`          // Tên người dùng (từ cột ten trong nguoi_dung)
-	TaskCode        *string  `json:"task_code"`          // Mã nhiệm vụ
-	BetType         *string  `json:"bet_type"`           // Loại kèo: "web" hoặc "Kèo ngoài"
-	WebBetAmountCNY *float64 `json:"web_bet_amount_cny"` // Tiền kèo web (tệ)
-	OrderCode       *string  `json:"order_code"`         // Mã đơn hàng
-	Notes           *string  `json:"notes"`              // Ghi chú
-	Account         *string  `json:"account"`            // Tài khoản
-	Password        *string  `json:"password"`           // Mật khẩu
-	Region          *string  `json:"region"`             // Khu vực
-	CompletedHours  *int     `json:"completed_hours"`    // Thời gian hoàn thành (số giờ)
+	UserName        *string    `json:"user_name"`          // Tên người dùng (từ cột ten trong nguoi_dung)
+	TaskCode        *string    `json:"task_code"`          // Mã nhiệm vụ
+	BetType         *string    `json:"bet_type"`           // Loại kèo: "web" hoặc "Kèo ngoài"
+	WebBetAmountCNY *money.CNY `json:"web_bet_amount_cny"` // Tiền kèo web (tệ)
+	OrderCode       *string    `json:"order_code"`         // Mã đơn hàng
+	Notes           *string    `json:"notes"`              // Ghi chú
+	Account         *string    `json:"account"`            // Tài khoản
+	Password        *string    `json:"password"`           // Mật khẩu
+	Region          *string    `json:"region"`             // Khu vực
+	CompletedHours  *int       `json:"completed_hours"`    // Thời gian hoàn thành (số giờ)
+}
+
+// BetReceiptFilter - Bộ lọc cho danh sách đơn hàng (GET /api/bet-receipts)
+// Các trường nil/rỗng sẽ không được áp dụng
+type BetReceiptFilter struct {
+	Statuses        []string   // Lọc theo nhiều status (tien_do_hoan_thanh IN ...)
+	BetType         string     // Loại kèo: "web" hoặc "Kèo ngoài"
+	UserID          *string    // Lọc theo người dùng (user thường luôn bị ép về chính mình)
+	Region          string     // Khu vực (so sánh chính xác, không phân biệt hoa thường)
+	ReceivedFrom    *time.Time // thoi_gian_nhan_keo >= ReceivedFrom
+	ReceivedTo      *time.Time // thoi_gian_nhan_keo < ReceivedTo
+	CompletedFrom   *time.Time // thoi_gian_hoan_thanh >= CompletedFrom
+	CompletedTo     *time.Time // thoi_gian_hoan_thanh < CompletedTo
+	DeadlineFrom    *time.Time // han_hoan_thanh >= DeadlineFrom
+	DeadlineTo      *time.Time // han_hoan_thanh < DeadlineTo
+	Overdue         *bool      // true = chỉ đơn quá hạn, false = chỉ đơn chưa quá hạn (xem BetReceipt.IsOverdue)
+	TaskCodePrefix  string     // Mã nhiệm vụ bắt đầu bằng (không phân biệt hoa thường)
+	OrderCodePrefix string     // Mã đơn hàng bắt đầu bằng (không phân biệt hoa thường)
+	CodePrefix      string     // Mã nhiệm vụ HOẶC mã đơn hàng bắt đầu bằng
+	Deleted         bool       // true = chỉ lấy đơn hàng đã xóa (thùng rác), false = chỉ lấy đơn hàng chưa xóa
+	Unassigned      bool       // true = chỉ lấy đơn hàng trong pool (chưa có người nhận)
+	SortBy          string     // Tên trường JSON để sắp xếp (vd: "stt", "received_at", "deadline_at", "web_bet_amount_cny")
+	SortDesc        bool       // true = giảm dần
+	Limit           int
+	Offset          int                // Chỉ dùng khi không có Cursor
+	CursorToken     string             // Cursor (opaque) từ query parameter "cursor"
+	Cursor          *pagination.Cursor // Cursor đã giải mã (service điền từ CursorToken)
 }
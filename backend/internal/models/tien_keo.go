@@ -1,30 +1,36 @@
 package models
 
-import "time"
+import (
+	"fullstack-backend/pkg/money"
+	"time"
+)
 
 // Wallet - Bảng tien_keo (Tổng hợp tài chính - Bảng 2)
-// Lưu tổng hợp tài chính theo user, được cập nhật khi thong_tin_nhan_keo chuyển sang DONE
+// Lưu tổng hợp tài chính theo user, tính lại từ sổ cái wallet_ledger_entries sau mỗi bút toán
 type Wallet struct {
 	ID     string `json:"id" db:"id"`
 	UserID string `json:"user_id" db:"id_nguoi_dung"` // FK -> nguoi_dung.id (unique)
 
 	// Số dư theo CNY (Tệ)
-	TotalReceivedCNY  float64 `json:"total_received_cny" db:"tong_cong_thuc_nhan_te"` // Tổng công thực nhận (tệ) - default 0
-	TotalWithdrawnCNY float64 `json:"total_withdrawn_cny" db:"tong_da_rut_te"`        // Tổng đã rút (tệ) - default 0
+	TotalReceivedCNY  money.CNY `json:"total_received_cny" db:"tong_cong_thuc_nhan_te"` // Tổng công thực nhận (tệ) - default 0
+	TotalWithdrawnCNY money.CNY `json:"total_withdrawn_cny" db:"tong_da_rut_te"`        // Tổng đã rút (tệ) - default 0
 
 	// Số dư theo VND
-	TotalReceivedVND  float64 `json:"total_received_vnd" db:"tong_cong_thuc_nhan_vnd"` // Tổng công thực nhận (VND) - default 0
-	TotalDepositVND   float64 `json:"total_deposit_vnd" db:"tong_coc_vnd"`             // Tổng cọc (VND) - default 0
-	TotalWithdrawnVND float64 `json:"total_withdrawn_vnd" db:"tong_da_rut_vnd"`        // Tổng đã rút (VND) - default 0
+	TotalReceivedVND   money.VND `json:"total_received_vnd" db:"tong_cong_thuc_nhan_vnd"` // Tổng công thực nhận (VND) - default 0
+	TotalDepositVND    money.VND `json:"total_deposit_vnd" db:"tong_coc_vnd"`             // Tổng cọc (VND) - default 0
+	TotalWithdrawnVND  money.VND `json:"total_withdrawn_vnd" db:"tong_da_rut_vnd"`        // Tổng đã rút (VND) - default 0
+	TotalAdjustmentVND money.VND `json:"total_adjustment_vnd" db:"tong_dieu_chinh_vnd"`   // Tổng điều chỉnh thủ công (VND) - default 0
 
-	// TODO: Tính toán công thức so_du_hien_tai_vnd
-	// Công thức: so_du_hien_tai_vnd = tong_cong_thuc_nhan_vnd + tong_coc_vnd - tong_da_rut_vnd
-	// Có thể tính trong code hoặc dùng database trigger/computed column
-	CurrentBalanceVND float64 `json:"current_balance_vnd" db:"so_du_hien_tai_vnd"` // Số dư hiện tại (VND) - TÍNH TOÁN SAU
+	// Công thức: so_du_hien_tai_vnd = tong_cong_thuc_nhan_vnd + tong_coc_vnd - tong_da_rut_vnd + tong_dieu_chinh_vnd
+	// (= tổng amount_vnd của tất cả bút toán trong sổ cái)
+	CurrentBalanceVND money.VND `json:"current_balance_vnd" db:"so_du_hien_tai_vnd"` // Số dư hiện tại (VND)
 
 	UpdatedAt time.Time `json:"updated_at" db:"thoi_gian_cap_nhat"`
 }
 
+// DefaultExchangeRate - Tỷ giá VND/CNY mặc định (3550 VND = 1 CNY) khi chưa có tỷ giá trong DB
+var DefaultExchangeRate = money.RateFromInt(3550)
+
 // Request DTOs
 type WalletUpdateRequest struct {
 	// Khi cập nhật wallet, có thể update trực tiếp các trường hoặc tính toán lại
@@ -44,6 +50,3 @@ type WalletUpdateRequest struct {
 // Ví dụ khi tính tong_cong_thuc_nhan_vnd từ tong_cong_thuc_nhan_te:
 // tong_cong_thuc_nhan_vnd = tong_cong_thuc_nhan_te * exchange_rate
 // Exchange rate hiện tại trong hình là 3550
-
-
-
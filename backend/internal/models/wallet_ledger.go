@@ -0,0 +1,57 @@
+package models
+
+import (
+	"fullstack-backend/pkg/money"
+	"time"
+)
+
+// Loại bút toán sổ cái ví (wallet_ledger_entries.entry_type)
+const (
+	LedgerEntryReceiptSettlement = "receipt_settlement" // Công thực nhận của đơn hàng DONE/HỦY BỎ
+	LedgerEntryCompensation      = "compensation"       // Tiền đền (ĐỀN)
+	LedgerEntryDeposit           = "deposit"            // Nộp cọc
+	LedgerEntryWithdrawal        = "withdrawal"         // Rút tiền
+	LedgerEntryRevaluation       = "revaluation"        // Đánh giá lại VND khi tỷ giá đơn hàng thay đổi
+	LedgerEntryAdjustment        = "adjustment"         // Điều chỉnh thủ công (admin)
+)
+
+// Nguồn của bút toán (wallet_ledger_entries.source_type)
+const (
+	LedgerSourceBetReceipt = "bet_receipt"
+	LedgerSourceDeposit    = "deposit"
+	LedgerSourceWithdrawal = "withdrawal"
+	LedgerSourceManual     = "manual"
+)
+
+// WalletLedgerEntry - Bút toán sổ cái ví (bảng wallet_ledger_entries, chỉ ghi thêm)
+// Số tiền mang dấu theo phía ví: dương = ví tăng, âm = ví giảm
+type WalletLedgerEntry struct {
+	ID              string      `json:"id"`
+	Seq             int64       `json:"seq"` // Thứ tự ghi sổ
+	UserID          string      `json:"user_id"`
+	EntryType       string      `json:"entry_type"`
+	AmountCNY       money.CNY   `json:"amount_cny"`
+	AmountVND       money.VND   `json:"amount_vnd"`
+	ExchangeRate    *money.Rate `json:"exchange_rate"` // nil nếu bút toán chỉ có VND
+	SourceType      string      `json:"source_type"`
+	SourceID        *string     `json:"source_id"`
+	STTCode         string      `json:"stt_code,omitempty"` // Mã STT khi nguồn là đơn hàng
+	Description     string      `json:"description"`
+	CreatedBy       *string     `json:"created_by"`
+	CreatedByName   string      `json:"created_by_name,omitempty"`
+	CreatedAt       time.Time   `json:"created_at"`
+	BalanceAfterVND money.VND   `json:"balance_after_vnd"` // Số dư ví (VND) sau bút toán
+}
+
+// WalletLedgerFilter - Bộ lọc danh sách bút toán của một user
+type WalletLedgerFilter struct {
+	EntryType string
+	From      *time.Time
+	To        *time.Time
+}
+
+// WalletAdjustmentRequest - Điều chỉnh thủ công số dư ví (admin)
+type WalletAdjustmentRequest struct {
+	AmountVND   money.VND `json:"amount_vnd" binding:"required"` // Dương = cộng, âm = trừ
+	Description string    `json:"description" binding:"required"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"fullstack-backend/pkg/money"
+	"time"
+)
+
+// StatementSubtotal - Cộng phát sinh của một nhóm bút toán (số có dấu theo phía ví)
+type StatementSubtotal struct {
+	Count     int       `json:"count"`
+	AmountCNY money.CNY `json:"amount_cny"`
+	AmountVND money.VND `json:"amount_vnd"`
+}
+
+// StatementSection - Một nhóm bút toán trong sao kê kèm cộng phát sinh
+type StatementSection struct {
+	Entries  []*WalletLedgerEntry `json:"entries"`
+	Subtotal StatementSubtotal    `json:"subtotal"`
+}
+
+// WalletStatement - Sao kê ví theo tháng (GET /api/wallets/:user_id/statement?month=YYYY-MM)
+// Lấy từ sổ cái: số dư đầu kỳ + phát sinh trong tháng = số dư cuối kỳ
+type WalletStatement struct {
+	UserID            string            `json:"user_id"`
+	UserName          string            `json:"user_name"`
+	Month             string            `json:"month"`
+	PeriodStart       time.Time         `json:"period_start"`
+	PeriodEnd         time.Time         `json:"period_end"` // Không bao gồm (00:00 ngày đầu tháng sau)
+	OpeningBalanceVND money.VND         `json:"opening_balance_vnd"`
+	Receipts          *StatementSection `json:"receipts"`      // Công thực nhận đơn hàng DONE/HỦY BỎ (kèm đánh giá lại tỷ giá)
+	Compensations     *StatementSection `json:"compensations"` // Tiền đền (ĐỀN)
+	Deposits          *StatementSection `json:"deposits"`
+	Withdrawals       *StatementSection `json:"withdrawals"`
+	Adjustments       *StatementSection `json:"adjustments"` // Điều chỉnh thủ công (admin)
+	Total             StatementSubtotal `json:"total"`       // Tổng phát sinh trong tháng
+	ClosingBalanceVND money.VND         `json:"closing_balance_vnd"`
+}
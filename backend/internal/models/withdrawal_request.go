@@ -0,0 +1,67 @@
+package models
+
+import (
+	"fullstack-backend/pkg/money"
+	"time"
+)
+
+// Trạng thái yêu cầu rút tiền (withdrawal_requests.status)
+const (
+	WithdrawalRequestStatusPending  = "pending"  // Chờ admin duyệt
+	WithdrawalRequestStatusApproved = "approved" // Đã duyệt, chờ chi tiền
+	WithdrawalRequestStatusRejected = "rejected" // Bị từ chối (có lý do)
+	WithdrawalRequestStatusPaid     = "paid"     // Đã chi tiền, đã ghi lich_su_rut_tien và trừ ví
+)
+
+// IsValidWithdrawalRequestStatus kiểm tra status yêu cầu rút tiền hợp lệ
+func IsValidWithdrawalRequestStatus(status string) bool {
+	switch status {
+	case WithdrawalRequestStatusPending, WithdrawalRequestStatusApproved,
+		WithdrawalRequestStatusRejected, WithdrawalRequestStatusPaid:
+		return true
+	}
+	return false
+}
+
+// WithdrawalRequest - Yêu cầu rút tiền của user (bảng withdrawal_requests)
+// pending -> approved/rejected -> paid, chỉ khi paid mới ghi lich_su_rut_tien và trừ ví
+type WithdrawalRequest struct {
+	ID             string     `json:"id"`
+	UserID         string     `json:"user_id"`
+	UserName       string     `json:"user_name"`
+	AmountVND      money.VND  `json:"amount_vnd"`
+	Note           string     `json:"note"`
+	Status         string     `json:"status"`
+	RejectReason   string     `json:"reject_reason,omitempty"`
+	ReviewedBy     *string    `json:"reviewed_by"`
+	ReviewedByName string     `json:"reviewed_by_name,omitempty"`
+	ReviewedAt     *time.Time `json:"reviewed_at"`
+	PaidBy         *string    `json:"paid_by"`
+	PaidAt         *time.Time `json:"paid_at"`
+	WithdrawalID   *string    `json:"withdrawal_id"` // lich_su_rut_tien.id khi đã chi
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// WithdrawalRequestFilter - Bộ lọc danh sách yêu cầu rút tiền (GET /api/withdrawals/requests)
+type WithdrawalRequestFilter struct {
+	Status string  // "" = tất cả
+	UserID *string // Lọc theo user (user thường chỉ xem được yêu cầu của mình)
+}
+
+// CreateWithdrawalRequestRequest - User gửi yêu cầu rút tiền
+type CreateWithdrawalRequestRequest struct {
+	AmountVND money.VND `json:"amount_vnd" binding:"required"` // Không vượt số dư khả dụng
+	Note      string    `json:"note"`
+}
+
+// RejectWithdrawalRequestRequest - Admin từ chối yêu cầu rút tiền
+type RejectWithdrawalRequestRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// PayWithdrawalRequestRequest - Admin ghi nhận đã chi tiền cho yêu cầu đã duyệt
+type PayWithdrawalRequestRequest struct {
+	AmountCNY *money.CNY `json:"amount_cny"` // Optional, số tệ đã chi (nếu chi bằng tệ)
+	Notes     string     `json:"notes"`      // Ghi chú lưu vào lich_su_rut_tien
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"database/sql"
+	"fullstack-backend/internal/models"
+	"log"
+)
+
+type AttachmentRepository struct {
+	db DBTX
+}
+
+func NewAttachmentRepository(db *sql.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+// WithTx trả về repository chạy các query trong transaction tx
+func (r *AttachmentRepository) WithTx(tx *sql.Tx) *AttachmentRepository {
+	return &AttachmentRepository{db: tx}
+}
+
+const attachmentColumns = `
+	a.id, a.bet_receipt_id, a.kind, a.file_name, a.stored_name, a.content_type,
+	a.size_bytes, a.uploaded_by, nd.ten, a.created_at
+`
+
+func scanAttachment(row rowScanner) (*models.BetReceiptAttachment, error) {
+	attachment := &models.BetReceiptAttachment{}
+	var uploadedBy, uploadedByName sql.NullString
+	err := row.Scan(
+		&attachment.ID, &attachment.BetReceiptID, &attachment.Kind, &attachment.FileName, &attachment.StoredName,
+		&attachment.ContentType, &attachment.SizeBytes, &uploadedBy, &uploadedByName, &attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if uploadedBy.Valid {
+		attachment.UploadedBy = &uploadedBy.String
+	}
+	attachment.UploadedByName = uploadedByName.String
+	return attachment, nil
+}
+
+// Create lưu thông tin tệp đính kèm (file đã được lưu trên đĩa)
+func (r *AttachmentRepository) Create(attachment *models.BetReceiptAttachment) error {
+	err := r.db.QueryRow(`
+		INSERT INTO bet_receipt_attachments (
+			bet_receipt_id, kind, file_name, stored_name, content_type, size_bytes, uploaded_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`,
+		attachment.BetReceiptID,
+		attachment.Kind,
+		attachment.FileName,
+		attachment.StoredName,
+		attachment.ContentType,
+		attachment.SizeBytes,
+		attachment.UploadedBy,
+	).Scan(&attachment.ID, &attachment.CreatedAt)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lưu tệp đính kèm: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetByBetReceiptID lấy tệp đính kèm của một đơn hàng (cũ nhất trước)
+func (r *AttachmentRepository) GetByBetReceiptID(betReceiptID string) ([]*models.BetReceiptAttachment, error) {
+	rows, err := r.db.Query(`
+		SELECT `+attachmentColumns+`
+		FROM bet_receipt_attachments a
+		LEFT JOIN nguoi_dung nd ON a.uploaded_by = nd.id
+		WHERE a.bet_receipt_id = $1
+		ORDER BY a.created_at, a.id
+	`, betReceiptID)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lấy tệp đính kèm: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []*models.BetReceiptAttachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, rows.Err()
+}
+
+// FindByID tìm tệp đính kèm theo ID và đơn hàng, trả về sql.ErrNoRows nếu không có
+func (r *AttachmentRepository) FindByID(betReceiptID, id string) (*models.BetReceiptAttachment, error) {
+	row := r.db.QueryRow(`
+		SELECT `+attachmentColumns+`
+		FROM bet_receipt_attachments a
+		LEFT JOIN nguoi_dung nd ON a.uploaded_by = nd.id
+		WHERE a.bet_receipt_id = $1 AND a.id = $2
+	`, betReceiptID, id)
+	return scanAttachment(row)
+}
+
+// Delete xóa thông tin tệp đính kèm (file trên đĩa do service xóa sau khi commit)
+func (r *AttachmentRepository) Delete(id string) error {
+	if _, err := r.db.Exec(`DELETE FROM bet_receipt_attachments WHERE id = $1`, id); err != nil {
+		log.Printf("Repository - ❌ Lỗi xóa tệp đính kèm: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"fullstack-backend/internal/models"
+	"log"
+	"sort"
+	"time"
+)
+
+// duplicateKeyExpr - Khóa nhóm đơn hàng nghi trùng theo từng quy tắc (khớp với các index của migration 027)
+var duplicateKeyExpr = map[string]string{
+	models.DuplicateRuleOrderCode:      "LOWER(TRIM(ttnk.ma_don_hang))",
+	models.DuplicateRuleTaskAccountDay: "LOWER(TRIM(ttnk.ma_nhiem_vu)) || '|' || ttnk.tai_khoan_hash || '|' || (ttnk.thoi_gian_nhan_keo::date)::text",
+}
+
+// duplicateKeyCondition - Điều kiện để đơn hàng tham gia quy tắc (có mã đơn hàng / có tài khoản)
+var duplicateKeyCondition = map[string]string{
+	models.DuplicateRuleOrderCode:      "COALESCE(TRIM(ttnk.ma_don_hang), '') <> ''",
+	models.DuplicateRuleTaskAccountDay: "ttnk.tai_khoan_hash IS NOT NULL",
+}
+
+const duplicateMatchColumns = `
+	ttnk.id, ttnk.ma_stt, ttnk.ma_nhiem_vu, COALESCE(ttnk.ma_don_hang, '') AS ma_don_hang, ttnk.id_nguoi_dung, nd.ten,
+	COALESCE(ttnk.tai_khoan_che, '') AS tai_khoan_che, ttnk.tien_do_hoan_thanh, ttnk.thoi_gian_nhan_keo
+`
+
+func scanDuplicateMatch(row rowScanner, extra ...interface{}) (*models.DuplicateMatch, error) {
+	m := &models.DuplicateMatch{}
+	var userID, userName sql.NullString
+	dest := append(extra,
+		&m.BetReceiptID, &m.STTCode, &m.TaskCode, &m.OrderCode, &userID, &userName,
+		&m.Account, &m.Status, &m.ReceivedAt,
+	)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	m.UserID = userID.String
+	m.UserName = userName.String
+	return m, nil
+}
+
+// LockDuplicateKeys khóa (advisory lock đến hết transaction) các khóa trùng của đơn hàng đang tạo/sửa
+// để hai lần tạo/sửa đồng thời cùng khóa trùng không cùng vượt qua bước kiểm tra
+// Khóa được lấy theo thứ tự cố định để các transaction không deadlock
+func (r *BetReceiptRepository) LockDuplicateKeys(keys []string) error {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	for _, key := range sorted {
+		if _, err := r.db.Exec(`SELECT pg_advisory_xact_lock(hashtext('bet_receipt_duplicate:' || $1))`, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindDuplicatesByOrderCode tìm đơn hàng chưa xóa có cùng mã đơn hàng (không phân biệt hoa thường), bỏ qua excludeID
+func (r *BetReceiptRepository) FindDuplicatesByOrderCode(orderCode, excludeID string) ([]*models.DuplicateMatch, error) {
+	return r.findDuplicates(`
+		LOWER(TRIM(ttnk.ma_don_hang)) = LOWER(TRIM($1)) AND ttnk.id <> $2
+	`, orderCode, excludeID)
+}
+
+// FindDuplicatesByTaskAccountDay tìm đơn hàng chưa xóa cùng mã nhiệm vụ + tài khoản (chỉ mục mù) nhận kèo cùng ngày receivedAt
+// receivedAt = nil: ngày hiện tại (đơn hàng đang tạo), bỏ qua excludeID
+func (r *BetReceiptRepository) FindDuplicatesByTaskAccountDay(taskCode, accountHash string, receivedAt *time.Time, excludeID string) ([]*models.DuplicateMatch, error) {
+	return r.findDuplicates(`
+		LOWER(TRIM(ttnk.ma_nhiem_vu)) = LOWER(TRIM($1)) AND ttnk.tai_khoan_hash = $2
+		AND ttnk.thoi_gian_nhan_keo::date = COALESCE($3::timestamp, LOCALTIMESTAMP)::date
+		AND ttnk.id <> $4
+	`, taskCode, accountHash, receivedAt, excludeID)
+}
+
+func (r *BetReceiptRepository) findDuplicates(condition string, args ...interface{}) ([]*models.DuplicateMatch, error) {
+	rows, err := r.db.Query(`
+		SELECT `+duplicateMatchColumns+`
+		FROM thong_tin_nhan_keo ttnk
+		LEFT JOIN nguoi_dung nd ON ttnk.id_nguoi_dung = nd.id
+		WHERE ttnk.deleted_at IS NULL AND `+condition+`
+		ORDER BY ttnk.stt
+	`, args...)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi tìm đơn hàng trùng: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := []*models.DuplicateMatch{}
+	for rows.Next() {
+		m, err := scanDuplicateMatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// GetDuplicateGroups lấy các nhóm đơn hàng chưa xóa đang nghi trùng nhau theo quy tắc rule (nhóm có từ 2 đơn trở lên)
+// Key của nhóm task_account_day là "mã nhiệm vụ / tài khoản đã che / ngày nhận kèo"
+func (r *BetReceiptRepository) GetDuplicateGroups(rule string) ([]*models.DuplicateGroup, error) {
+	keyExpr, ok := duplicateKeyExpr[rule]
+	if !ok {
+		return nil, fmt.Errorf("quy tắc trùng không hợp lệ: %s", rule)
+	}
+	rows, err := r.db.Query(`
+		SELECT * FROM (
+			SELECT ` + keyExpr + ` AS duplicate_key, COUNT(*) OVER (PARTITION BY ` + keyExpr + `) AS duplicate_count,
+			       ttnk.stt AS sort_stt, ` + duplicateMatchColumns + `
+			FROM thong_tin_nhan_keo ttnk
+			LEFT JOIN nguoi_dung nd ON ttnk.id_nguoi_dung = nd.id
+			WHERE ttnk.deleted_at IS NULL AND ` + duplicateKeyCondition[rule] + `
+		) d
+		WHERE d.duplicate_count > 1
+		ORDER BY d.duplicate_key, d.sort_stt
+	`)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lấy nhóm đơn hàng trùng: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []*models.DuplicateGroup{}
+	var current *models.DuplicateGroup
+	currentKey := ""
+	for rows.Next() {
+		var key string
+		var count, stt int
+		m, err := scanDuplicateMatch(rows, &key, &count, &stt)
+		if err != nil {
+			return nil, err
+		}
+		m.Rule = rule
+		if current == nil || key != currentKey {
+			current = &models.DuplicateGroup{Rule: rule, Key: m.OrderCode}
+			if rule == models.DuplicateRuleTaskAccountDay {
+				current.Key = m.TaskCode + " / " + m.Account + " / " + m.ReceivedAt.Format("2006-01-02")
+			}
+			currentKey = key
+			groups = append(groups, current)
+		}
+		current.BetReceipts = append(current.BetReceipts, m)
+	}
+	return groups, rows.Err()
+}
+
+// LockAccountsToIndex khóa và lấy tối đa limit đơn hàng (kể cả đã xóa) có ID > afterID, có tài khoản
+// nhưng chưa có chỉ mục mù (chỉ điền ID, AccountEncrypted), theo thứ tự ID
+// Dùng FOR UPDATE SKIP LOCKED, phải gọi trong transaction (WithTx)
+func (r *BetReceiptRepository) LockAccountsToIndex(afterID string, limit int) ([]*models.BetReceipt, error) {
+	rows, err := r.db.Query(`
+		SELECT id, tai_khoan
+		FROM thong_tin_nhan_keo
+		WHERE COALESCE(tai_khoan, '') <> '' AND tai_khoan_hash IS NULL AND id > $1
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, afterID, limit)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lấy đơn hàng cần tính chỉ mục tài khoản: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	betReceipts := []*models.BetReceipt{}
+	for rows.Next() {
+		b := &models.BetReceipt{}
+		if err := rows.Scan(&b.ID, &b.AccountEncrypted); err != nil {
+			return nil, err
+		}
+		betReceipts = append(betReceipts, b)
+	}
+	return betReceipts, rows.Err()
+}
+
+// UpdateAccountHash ghi chỉ mục mù của tài khoản (không đổi thoi_gian_cap_nhat)
+func (r *BetReceiptRepository) UpdateAccountHash(id, accountHash string) error {
+	_, err := r.db.Exec(`UPDATE thong_tin_nhan_keo SET tai_khoan_hash = NULLIF($2, '') WHERE id = $1`, id, accountHash)
+	return err
+}
@@ -4,17 +4,23 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fullstack-backend/internal/models"
+	"fullstack-backend/pkg/pagination"
 	"log"
 )
 
 type BetReceiptHistoryRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewBetReceiptHistoryRepository(db *sql.DB) *BetReceiptHistoryRepository {
 	return &BetReceiptHistoryRepository{db: db}
 }
 
+// WithTx trả về repository chạy các query trong transaction tx
+func (r *BetReceiptHistoryRepository) WithTx(tx *sql.Tx) *BetReceiptHistoryRepository {
+	return &BetReceiptHistoryRepository{db: tx}
+}
+
 // Create tạo bản ghi lịch sử
 func (r *BetReceiptHistoryRepository) Create(history *models.BetReceiptHistory) error {
 	// Convert old_data, new_data, changed_fields to JSON strings
@@ -62,8 +68,12 @@ func (r *BetReceiptHistoryRepository) Create(history *models.BetReceiptHistory)
 	return nil
 }
 
-// GetAll lấy tất cả lịch sử (có phân trang)
-func (r *BetReceiptHistoryRepository) GetAll(limit, offset int) ([]*models.BetReceiptHistory, error) {
+// HistoryCursorColumns - Các cột keyset của danh sách lịch sử
+var HistoryCursorColumns = []string{"h.created_at", "h.id"}
+
+// GetAll lấy tất cả lịch sử (có phân trang, sắp xếp theo (created_at, id) tăng dần)
+// Kết quả có thể dư 1 dòng (FetchLimit) - dùng pagination.Paginate để cắt
+func (r *BetReceiptHistoryRepository) GetAll(page pagination.Request) ([]*models.BetReceiptHistory, error) {
 	query := `
 		SELECT 
 			h.id,
@@ -77,12 +87,11 @@ func (r *BetReceiptHistoryRepository) GetAll(limit, offset int) ([]*models.BetRe
 			COALESCE(h.description, ''),
 			h.created_at
 		FROM bet_receipt_history h
-		LEFT JOIN nguoi_dung u ON h.performed_by = u.id
-		ORDER BY h.created_at ASC
-		LIMIT $1 OFFSET $2
-	`
+		LEFT JOIN nguoi_dung u ON h.performed_by = u.id`
+	clause, args := page.SQL(HistoryCursorColumns, false, false, 1)
+	query += clause
 
-	rows, err := r.db.Query(query, limit, offset)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		log.Printf("Repository - ❌ Lỗi lấy danh sách lịch sử: %v", err)
 		return nil, err
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"fullstack-backend/internal/models"
+	"log"
+)
+
+type ClaimRepository struct {
+	db DBTX
+}
+
+func NewClaimRepository(db *sql.DB) *ClaimRepository {
+	return &ClaimRepository{db: db}
+}
+
+// WithTx trả về repository chạy các query trong transaction tx
+func (r *ClaimRepository) WithTx(tx *sql.Tx) *ClaimRepository {
+	return &ClaimRepository{db: tx}
+}
+
+// Create ghi một lần nhận/trả/giao đơn hàng
+func (r *ClaimRepository) Create(claim *models.BetReceiptClaim) error {
+	err := r.db.QueryRow(`
+		INSERT INTO bet_receipt_claims (bet_receipt_id, action, from_user_id, to_user_id, performed_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`,
+		claim.BetReceiptID,
+		claim.Action,
+		claim.FromUserID,
+		claim.ToUserID,
+		claim.PerformedBy,
+	).Scan(&claim.ID, &claim.CreatedAt)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi ghi lịch sử nhận đơn: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetByBetReceiptID lấy lịch sử nhận/trả/giao của một đơn hàng (cũ nhất trước)
+func (r *ClaimRepository) GetByBetReceiptID(betReceiptID string) ([]*models.BetReceiptClaim, error) {
+	rows, err := r.db.Query(`
+		SELECT c.id, c.bet_receipt_id, c.action,
+		       c.from_user_id, from_user.ten, c.to_user_id, to_user.ten,
+		       c.performed_by, performer.ten, c.created_at
+		FROM bet_receipt_claims c
+		LEFT JOIN nguoi_dung from_user ON c.from_user_id = from_user.id
+		LEFT JOIN nguoi_dung to_user ON c.to_user_id = to_user.id
+		LEFT JOIN nguoi_dung performer ON c.performed_by = performer.id
+		WHERE c.bet_receipt_id = $1
+		ORDER BY c.created_at, c.id
+	`, betReceiptID)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lấy lịch sử nhận đơn: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	claims := []*models.BetReceiptClaim{}
+	for rows.Next() {
+		claim := &models.BetReceiptClaim{}
+		var fromUserID, fromUserName, toUserID, toUserName, performedBy, performedByName sql.NullString
+		if err := rows.Scan(
+			&claim.ID, &claim.BetReceiptID, &claim.Action,
+			&fromUserID, &fromUserName, &toUserID, &toUserName,
+			&performedBy, &performedByName, &claim.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if fromUserID.Valid {
+			claim.FromUserID = &fromUserID.String
+		}
+		if toUserID.Valid {
+			claim.ToUserID = &toUserID.String
+		}
+		if performedBy.Valid {
+			claim.PerformedBy = &performedBy.String
+		}
+		claim.FromUserName = fromUserName.String
+		claim.ToUserName = toUserName.String
+		claim.PerformedByName = performedByName.String
+		claims = append(claims, claim)
+	}
+	return claims, rows.Err()
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"database/sql"
+	"fullstack-backend/internal/models"
+	"log"
+)
+
+type CommentRepository struct {
+	db DBTX
+}
+
+func NewCommentRepository(db *sql.DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+// WithTx trả về repository chạy các query trong transaction tx
+func (r *CommentRepository) WithTx(tx *sql.Tx) *CommentRepository {
+	return &CommentRepository{db: tx}
+}
+
+const commentColumns = `
+	c.id, c.bet_receipt_id, c.author_id, author.ten, c.content,
+	c.mentioned_user_id, mentioned.ten, c.internal, c.created_at, c.edited_at
+`
+
+const commentFrom = `
+	FROM bet_receipt_comments c
+	LEFT JOIN nguoi_dung author ON c.author_id = author.id
+	LEFT JOIN nguoi_dung mentioned ON c.mentioned_user_id = mentioned.id
+`
+
+func scanComment(row rowScanner) (*models.BetReceiptComment, error) {
+	comment := &models.BetReceiptComment{}
+	var authorID, authorName, mentionedUserID, mentionedUserName sql.NullString
+	var editedAt sql.NullTime
+	err := row.Scan(
+		&comment.ID, &comment.BetReceiptID, &authorID, &authorName, &comment.Content,
+		&mentionedUserID, &mentionedUserName, &comment.Internal, &comment.CreatedAt, &editedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if authorID.Valid {
+		comment.AuthorID = &authorID.String
+	}
+	comment.AuthorName = authorName.String
+	if mentionedUserID.Valid {
+		comment.MentionedUserID = &mentionedUserID.String
+	}
+	comment.MentionedUserName = mentionedUserName.String
+	if editedAt.Valid {
+		comment.EditedAt = &editedAt.Time
+	}
+	return comment, nil
+}
+
+// Create lưu bình luận mới
+func (r *CommentRepository) Create(comment *models.BetReceiptComment) error {
+	err := r.db.QueryRow(`
+		INSERT INTO bet_receipt_comments (bet_receipt_id, author_id, content, mentioned_user_id, internal)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`,
+		comment.BetReceiptID,
+		comment.AuthorID,
+		comment.Content,
+		comment.MentionedUserID,
+		comment.Internal,
+	).Scan(&comment.ID, &comment.CreatedAt)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lưu bình luận: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetByBetReceiptID lấy bình luận của một đơn hàng (cũ nhất trước)
+// includeInternal = false: bỏ qua ghi chú nội bộ
+func (r *CommentRepository) GetByBetReceiptID(betReceiptID string, includeInternal bool) ([]*models.BetReceiptComment, error) {
+	rows, err := r.db.Query(`
+		SELECT `+commentColumns+commentFrom+`
+		WHERE c.bet_receipt_id = $1 AND ($2 OR NOT c.internal)
+		ORDER BY c.created_at, c.id
+	`, betReceiptID, includeInternal)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lấy bình luận: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []*models.BetReceiptComment{}
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+// FindByID tìm bình luận theo ID và đơn hàng, trả về sql.ErrNoRows nếu không có
+func (r *CommentRepository) FindByID(betReceiptID, id string) (*models.BetReceiptComment, error) {
+	row := r.db.QueryRow(`
+		SELECT `+commentColumns+commentFrom+`
+		WHERE c.bet_receipt_id = $1 AND c.id = $2
+	`, betReceiptID, id)
+	return scanComment(row)
+}
+
+// UpdateContent sửa nội dung bình luận và ghi lại thời gian sửa
+func (r *CommentRepository) UpdateContent(id, content string) error {
+	if _, err := r.db.Exec(`
+		UPDATE bet_receipt_comments SET content = $1, edited_at = NOW() WHERE id = $2
+	`, content, id); err != nil {
+		log.Printf("Repository - ❌ Lỗi sửa bình luận: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Delete xóa bình luận
+func (r *CommentRepository) Delete(id string) error {
+	if _, err := r.db.Exec(`DELETE FROM bet_receipt_comments WHERE id = $1`, id); err != nil {
+		log.Printf("Repository - ❌ Lỗi xóa bình luận: %v", err)
+		return err
+	}
+	return nil
+}
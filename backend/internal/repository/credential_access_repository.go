@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"database/sql"
+	"fullstack-backend/internal/models"
+	"log"
+)
+
+type CredentialAccessRepository struct {
+	db DBTX
+}
+
+func NewCredentialAccessRepository(db *sql.DB) *CredentialAccessRepository {
+	return &CredentialAccessRepository{db: db}
+}
+
+// Create ghi một lần xem tài khoản/mật khẩu vào nhật ký
+func (r *CredentialAccessRepository) Create(entry *models.CredentialAccessLog) error {
+	query := `
+		INSERT INTO bet_receipt_credential_access (
+			bet_receipt_id, user_id, allowed, reason, ip_address, user_agent
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		entry.BetReceiptID,
+		entry.UserID,
+		entry.Allowed,
+		entry.Reason,
+		entry.IPAddress,
+		entry.UserAgent,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi ghi nhật ký xem tài khoản: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetByBetReceiptID lấy nhật ký xem tài khoản/mật khẩu của một đơn hàng (mới nhất trước)
+func (r *CredentialAccessRepository) GetByBetReceiptID(betReceiptID string) ([]*models.CredentialAccessLog, error) {
+	query := `
+		SELECT a.id, a.bet_receipt_id, a.user_id, nd.ten, a.allowed,
+		       COALESCE(a.reason, ''), COALESCE(a.ip_address, ''), COALESCE(a.user_agent, ''), a.created_at
+		FROM bet_receipt_credential_access a
+		LEFT JOIN nguoi_dung nd ON a.user_id = nd.id
+		WHERE a.bet_receipt_id = $1
+		ORDER BY a.created_at DESC, a.id DESC
+	`
+
+	rows, err := r.db.Query(query, betReceiptID)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lấy nhật ký xem tài khoản: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.CredentialAccessLog{}
+	for rows.Next() {
+		entry := &models.CredentialAccessLog{}
+		var userID, userName sql.NullString
+		err := rows.Scan(
+			&entry.ID, &entry.BetReceiptID, &userID, &userName, &entry.Allowed,
+			&entry.Reason, &entry.IPAddress, &entry.UserAgent, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if userID.Valid {
+			entry.UserID = &userID.String
+		}
+		entry.UserName = userName.String
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -3,16 +3,28 @@ package repository
 import (
 	"database/sql"
 	"fullstack-backend/internal/models"
+	"fullstack-backend/pkg/pagination"
 	"log"
 	"time"
 )
 
 type DepositRepository struct {
-	db *sql.DB
+	db   DBTX    // *sql.DB hoặc *sql.Tx (khi dùng WithTx)
+	conn *sql.DB // Connection gốc (để mở transaction)
 }
 
 func NewDepositRepository(db *sql.DB) *DepositRepository {
-	return &DepositRepository{db: db}
+	return &DepositRepository{db: db, conn: db}
+}
+
+// GetDB trả về connection gốc (để mở transaction)
+func (r *DepositRepository) GetDB() *sql.DB {
+	return r.conn
+}
+
+// WithTx trả về repository chạy các query trong transaction tx
+func (r *DepositRepository) WithTx(tx *sql.Tx) *DepositRepository {
+	return &DepositRepository{db: tx, conn: r.conn}
 }
 
 // Create tạo record nạp tiền mới
@@ -42,7 +54,7 @@ func (r *DepositRepository) Create(deposit *models.Deposit) error {
 	}
 
 	deposit.DepositMonth = depositMonth
-	log.Printf("Repository - ✅ Đã tạo deposit với ID: %s, UserID: %s, AmountVND: %.2f", 
+	log.Printf("Repository - ✅ Đã tạo deposit với ID: %s, UserID: %s, AmountVND: %s", 
 		deposit.ID, deposit.UserID, deposit.AmountVND)
 	return nil
 }
@@ -53,8 +65,28 @@ type DepositWithUser struct {
 	UserName string `json:"user_name" db:"user_name"`
 }
 
-// GetAll lấy tất cả lịch sử nạp tiền kèm tên người dùng, sắp xếp theo thời gian mới nhất
-func (r *DepositRepository) GetAll() ([]DepositWithUser, error) {
+// DepositCursorColumns - Các cột keyset của danh sách nạp tiền (sắp xếp giảm dần)
+var DepositCursorColumns = []string{"d.thoi_gian_tao", "d.id"}
+
+// GetAll lấy lịch sử nạp tiền kèm tên người dùng, sắp xếp theo thời gian mới nhất
+// page.Limit = 0 để lấy tất cả; kết quả có thể dư 1 dòng (FetchLimit) - dùng pagination.Paginate để cắt
+func (r *DepositRepository) GetAll(page pagination.Request) ([]DepositWithUser, error) {
+	var deposits []DepositWithUser
+	err := r.Stream(page, func(d DepositWithUser) error {
+		deposits = append(deposits, d)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Repository - ✅ Đã lấy %d deposits", len(deposits))
+	return deposits, nil
+}
+
+// Stream duyệt lịch sử theo thứ tự của GetAll, gọi fn cho từng dòng ngay khi đọc từ DB
+// (không giữ toàn bộ trong bộ nhớ, dùng cho export)
+func (r *DepositRepository) Stream(page pagination.Request, fn func(DepositWithUser) error) error {
 	query := `
 		SELECT 
 			d.id,
@@ -65,18 +97,17 @@ func (r *DepositRepository) GetAll() ([]DepositWithUser, error) {
 			d.thoi_gian_tao,
 			COALESCE(u.ten, 'N/A') as user_name
 		FROM lich_su_nop_tien d
-		LEFT JOIN nguoi_dung u ON d.id_nguoi_dung = u.id
-		ORDER BY d.thoi_gian_tao DESC
-	`
+		LEFT JOIN nguoi_dung u ON d.id_nguoi_dung = u.id`
+	clause, args := page.SQL(DepositCursorColumns, true, false, 1)
+	query += clause
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		log.Printf("Repository - ❌ Lỗi lấy danh sách deposits: %v", err)
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var deposits []DepositWithUser
 	for rows.Next() {
 		var d DepositWithUser
 		err := rows.Scan(
@@ -92,15 +123,16 @@ func (r *DepositRepository) GetAll() ([]DepositWithUser, error) {
 			log.Printf("Repository - ❌ Lỗi scan deposit: %v", err)
 			continue
 		}
-		deposits = append(deposits, d)
+		if err := fn(d); err != nil {
+			return err
+		}
 	}
 
 	if err = rows.Err(); err != nil {
 		log.Printf("Repository - ❌ Lỗi khi iterate deposits: %v", err)
-		return nil, err
+		return err
 	}
 
-	log.Printf("Repository - ✅ Đã lấy %d deposits", len(deposits))
-	return deposits, nil
+	return nil
 }
 
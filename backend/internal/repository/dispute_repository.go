@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/pkg/money"
+	"log"
+	"strings"
+	"time"
+)
+
+type DisputeRepository struct {
+	db DBTX
+}
+
+func NewDisputeRepository(db *sql.DB) *DisputeRepository {
+	return &DisputeRepository{db: db}
+}
+
+// WithTx trả về repository chạy các query trong transaction tx
+func (r *DisputeRepository) WithTx(tx *sql.Tx) *DisputeRepository {
+	return &DisputeRepository{db: tx}
+}
+
+const disputeSelect = `
+	SELECT d.id, d.bet_receipt_id, ttnk.ma_stt, ttnk.ma_nhiem_vu,
+	       d.worker_id, worker.ten, d.previous_status, d.opened_by, opener.ten,
+	       d.reason, d.evidence, d.referee_id, referee.ten,
+	       d.decision, d.decision_amount_cny, d.decision_note, d.decided_by, d.decided_at, d.created_at
+	FROM bet_receipt_disputes d
+	JOIN thong_tin_nhan_keo ttnk ON d.bet_receipt_id = ttnk.id
+	LEFT JOIN nguoi_dung worker ON d.worker_id = worker.id
+	LEFT JOIN nguoi_dung opener ON d.opened_by = opener.id
+	LEFT JOIN nguoi_dung referee ON d.referee_id = referee.id
+`
+
+func scanDispute(row rowScanner) (*models.BetReceiptDispute, error) {
+	d := &models.BetReceiptDispute{}
+	var workerID, workerName, openedBy, openedByName, evidence sql.NullString
+	var refereeID, refereeName, decision, decisionNote, decidedBy sql.NullString
+	var decisionAmount *money.CNY
+	var decidedAt sql.NullTime
+	err := row.Scan(
+		&d.ID, &d.BetReceiptID, &d.STTCode, &d.TaskCode,
+		&workerID, &workerName, &d.PreviousStatus, &openedBy, &openedByName,
+		&d.Reason, &evidence, &refereeID, &refereeName,
+		&decision, &decisionAmount, &decisionNote, &decidedBy, &decidedAt, &d.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	d.WorkerID = nullStringPtr(workerID)
+	d.WorkerName = workerName.String
+	d.OpenedBy = nullStringPtr(openedBy)
+	d.OpenedByName = openedByName.String
+	d.Evidence = evidence.String
+	d.RefereeID = nullStringPtr(refereeID)
+	d.RefereeName = refereeName.String
+	d.Decision = nullStringPtr(decision)
+	d.DecisionAmountCNY = decisionAmount
+	d.DecisionNote = decisionNote.String
+	d.DecidedBy = nullStringPtr(decidedBy)
+	if decidedAt.Valid {
+		d.DecidedAt = &decidedAt.Time
+	}
+	return d, nil
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+// Create lưu tranh chấp mới
+// Đơn hàng đã có tranh chấp đang mở sẽ vi phạm unique index idx_bet_receipt_disputes_open
+func (r *DisputeRepository) Create(d *models.BetReceiptDispute) error {
+	err := r.db.QueryRow(`
+		INSERT INTO bet_receipt_disputes (bet_receipt_id, worker_id, previous_status, opened_by, reason, evidence)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, d.BetReceiptID, d.WorkerID, d.PreviousStatus, d.OpenedBy, d.Reason, d.Evidence).Scan(&d.ID, &d.CreatedAt)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi tạo tranh chấp: %v", err)
+		return err
+	}
+	return nil
+}
+
+// FindByID tìm tranh chấp theo ID, trả về sql.ErrNoRows nếu không có
+func (r *DisputeRepository) FindByID(id string) (*models.BetReceiptDispute, error) {
+	return scanDispute(r.db.QueryRow(disputeSelect+` WHERE d.id = $1`, id))
+}
+
+// FindOpenByBetReceiptID tìm tranh chấp đang mở của đơn hàng, trả về sql.ErrNoRows nếu không có
+func (r *DisputeRepository) FindOpenByBetReceiptID(betReceiptID string) (*models.BetReceiptDispute, error) {
+	return scanDispute(r.db.QueryRow(disputeSelect+` WHERE d.bet_receipt_id = $1 AND d.decided_at IS NULL`, betReceiptID))
+}
+
+// GetByBetReceiptID lấy tất cả tranh chấp của đơn hàng (cũ nhất trước)
+func (r *DisputeRepository) GetByBetReceiptID(betReceiptID string) ([]*models.BetReceiptDispute, error) {
+	return r.query(disputeSelect+` WHERE d.bet_receipt_id = $1 ORDER BY d.created_at, d.id`, betReceiptID)
+}
+
+// GetAll lấy tranh chấp theo filter (mới nhất trước), bỏ qua đơn hàng đã xóa
+func (r *DisputeRepository) GetAll(filter *models.DisputeFilter) ([]*models.BetReceiptDispute, error) {
+	conditions := []string{"ttnk.deleted_at IS NULL"}
+	args := []interface{}{}
+	if filter.Open != nil {
+		if *filter.Open {
+			conditions = append(conditions, "d.decided_at IS NULL")
+		} else {
+			conditions = append(conditions, "d.decided_at IS NOT NULL")
+		}
+	}
+	if filter.RefereeID != nil {
+		args = append(args, *filter.RefereeID)
+		conditions = append(conditions, fmt.Sprintf("d.referee_id = $%d", len(args)))
+	}
+	if filter.WorkerID != nil {
+		args = append(args, *filter.WorkerID)
+		conditions = append(conditions, fmt.Sprintf("d.worker_id = $%d", len(args)))
+	}
+	return r.query(disputeSelect+" WHERE "+strings.Join(conditions, " AND ")+" ORDER BY d.created_at DESC, d.id", args...)
+}
+
+func (r *DisputeRepository) query(query string, args ...interface{}) ([]*models.BetReceiptDispute, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lấy tranh chấp: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	disputes := []*models.BetReceiptDispute{}
+	for rows.Next() {
+		d, err := scanDispute(rows)
+		if err != nil {
+			return nil, err
+		}
+		disputes = append(disputes, d)
+	}
+	return disputes, rows.Err()
+}
+
+// AssignReferee giao trọng tài cho tranh chấp đang mở, trả về false nếu tranh chấp đã có quyết định
+func (r *DisputeRepository) AssignReferee(id, refereeID string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE bet_receipt_disputes SET referee_id = $2 WHERE id = $1 AND decided_at IS NULL
+	`, id, refereeID)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi giao trọng tài: %v", err)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// Resolve ghi quyết định cho tranh chấp đang mở, trả về false nếu tranh chấp đã có quyết định trước đó
+func (r *DisputeRepository) Resolve(id, decision string, amount *money.CNY, note string, decidedBy *string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE bet_receipt_disputes
+		SET decision = $2, decision_amount_cny = $3, decision_note = $4, decided_by = $5, decided_at = NOW()
+		WHERE id = $1 AND decided_at IS NULL
+	`, id, decision, amount, note, decidedBy)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi ghi quyết định tranh chấp: %v", err)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// ReportByWorker thống kê tranh chấp theo người nhận kèo, tranh chấp mở trong [from, to) (nil = không giới hạn)
+func (r *DisputeRepository) ReportByWorker(from, to *time.Time) ([]*models.DisputeWorkerReport, error) {
+	rows, err := r.db.Query(`
+		SELECT d.worker_id, COALESCE(worker.ten, ''),
+		       COUNT(*),
+		       COUNT(*) FILTER (WHERE d.decided_at IS NULL),
+		       COUNT(*) FILTER (WHERE d.decided_at IS NOT NULL),
+		       COUNT(*) FILTER (WHERE d.decision = $3),
+		       COUNT(*) FILTER (WHERE d.decision = $4),
+		       COUNT(*) FILTER (WHERE d.decision = $5),
+		       AVG(EXTRACT(EPOCH FROM (d.decided_at - d.created_at)) / 3600) FILTER (WHERE d.decided_at IS NOT NULL)
+		FROM bet_receipt_disputes d
+		JOIN thong_tin_nhan_keo ttnk ON d.bet_receipt_id = ttnk.id AND ttnk.deleted_at IS NULL
+		LEFT JOIN nguoi_dung worker ON d.worker_id = worker.id
+		WHERE d.worker_id IS NOT NULL
+		  AND ($1::timestamp IS NULL OR d.created_at >= $1)
+		  AND ($2::timestamp IS NULL OR d.created_at < $2)
+		GROUP BY d.worker_id, worker.ten
+		ORDER BY COUNT(*) DESC, worker.ten
+	`, from, to, models.BetReceiptStatusDone, models.BetReceiptStatusCancelled, models.BetReceiptStatusCompensation)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi thống kê tranh chấp: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []*models.DisputeWorkerReport{}
+	for rows.Next() {
+		report := &models.DisputeWorkerReport{}
+		var avgHours sql.NullFloat64
+		if err := rows.Scan(
+			&report.WorkerID, &report.WorkerName, &report.Total, &report.Open, &report.Resolved,
+			&report.DecidedDone, &report.DecidedCancelled, &report.DecidedCompensation, &avgHours,
+		); err != nil {
+			return nil, err
+		}
+		if avgHours.Valid {
+			hours := avgHours.Float64
+			report.AvgResolutionHours = &hours
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
@@ -4,271 +4,622 @@ import (
 	"database/sql"
 	"fmt"
 	"fullstack-backend/internal/models"
+	"fullstack-backend/pkg/money"
+	"fullstack-backend/pkg/pagination"
+	"fullstack-backend/pkg/secret"
 	"log"
 	"math"
 	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type BetReceiptRepository struct {
-	db *sql.DB
+	db   DBTX    // *sql.DB hoặc *sql.Tx (khi dùng WithTx)
+	conn *sql.DB // Connection gốc (để mở transaction, ping)
 }
 
 func NewBetReceiptRepository(db *sql.DB) *BetReceiptRepository {
-	return &BetReceiptRepository{db: db}
+	return &BetReceiptRepository{db: db, conn: db}
 }
 
 // GetDB trả về database connection (để sử dụng trong service)
 func (r *BetReceiptRepository) GetDB() *sql.DB {
-	return r.db
+	return r.conn
 }
 
-// Create tạo đơn hàng (thông tin nhận kèo) mới
-func (r *BetReceiptRepository) Create(betReceipt *models.BetReceipt) error {
-	// Lấy số thứ tự tiếp theo (số lượng đơn hàng hiện tại + 1)
-	var maxSTT sql.NullInt64
-	err := r.db.QueryRow("SELECT COALESCE(MAX(stt), 0) FROM thong_tin_nhan_keo").Scan(&maxSTT)
+// WithTx trả về repository chạy các query trong transaction tx
+func (r *BetReceiptRepository) WithTx(tx *sql.Tx) *BetReceiptRepository {
+	return &BetReceiptRepository{db: tx, conn: r.conn}
+}
+
+// UserCode lấy mã ngắn của người dùng (nguoi_dung.ma_nguoi_dung, vd: "U001") dùng trong mã STT theo người dùng
+func (r *BetReceiptRepository) UserCode(userID string) (string, error) {
+	var code string
+	err := r.db.QueryRow(`SELECT ma_nguoi_dung FROM nguoi_dung WHERE id = $1`, userID).Scan(&code)
 	if err != nil {
-		return err
+		log.Printf("Repository - ❌ Lỗi lấy mã người dùng %s: %v", userID, err)
+		return "", err
 	}
-	stt := 1
-	if maxSTT.Valid {
-		stt = int(maxSTT.Int64) + 1
+	return code, nil
+}
+
+// UpdateSTTCode đổi mã STT hiển thị của đơn hàng (cấp lại mã pool theo người nhận)
+func (r *BetReceiptRepository) UpdateSTTCode(id, code string) error {
+	_, err := r.db.Exec(`UPDATE thong_tin_nhan_keo SET ma_stt = $2, thoi_gian_cap_nhat = NOW() WHERE id = $1`, id, code)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi cập nhật mã STT đơn hàng %s: %v", id, err)
+		return err
 	}
-	betReceipt.STT = stt
+	return nil
+}
 
+// NextSTT cấp số thứ tự tiếp theo của bộ đếm scope (tạo bộ đếm nếu chưa có)
+// Dòng bộ đếm bị khóa đến hết transaction nên không có 2 đơn hàng nhận cùng một số,
+// và nếu transaction bị rollback thì số đã cấp cũng được trả lại (không bị nhảy số)
+func (r *BetReceiptRepository) NextSTT(scope string) (int, error) {
+	query := `
+        INSERT INTO stt_counters (scope, last_value, thoi_gian_cap_nhat)
+        VALUES ($1, 1, NOW())
+        ON CONFLICT (scope) DO UPDATE
+        SET last_value = stt_counters.last_value + 1, thoi_gian_cap_nhat = NOW()
+        RETURNING last_value
+    `
+	var next int
+	err := r.db.QueryRow(query, scope).Scan(&next)
+	return next, err
+}
+
+// Create tạo đơn hàng (thông tin nhận kèo) mới
+// STT và mã STT phải được cấp trước (NextSTT) trong cùng transaction
+func (r *BetReceiptRepository) Create(betReceipt *models.BetReceipt) error {
 	query := `
         INSERT INTO thong_tin_nhan_keo (
             stt, id_nguoi_dung, ma_nhiem_vu, loai_keo, tien_keo_web_te, 
             ma_don_hang, ghi_chu, tien_do_hoan_thanh, 
             tai_khoan, mat_khau, khu_vuc,
-            thoi_gian_nhan_keo, thoi_gian_con_lai_gio, thoi_gian_cap_nhat
+            thoi_gian_nhan_keo, thoi_gian_con_lai_gio, thoi_gian_cap_nhat, tai_khoan_che, ma_stt, tai_khoan_hash
         ) 
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW(), $12, NOW()) 
-        RETURNING id, thoi_gian_nhan_keo, thoi_gian_cap_nhat
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW(), $12, NOW(), $13, $14, NULLIF($15, '')) 
+        RETURNING id, thoi_gian_nhan_keo, thoi_gian_cap_nhat, han_hoan_thanh
     `
-	return r.db.QueryRow(
+	var deadlineAt sql.NullTime
+	err := r.db.QueryRow(
 		query,
 		betReceipt.STT,
-		betReceipt.UserID,
+		nullUserID(betReceipt.UserID),
 		betReceipt.TaskCode,
 		betReceipt.BetType,
 		betReceipt.WebBetAmountCNY,
 		betReceipt.OrderCode,
 		betReceipt.Notes,
 		betReceipt.Status,
-		betReceipt.Account,
-		betReceipt.Password,
+		betReceipt.AccountEncrypted,
+		betReceipt.PasswordEncrypted,
 		betReceipt.Region,
 		betReceipt.TimeRemainingHours,
-	).Scan(&betReceipt.ID, &betReceipt.ReceivedAt, &betReceipt.UpdatedAt)
+		betReceipt.Account,
+		betReceipt.STTCode,
+		betReceipt.AccountHash,
+	).Scan(&betReceipt.ID, &betReceipt.ReceivedAt, &betReceipt.UpdatedAt, &deadlineAt)
+	if err != nil {
+		return err
+	}
+	setBetReceiptDeadline(betReceipt, deadlineAt, sql.NullTime{})
+	return nil
+}
+
+// betReceiptSortColumns - Map tên trường JSON -> cột SQL được phép dùng để sắp xếp
+var betReceiptSortColumns = map[string]string{
+	"stt":                  "ttnk.stt",
+	"user_name":            "nd.ten",
+	"task_code":            "ttnk.ma_nhiem_vu",
+	"bet_type":             "ttnk.loai_keo",
+	"web_bet_amount_cny":   "ttnk.tien_keo_web_te",
+	"order_code":           "ttnk.ma_don_hang",
+	"status":               "ttnk.tien_do_hoan_thanh",
+	"actual_received_cny":  "ttnk.tien_keo_web_thuc_nhan_te",
+	"compensation_cny":     "ttnk.tien_den_te",
+	"actual_amount_cny":    "ttnk.cong_thuc_nhan_te",
+	"exchange_rate":        "ttnk.exchange_rate",
+	"region":               "ttnk.khu_vuc",
+	"received_at":          "ttnk.thoi_gian_nhan_keo",
+	"completed_at":         "ttnk.thoi_gian_hoan_thanh",
+	"time_remaining_hours": "ttnk.thoi_gian_con_lai_gio",
+	"deadline_at":          "ttnk.han_hoan_thanh",
+	"updated_at":           "ttnk.thoi_gian_cap_nhat",
+}
+
+// betReceiptKeysetValues - Các trường sắp xếp NOT NULL hỗ trợ cursor pagination
+// và cách lấy giá trị của trường đó từ một đơn hàng (để tạo cursor)
+var betReceiptKeysetValues = map[string]func(*models.BetReceipt) interface{}{
+	"task_code":          func(b *models.BetReceipt) interface{} { return b.TaskCode },
+	"bet_type":           func(b *models.BetReceipt) interface{} { return b.BetType },
+	"web_bet_amount_cny": func(b *models.BetReceipt) interface{} { return b.WebBetAmountCNY },
+	"status":             func(b *models.BetReceipt) interface{} { return b.Status },
+	"received_at":        func(b *models.BetReceipt) interface{} { return b.ReceivedAt },
+	"updated_at":         func(b *models.BetReceipt) interface{} { return b.UpdatedAt },
+}
+
+// betReceiptKeysetColumns trả về các cột keyset theo trường sắp xếp (nil nếu không hỗ trợ cursor)
+// Luôn kết thúc bằng (stt, id) để thứ tự là duy nhất
+func betReceiptKeysetColumns(sortBy string) []string {
+	if sortBy == "" || sortBy == "stt" {
+		return []string{"ttnk.stt", "ttnk.id"}
+	}
+	if _, ok := betReceiptKeysetValues[sortBy]; !ok {
+		return nil
+	}
+	return []string{betReceiptSortColumns[sortBy], "ttnk.stt", "ttnk.id"}
+}
+
+// BetReceiptCursorKey trả về hàm lấy giá trị keyset của đơn hàng theo trường sắp xếp
+// Trả về nil nếu trường sắp xếp không hỗ trợ cursor (cột có thể NULL)
+func BetReceiptCursorKey(sortBy string) func(*models.BetReceipt) []interface{} {
+	if sortBy == "" || sortBy == "stt" {
+		return func(b *models.BetReceipt) []interface{} { return []interface{}{b.STT, b.ID} }
+	}
+	valueFn, ok := betReceiptKeysetValues[sortBy]
+	if !ok {
+		return nil
+	}
+	return func(b *models.BetReceipt) []interface{} { return []interface{}{valueFn(b), b.STT, b.ID} }
 }
 
-// GetAll lấy tất cả đơn hàng (thông tin nhận kèo) có phân trang, join với bảng nguoi_dung để lấy tên
-// Nếu userID != nil, chỉ lấy đơn hàng của user đó
-func (r *BetReceiptRepository) GetAll(limit, offset int, userID *string) ([]*models.BetReceipt, error) {
+// IsValidBetReceiptSortField kiểm tra trường sắp xếp có được hỗ trợ không
+func IsValidBetReceiptSortField(field string) bool {
+	_, ok := betReceiptSortColumns[field]
+	return ok
+}
+
+// escapeLikePattern escape các ký tự đặc biệt của LIKE (%, _, \) để tìm theo tiền tố
+func escapeLikePattern(value string) string {
+	replacer := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_")
+	return replacer.Replace(value)
+}
+
+// buildBetReceiptWhere tạo mệnh đề WHERE từ filter, trả về câu WHERE (có thể rỗng), args và chỉ số $ tiếp theo
+func buildBetReceiptWhere(filter *models.BetReceiptFilter) (string, []interface{}, int) {
+	args := []interface{}{}
+	argIndex := 1
+	whereConditions := []string{}
+
+	addCondition := func(format string, value interface{}) {
+		whereConditions = append(whereConditions, fmt.Sprintf(format, argIndex))
+		args = append(args, value)
+		argIndex++
+	}
+
+	// Thùng rác chỉ lấy đơn đã xóa, các trường hợp khác luôn bỏ qua đơn đã xóa
+	if filter.Deleted {
+		whereConditions = append(whereConditions, "ttnk.deleted_at IS NOT NULL")
+	} else {
+		whereConditions = append(whereConditions, "ttnk.deleted_at IS NULL")
+	}
+
+	if filter.UserID != nil {
+		addCondition("ttnk.id_nguoi_dung = $%d", *filter.UserID)
+		log.Printf("Repository - 🔍 Filtering by user_id: %s", *filter.UserID)
+	}
+
+	if filter.Unassigned {
+		whereConditions = append(whereConditions, "ttnk.id_nguoi_dung IS NULL")
+	}
+
+	if len(filter.Statuses) > 0 {
+		placeholders := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			placeholders[i] = fmt.Sprintf("$%d", argIndex)
+			args = append(args, status)
+			argIndex++
+		}
+		whereConditions = append(whereConditions, "ttnk.tien_do_hoan_thanh IN ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	if filter.BetType != "" {
+		addCondition("ttnk.loai_keo = $%d", filter.BetType)
+	}
+	if filter.Region != "" {
+		addCondition("LOWER(ttnk.khu_vuc) = LOWER($%d)", filter.Region)
+	}
+	if filter.ReceivedFrom != nil {
+		addCondition("ttnk.thoi_gian_nhan_keo >= $%d", *filter.ReceivedFrom)
+	}
+	if filter.ReceivedTo != nil {
+		addCondition("ttnk.thoi_gian_nhan_keo < $%d", *filter.ReceivedTo)
+	}
+	if filter.CompletedFrom != nil {
+		addCondition("ttnk.thoi_gian_hoan_thanh >= $%d", *filter.CompletedFrom)
+	}
+	if filter.CompletedTo != nil {
+		addCondition("ttnk.thoi_gian_hoan_thanh < $%d", *filter.CompletedTo)
+	}
+	if filter.DeadlineFrom != nil {
+		addCondition("ttnk.han_hoan_thanh >= $%d", *filter.DeadlineFrom)
+	}
+	if filter.DeadlineTo != nil {
+		addCondition("ttnk.han_hoan_thanh < $%d", *filter.DeadlineTo)
+	}
+	if filter.Overdue != nil {
+		// Quá hạn = đã qua deadline và vẫn ở status chịu deadline (giống BetReceipt.IsOverdue)
+		condition := fmt.Sprintf("COALESCE(ttnk.han_hoan_thanh < NOW() AND ttnk.tien_do_hoan_thanh = ANY($%d), FALSE)", argIndex)
+		if !*filter.Overdue {
+			condition = "NOT " + condition
+		}
+		whereConditions = append(whereConditions, condition)
+		args = append(args, pq.Array(models.BetReceiptDeadlineStatuses))
+		argIndex++
+	}
+	if filter.TaskCodePrefix != "" {
+		addCondition("ttnk.ma_nhiem_vu ILIKE $%d", escapeLikePattern(filter.TaskCodePrefix)+"%")
+	}
+	if filter.OrderCodePrefix != "" {
+		addCondition("ttnk.ma_don_hang ILIKE $%d", escapeLikePattern(filter.OrderCodePrefix)+"%")
+	}
+	if filter.CodePrefix != "" {
+		whereConditions = append(whereConditions, fmt.Sprintf("(ttnk.ma_nhiem_vu ILIKE $%d OR ttnk.ma_don_hang ILIKE $%d)", argIndex, argIndex))
+		args = append(args, escapeLikePattern(filter.CodePrefix)+"%")
+		argIndex++
+	}
+
+	if len(whereConditions) == 0 {
+		return "", args, argIndex
+	}
+	return " WHERE " + strings.Join(whereConditions, " AND "), args, argIndex
+}
+
+// buildBetReceiptOrderBy tạo mệnh đề ORDER BY từ filter (mặc định theo stt tăng dần)
+// Luôn thêm stt và id vào cuối để thứ tự ổn định khi có giá trị trùng nhau
+func buildBetReceiptOrderBy(filter *models.BetReceiptFilter) string {
+	direction := "ASC"
+	if filter.SortDesc {
+		direction = "DESC"
+	}
+
+	column, ok := betReceiptSortColumns[filter.SortBy]
+	if !ok || column == "ttnk.stt" {
+		return fmt.Sprintf(" ORDER BY ttnk.stt %s, ttnk.id %s", direction, direction)
+	}
+	return fmt.Sprintf(" ORDER BY %s %s NULLS LAST, ttnk.stt %s, ttnk.id %s", column, direction, direction, direction)
+}
+
+// CountAll đếm tổng số đơn hàng khớp với filter (không tính limit/offset)
+func (r *BetReceiptRepository) CountAll(filter *models.BetReceiptFilter) (int, error) {
+	where, args, _ := buildBetReceiptWhere(filter)
+	query := `
+        SELECT COUNT(*)
+        FROM thong_tin_nhan_keo ttnk
+        LEFT JOIN nguoi_dung nd ON ttnk.id_nguoi_dung = nd.id
+    ` + where
+
+	var total int
+	if err := r.db.QueryRow(query, args...).Scan(&total); err != nil {
+		log.Printf("Repository - ❌ Lỗi khi đếm đơn hàng: %v", err)
+		return 0, err
+	}
+	return total, nil
+}
+
+// GetAll lấy đơn hàng (thông tin nhận kèo) theo filter có phân trang, join với bảng nguoi_dung để lấy tên
+func (r *BetReceiptRepository) GetAll(filter *models.BetReceiptFilter) ([]*models.BetReceipt, error) {
+	betReceipts := []*models.BetReceipt{}
+	err := r.Stream(filter, func(betReceipt *models.BetReceipt) error {
+		betReceipts = append(betReceipts, betReceipt)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return betReceipts, nil
+}
+
+// Stream duyệt đơn hàng theo filter, gọi fn cho từng đơn hàng ngay khi đọc từ DB (không giữ toàn bộ trong bộ nhớ)
+// filter.Limit = 0 để lấy tất cả (dùng cho export)
+func (r *BetReceiptRepository) Stream(filter *models.BetReceiptFilter, fn func(*models.BetReceipt) error) error {
 	query := `
         SELECT 
-            ttnk.id, ttnk.stt, ttnk.id_nguoi_dung, nd.ten as user_name,
+            ttnk.id, ttnk.stt, ttnk.ma_stt, ttnk.id_nguoi_dung, nd.ten as user_name,
             ttnk.ma_nhiem_vu, ttnk.loai_keo, ttnk.tien_keo_web_te,
             ttnk.ma_don_hang, ttnk.ghi_chu, ttnk.tien_do_hoan_thanh, 
             ttnk.tien_keo_web_thuc_nhan_te, ttnk.tien_den_te, ttnk.cong_thuc_nhan_te,
             ttnk.exchange_rate, ttnk.ly_do_huy, ttnk.tai_khoan, ttnk.mat_khau, ttnk.khu_vuc,
             ttnk.thoi_gian_nhan_keo, ttnk.thoi_gian_hoan_thanh,
-            ttnk.thoi_gian_con_lai_gio, ttnk.thoi_gian_cap_nhat
+            ttnk.thoi_gian_con_lai_gio, ttnk.thoi_gian_cap_nhat,
+            ttnk.han_hoan_thanh, ttnk.thoi_gian_bao_qua_han, ttnk.tai_khoan_che,
+            ttnk.fee_schedule_version, ttnk.deleted_at, ttnk.deleted_by, ttnk.locked_period
         FROM thong_tin_nhan_keo ttnk
         LEFT JOIN nguoi_dung nd ON ttnk.id_nguoi_dung = nd.id
     `
 
-	// Thêm WHERE clause
-	args := []interface{}{}
-	argIndex := 1
-	whereConditions := []string{}
-
-	if userID != nil {
-		whereConditions = append(whereConditions, fmt.Sprintf("ttnk.id_nguoi_dung = $%d", argIndex))
-		args = append(args, *userID)
-		argIndex++
-		log.Printf("Repository - 🔍 Filtering by user_id: %s", *userID)
+	// Thêm WHERE clause và ORDER BY
+	where, args, argIndex := buildBetReceiptWhere(filter)
+	if keysetColumns := betReceiptKeysetColumns(filter.SortBy); keysetColumns != nil {
+		// Trường sắp xếp NOT NULL: dùng keyset (cursor) nếu có, ORDER BY cùng thứ tự với cursor
+		condition, orderBy, cursorArgs, nextIndex := pagination.KeysetQuery(keysetColumns, filter.SortDesc, filter.Cursor, argIndex)
+		if condition != "" {
+			if where == "" {
+				where = " WHERE " + condition
+			} else {
+				where += " AND " + condition
+			}
+			args = append(args, cursorArgs...)
+		}
+		argIndex = nextIndex
+		query += where + orderBy
+	} else {
+		query += where + buildBetReceiptOrderBy(filter)
 	}
 
-	if len(whereConditions) > 0 {
-		query += " WHERE " + strings.Join(whereConditions, " AND ")
+	offset := filter.Offset
+	if filter.Cursor != nil {
+		offset = 0
+	}
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+		args = append(args, filter.Limit, offset)
 	}
 
-	query += fmt.Sprintf(" ORDER BY ttnk.stt ASC LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
-	args = append(args, limit, offset)
-
-	log.Printf("Repository - 🔍 Executing query với limit=%d, offset=%d", limit, offset)
+	log.Printf("Repository - 🔍 Executing query với limit=%d, offset=%d, sort_by=%s", filter.Limit, filter.Offset, filter.SortBy)
 
 	// Kiểm tra connection trước khi query (connection pool sẽ tự động reconnect nếu cần)
-	if err := r.db.Ping(); err != nil {
+	if err := r.conn.Ping(); err != nil {
 		log.Printf("Repository - ❌ Database connection error: %v", err)
-		return nil, fmt.Errorf("database connection error: %w", err)
+		return fmt.Errorf("database connection error: %w", err)
 	}
 
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		log.Printf("Repository - ❌ Lỗi khi execute query: %v", err)
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	betReceipts := []*models.BetReceipt{}
 	rowCount := 0
 	for rows.Next() {
 		rowCount++
-		betReceipt := &models.BetReceipt{}
-		var completedAt sql.NullTime
-		var timeRemainingHours sql.NullInt64
-		var userName sql.NullString
-		var cancelReason sql.NullString
-		var account sql.NullString
-		var password sql.NullString
-		var region sql.NullString
-
-		var exchangeRate sql.NullFloat64
-		err := rows.Scan(
-			&betReceipt.ID,
-			&betReceipt.STT,
-			&betReceipt.UserID,
-			&userName,
-			&betReceipt.TaskCode,
-			&betReceipt.BetType,
-			&betReceipt.WebBetAmountCNY,
-			&betReceipt.OrderCode,
-			&betReceipt.Notes,
-			&betReceipt.Status,
-			&betReceipt.ActualReceivedCNY,
-			&betReceipt.CompensationCNY,
-			&betReceipt.ActualAmountCNY,
-			&exchangeRate,
-			&cancelReason,
-			&account,
-			&password,
-			&region,
-			&betReceipt.ReceivedAt,
-			&completedAt,
-			&timeRemainingHours,
-			&betReceipt.UpdatedAt,
-		)
+		betReceipt, err := scanBetReceiptListRow(rows)
 		if err != nil {
-			return nil, err
+			return err
 		}
-
-		if userName.Valid {
-			betReceipt.UserName = userName.String
-			log.Printf("Repository - ✅ BetReceipt ID: %s, UserID: %s, UserName: %s", betReceipt.ID, betReceipt.UserID, betReceipt.UserName)
-		} else {
-			// Nếu không tìm thấy tên trong DB (JOIN không match), hiển thị thông báo
-			betReceipt.UserName = "không có trong db"
-			log.Printf("Repository - ⚠️ BetReceipt ID: %s, UserID: %s, UserName: NULL (không tìm thấy trong DB)", betReceipt.ID, betReceipt.UserID)
+		if err := fn(betReceipt); err != nil {
+			return err
 		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
-		if exchangeRate.Valid {
-			betReceipt.ExchangeRate = exchangeRate.Float64
-		} else {
-			betReceipt.ExchangeRate = 3550.0 // Giá trị mặc định
-		}
+	log.Printf("Repository - ✅ Đã scan %d rows từ database", rowCount)
+	return nil
+}
 
-		if cancelReason.Valid {
-			betReceipt.CancelReason = cancelReason.String
-		}
+// scanBetReceiptListRow đọc một dòng của query danh sách đơn hàng (Stream)
+// và tính các trường hiển thị (số giờ hoàn thành, thời gian còn lại)
+func scanBetReceiptListRow(rows *sql.Rows) (*models.BetReceipt, error) {
+	betReceipt := &models.BetReceipt{}
+	var completedAt sql.NullTime
+	var timeRemainingHours sql.NullInt64
+	var userName sql.NullString
+	var cancelReason sql.NullString
+	var account sql.NullString
+	var password sql.NullString
+	var region sql.NullString
 
-		if account.Valid {
-			betReceipt.Account = account.String
-		} else {
-			betReceipt.Account = ""
-		}
+	var exchangeRate *money.Rate
+	var deadlineAt, overdueFlaggedAt sql.NullTime
+	var accountMasked sql.NullString
+	var feeScheduleVersion sql.NullInt64
+	var deletedAt sql.NullTime
+	var deletedBy sql.NullString
+	var lockedPeriod sql.NullString
+	var userID sql.NullString
+	err := rows.Scan(
+		&betReceipt.ID,
+		&betReceipt.STT,
+		&betReceipt.STTCode,
+		&userID,
+		&userName,
+		&betReceipt.TaskCode,
+		&betReceipt.BetType,
+		&betReceipt.WebBetAmountCNY,
+		&betReceipt.OrderCode,
+		&betReceipt.Notes,
+		&betReceipt.Status,
+		&betReceipt.ActualReceivedCNY,
+		&betReceipt.CompensationCNY,
+		&betReceipt.ActualAmountCNY,
+		&exchangeRate,
+		&cancelReason,
+		&account,
+		&password,
+		&region,
+		&betReceipt.ReceivedAt,
+		&completedAt,
+		&timeRemainingHours,
+		&betReceipt.UpdatedAt,
+		&deadlineAt,
+		&overdueFlaggedAt,
+		&accountMasked,
+		&feeScheduleVersion,
+		&deletedAt,
+		&deletedBy,
+		&lockedPeriod,
+	)
+	if err != nil {
+		return nil, err
+	}
 
-		if password.Valid {
-			betReceipt.Password = password.String
-		} else {
-			betReceipt.Password = ""
+	betReceipt.UserID = userID.String
+	if !userID.Valid {
+		// Đơn hàng trong pool, chưa có người nhận
+		betReceipt.UserName = ""
+	} else if userName.Valid {
+		betReceipt.UserName = userName.String
+		log.Printf("Repository - ✅ BetReceipt ID: %s, UserID: %s, UserName: %s", betReceipt.ID, betReceipt.UserID, betReceipt.UserName)
+	} else {
+		// Nếu không tìm thấy tên trong DB (JOIN không match), hiển thị thông báo
+		betReceipt.UserName = "không có trong db"
+		log.Printf("Repository - ⚠️ BetReceipt ID: %s, UserID: %s, UserName: NULL (không tìm thấy trong DB)", betReceipt.ID, betReceipt.UserID)
+	}
+
+	if exchangeRate != nil {
+		betReceipt.ExchangeRate = *exchangeRate
+	} else {
+		betReceipt.ExchangeRate = models.DefaultExchangeRate // Giá trị mặc định
+	}
+
+	if cancelReason.Valid {
+		betReceipt.CancelReason = cancelReason.String
+	}
+
+	setBetReceiptCredentials(betReceipt, account, password, accountMasked)
+	if feeScheduleVersion.Valid {
+		version := int(feeScheduleVersion.Int64)
+		betReceipt.FeeScheduleVersion = &version
+	}
+
+	if region.Valid {
+		betReceipt.Region = region.String
+	} else {
+		betReceipt.Region = ""
+	}
+
+	if completedAt.Valid {
+		betReceipt.CompletedAt = &completedAt.Time
+		// Tính thời gian hoàn thành thực tế (số giờ) = CompletedAt - ReceivedAt
+		elapsed := completedAt.Time.Sub(betReceipt.ReceivedAt)
+		// Làm tròn theo quy tắc chuẩn: .1-.4 làm tròn xuống, từ .5 làm tròn lên
+		completedHours := int(math.Round(elapsed.Hours()))
+		// Nếu < 1 giờ thì trả về 1 giờ
+		if completedHours < 1 {
+			completedHours = 1
 		}
+		betReceipt.CompletedHours = &completedHours
+	} else {
+		betReceipt.CompletedHours = nil
+	}
+	if timeRemainingHours.Valid {
+		hours := int(timeRemainingHours.Int64)
+		betReceipt.TimeRemainingHours = &hours
 
-		if region.Valid {
-			betReceipt.Region = region.String
-		} else {
-			betReceipt.Region = ""
+		// Tính toán thời gian còn lại thực tế dựa trên thời gian đã trôi qua
+		now := time.Now()
+		elapsed := now.Sub(betReceipt.ReceivedAt)
+		elapsedHours := int(elapsed.Hours())
+
+		// Thời gian còn lại = Thời gian hoàn thành - Số giờ đã trôi qua
+		remainingHours := hours - elapsedHours
+		if remainingHours < 0 {
+			remainingHours = 0
 		}
 
-		if completedAt.Valid {
-			betReceipt.CompletedAt = &completedAt.Time
-			// Tính thời gian hoàn thành thực tế (số giờ) = CompletedAt - ReceivedAt
-			elapsed := completedAt.Time.Sub(betReceipt.ReceivedAt)
-			// Làm tròn theo quy tắc chuẩn: .1-.4 làm tròn xuống, từ .5 làm tròn lên
-			completedHours := int(math.Round(elapsed.Hours()))
-			// Nếu < 1 giờ thì trả về 1 giờ
-			if completedHours < 1 {
-				completedHours = 1
-			}
-			betReceipt.CompletedHours = &completedHours
-		} else {
-			betReceipt.CompletedHours = nil
+		// Tính số phút còn lại (phần lẻ của giờ)
+		elapsedMinutes := int(elapsed.Minutes())
+		remainingMinutes := (hours * 60) - elapsedMinutes
+		if remainingMinutes < 0 {
+			remainingMinutes = 0
 		}
-		if timeRemainingHours.Valid {
-			hours := int(timeRemainingHours.Int64)
-			betReceipt.TimeRemainingHours = &hours
-
-			// Tính toán thời gian còn lại thực tế dựa trên thời gian đã trôi qua
-			now := time.Now()
-			elapsed := now.Sub(betReceipt.ReceivedAt)
-			elapsedHours := int(elapsed.Hours())
-
-			// Thời gian còn lại = Thời gian hoàn thành - Số giờ đã trôi qua
-			remainingHours := hours - elapsedHours
-			if remainingHours < 0 {
-				remainingHours = 0
-			}
 
-			// Tính số phút còn lại (phần lẻ của giờ)
-			elapsedMinutes := int(elapsed.Minutes())
-			remainingMinutes := (hours * 60) - elapsedMinutes
-			if remainingMinutes < 0 {
-				remainingMinutes = 0
-			}
+		// Format: giờ:phút (ví dụ: 20:00, 19:30)
+		remainingHoursFormatted := remainingMinutes / 60
+		remainingMinutesFormatted := remainingMinutes % 60
+		betReceipt.TimeRemainingFormatted = fmt.Sprintf("%02d:%02d", remainingHoursFormatted, remainingMinutesFormatted)
+	} else {
+		betReceipt.TimeRemainingFormatted = ""
+	}
+	setBetReceiptDeadline(betReceipt, deadlineAt, overdueFlaggedAt)
+	setBetReceiptDeleted(betReceipt, deletedAt, deletedBy)
+	if lockedPeriod.Valid {
+		betReceipt.LockedPeriod = &lockedPeriod.String
+	}
 
-			// Format: giờ:phút (ví dụ: 20:00, 19:30)
-			remainingHoursFormatted := remainingMinutes / 60
-			remainingMinutesFormatted := remainingMinutes % 60
-			betReceipt.TimeRemainingFormatted = fmt.Sprintf("%02d:%02d", remainingHoursFormatted, remainingMinutesFormatted)
-		} else {
-			betReceipt.TimeRemainingFormatted = ""
-		}
+	return betReceipt, nil
+}
 
-		betReceipts = append(betReceipts, betReceipt)
+// setBetReceiptCredentials gán tài khoản/mật khẩu đã mã hóa (như trong DB) và giá trị đã che để hiển thị
+func setBetReceiptCredentials(betReceipt *models.BetReceipt, account, password, accountMasked sql.NullString) {
+	betReceipt.AccountEncrypted = account.String
+	betReceipt.PasswordEncrypted = password.String
+	betReceipt.Account = accountMasked.String
+	betReceipt.Password = ""
+	if betReceipt.PasswordEncrypted != "" {
+		betReceipt.Password = secret.Masked
 	}
+}
 
-	log.Printf("Repository - ✅ Đã scan %d rows từ database", rowCount)
-	return betReceipts, nil
+// setBetReceiptDeadline gán deadline, thời điểm đánh dấu quá hạn và tính cờ overdue
+func setBetReceiptDeadline(betReceipt *models.BetReceipt, deadlineAt, overdueFlaggedAt sql.NullTime) {
+	if deadlineAt.Valid {
+		betReceipt.DeadlineAt = &deadlineAt.Time
+	}
+	if overdueFlaggedAt.Valid {
+		betReceipt.OverdueFlaggedAt = &overdueFlaggedAt.Time
+	}
+	betReceipt.Overdue = betReceipt.IsOverdue(time.Now())
 }
 
-// FindByID tìm đơn hàng (thông tin nhận kèo) theo ID
+// nullUserID chuyển ID người nhận kèo sang giá trị lưu DB ("" = NULL, đơn hàng trong pool)
+func nullUserID(userID string) sql.NullString {
+	return sql.NullString{String: userID, Valid: userID != ""}
+}
+
+// setBetReceiptDeleted gán thời gian/người xóa mềm (nil nếu đơn hàng chưa bị xóa)
+func setBetReceiptDeleted(betReceipt *models.BetReceipt, deletedAt sql.NullTime, deletedBy sql.NullString) {
+	if deletedAt.Valid {
+		betReceipt.DeletedAt = &deletedAt.Time
+	}
+	if deletedBy.Valid {
+		betReceipt.DeletedBy = &deletedBy.String
+	}
+}
+
+// FindByID tìm đơn hàng (thông tin nhận kèo) chưa bị xóa theo ID
+// Đơn hàng đã xóa (trong thùng rác) trả về sql.ErrNoRows
 func (r *BetReceiptRepository) FindByID(id string) (*models.BetReceipt, error) {
+	return r.findByID(id, false)
+}
+
+// FindDeletedByID tìm đơn hàng đã xóa (trong thùng rác) theo ID
+func (r *BetReceiptRepository) FindDeletedByID(id string) (*models.BetReceipt, error) {
+	return r.findByID(id, true)
+}
+
+func (r *BetReceiptRepository) findByID(id string, deleted bool) (*models.BetReceipt, error) {
 	betReceipt := &models.BetReceipt{}
 	var completedAt sql.NullTime
 	var timeRemainingHours sql.NullInt64
 
 	query := `
         SELECT 
-            id, stt, id_nguoi_dung, ma_nhiem_vu, loai_keo, tien_keo_web_te,
+            id, stt, ma_stt, id_nguoi_dung, ma_nhiem_vu, loai_keo, tien_keo_web_te,
             ma_don_hang, ghi_chu, tien_do_hoan_thanh, tien_keo_web_thuc_nhan_te,
             tien_den_te, cong_thuc_nhan_te, exchange_rate, ly_do_huy, tai_khoan, mat_khau, khu_vuc,
             thoi_gian_nhan_keo, thoi_gian_hoan_thanh,
-            thoi_gian_con_lai_gio, thoi_gian_cap_nhat
+            thoi_gian_con_lai_gio, thoi_gian_cap_nhat,
+            han_hoan_thanh, thoi_gian_bao_qua_han, tai_khoan_che,
+            fee_schedule_version, deleted_at, deleted_by, tai_khoan_hash, locked_period
         FROM thong_tin_nhan_keo 
-        WHERE id = $1
+        WHERE id = $1 AND (deleted_at IS NOT NULL) = $2
     `
 	var cancelReason sql.NullString
 	var account sql.NullString
 	var password sql.NullString
 	var region sql.NullString
-	var exchangeRate sql.NullFloat64
-	err := r.db.QueryRow(query, id).Scan(
+	var exchangeRate *money.Rate
+	var deadlineAt, overdueFlaggedAt sql.NullTime
+	var accountMasked sql.NullString
+	var feeScheduleVersion sql.NullInt64
+	var deletedAt sql.NullTime
+	var deletedBy sql.NullString
+	var userID sql.NullString
+	var accountHash sql.NullString
+	var lockedPeriod sql.NullString
+	err := r.db.QueryRow(query, id, deleted).Scan(
 		&betReceipt.ID,
 		&betReceipt.STT,
-		&betReceipt.UserID,
+		&betReceipt.STTCode,
+		&userID,
 		&betReceipt.TaskCode,
 		&betReceipt.BetType,
 		&betReceipt.WebBetAmountCNY,
@@ -287,31 +638,35 @@ func (r *BetReceiptRepository) FindByID(id string) (*models.BetReceipt, error) {
 		&completedAt,
 		&timeRemainingHours,
 		&betReceipt.UpdatedAt,
+		&deadlineAt,
+		&overdueFlaggedAt,
+		&accountMasked,
+		&feeScheduleVersion,
+		&deletedAt,
+		&deletedBy,
+		&accountHash,
+		&lockedPeriod,
 	)
 	if err != nil {
 		return nil, err
 	}
+	betReceipt.UserID = userID.String
+	betReceipt.AccountHash = accountHash.String
 
-	if exchangeRate.Valid {
-		betReceipt.ExchangeRate = exchangeRate.Float64
+	if exchangeRate != nil {
+		betReceipt.ExchangeRate = *exchangeRate
 	} else {
-		betReceipt.ExchangeRate = 3550.0 // Giá trị mặc định
+		betReceipt.ExchangeRate = models.DefaultExchangeRate // Giá trị mặc định
 	}
 
 	if cancelReason.Valid {
 		betReceipt.CancelReason = cancelReason.String
 	}
 
-	if account.Valid {
-		betReceipt.Account = account.String
-	} else {
-		betReceipt.Account = ""
-	}
-
-	if password.Valid {
-		betReceipt.Password = password.String
-	} else {
-		betReceipt.Password = ""
+	setBetReceiptCredentials(betReceipt, account, password, accountMasked)
+	if feeScheduleVersion.Valid {
+		version := int(feeScheduleVersion.Int64)
+		betReceipt.FeeScheduleVersion = &version
 	}
 
 	if region.Valid {
@@ -333,6 +688,11 @@ func (r *BetReceiptRepository) FindByID(id string) (*models.BetReceipt, error) {
 		hours := int(timeRemainingHours.Int64)
 		betReceipt.TimeRemainingHours = &hours
 	}
+	setBetReceiptDeadline(betReceipt, deadlineAt, overdueFlaggedAt)
+	setBetReceiptDeleted(betReceipt, deletedAt, deletedBy)
+	if lockedPeriod.Valid {
+		betReceipt.LockedPeriod = &lockedPeriod.String
+	}
 
 	return betReceipt, nil
 }
@@ -380,8 +740,9 @@ func (r *BetReceiptRepository) UpdateStatus(betReceipt *models.BetReceipt) error
 			thoi_gian_hoan_thanh = $7,
 			thoi_gian_con_lai_gio = $8,
 			ly_do_huy = $9,
+			fee_schedule_version = $10,
 			thoi_gian_cap_nhat = NOW()
-		WHERE id = $10
+		WHERE id = $11 AND deleted_at IS NULL
 	`
 
 	var cancelReason interface{}
@@ -401,14 +762,15 @@ func (r *BetReceiptRepository) UpdateStatus(betReceipt *models.BetReceipt) error
 	_, err := r.db.Exec(
 		query,
 		betReceipt.Status,
-		exchangeRate,                 // exchange_rate
-		betReceipt.ActualAmountCNY,   // cong_thuc_nhan_te
-		betReceipt.ActualReceivedCNY, // tien_keo_web_thuc_nhan_te
-		betReceipt.CompensationCNY,   // tien_den_te
-		betReceipt.WebBetAmountCNY,   // tien_keo_web_te (có thể được cập nhật khi status = HỦY BỎ)
-		completedAt,                  // thoi_gian_hoan_thanh
-		timeRemainingHours,           // thoi_gian_con_lai_gio
-		cancelReason,                 // ly_do_huy
+		exchangeRate,                  // exchange_rate
+		betReceipt.ActualAmountCNY,    // cong_thuc_nhan_te
+		betReceipt.ActualReceivedCNY,  // tien_keo_web_thuc_nhan_te
+		betReceipt.CompensationCNY,    // tien_den_te
+		betReceipt.WebBetAmountCNY,    // tien_keo_web_te (có thể được cập nhật khi status = HỦY BỎ)
+		completedAt,                   // thoi_gian_hoan_thanh
+		timeRemainingHours,            // thoi_gian_con_lai_gio
+		cancelReason,                  // ly_do_huy
+		betReceipt.FeeScheduleVersion, // fee_schedule_version
 		betReceipt.ID,
 	)
 
@@ -421,7 +783,189 @@ func (r *BetReceiptRepository) UpdateStatus(betReceipt *models.BetReceipt) error
 	return nil
 }
 
+// UpdateFromSnapshot ghi đè các trường dữ liệu của đơn hàng (thông thường + status/tài chính) bằng giá trị trong betReceipt
+// Dùng khi hoàn tác về một phiên bản trong lịch sử; tài khoản/mật khẩu và STT không bị thay đổi
+func (r *BetReceiptRepository) UpdateFromSnapshot(betReceipt *models.BetReceipt) error {
+	var cancelReason interface{}
+	if betReceipt.CancelReason != "" {
+		cancelReason = betReceipt.CancelReason
+	}
+	var exchangeRate interface{}
+	if betReceipt.ExchangeRate > 0 {
+		exchangeRate = betReceipt.ExchangeRate
+	}
+
+	_, err := r.db.Exec(`
+		UPDATE thong_tin_nhan_keo
+		SET 
+			id_nguoi_dung = $1,
+			ma_nhiem_vu = $2,
+			loai_keo = $3,
+			tien_keo_web_te = $4,
+			ma_don_hang = $5,
+			ghi_chu = $6,
+			khu_vuc = $7,
+			thoi_gian_con_lai_gio = $8,
+			tien_do_hoan_thanh = $9,
+			ly_do_huy = $10,
+			tien_keo_web_thuc_nhan_te = $11,
+			tien_den_te = $12,
+			cong_thuc_nhan_te = $13,
+			exchange_rate = $14,
+			fee_schedule_version = $15,
+			thoi_gian_hoan_thanh = $16,
+			thoi_gian_bao_qua_han = CASE WHEN thoi_gian_con_lai_gio IS DISTINCT FROM $8 THEN NULL ELSE thoi_gian_bao_qua_han END,
+			thoi_gian_cap_nhat = NOW()
+		WHERE id = $17 AND deleted_at IS NULL
+	`,
+		nullUserID(betReceipt.UserID),
+		betReceipt.TaskCode,
+		betReceipt.BetType,
+		betReceipt.WebBetAmountCNY,
+		betReceipt.OrderCode,
+		betReceipt.Notes,
+		betReceipt.Region,
+		betReceipt.TimeRemainingHours,
+		betReceipt.Status,
+		cancelReason,
+		betReceipt.ActualReceivedCNY,
+		betReceipt.CompensationCNY,
+		betReceipt.ActualAmountCNY,
+		exchangeRate,
+		betReceipt.FeeScheduleVersion,
+		betReceipt.CompletedAt,
+		betReceipt.ID,
+	)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi hoàn tác đơn hàng: %v", err)
+		return err
+	}
+
+	log.Printf("Repository - ✅ Đã hoàn tác đơn hàng ID: %s", betReceipt.ID)
+	return nil
+}
+
+// LockByIDs khóa các đơn hàng (SELECT ... FOR UPDATE) trong transaction hiện tại
+// Trả về các ID tìm thấy (ID không tồn tại hoặc đã xóa sẽ không có trong kết quả)
+// Chỉ có tác dụng khi repository được tạo bằng WithTx
+func (r *BetReceiptRepository) LockByIDs(ids []string) (map[string]bool, error) {
+	rows, err := r.db.Query(`
+		SELECT id FROM thong_tin_nhan_keo
+		WHERE id = ANY($1) AND deleted_at IS NULL
+		ORDER BY id
+		FOR UPDATE
+	`, pq.Array(ids))
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi khóa đơn hàng: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[string]bool, len(ids))
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		found[id] = true
+	}
+	return found, rows.Err()
+}
+
+// IsPeriodClosed kiểm tra tháng month (YYYY-MM) đã khóa sổ chưa
+func (r *BetReceiptRepository) IsPeriodClosed(month string) (bool, error) {
+	var closed bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM closed_periods WHERE month = $1)`, month).Scan(&closed)
+	return closed, err
+}
+
+// FlagOverdue đánh dấu (thoi_gian_bao_qua_han = NOW()) tối đa limit đơn hàng đã quá deadline
+// mà vẫn ở status chịu deadline và chưa từng được đánh dấu, trả về ID các đơn vừa đánh dấu
+// Dùng FOR UPDATE SKIP LOCKED nên nhiều instance chạy cùng lúc không đánh dấu trùng
+// Nên gọi trong transaction (WithTx) để ghi lịch sử/thông báo cùng lúc với việc đánh dấu
+func (r *BetReceiptRepository) FlagOverdue(limit int) ([]string, error) {
+	rows, err := r.db.Query(`
+		UPDATE thong_tin_nhan_keo
+		SET thoi_gian_bao_qua_han = NOW()
+		WHERE id IN (
+			SELECT id FROM thong_tin_nhan_keo
+			WHERE thoi_gian_bao_qua_han IS NULL
+			  AND deleted_at IS NULL
+			  AND han_hoan_thanh < NOW()
+			  AND tien_do_hoan_thanh = ANY($1)
+			ORDER BY han_hoan_thanh
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id
+	`, pq.Array(models.BetReceiptDeadlineStatuses), limit)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi đánh dấu đơn hàng quá hạn: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// UpdateCredentials ghi tài khoản/mật khẩu đã mã hóa (AccountEncrypted, PasswordEncrypted),
+// tài khoản đã che (Account) và chỉ mục mù của tài khoản (AccountHash)
+// Không đổi thoi_gian_cap_nhat (mã hóa lại khi đổi key không phải là sửa đơn hàng)
+func (r *BetReceiptRepository) UpdateCredentials(betReceipt *models.BetReceipt) error {
+	_, err := r.db.Exec(`
+		UPDATE thong_tin_nhan_keo
+		SET tai_khoan = $1, mat_khau = $2, tai_khoan_che = $3, tai_khoan_hash = NULLIF($5, '')
+		WHERE id = $4
+	`, betReceipt.AccountEncrypted, betReceipt.PasswordEncrypted, betReceipt.Account, betReceipt.ID, betReceipt.AccountHash)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi cập nhật tài khoản/mật khẩu: %v", err)
+		return err
+	}
+	return nil
+}
+
+// LockCredentialsToRotate khóa và lấy tối đa limit đơn hàng có tài khoản/mật khẩu chưa mã hóa
+// hoặc mã hóa bằng master key khác activeKeyID (chỉ điền ID, AccountEncrypted, PasswordEncrypted, Account, AccountHash)
+// Dùng FOR UPDATE SKIP LOCKED, phải gọi trong transaction (WithTx)
+func (r *BetReceiptRepository) LockCredentialsToRotate(activeKeyID string, limit int) ([]*models.BetReceipt, error) {
+	activePattern := escapeLikePattern("enc:v1:"+activeKeyID+":") + "%"
+	rows, err := r.db.Query(`
+		SELECT id, COALESCE(tai_khoan, ''), COALESCE(mat_khau, ''), COALESCE(tai_khoan_che, ''), COALESCE(tai_khoan_hash, '')
+		FROM thong_tin_nhan_keo
+		WHERE (COALESCE(tai_khoan, '') <> '' AND tai_khoan NOT LIKE $1)
+		   OR (COALESCE(mat_khau, '') <> '' AND mat_khau NOT LIKE $1)
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, activePattern, limit)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lấy đơn hàng cần mã hóa lại: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	betReceipts := []*models.BetReceipt{}
+	for rows.Next() {
+		b := &models.BetReceipt{}
+		if err := rows.Scan(&b.ID, &b.AccountEncrypted, &b.PasswordEncrypted, &b.Account, &b.AccountHash); err != nil {
+			return nil, err
+		}
+		betReceipts = append(betReceipts, b)
+	}
+	return betReceipts, rows.Err()
+}
+
 // Update cập nhật các trường thông thường của đơn hàng (không phải status)
+// Tài khoản/mật khẩu không được cập nhật ở đây (service mã hóa rồi gọi UpdateCredentials)
+// Người nhận kèo không được cập nhật ở đây (đổi qua Assign để ghi lịch sử nhận đơn và tính lại wallet)
 func (r *BetReceiptRepository) Update(id string, req *models.UpdateBetReceiptRequest) error {
 	// Lấy thông tin đơn hàng hiện tại
 	betReceipt, err := r.FindByID(id)
@@ -430,19 +974,6 @@ func (r *BetReceiptRepository) Update(id string, req *models.UpdateBetReceiptReq
 	}
 
 	// Cập nhật các trường nếu được cung cấp
-	if req.UserName != nil {
-		// Tìm user theo tên (tìm chính xác)
-		userRepo := NewUserRepository(r.db)
-		users, err := userRepo.FindByName(*req.UserName)
-		if err != nil {
-			return fmt.Errorf("lỗi khi tìm user: %w", err)
-		}
-		if len(users) == 0 {
-			return fmt.Errorf("không tìm thấy người dùng với tên: %s", *req.UserName)
-		}
-		// Lấy user đầu tiên (FindByName tìm chính xác nên chỉ có 1 kết quả)
-		betReceipt.UserID = users[0].ID
-	}
 	if req.TaskCode != nil {
 		betReceipt.TaskCode = *req.TaskCode
 	}
@@ -458,12 +989,6 @@ func (r *BetReceiptRepository) Update(id string, req *models.UpdateBetReceiptReq
 	if req.Notes != nil {
 		betReceipt.Notes = *req.Notes
 	}
-	if req.Account != nil {
-		betReceipt.Account = *req.Account
-	}
-	if req.Password != nil {
-		betReceipt.Password = *req.Password
-	}
 	if req.Region != nil {
 		betReceipt.Region = *req.Region
 	}
@@ -476,30 +1001,25 @@ func (r *BetReceiptRepository) Update(id string, req *models.UpdateBetReceiptReq
 	query := `
 		UPDATE thong_tin_nhan_keo
 		SET 
-			id_nguoi_dung = $1,
-			ma_nhiem_vu = $2,
-			loai_keo = $3,
-			tien_keo_web_te = $4,
-			ma_don_hang = $5,
-			ghi_chu = $6,
-			tai_khoan = $7,
-			mat_khau = $8,
-			khu_vuc = $9,
-			thoi_gian_con_lai_gio = $10,
+			ma_nhiem_vu = $1,
+			loai_keo = $2,
+			tien_keo_web_te = $3,
+			ma_don_hang = $4,
+			ghi_chu = $5,
+			khu_vuc = $6,
+			thoi_gian_con_lai_gio = $7,
+			thoi_gian_bao_qua_han = CASE WHEN thoi_gian_con_lai_gio IS DISTINCT FROM $7 THEN NULL ELSE thoi_gian_bao_qua_han END,
 			thoi_gian_cap_nhat = NOW()
-		WHERE id = $11
+		WHERE id = $8 AND deleted_at IS NULL
 	`
 
 	_, err = r.db.Exec(
 		query,
-		betReceipt.UserID,
 		betReceipt.TaskCode,
 		betReceipt.BetType,
 		betReceipt.WebBetAmountCNY,
 		betReceipt.OrderCode,
 		betReceipt.Notes,
-		betReceipt.Account,
-		betReceipt.Password,
 		betReceipt.Region,
 		betReceipt.TimeRemainingHours,
 		id,
@@ -514,33 +1034,105 @@ func (r *BetReceiptRepository) Update(id string, req *models.UpdateBetReceiptReq
 	return nil
 }
 
-// Delete xóa đơn hàng theo ID
-func (r *BetReceiptRepository) Delete(id string) error {
-	// Kiểm tra xem đơn hàng có tồn tại không
-	_, err := r.FindByID(id)
+// SoftDelete xóa mềm đơn hàng (chuyển vào thùng rác), ghi thời gian và người xóa
+// Trả về false nếu đơn hàng không tồn tại hoặc đã bị xóa trước đó
+func (r *BetReceiptRepository) SoftDelete(id string, deletedBy *string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE thong_tin_nhan_keo
+		SET deleted_at = NOW(), deleted_by = $2
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id, deletedBy)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return fmt.Errorf("không tìm thấy đơn hàng với ID: %s", id)
-		}
-		return err
+		log.Printf("Repository - ❌ Lỗi xóa đơn hàng: %v", err)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
 	}
 
-	query := `DELETE FROM thong_tin_nhan_keo WHERE id = $1`
-	_, err = r.db.Exec(query, id)
+	log.Printf("Repository - ✅ Đã xóa (mềm) đơn hàng ID: %s", id)
+	return affected > 0, nil
+}
+
+// Restore khôi phục đơn hàng đã xóa mềm (đưa ra khỏi thùng rác)
+// Trả về false nếu đơn hàng không tồn tại hoặc chưa bị xóa
+func (r *BetReceiptRepository) Restore(id string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE thong_tin_nhan_keo
+		SET deleted_at = NULL, deleted_by = NULL, thoi_gian_cap_nhat = NOW()
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`, id)
 	if err != nil {
-		log.Printf("Repository - ❌ Lỗi xóa đơn hàng: %v", err)
-		return err
+		log.Printf("Repository - ❌ Lỗi khôi phục đơn hàng: %v", err)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
 	}
 
-	log.Printf("Repository - ✅ Đã xóa đơn hàng thành công cho ID: %s", id)
+	log.Printf("Repository - ✅ Đã khôi phục đơn hàng ID: %s", id)
+	return affected > 0, nil
+}
+
+// Claim gán người nhận kèo cho đơn hàng trong pool (chưa có người nhận, còn "Đơn hàng mới", chưa xóa)
+// Cập nhật có điều kiện nên ai nhận trước được trước: trả về false nếu đơn hàng không còn trong pool
+func (r *BetReceiptRepository) Claim(id, userID string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE thong_tin_nhan_keo
+		SET id_nguoi_dung = $2, thoi_gian_cap_nhat = NOW()
+		WHERE id = $1 AND id_nguoi_dung IS NULL AND tien_do_hoan_thanh = $3 AND deleted_at IS NULL
+	`, id, userID, models.BetReceiptStatusNew)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi nhận đơn hàng: %v", err)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// Assign đổi người nhận kèo của đơn hàng ("" = trả về pool, chỉ hợp lệ khi còn "Đơn hàng mới")
+func (r *BetReceiptRepository) Assign(id, userID string) error {
+	_, err := r.db.Exec(`
+		UPDATE thong_tin_nhan_keo
+		SET id_nguoi_dung = $2, thoi_gian_cap_nhat = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id, nullUserID(userID))
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi giao đơn hàng: %v", err)
+		return err
+	}
 	return nil
 }
 
+// LockClaimsOfUser khóa (advisory lock đến hết transaction) việc nhận đơn của userID
+// để đếm số đơn đang mở và nhận đơn mới không bị vượt giới hạn khi nhận đồng thời
+func (r *BetReceiptRepository) LockClaimsOfUser(userID string) error {
+	_, err := r.db.Exec(`SELECT pg_advisory_xact_lock(hashtext('bet_receipt_claim:' || $1))`, userID)
+	return err
+}
+
+// CountOpenByUser đếm đơn hàng đang mở (chưa xử lý xong: không phải DONE, HỦY BỎ, ĐỀN) của người nhận kèo
+func (r *BetReceiptRepository) CountOpenByUser(userID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*)
+		FROM thong_tin_nhan_keo
+		WHERE id_nguoi_dung = $1 AND deleted_at IS NULL
+		  AND tien_do_hoan_thanh NOT IN ($2, $3, $4)
+	`, userID, models.BetReceiptStatusDone, models.BetReceiptStatusCancelled, models.BetReceiptStatusCompensation).Scan(&count)
+	return count, err
+}
+
 // TopUserMonthlyResult - Kết quả top user theo tháng
 type TopUserMonthlyResult struct {
 	UserID    string
 	UserName  string
-	AmountCNY float64
+	AmountCNY money.CNY
 	AvatarURL *string
 }
 
@@ -556,7 +1148,8 @@ func (r *BetReceiptRepository) GetTop5UsersByMonthlyReceivedAmount(month string)
 		FROM thong_tin_nhan_keo ttnk
 		LEFT JOIN nguoi_dung nd ON ttnk.id_nguoi_dung = nd.id
 		WHERE 
-			ttnk.tien_do_hoan_thanh IN ('DONE', 'HỦY BỎ', 'ĐỀN')
+			ttnk.deleted_at IS NULL
+			AND ttnk.tien_do_hoan_thanh IN ('DONE', 'HỦY BỎ', 'ĐỀN')
 			AND ttnk.thoi_gian_hoan_thanh IS NOT NULL
 			AND TO_CHAR(ttnk.thoi_gian_hoan_thanh, 'YYYY-MM') = $1
 		GROUP BY ttnk.id_nguoi_dung
@@ -603,7 +1196,7 @@ func (r *BetReceiptRepository) GetTop5UsersByMonthlyReceivedAmount(month string)
 // GetMonthlyTotalByUserID tính tổng số tiền đã nhận (actual_amount_cny) theo tháng cho user cụ thể
 // month: format "YYYY-MM" (ví dụ: "2026-01"), nếu rỗng thì tính tất cả
 // userID: ID của user cần tính
-func (r *BetReceiptRepository) GetMonthlyTotalByUserID(userID string, month string) (float64, error) {
+func (r *BetReceiptRepository) GetMonthlyTotalByUserID(userID string, month string) (money.CNY, error) {
 	var query string
 	var args []interface{}
 
@@ -613,6 +1206,7 @@ func (r *BetReceiptRepository) GetMonthlyTotalByUserID(userID string, month stri
 			SELECT COALESCE(SUM(cong_thuc_nhan_te), 0) as total_amount_cny
 			FROM thong_tin_nhan_keo
 			WHERE id_nguoi_dung = $1
+				AND deleted_at IS NULL
 				AND tien_do_hoan_thanh IN ('DONE', 'HỦY BỎ', 'ĐỀN')
 				AND thoi_gian_hoan_thanh IS NOT NULL
 				AND TO_CHAR(thoi_gian_hoan_thanh, 'YYYY-MM') = $2
@@ -624,19 +1218,20 @@ func (r *BetReceiptRepository) GetMonthlyTotalByUserID(userID string, month stri
 			SELECT COALESCE(SUM(cong_thuc_nhan_te), 0) as total_amount_cny
 			FROM thong_tin_nhan_keo
 			WHERE id_nguoi_dung = $1
+				AND deleted_at IS NULL
 				AND tien_do_hoan_thanh IN ('DONE', 'HỦY BỎ', 'ĐỀN')
 				AND thoi_gian_hoan_thanh IS NOT NULL
 		`
 		args = []interface{}{userID}
 	}
 
-	var totalAmountCNY float64
+	var totalAmountCNY money.CNY
 	err := r.db.QueryRow(query, args...).Scan(&totalAmountCNY)
 	if err != nil {
 		log.Printf("Repository - ❌ Lỗi khi tính tổng theo tháng cho user %s, tháng %s: %v", userID, month, err)
 		return 0, err
 	}
 
-	log.Printf("Repository - ✅ Đã tính tổng cho user %s, tháng %s: %.2f ¥", userID, month, totalAmountCNY)
+	log.Printf("Repository - ✅ Đã tính tổng cho user %s, tháng %s: %s ¥", userID, month, totalAmountCNY)
 	return totalAmountCNY, nil
 }
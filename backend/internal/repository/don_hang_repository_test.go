@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"fullstack-backend/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestBuildBetReceiptWhereDeleted(t *testing.T) {
+	userID := "user-1"
+	tests := []struct {
+		name     string
+		filter   models.BetReceiptFilter
+		want     string
+		wantNot  string
+		wantArgs int
+	}{
+		{
+			name:    "danh sách thường bỏ qua đơn đã xóa",
+			filter:  models.BetReceiptFilter{},
+			want:    "ttnk.deleted_at IS NULL",
+			wantNot: "ttnk.deleted_at IS NOT NULL",
+		},
+		{
+			name:    "thùng rác chỉ lấy đơn đã xóa",
+			filter:  models.BetReceiptFilter{Deleted: true},
+			want:    "ttnk.deleted_at IS NOT NULL",
+			wantNot: "ttnk.deleted_at IS NULL",
+		},
+		{
+			name:     "lọc theo người dùng vẫn bỏ qua đơn đã xóa",
+			filter:   models.BetReceiptFilter{UserID: &userID},
+			want:     "ttnk.deleted_at IS NULL AND ttnk.id_nguoi_dung = $1",
+			wantArgs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args, next := buildBetReceiptWhere(&tt.filter)
+			if !strings.Contains(where, tt.want) {
+				t.Errorf("buildBetReceiptWhere() = %q, muốn chứa %q", where, tt.want)
+			}
+			if tt.wantNot != "" && strings.Contains(where, tt.wantNot) {
+				t.Errorf("buildBetReceiptWhere() = %q, không được chứa %q", where, tt.wantNot)
+			}
+			if len(args) != tt.wantArgs || next != tt.wantArgs+1 {
+				t.Errorf("buildBetReceiptWhere() args = %v, chỉ số tiếp theo = %d, muốn %d args", args, next, tt.wantArgs)
+			}
+		})
+	}
+}
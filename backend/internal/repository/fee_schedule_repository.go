@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fullstack-backend/internal/models"
+	"log"
+	"time"
+)
+
+type FeeScheduleRepository struct {
+	db DBTX
+}
+
+func NewFeeScheduleRepository(db *sql.DB) *FeeScheduleRepository {
+	return &FeeScheduleRepository{db: db}
+}
+
+// feeScheduleColumns - Các cột đọc biểu phí (in_use: đã có đơn hàng tính theo biểu phí này)
+const feeScheduleColumns = `
+	fs.version, fs.name, fs.effective_from, fs.web_fee_brackets,
+	fs.web_withdrawal_fee_rate, fs.external_withdrawal_fee_rate, fs.intermediary_fee_rate,
+	fs.created_by, fs.created_at, fs.updated_at,
+	EXISTS (SELECT 1 FROM thong_tin_nhan_keo ttnk WHERE ttnk.fee_schedule_version = fs.version) AS in_use
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanFeeSchedule(row rowScanner) (*models.FeeSchedule, error) {
+	schedule := &models.FeeSchedule{}
+	var brackets []byte
+	var createdBy sql.NullString
+	err := row.Scan(
+		&schedule.Version,
+		&schedule.Name,
+		&schedule.EffectiveFrom,
+		&brackets,
+		&schedule.WebWithdrawalFeeRate,
+		&schedule.ExternalWithdrawalFeeRate,
+		&schedule.IntermediaryFeeRate,
+		&createdBy,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+		&schedule.InUse,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(brackets, &schedule.WebFeeBrackets); err != nil {
+		return nil, err
+	}
+	if createdBy.Valid {
+		schedule.CreatedBy = &createdBy.String
+	}
+	return schedule, nil
+}
+
+// GetAll lấy tất cả biểu phí (hiệu lực mới nhất trước)
+func (r *FeeScheduleRepository) GetAll() ([]*models.FeeSchedule, error) {
+	rows, err := r.db.Query(`
+		SELECT ` + feeScheduleColumns + `
+		FROM fee_schedules fs
+		ORDER BY fs.effective_from DESC, fs.version DESC
+	`)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lấy danh sách biểu phí: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []*models.FeeSchedule{}
+	for rows.Next() {
+		schedule, err := scanFeeSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+// FindByVersion tìm biểu phí theo version (sql.ErrNoRows nếu không có)
+func (r *FeeScheduleRepository) FindByVersion(version int) (*models.FeeSchedule, error) {
+	row := r.db.QueryRow(`
+		SELECT `+feeScheduleColumns+`
+		FROM fee_schedules fs
+		WHERE fs.version = $1
+	`, version)
+	return scanFeeSchedule(row)
+}
+
+// FindEffective tìm biểu phí có hiệu lực tại thời điểm at
+// (effective_from lớn nhất mà <= at; cùng thời điểm thì version mới hơn được ưu tiên)
+func (r *FeeScheduleRepository) FindEffective(at time.Time) (*models.FeeSchedule, error) {
+	row := r.db.QueryRow(`
+		SELECT `+feeScheduleColumns+`
+		FROM fee_schedules fs
+		WHERE fs.effective_from <= $1
+		ORDER BY fs.effective_from DESC, fs.version DESC
+		LIMIT 1
+	`, at)
+	return scanFeeSchedule(row)
+}
+
+// Create tạo biểu phí mới (version tự tăng)
+func (r *FeeScheduleRepository) Create(schedule *models.FeeSchedule) error {
+	brackets, err := json.Marshal(schedule.WebFeeBrackets)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.QueryRow(`
+		INSERT INTO fee_schedules (
+			name, effective_from, web_fee_brackets,
+			web_withdrawal_fee_rate, external_withdrawal_fee_rate, intermediary_fee_rate, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING version, created_at, updated_at
+	`,
+		schedule.Name,
+		schedule.EffectiveFrom,
+		brackets,
+		schedule.WebWithdrawalFeeRate,
+		schedule.ExternalWithdrawalFeeRate,
+		schedule.IntermediaryFeeRate,
+		schedule.CreatedBy,
+	).Scan(&schedule.Version, &schedule.CreatedAt, &schedule.UpdatedAt)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi tạo biểu phí: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Update sửa biểu phí chưa được đơn hàng nào sử dụng
+// Trả về false nếu không có dòng nào được cập nhật (không tồn tại hoặc đã được sử dụng)
+func (r *FeeScheduleRepository) Update(schedule *models.FeeSchedule) (bool, error) {
+	brackets, err := json.Marshal(schedule.WebFeeBrackets)
+	if err != nil {
+		return false, err
+	}
+
+	err = r.db.QueryRow(`
+		UPDATE fee_schedules
+		SET name = $1, effective_from = $2, web_fee_brackets = $3,
+			web_withdrawal_fee_rate = $4, external_withdrawal_fee_rate = $5, intermediary_fee_rate = $6,
+			updated_at = NOW()
+		WHERE version = $7
+		  AND NOT EXISTS (SELECT 1 FROM thong_tin_nhan_keo WHERE fee_schedule_version = $7)
+		RETURNING updated_at
+	`,
+		schedule.Name,
+		schedule.EffectiveFrom,
+		brackets,
+		schedule.WebWithdrawalFeeRate,
+		schedule.ExternalWithdrawalFeeRate,
+		schedule.IntermediaryFeeRate,
+		schedule.Version,
+	).Scan(&schedule.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi cập nhật biểu phí: %v", err)
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete xóa biểu phí chưa được đơn hàng nào sử dụng
+// Trả về false nếu không có dòng nào bị xóa (không tồn tại hoặc đã được sử dụng)
+func (r *FeeScheduleRepository) Delete(version int) (bool, error) {
+	result, err := r.db.Exec(`
+		DELETE FROM fee_schedules
+		WHERE version = $1
+		  AND NOT EXISTS (SELECT 1 FROM thong_tin_nhan_keo WHERE fee_schedule_version = $1)
+	`, version)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi xóa biểu phí: %v", err)
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"database/sql"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/pkg/pagination"
+	"log"
+)
+
+type NotificationRepository struct {
+	db DBTX
+}
+
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// WithTx trả về repository chạy các query trong transaction tx
+func (r *NotificationRepository) WithTx(tx *sql.Tx) *NotificationRepository {
+	return &NotificationRepository{db: tx}
+}
+
+// Create tạo thông báo mới
+func (r *NotificationRepository) Create(notification *models.Notification) error {
+	query := `
+		INSERT INTO thong_bao (id_nguoi_dung, loai, tieu_de, noi_dung, id_don_hang)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, da_doc, thoi_gian_tao
+	`
+
+	err := r.db.QueryRow(
+		query,
+		notification.UserID,
+		notification.Type,
+		notification.Title,
+		notification.Content,
+		notification.BetReceiptID,
+	).Scan(&notification.ID, &notification.Read, &notification.CreatedAt)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi tạo thông báo: %v", err)
+		return err
+	}
+	return nil
+}
+
+// NotificationCursorColumns - Các cột keyset của danh sách thông báo (sắp xếp giảm dần)
+var NotificationCursorColumns = []string{"thoi_gian_tao", "id"}
+
+// GetByUserID lấy thông báo của user, mới nhất trước
+// unreadOnly = true: chỉ lấy thông báo chưa đọc
+// Kết quả có thể dư 1 dòng (FetchLimit) - dùng pagination.Paginate để cắt
+func (r *NotificationRepository) GetByUserID(userID string, unreadOnly bool, page pagination.Request) ([]*models.Notification, error) {
+	query := `
+		SELECT id, id_nguoi_dung, loai, tieu_de, noi_dung, id_don_hang, da_doc, thoi_gian_tao
+		FROM thong_bao
+		WHERE id_nguoi_dung = $1`
+	if unreadOnly {
+		query += " AND da_doc = FALSE"
+	}
+	clause, args := page.SQL(NotificationCursorColumns, true, true, 2)
+	query += clause
+
+	rows, err := r.db.Query(query, append([]interface{}{userID}, args...)...)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lấy danh sách thông báo: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*models.Notification{}
+	for rows.Next() {
+		n := &models.Notification{}
+		var content sql.NullString
+		var betReceiptID sql.NullString
+		err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.Title, &content, &betReceiptID, &n.Read, &n.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		n.Content = content.String
+		if betReceiptID.Valid {
+			n.BetReceiptID = &betReceiptID.String
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+// CountUnread đếm số thông báo chưa đọc của user
+func (r *NotificationRepository) CountUnread(userID string) (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM thong_bao WHERE id_nguoi_dung = $1 AND da_doc = FALSE", userID).Scan(&count)
+	return count, err
+}
+
+// MarkRead đánh dấu đã đọc một thông báo của user
+// Trả về false nếu thông báo không tồn tại hoặc không thuộc về user
+func (r *NotificationRepository) MarkRead(id, userID string) (bool, error) {
+	result, err := r.db.Exec("UPDATE thong_bao SET da_doc = TRUE WHERE id = $1 AND id_nguoi_dung = $2", id, userID)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi đánh dấu đã đọc thông báo: %v", err)
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// MarkAllRead đánh dấu đã đọc tất cả thông báo của user, trả về số thông báo được cập nhật
+func (r *NotificationRepository) MarkAllRead(userID string) (int64, error) {
+	result, err := r.db.Exec("UPDATE thong_bao SET da_doc = TRUE WHERE id_nguoi_dung = $1 AND da_doc = FALSE", userID)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi đánh dấu đã đọc tất cả thông báo: %v", err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"database/sql"
+	"fullstack-backend/internal/models"
+	"log"
+)
+
+// PeriodRepository - Khóa sổ theo tháng (closed_periods, wallet_period_snapshots, cột locked_period)
+type PeriodRepository struct {
+	db   DBTX
+	conn *sql.DB // Connection gốc (để mở transaction)
+}
+
+func NewPeriodRepository(db *sql.DB) *PeriodRepository {
+	return &PeriodRepository{db: db, conn: db}
+}
+
+// GetDB trả về connection gốc (để mở transaction)
+func (r *PeriodRepository) GetDB() *sql.DB {
+	return r.conn
+}
+
+// WithTx trả về repository chạy trong transaction tx
+func (r *PeriodRepository) WithTx(tx *sql.Tx) *PeriodRepository {
+	return &PeriodRepository{db: tx, conn: r.conn}
+}
+
+// LockClosing khóa (advisory lock đến hết transaction) việc khóa sổ để hai lần khóa sổ không chạy đồng thời
+func (r *PeriodRepository) LockClosing() error {
+	_, err := r.db.Exec(`SELECT pg_advisory_xact_lock(hashtext('period_close'))`)
+	return err
+}
+
+// IsClosed kiểm tra tháng month (YYYY-MM) đã khóa sổ chưa
+func (r *PeriodRepository) IsClosed(month string) (bool, error) {
+	var closed bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM closed_periods WHERE month = $1)`, month).Scan(&closed)
+	return closed, err
+}
+
+// FindUnfinishedReceipts lấy mã STT các đơn hàng (chưa xóa) có thời gian hoàn thành trong tháng month nhưng chưa hoàn tất
+// (CHỜ CHẤP NHẬN, CHỜ TRỌNG TÀI - các status này cũng ghi thời gian hoàn thành)
+func (r *PeriodRepository) FindUnfinishedReceipts(month string) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT ma_stt FROM thong_tin_nhan_keo
+		WHERE locked_period IS NULL AND deleted_at IS NULL AND TO_CHAR(thoi_gian_hoan_thanh, 'YYYY-MM') = $1
+			AND tien_do_hoan_thanh NOT IN ('DONE', 'HỦY BỎ', 'ĐỀN')
+		ORDER BY thoi_gian_hoan_thanh, ma_stt
+	`, month)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lấy đơn hàng chưa hoàn tất tháng %s: %v", month, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	codes := []string{}
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, rows.Err()
+}
+
+// Close khóa sổ tháng month (chạy trong transaction, xem WithTx):
+// 1. Khóa đơn hàng (chưa xóa) đã hoàn tất (DONE, HỦY BỎ, ĐỀN) trong tháng, nộp cọc và rút tiền tạo trong tháng
+// 2. Ghi closed_periods (từ đây trigger chặn ghi đơn hàng hoàn thành vào tháng này)
+// 3. Chụp số dư mọi ví từ sổ cái: đầu kỳ = bút toán trước tháng, phát sinh theo loại, cuối kỳ = bút toán đến hết tháng
+// Bút toán được tính vào tháng theo cùng mốc thời gian dùng để khóa (xem ledgerEntriesByPeriod)
+func (r *PeriodRepository) Close(month, note string, closedBy *string) error {
+	lockedReceipts, err := r.lockRows(`
+		UPDATE thong_tin_nhan_keo SET locked_period = $1
+		WHERE locked_period IS NULL AND deleted_at IS NULL AND TO_CHAR(thoi_gian_hoan_thanh, 'YYYY-MM') = $1
+			AND tien_do_hoan_thanh IN ('DONE', 'HỦY BỎ', 'ĐỀN')
+	`, month)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi khóa đơn hàng tháng %s: %v", month, err)
+		return err
+	}
+	lockedDeposits, err := r.lockRows(`
+		UPDATE lich_su_nop_tien SET locked_period = $1
+		WHERE locked_period IS NULL AND TO_CHAR(thoi_gian_tao, 'YYYY-MM') = $1
+	`, month)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi khóa nộp cọc tháng %s: %v", month, err)
+		return err
+	}
+	lockedWithdrawals, err := r.lockRows(`
+		UPDATE lich_su_rut_tien SET locked_period = $1
+		WHERE locked_period IS NULL AND TO_CHAR(thoi_gian_tao, 'YYYY-MM') = $1
+	`, month)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi khóa rút tiền tháng %s: %v", month, err)
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO closed_periods (month, note, locked_receipts, locked_deposits, locked_withdrawals, closed_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, month, note, lockedReceipts, lockedDeposits, lockedWithdrawals, closedBy)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi ghi kỳ khóa sổ %s: %v", month, err)
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO wallet_period_snapshots (
+			month, id_nguoi_dung, opening_balance_vnd, received_cny, received_vnd,
+			compensation_cny, compensation_vnd, deposit_vnd, withdrawn_cny, withdrawn_vnd,
+			adjustment_vnd, closing_balance_vnd
+		)
+		SELECT
+			$1, nd.id,
+			COALESCE(SUM(e.amount_vnd) FILTER (WHERE e.period_at < p.start_at), 0),
+			COALESCE(SUM(e.amount_cny) FILTER (WHERE e.period_at >= p.start_at AND e.entry_type IN ('receipt_settlement', 'revaluation')), 0),
+			COALESCE(SUM(e.amount_vnd) FILTER (WHERE e.period_at >= p.start_at AND e.entry_type IN ('receipt_settlement', 'revaluation')), 0),
+			COALESCE(SUM(e.amount_cny) FILTER (WHERE e.period_at >= p.start_at AND e.entry_type = 'compensation'), 0),
+			COALESCE(SUM(e.amount_vnd) FILTER (WHERE e.period_at >= p.start_at AND e.entry_type = 'compensation'), 0),
+			COALESCE(SUM(e.amount_vnd) FILTER (WHERE e.period_at >= p.start_at AND e.entry_type = 'deposit'), 0),
+			COALESCE(SUM(e.amount_cny) FILTER (WHERE e.period_at >= p.start_at AND e.entry_type = 'withdrawal'), 0),
+			COALESCE(SUM(e.amount_vnd) FILTER (WHERE e.period_at >= p.start_at AND e.entry_type = 'withdrawal'), 0),
+			COALESCE(SUM(e.amount_vnd) FILTER (WHERE e.period_at >= p.start_at AND e.entry_type = 'adjustment'), 0),
+			COALESCE(SUM(e.amount_vnd), 0)
+		FROM nguoi_dung nd
+		CROSS JOIN (SELECT ($1 || '-01')::timestamp AS start_at) p
+		LEFT JOIN (`+ledgerEntriesByPeriod+`) e
+			ON e.id_nguoi_dung = nd.id AND e.period_at < p.start_at + INTERVAL '1 month'
+		WHERE nd.vai_tro = 'user' OR e.id IS NOT NULL
+		GROUP BY nd.id, p.start_at
+	`, month)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi chụp số dư ví tháng %s: %v", month, err)
+		return err
+	}
+
+	log.Printf("Repository - 🔒 Đã khóa sổ tháng %s: %d đơn hàng, %d nộp cọc, %d rút tiền",
+		month, lockedReceipts, lockedDeposits, lockedWithdrawals)
+	return nil
+}
+
+// ledgerEntriesByPeriod - Bút toán sổ cái kèm mốc thời gian tính kỳ (period_at) trùng với mốc khóa sổ của nguồn:
+// thời gian hoàn thành của đơn hàng, thời gian tạo của nộp cọc / rút tiền, thời gian ghi sổ nếu không còn nguồn
+// (điều chỉnh thủ công, nộp cọc / rút tiền đã xóa, đơn hàng đã quay về status chưa hoàn thành)
+const ledgerEntriesByPeriod = `
+	SELECT e.*, COALESCE(t.thoi_gian_hoan_thanh, d.thoi_gian_tao, w.thoi_gian_tao, e.created_at) AS period_at
+	FROM wallet_ledger_entries e
+	LEFT JOIN thong_tin_nhan_keo t ON e.source_type = 'bet_receipt' AND t.id = e.source_id
+	LEFT JOIN lich_su_nop_tien d ON e.source_type = 'deposit' AND d.id = e.source_id
+	LEFT JOIN lich_su_rut_tien w ON e.source_type = 'withdrawal' AND w.id = e.source_id
+`
+
+// lockRows chạy câu lệnh khóa và trả về số dòng bị khóa
+func (r *PeriodRepository) lockRows(query, month string) (int, error) {
+	result, err := r.db.Exec(query, month)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// closedPeriodColumns - Các cột đọc kỳ khóa sổ (kèm tên người khóa và số ví đã chụp số dư)
+const closedPeriodColumns = `
+	cp.month, cp.note, cp.locked_receipts, cp.locked_deposits, cp.locked_withdrawals,
+	(SELECT COUNT(*) FROM wallet_period_snapshots s WHERE s.month = cp.month) AS wallet_count,
+	cp.closed_by, nd.ten, cp.closed_at
+`
+
+func scanClosedPeriod(row rowScanner) (*models.ClosedPeriod, error) {
+	period := &models.ClosedPeriod{}
+	var closedBy, closedByName sql.NullString
+	err := row.Scan(
+		&period.Month,
+		&period.Note,
+		&period.LockedReceipts,
+		&period.LockedDeposits,
+		&period.LockedWithdrawals,
+		&period.WalletCount,
+		&closedBy,
+		&closedByName,
+		&period.ClosedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	period.ClosedBy = nullStringPtr(closedBy)
+	period.ClosedByName = closedByName.String
+	return period, nil
+}
+
+// GetAll lấy các tháng đã khóa sổ, mới nhất trước
+func (r *PeriodRepository) GetAll() ([]*models.ClosedPeriod, error) {
+	rows, err := r.db.Query(`
+		SELECT ` + closedPeriodColumns + `
+		FROM closed_periods cp
+		LEFT JOIN nguoi_dung nd ON nd.id = cp.closed_by
+		ORDER BY cp.month DESC
+	`)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lấy danh sách kỳ khóa sổ: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	periods := []*models.ClosedPeriod{}
+	for rows.Next() {
+		period, err := scanClosedPeriod(rows)
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, period)
+	}
+	return periods, rows.Err()
+}
+
+// FindByMonth tìm kỳ khóa sổ theo tháng (sql.ErrNoRows nếu tháng chưa khóa sổ)
+func (r *PeriodRepository) FindByMonth(month string) (*models.ClosedPeriod, error) {
+	row := r.db.QueryRow(`
+		SELECT `+closedPeriodColumns+`
+		FROM closed_periods cp
+		LEFT JOIN nguoi_dung nd ON nd.id = cp.closed_by
+		WHERE cp.month = $1
+	`, month)
+	return scanClosedPeriod(row)
+}
+
+// GetSnapshots lấy số dư ví đã chụp khi khóa sổ tháng month, theo tên người dùng
+func (r *PeriodRepository) GetSnapshots(month string) ([]*models.WalletPeriodSnapshot, error) {
+	rows, err := r.db.Query(`
+		SELECT
+			s.month, s.id_nguoi_dung, nd.ten, s.opening_balance_vnd, s.received_cny, s.received_vnd,
+			s.compensation_cny, s.compensation_vnd, s.deposit_vnd, s.withdrawn_cny, s.withdrawn_vnd,
+			s.adjustment_vnd, s.closing_balance_vnd, s.created_at
+		FROM wallet_period_snapshots s
+		JOIN nguoi_dung nd ON nd.id = s.id_nguoi_dung
+		WHERE s.month = $1
+		ORDER BY nd.ten, s.id_nguoi_dung
+	`, month)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lấy số dư ví khóa sổ tháng %s: %v", month, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []*models.WalletPeriodSnapshot{}
+	for rows.Next() {
+		snapshot := &models.WalletPeriodSnapshot{}
+		err := rows.Scan(
+			&snapshot.Month,
+			&snapshot.UserID,
+			&snapshot.UserName,
+			&snapshot.OpeningBalanceVND,
+			&snapshot.ReceivedCNY,
+			&snapshot.ReceivedVND,
+			&snapshot.CompensationCNY,
+			&snapshot.CompensationVND,
+			&snapshot.DepositVND,
+			&snapshot.WithdrawnCNY,
+			&snapshot.WithdrawnVND,
+			&snapshot.AdjustmentVND,
+			&snapshot.ClosingBalanceVND,
+			&snapshot.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, rows.Err()
+}
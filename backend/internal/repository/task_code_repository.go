@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"database/sql"
+	"fullstack-backend/internal/models"
+	"log"
+	"time"
+)
+
+type TaskCodeRepository struct {
+	db DBTX
+}
+
+func NewTaskCodeRepository(db *sql.DB) *TaskCodeRepository {
+	return &TaskCodeRepository{db: db}
+}
+
+// taskCodeColumns - Các cột đọc mã nhiệm vụ (bet_receipt_count: số đơn hàng chưa xóa có mã này)
+const taskCodeColumns = `
+	tc.id, tc.code, tc.description, tc.default_bet_type, tc.default_web_bet_amount_cny, tc.default_completed_hours,
+	tc.active, tc.created_by, tc.created_at, tc.updated_at,
+	(SELECT COUNT(*) FROM thong_tin_nhan_keo ttnk
+	 WHERE ttnk.deleted_at IS NULL AND LOWER(TRIM(ttnk.ma_nhiem_vu)) = LOWER(TRIM(tc.code))) AS bet_receipt_count
+`
+
+func scanTaskCode(row rowScanner) (*models.TaskCode, error) {
+	taskCode := &models.TaskCode{}
+	var betType, createdBy sql.NullString
+	var hours sql.NullInt64
+	err := row.Scan(
+		&taskCode.ID,
+		&taskCode.Code,
+		&taskCode.Description,
+		&betType,
+		&taskCode.DefaultWebBetAmountCNY,
+		&hours,
+		&taskCode.Active,
+		&createdBy,
+		&taskCode.CreatedAt,
+		&taskCode.UpdatedAt,
+		&taskCode.BetReceiptCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	taskCode.DefaultBetType = nullStringPtr(betType)
+	taskCode.CreatedBy = nullStringPtr(createdBy)
+	if hours.Valid {
+		h := int(hours.Int64)
+		taskCode.DefaultCompletedHours = &h
+	}
+	return taskCode, nil
+}
+
+// GetAll lấy danh mục mã nhiệm vụ theo thứ tự mã, active = nil: tất cả
+func (r *TaskCodeRepository) GetAll(active *bool) ([]*models.TaskCode, error) {
+	rows, err := r.db.Query(`
+		SELECT `+taskCodeColumns+`
+		FROM task_codes tc
+		WHERE ($1::boolean IS NULL OR tc.active = $1)
+		ORDER BY LOWER(tc.code)
+	`, active)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lấy danh mục mã nhiệm vụ: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	taskCodes := []*models.TaskCode{}
+	for rows.Next() {
+		taskCode, err := scanTaskCode(rows)
+		if err != nil {
+			return nil, err
+		}
+		taskCodes = append(taskCodes, taskCode)
+	}
+	return taskCodes, rows.Err()
+}
+
+// FindByID tìm mã nhiệm vụ theo ID (sql.ErrNoRows nếu không có)
+func (r *TaskCodeRepository) FindByID(id string) (*models.TaskCode, error) {
+	row := r.db.QueryRow(`
+		SELECT `+taskCodeColumns+`
+		FROM task_codes tc
+		WHERE tc.id = $1
+	`, id)
+	return scanTaskCode(row)
+}
+
+// FindByCode tìm mã nhiệm vụ theo mã, không phân biệt hoa thường và khoảng trắng hai đầu (sql.ErrNoRows nếu không có)
+func (r *TaskCodeRepository) FindByCode(code string) (*models.TaskCode, error) {
+	row := r.db.QueryRow(`
+		SELECT `+taskCodeColumns+`
+		FROM task_codes tc
+		WHERE LOWER(TRIM(tc.code)) = LOWER(TRIM($1))
+	`, code)
+	return scanTaskCode(row)
+}
+
+// Create thêm mã nhiệm vụ vào danh mục
+func (r *TaskCodeRepository) Create(taskCode *models.TaskCode) error {
+	err := r.db.QueryRow(`
+		INSERT INTO task_codes (
+			code, description, default_bet_type, default_web_bet_amount_cny, default_completed_hours, active, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`,
+		taskCode.Code,
+		taskCode.Description,
+		taskCode.DefaultBetType,
+		taskCode.DefaultWebBetAmountCNY,
+		taskCode.DefaultCompletedHours,
+		taskCode.Active,
+		taskCode.CreatedBy,
+	).Scan(&taskCode.ID, &taskCode.CreatedAt, &taskCode.UpdatedAt)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi tạo mã nhiệm vụ: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Update sửa mã nhiệm vụ, trả về false nếu không tồn tại
+func (r *TaskCodeRepository) Update(taskCode *models.TaskCode) (bool, error) {
+	err := r.db.QueryRow(`
+		UPDATE task_codes
+		SET code = $1, description = $2, default_bet_type = $3, default_web_bet_amount_cny = $4,
+			default_completed_hours = $5, active = $6, updated_at = NOW()
+		WHERE id = $7
+		RETURNING updated_at
+	`,
+		taskCode.Code,
+		taskCode.Description,
+		taskCode.DefaultBetType,
+		taskCode.DefaultWebBetAmountCNY,
+		taskCode.DefaultCompletedHours,
+		taskCode.Active,
+		taskCode.ID,
+	).Scan(&taskCode.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi cập nhật mã nhiệm vụ: %v", err)
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete xóa mã nhiệm vụ chưa có đơn hàng nào (kể cả đơn hàng đã xóa mềm) sử dụng
+// Trả về false nếu không có dòng nào bị xóa (không tồn tại hoặc đã được sử dụng)
+func (r *TaskCodeRepository) Delete(id string) (bool, error) {
+	result, err := r.db.Exec(`
+		DELETE FROM task_codes tc
+		WHERE tc.id = $1
+		  AND NOT EXISTS (SELECT 1 FROM thong_tin_nhan_keo ttnk WHERE LOWER(TRIM(ttnk.ma_nhiem_vu)) = LOWER(TRIM(tc.code)))
+	`, id)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi xóa mã nhiệm vụ: %v", err)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// Stats thống kê đơn hàng chưa xóa theo mã nhiệm vụ (nhóm không phân biệt hoa thường),
+// nhận kèo trong [from, to) (nil = không giới hạn), gồm cả mã chưa có trong danh mục
+func (r *TaskCodeRepository) Stats(from, to *time.Time) ([]*models.TaskCodeStats, error) {
+	rows, err := r.db.Query(`
+		SELECT COALESCE(tc.code, s.task_code), tc.id, COALESCE(tc.description, ''),
+		       s.total, s.done, s.cancelled, s.compensation, s.avg_hours
+		FROM (
+			SELECT LOWER(TRIM(ttnk.ma_nhiem_vu)) AS code_key,
+			       MIN(TRIM(ttnk.ma_nhiem_vu)) AS task_code,
+			       COUNT(*) AS total,
+			       COUNT(*) FILTER (WHERE ttnk.tien_do_hoan_thanh = $3) AS done,
+			       COUNT(*) FILTER (WHERE ttnk.tien_do_hoan_thanh = $4) AS cancelled,
+			       COUNT(*) FILTER (WHERE ttnk.tien_do_hoan_thanh = $5) AS compensation,
+			       AVG(EXTRACT(EPOCH FROM (ttnk.thoi_gian_hoan_thanh - ttnk.thoi_gian_nhan_keo)) / 3600)
+			           FILTER (WHERE ttnk.tien_do_hoan_thanh = $3 AND ttnk.thoi_gian_hoan_thanh IS NOT NULL) AS avg_hours
+			FROM thong_tin_nhan_keo ttnk
+			WHERE ttnk.deleted_at IS NULL
+			  AND ($1::timestamp IS NULL OR ttnk.thoi_gian_nhan_keo >= $1)
+			  AND ($2::timestamp IS NULL OR ttnk.thoi_gian_nhan_keo < $2)
+			GROUP BY LOWER(TRIM(ttnk.ma_nhiem_vu))
+		) s
+		LEFT JOIN task_codes tc ON LOWER(TRIM(tc.code)) = s.code_key
+		ORDER BY s.total DESC, s.code_key
+	`, from, to, models.BetReceiptStatusDone, models.BetReceiptStatusCancelled, models.BetReceiptStatusCompensation)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi thống kê theo mã nhiệm vụ: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []*models.TaskCodeStats{}
+	for rows.Next() {
+		s := &models.TaskCodeStats{}
+		var taskCodeID sql.NullString
+		var avgHours sql.NullFloat64
+		if err := rows.Scan(
+			&s.TaskCode, &taskCodeID, &s.Description,
+			&s.Total, &s.Done, &s.Cancelled, &s.Compensation, &avgHours,
+		); err != nil {
+			return nil, err
+		}
+		s.TaskCodeID = nullStringPtr(taskCodeID)
+		s.InCatalog = taskCodeID.Valid
+		s.Processed = s.Done + s.Cancelled + s.Compensation
+		if avgHours.Valid {
+			hours := avgHours.Float64
+			s.AvgCompletionHours = &hours
+		}
+		if s.Processed > 0 {
+			rate := float64(s.Compensation) / float64(s.Processed)
+			s.CompensationRate = &rate
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"database/sql"
+	"log"
+)
+
+// DBTX - Các hàm chung của *sql.DB và *sql.Tx
+// Repository dùng DBTX để cùng một code có thể chạy trong hoặc ngoài transaction
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// RunInTx chạy fn trong một transaction
+// fn trả về lỗi (hoặc panic) thì rollback, ngược lại commit
+func RunInTx(db *sql.DB, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("Repository - ⚠️ Lỗi rollback transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
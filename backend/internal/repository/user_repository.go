@@ -3,6 +3,7 @@ package repository
 import (
 	"database/sql"
 	"fullstack-backend/internal/models"
+	"fullstack-backend/pkg/pagination"
 )
 
 type UserRepository struct {
@@ -148,6 +149,25 @@ func (r *UserRepository) FindByName(name string) ([]*models.User, error) {
 	return users, nil
 }
 
+// FindIDsByRole lấy ID của tất cả users có vai_tro = role (vd: "admin")
+func (r *UserRepository) FindIDsByRole(role string) ([]string, error) {
+	rows, err := r.db.Query("SELECT id FROM nguoi_dung WHERE vai_tro = $1 ORDER BY id", role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 // UpdateUser cập nhật user (đổi tên và email) - DEPRECATED: Không cho phép đổi email
 func (r *UserRepository) UpdateUser(id string, name string, email string) error {
 	query := `
@@ -336,16 +356,20 @@ func (r *UserRepository) GetAll(limit, offset int) ([]*models.User, error) {
 	return users, nil
 }
 
-// GetAllUsers lấy tất cả users có role = 'user' (có phân trang, sắp xếp theo tên)
-func (r *UserRepository) GetAllUsers(limit, offset int) ([]*models.User, error) {
+// UserCursorColumns - Các cột keyset của danh sách users
+var UserCursorColumns = []string{"ten", "id"}
+
+// GetAllUsers lấy tất cả users có role = 'user' (có phân trang, sắp xếp theo (ten, id))
+// Kết quả có thể dư 1 dòng (FetchLimit) - dùng pagination.Paginate để cắt
+func (r *UserRepository) GetAllUsers(page pagination.Request) ([]*models.User, error) {
 	query := `
         SELECT id, email, mat_khau, ten, vai_tro, so_dien_thoai, avatar_url, thoi_gian_tao, thoi_gian_cap_nhat 
         FROM nguoi_dung 
-        WHERE vai_tro = 'user'
-        ORDER BY ten ASC
-        LIMIT $1 OFFSET $2
-    `
-	rows, err := r.db.Query(query, limit, offset)
+        WHERE vai_tro = 'user'`
+	clause, args := page.SQL(UserCursorColumns, false, true, 1)
+	query += clause
+
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,386 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/pkg/money"
+	"fullstack-backend/pkg/pagination"
+	"log"
+	"strings"
+	"time"
+)
+
+// Sổ cái ví (wallet_ledger_entries) - chỉ ghi thêm
+// Mỗi nguồn (đơn hàng, nộp cọc, rút tiền) được đối soát theo source_id: tổng các bút toán đã ghi của nguồn
+// phải bằng số tiền hiện tại của nguồn, phần chênh lệch được ghi thành bút toán mới (không sửa bút toán cũ)
+// tien_keo là bảng tổng hợp tính lại từ sổ cái (projectWallet)
+
+// inTx chạy fn trong transaction (tạo mới nếu repository chưa chạy trong transaction)
+func (r *WalletRepository) inTx(fn func(repo *WalletRepository) error) error {
+	db, ok := r.db.(*sql.DB)
+	if !ok {
+		return fn(r)
+	}
+	return RunInTx(db, func(tx *sql.Tx) error {
+		return fn(r.WithTx(tx))
+	})
+}
+
+// lockLedger khóa sổ cái của user đến hết transaction (đối soát đồng thời không ghi trùng bút toán)
+func (r *WalletRepository) lockLedger(userID string) error {
+	_, err := r.db.Exec("SELECT pg_advisory_xact_lock(hashtext('wallet_ledger:' || $1))", userID)
+	return err
+}
+
+// LockWallet khóa ví của user đến hết transaction (dùng khóa của sổ cái)
+// để kiểm tra số dư rồi ghi thay đổi mà số dư không bị đổi đồng thời
+func (r *WalletRepository) LockWallet(userID string) error {
+	return r.lockLedger(userID)
+}
+
+// insertLedgerEntry ghi một bút toán, bỏ qua bút toán có số tiền bằng 0
+func (r *WalletRepository) insertLedgerEntry(entry *models.WalletLedgerEntry) error {
+	if entry.AmountCNY == 0 && entry.AmountVND == 0 {
+		return nil
+	}
+	query := `
+		INSERT INTO wallet_ledger_entries (
+			id_nguoi_dung, entry_type, amount_cny, amount_vnd, exchange_rate,
+			source_type, source_id, description, created_by
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, seq, created_at
+	`
+	return r.db.QueryRow(query,
+		entry.UserID, entry.EntryType, entry.AmountCNY, entry.AmountVND, entry.ExchangeRate,
+		entry.SourceType, entry.SourceID, entry.Description, entry.CreatedBy,
+	).Scan(&entry.ID, &entry.Seq, &entry.CreatedAt)
+}
+
+// betReceiptLedgerDelta - Chênh lệch giữa số tiền hiện tại của đơn hàng và tổng bút toán đã ghi
+type betReceiptLedgerDelta struct {
+	sourceID     string
+	label        string
+	status       sql.NullString
+	expectedKind sql.NullString // NULL: đơn hàng không còn tính vào ví (bị xóa, đổi status/người nhận)
+	expectedCNY  money.CNY
+	expectedVND  money.VND
+	rate         *money.Rate
+	postedKind   sql.NullString // Loại bút toán (không tính revaluation) ghi gần nhất
+	postedCNY    money.CNY
+	postedVND    money.VND
+}
+
+// entries trả về các bút toán cần ghi để tổng bút toán của đơn hàng khớp với số tiền hiện tại
+// Đổi loại bút toán (ĐỀN <-> DONE/HỦY BỎ) thì bút toán đầu tiên đảo toàn bộ số đã ghi theo loại cũ
+func (d betReceiptLedgerDelta) entries(userID string) []models.WalletLedgerEntry {
+	sourceID := d.sourceID
+	entry := models.WalletLedgerEntry{
+		UserID:     userID,
+		SourceType: models.LedgerSourceBetReceipt,
+		SourceID:   &sourceID,
+	}
+	entries := []models.WalletLedgerEntry{}
+	posted := d.postedCNY != 0 || d.postedVND != 0
+
+	if d.expectedKind.Valid && d.postedKind.Valid && d.expectedKind.String != d.postedKind.String && posted {
+		reversal := entry
+		reversal.EntryType = d.postedKind.String
+		reversal.AmountCNY = -d.postedCNY
+		reversal.AmountVND = -d.postedVND
+		reversal.Description = fmt.Sprintf("Đảo bút toán đơn hàng %s (đổi sang %s)", d.label, d.status.String)
+		entries = append(entries, reversal)
+		d.postedCNY, d.postedVND, posted = 0, 0, false
+	}
+
+	entry.AmountCNY = d.expectedCNY - d.postedCNY
+	entry.AmountVND = d.expectedVND - d.postedVND
+	entry.ExchangeRate = d.rate
+	switch {
+	case !d.expectedKind.Valid:
+		entry.EntryType = models.LedgerEntryReceiptSettlement
+		if d.postedKind.Valid {
+			entry.EntryType = d.postedKind.String
+		}
+		entry.Description = fmt.Sprintf("Đảo bút toán đơn hàng %s (đã xóa hoặc không còn tính vào ví)", d.label)
+	case entry.AmountCNY == 0:
+		entry.EntryType = models.LedgerEntryRevaluation
+		entry.Description = fmt.Sprintf("Đánh giá lại đơn hàng %s theo tỷ giá %s", d.label, d.rate)
+	case !posted:
+		entry.EntryType = d.expectedKind.String
+		entry.Description = fmt.Sprintf("Đơn hàng %s (%s)", d.label, d.status.String)
+	default:
+		entry.EntryType = d.expectedKind.String
+		entry.Description = fmt.Sprintf("Điều chỉnh đơn hàng %s (%s)", d.label, d.status.String)
+	}
+	return append(entries, entry)
+}
+
+// syncBetReceiptEntries đối soát bút toán của các đơn hàng DONE/HỦY BỎ/ĐỀN của user
+// - Đơn hàng mới tính vào ví: ghi receipt_settlement (compensation nếu ĐỀN)
+// - Đổi giữa ĐỀN và DONE/HỦY BỎ: ghi bút toán đảo số đã ghi rồi ghi lại toàn bộ số mới
+// - Công thực nhận thay đổi: ghi bút toán chênh lệch, chỉ VND thay đổi (tỷ giá): ghi revaluation
+// - Đơn hàng bị xóa / không còn tính vào ví: ghi bút toán đảo
+func (r *WalletRepository) syncBetReceiptEntries(userID string, exchangeRate money.Rate) error {
+	query := `
+		WITH expected AS (
+			SELECT id,
+			       tien_do_hoan_thanh AS status,
+			       CASE WHEN tien_do_hoan_thanh = 'ĐỀN' THEN 'compensation' ELSE 'receipt_settlement' END AS kind,
+			       cong_thuc_nhan_te AS cny,
+			       ROUND(cong_thuc_nhan_te * COALESCE(exchange_rate, $2), 0) AS vnd,
+			       COALESCE(exchange_rate, $2) AS rate
+			FROM thong_tin_nhan_keo
+			WHERE id_nguoi_dung = $1 AND deleted_at IS NULL AND tien_do_hoan_thanh IN ('DONE', 'HỦY BỎ', 'ĐỀN')
+		), posted AS (
+			SELECT source_id,
+			       SUM(amount_cny) AS cny,
+			       SUM(amount_vnd) AS vnd,
+			       (ARRAY_AGG(entry_type ORDER BY seq DESC) FILTER (WHERE entry_type <> 'revaluation'))[1] AS kind
+			FROM wallet_ledger_entries
+			WHERE id_nguoi_dung = $1 AND source_type = 'bet_receipt'
+			GROUP BY source_id
+		)
+		SELECT COALESCE(e.id, p.source_id), COALESCE(t.ma_stt, t.stt::text, ''),
+		       e.status, e.kind, COALESCE(e.cny, 0), COALESCE(e.vnd, 0), e.rate,
+		       p.kind, COALESCE(p.cny, 0), COALESCE(p.vnd, 0)
+		FROM expected e
+		FULL OUTER JOIN posted p ON p.source_id = e.id
+		LEFT JOIN thong_tin_nhan_keo t ON t.id = COALESCE(e.id, p.source_id)
+		WHERE COALESCE(e.cny, 0) <> COALESCE(p.cny, 0)
+		   OR COALESCE(e.vnd, 0) <> COALESCE(p.vnd, 0)
+		   OR (e.kind <> p.kind AND (COALESCE(p.cny, 0) <> 0 OR COALESCE(p.vnd, 0) <> 0))
+		ORDER BY t.stt NULLS LAST, 1
+	`
+	rows, err := r.db.Query(query, userID, exchangeRate)
+	if err != nil {
+		return err
+	}
+	deltas := []betReceiptLedgerDelta{}
+	for rows.Next() {
+		var d betReceiptLedgerDelta
+		if err := rows.Scan(
+			&d.sourceID, &d.label, &d.status, &d.expectedKind, &d.expectedCNY, &d.expectedVND, &d.rate,
+			&d.postedKind, &d.postedCNY, &d.postedVND,
+		); err != nil {
+			rows.Close()
+			return err
+		}
+		deltas = append(deltas, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, d := range deltas {
+		for _, entry := range d.entries(userID) {
+			if err := r.insertLedgerEntry(&entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(deltas) > 0 {
+		log.Printf("Repository - 📒 Đã ghi sổ cái %d đơn hàng cho user %s", len(deltas), userID)
+	}
+	return nil
+}
+
+// syncDepositEntries đối soát bút toán nộp cọc (lich_su_nop_tien) của user
+func (r *WalletRepository) syncDepositEntries(userID string) error {
+	query := `
+		INSERT INTO wallet_ledger_entries (id_nguoi_dung, entry_type, amount_vnd, source_type, source_id, description)
+		SELECT $1, 'deposit', COALESCE(d.so_tien_coc_vnd, 0) - COALESCE(p.vnd, 0), 'deposit', COALESCE(d.id, p.source_id),
+		       CASE
+		           WHEN d.id IS NULL THEN 'Đảo bút toán nộp cọc đã xóa'
+		           WHEN p.source_id IS NULL THEN 'Nộp cọc tháng ' || d.thang_nop
+		           ELSE 'Điều chỉnh nộp cọc tháng ' || d.thang_nop
+		       END
+		FROM (SELECT id, so_tien_coc_vnd, thang_nop, thoi_gian_tao FROM lich_su_nop_tien WHERE id_nguoi_dung = $1) d
+		FULL OUTER JOIN (
+			SELECT source_id, SUM(amount_vnd) AS vnd
+			FROM wallet_ledger_entries
+			WHERE id_nguoi_dung = $1 AND source_type = 'deposit'
+			GROUP BY source_id
+		) p ON p.source_id = d.id
+		WHERE COALESCE(d.so_tien_coc_vnd, 0) <> COALESCE(p.vnd, 0)
+		ORDER BY d.thoi_gian_tao NULLS LAST, 5
+	`
+	_, err := r.db.Exec(query, userID)
+	return err
+}
+
+// syncWithdrawalEntries đối soát bút toán rút tiền (lich_su_rut_tien) của user (số âm)
+func (r *WalletRepository) syncWithdrawalEntries(userID string) error {
+	query := `
+		INSERT INTO wallet_ledger_entries (id_nguoi_dung, entry_type, amount_cny, amount_vnd, source_type, source_id, description)
+		SELECT $1, 'withdrawal',
+		       -COALESCE(w.so_tien_rut_te, 0) - COALESCE(p.cny, 0),
+		       -COALESCE(w.so_tien_rut_vnd, 0) - COALESCE(p.vnd, 0),
+		       'withdrawal', COALESCE(w.id, p.source_id),
+		       CASE
+		           WHEN w.id IS NULL THEN 'Đảo bút toán rút tiền đã xóa'
+		           WHEN p.source_id IS NULL THEN 'Rút tiền tháng ' || w.thang_rut
+		           ELSE 'Điều chỉnh rút tiền tháng ' || w.thang_rut
+		       END
+		FROM (SELECT id, so_tien_rut_te, so_tien_rut_vnd, thang_rut, thoi_gian_tao FROM lich_su_rut_tien WHERE id_nguoi_dung = $1) w
+		FULL OUTER JOIN (
+			SELECT source_id, SUM(amount_cny) AS cny, SUM(amount_vnd) AS vnd
+			FROM wallet_ledger_entries
+			WHERE id_nguoi_dung = $1 AND source_type = 'withdrawal'
+			GROUP BY source_id
+		) p ON p.source_id = w.id
+		WHERE -COALESCE(w.so_tien_rut_te, 0) <> COALESCE(p.cny, 0)
+		   OR -COALESCE(w.so_tien_rut_vnd, 0) <> COALESCE(p.vnd, 0)
+		ORDER BY w.thoi_gian_tao NULLS LAST, 6
+	`
+	_, err := r.db.Exec(query, userID)
+	return err
+}
+
+// projectWallet tính lại tien_keo của user từ tổng các bút toán trong sổ cái (tạo wallet nếu chưa có)
+func (r *WalletRepository) projectWallet(userID string) error {
+	query := `
+		INSERT INTO tien_keo (
+			id_nguoi_dung, tong_cong_thuc_nhan_te, tong_da_rut_te, tong_cong_thuc_nhan_vnd,
+			tong_coc_vnd, tong_da_rut_vnd, tong_dieu_chinh_vnd, so_du_hien_tai_vnd, thoi_gian_cap_nhat
+		)
+		SELECT $1,
+		       COALESCE(SUM(amount_cny) FILTER (WHERE entry_type IN ('receipt_settlement', 'compensation', 'revaluation')), 0),
+		       COALESCE(-SUM(amount_cny) FILTER (WHERE entry_type = 'withdrawal'), 0),
+		       COALESCE(SUM(amount_vnd) FILTER (WHERE entry_type IN ('receipt_settlement', 'compensation', 'revaluation')), 0),
+		       COALESCE(SUM(amount_vnd) FILTER (WHERE entry_type = 'deposit'), 0),
+		       COALESCE(-SUM(amount_vnd) FILTER (WHERE entry_type = 'withdrawal'), 0),
+		       COALESCE(SUM(amount_vnd) FILTER (WHERE entry_type = 'adjustment'), 0),
+		       COALESCE(SUM(amount_vnd), 0),
+		       NOW()
+		FROM wallet_ledger_entries
+		WHERE id_nguoi_dung = $1
+		ON CONFLICT (id_nguoi_dung) DO UPDATE SET
+			tong_cong_thuc_nhan_te = EXCLUDED.tong_cong_thuc_nhan_te,
+			tong_da_rut_te = EXCLUDED.tong_da_rut_te,
+			tong_cong_thuc_nhan_vnd = EXCLUDED.tong_cong_thuc_nhan_vnd,
+			tong_coc_vnd = EXCLUDED.tong_coc_vnd,
+			tong_da_rut_vnd = EXCLUDED.tong_da_rut_vnd,
+			tong_dieu_chinh_vnd = EXCLUDED.tong_dieu_chinh_vnd,
+			so_du_hien_tai_vnd = EXCLUDED.so_du_hien_tai_vnd,
+			thoi_gian_cap_nhat = EXCLUDED.thoi_gian_cap_nhat
+	`
+	_, err := r.db.Exec(query, userID)
+	return err
+}
+
+// syncLedger khóa sổ cái của user, chạy các bước đối soát rồi tính lại tien_keo (trong một transaction)
+// userID rỗng (đơn hàng chưa có người nhận) thì bỏ qua
+func (r *WalletRepository) syncLedger(userID string, steps ...func(repo *WalletRepository) error) error {
+	if userID == "" {
+		return nil
+	}
+	return r.inTx(func(repo *WalletRepository) error {
+		if err := repo.lockLedger(userID); err != nil {
+			return err
+		}
+		for _, step := range steps {
+			if err := step(repo); err != nil {
+				return err
+			}
+		}
+		return repo.projectWallet(userID)
+	})
+}
+
+// AddAdjustment ghi bút toán điều chỉnh thủ công (admin) và tính lại tien_keo
+func (r *WalletRepository) AddAdjustment(userID string, amountVND money.VND, description, createdBy string) (*models.WalletLedgerEntry, error) {
+	entry := &models.WalletLedgerEntry{
+		UserID:      userID,
+		EntryType:   models.LedgerEntryAdjustment,
+		AmountVND:   amountVND,
+		SourceType:  models.LedgerSourceManual,
+		Description: description,
+		CreatedBy:   &createdBy,
+	}
+	err := r.syncLedger(userID, func(repo *WalletRepository) error {
+		return repo.insertLedgerEntry(entry)
+	})
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi ghi bút toán điều chỉnh: %v", err)
+		return nil, err
+	}
+	return entry, nil
+}
+
+// GetLedgerBalanceVND tính số dư ví (VND) từ các bút toán ghi trước thời điểm before
+func (r *WalletRepository) GetLedgerBalanceVND(userID string, before time.Time) (money.VND, error) {
+	var balance money.VND
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(amount_vnd), 0)
+		FROM wallet_ledger_entries
+		WHERE id_nguoi_dung = $1 AND created_at < $2
+	`, userID, before).Scan(&balance)
+	return balance, err
+}
+
+// LedgerCursorColumns - Cột keyset của danh sách bút toán (sắp xếp giảm dần theo thứ tự ghi sổ)
+var LedgerCursorColumns = []string{"e.seq"}
+
+// GetLedgerEntries lấy bút toán của user, mới nhất trước, kèm số dư lũy kế sau mỗi bút toán
+// Kết quả có thể dư 1 dòng (FetchLimit) - dùng pagination.Paginate để cắt
+func (r *WalletRepository) GetLedgerEntries(userID string, filter models.WalletLedgerFilter, page pagination.Request) ([]*models.WalletLedgerEntry, error) {
+	query := `
+		SELECT e.id, e.seq, e.id_nguoi_dung, e.entry_type, e.amount_cny, e.amount_vnd, e.exchange_rate,
+		       e.source_type, e.source_id, COALESCE(t.ma_stt, ''), e.description,
+		       e.created_by, COALESCE(u.ten, ''), e.created_at, e.balance_after_vnd
+		FROM (
+			SELECT *, SUM(amount_vnd) OVER (ORDER BY seq) AS balance_after_vnd
+			FROM wallet_ledger_entries
+			WHERE id_nguoi_dung = $1
+		) e
+		LEFT JOIN thong_tin_nhan_keo t ON e.source_type = 'bet_receipt' AND t.id = e.source_id
+		LEFT JOIN nguoi_dung u ON u.id = e.created_by`
+	args := []interface{}{userID}
+	conditions := []string{}
+	if filter.EntryType != "" {
+		args = append(args, filter.EntryType)
+		conditions = append(conditions, fmt.Sprintf("e.entry_type = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("e.created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("e.created_at < $%d", len(args)))
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	clause, pageArgs := page.SQL(LedgerCursorColumns, true, len(conditions) > 0, len(args)+1)
+	query += clause
+
+	rows, err := r.db.Query(query, append(args, pageArgs...)...)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lấy sổ cái ví: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.WalletLedgerEntry{}
+	for rows.Next() {
+		e := &models.WalletLedgerEntry{}
+		var sourceID, createdBy sql.NullString
+		err := rows.Scan(
+			&e.ID, &e.Seq, &e.UserID, &e.EntryType, &e.AmountCNY, &e.AmountVND, &e.ExchangeRate,
+			&e.SourceType, &sourceID, &e.STTCode, &e.Description,
+			&createdBy, &e.CreatedByName, &e.CreatedAt, &e.BalanceAfterVND,
+		)
+		if err != nil {
+			return nil, err
+		}
+		e.SourceID = nullStringPtr(sourceID)
+		e.CreatedBy = nullStringPtr(createdBy)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
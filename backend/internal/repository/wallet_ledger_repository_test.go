@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"database/sql"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/pkg/money"
+	"testing"
+)
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func TestBetReceiptLedgerDeltaEntries(t *testing.T) {
+	rate := money.RateFromInt(3550)
+
+	type wantEntry struct {
+		entryType string
+		cny       money.CNY
+		vnd       money.VND
+	}
+	tests := []struct {
+		name  string
+		delta betReceiptLedgerDelta
+		want  []wantEntry
+	}{
+		{
+			name: "đơn hàng mới tính vào ví",
+			delta: betReceiptLedgerDelta{
+				status: nullString("DONE"), expectedKind: nullString("receipt_settlement"),
+				expectedCNY: 10000, expectedVND: 355000, rate: &rate,
+			},
+			want: []wantEntry{{"receipt_settlement", 10000, 355000}},
+		},
+		{
+			name: "đơn hàng ĐỀN mới",
+			delta: betReceiptLedgerDelta{
+				status: nullString("ĐỀN"), expectedKind: nullString("compensation"),
+				expectedCNY: -5000, expectedVND: -177500, rate: &rate,
+			},
+			want: []wantEntry{{"compensation", -5000, -177500}},
+		},
+		{
+			name: "công thực nhận thay đổi ghi chênh lệch",
+			delta: betReceiptLedgerDelta{
+				status: nullString("DONE"), expectedKind: nullString("receipt_settlement"),
+				expectedCNY: 12000, expectedVND: 426000, rate: &rate,
+				postedKind: nullString("receipt_settlement"), postedCNY: 10000, postedVND: 355000,
+			},
+			want: []wantEntry{{"receipt_settlement", 2000, 71000}},
+		},
+		{
+			name: "chỉ VND thay đổi ghi đánh giá lại",
+			delta: betReceiptLedgerDelta{
+				status: nullString("DONE"), expectedKind: nullString("receipt_settlement"),
+				expectedCNY: 10000, expectedVND: 360000, rate: &rate,
+				postedKind: nullString("receipt_settlement"), postedCNY: 10000, postedVND: 355000,
+			},
+			want: []wantEntry{{"revaluation", 0, 5000}},
+		},
+		{
+			name: "đổi DONE sang ĐỀN đảo bút toán cũ rồi ghi lại",
+			delta: betReceiptLedgerDelta{
+				status: nullString("ĐỀN"), expectedKind: nullString("compensation"),
+				expectedCNY: -5000, expectedVND: -177500, rate: &rate,
+				postedKind: nullString("receipt_settlement"), postedCNY: 10000, postedVND: 355000,
+			},
+			want: []wantEntry{{"receipt_settlement", -10000, -355000}, {"compensation", -5000, -177500}},
+		},
+		{
+			name: "đơn hàng không còn tính vào ví",
+			delta: betReceiptLedgerDelta{
+				postedKind: nullString("receipt_settlement"), postedCNY: 10000, postedVND: 355000,
+			},
+			want: []wantEntry{{"receipt_settlement", -10000, -355000}},
+		},
+		{
+			name: "đơn hàng ĐỀN bị xóa đảo theo loại đã ghi",
+			delta: betReceiptLedgerDelta{
+				postedKind: nullString("compensation"), postedCNY: -5000, postedVND: -177500,
+			},
+			want: []wantEntry{{"compensation", 5000, 177500}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.delta.sourceID = "receipt-1"
+			tt.delta.label = "1"
+			entries := tt.delta.entries("user-1")
+			if len(entries) != len(tt.want) {
+				t.Fatalf("entries() = %d bút toán, muốn %d: %+v", len(entries), len(tt.want), entries)
+			}
+
+			var sumCNY money.CNY
+			var sumVND money.VND
+			for i, entry := range entries {
+				want := tt.want[i]
+				if entry.EntryType != want.entryType || entry.AmountCNY != want.cny || entry.AmountVND != want.vnd {
+					t.Errorf("entries()[%d] = {%s %s %s}, muốn {%s %s %s}", i,
+						entry.EntryType, entry.AmountCNY, entry.AmountVND, want.entryType, want.cny, want.vnd)
+				}
+				if entry.UserID != "user-1" || entry.SourceType != models.LedgerSourceBetReceipt ||
+					entry.SourceID == nil || *entry.SourceID != "receipt-1" || entry.Description == "" {
+					t.Errorf("entries()[%d] thiếu thông tin nguồn: %+v", i, entry)
+				}
+				sumCNY += entry.AmountCNY
+				sumVND += entry.AmountVND
+			}
+
+			// Sau khi ghi, tổng bút toán của đơn hàng phải bằng số tiền hiện tại
+			if tt.delta.postedCNY+sumCNY != tt.delta.expectedCNY || tt.delta.postedVND+sumVND != tt.delta.expectedVND {
+				t.Errorf("tổng bút toán = %s / %s, muốn %s / %s",
+					tt.delta.postedCNY+sumCNY, tt.delta.postedVND+sumVND, tt.delta.expectedCNY, tt.delta.expectedVND)
+			}
+		})
+	}
+}
@@ -3,16 +3,29 @@ package repository
 import (
 	"database/sql"
 	"fullstack-backend/internal/models"
+	"fullstack-backend/pkg/money"
+	"fullstack-backend/pkg/pagination"
 	"log"
 	"time"
 )
 
 type WithdrawalRepository struct {
-	db *sql.DB
+	db   DBTX    // *sql.DB hoặc *sql.Tx (khi dùng WithTx)
+	conn *sql.DB // Connection gốc (để mở transaction)
 }
 
 func NewWithdrawalRepository(db *sql.DB) *WithdrawalRepository {
-	return &WithdrawalRepository{db: db}
+	return &WithdrawalRepository{db: db, conn: db}
+}
+
+// GetDB trả về connection gốc (để mở transaction)
+func (r *WithdrawalRepository) GetDB() *sql.DB {
+	return r.conn
+}
+
+// WithTx trả về repository chạy các query trong transaction tx
+func (r *WithdrawalRepository) WithTx(tx *sql.Tx) *WithdrawalRepository {
+	return &WithdrawalRepository{db: tx, conn: r.conn}
 }
 
 // Create tạo record rút tiền mới
@@ -20,7 +33,7 @@ func (r *WithdrawalRepository) Create(withdrawal *models.Withdrawal) error {
 	// Tạo thang_rut từ thoi_gian_tao (format: YYYY-MM)
 	withdrawalMonth := time.Now().Format("2006-01")
 
-	var amountCNY *float64
+	var amountCNY *money.CNY
 	if withdrawal.AmountCNY > 0 {
 		amountCNY = &withdrawal.AmountCNY
 	}
@@ -48,7 +61,7 @@ func (r *WithdrawalRepository) Create(withdrawal *models.Withdrawal) error {
 	}
 
 	withdrawal.WithdrawalMonth = withdrawalMonth
-	log.Printf("Repository - ✅ Đã tạo withdrawal với ID: %s, UserID: %s, AmountVND: %.2f",
+	log.Printf("Repository - ✅ Đã tạo withdrawal với ID: %s, UserID: %s, AmountVND: %s",
 		withdrawal.ID, withdrawal.UserID, withdrawal.AmountVND)
 	return nil
 }
@@ -59,8 +72,28 @@ type WithdrawalWithUser struct {
 	UserName string `json:"user_name" db:"user_name"`
 }
 
-// GetAll lấy tất cả lịch sử rút tiền kèm tên người dùng, sắp xếp theo thời gian mới nhất
-func (r *WithdrawalRepository) GetAll() ([]WithdrawalWithUser, error) {
+// WithdrawalCursorColumns - Các cột keyset của danh sách rút tiền (sắp xếp giảm dần)
+var WithdrawalCursorColumns = []string{"w.thoi_gian_tao", "w.id"}
+
+// GetAll lấy lịch sử rút tiền kèm tên người dùng, sắp xếp theo thời gian mới nhất
+// page.Limit = 0 để lấy tất cả; kết quả có thể dư 1 dòng (FetchLimit) - dùng pagination.Paginate để cắt
+func (r *WithdrawalRepository) GetAll(page pagination.Request) ([]WithdrawalWithUser, error) {
+	var withdrawals []WithdrawalWithUser
+	err := r.Stream(page, func(w WithdrawalWithUser) error {
+		withdrawals = append(withdrawals, w)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Repository - ✅ Đã lấy %d withdrawals", len(withdrawals))
+	return withdrawals, nil
+}
+
+// Stream duyệt lịch sử theo thứ tự của GetAll, gọi fn cho từng dòng ngay khi đọc từ DB
+// (không giữ toàn bộ trong bộ nhớ, dùng cho export)
+func (r *WithdrawalRepository) Stream(page pagination.Request, fn func(WithdrawalWithUser) error) error {
 	query := `
 		SELECT 
 			w.id,
@@ -72,21 +105,20 @@ func (r *WithdrawalRepository) GetAll() ([]WithdrawalWithUser, error) {
 			w.thoi_gian_tao,
 			COALESCE(u.ten, 'N/A') as user_name
 		FROM lich_su_rut_tien w
-		LEFT JOIN nguoi_dung u ON w.id_nguoi_dung = u.id
-		ORDER BY w.thoi_gian_tao DESC
-	`
+		LEFT JOIN nguoi_dung u ON w.id_nguoi_dung = u.id`
+	clause, args := page.SQL(WithdrawalCursorColumns, true, false, 1)
+	query += clause
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		log.Printf("Repository - ❌ Lỗi lấy danh sách withdrawals: %v", err)
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var withdrawals []WithdrawalWithUser
 	for rows.Next() {
 		var w WithdrawalWithUser
-		var amountCNY sql.NullFloat64
+		var amountCNY *money.CNY
 		err := rows.Scan(
 			&w.ID,
 			&w.UserID,
@@ -101,18 +133,19 @@ func (r *WithdrawalRepository) GetAll() ([]WithdrawalWithUser, error) {
 			log.Printf("Repository - ❌ Lỗi scan withdrawal: %v", err)
 			continue
 		}
-		if amountCNY.Valid {
-			w.AmountCNY = amountCNY.Float64
+		if amountCNY != nil {
+			w.AmountCNY = *amountCNY
+		}
+		if err := fn(w); err != nil {
+			return err
 		}
-		withdrawals = append(withdrawals, w)
 	}
 
 	if err = rows.Err(); err != nil {
 		log.Printf("Repository - ❌ Lỗi khi iterate withdrawals: %v", err)
-		return nil, err
+		return err
 	}
 
-	log.Printf("Repository - ✅ Đã lấy %d withdrawals", len(withdrawals))
-	return withdrawals, nil
+	return nil
 }
 
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/pkg/money"
+	"log"
+	"strings"
+)
+
+type WithdrawalRequestRepository struct {
+	db DBTX
+}
+
+func NewWithdrawalRequestRepository(db *sql.DB) *WithdrawalRequestRepository {
+	return &WithdrawalRequestRepository{db: db}
+}
+
+// WithTx trả về repository chạy các query trong transaction tx
+func (r *WithdrawalRequestRepository) WithTx(tx *sql.Tx) *WithdrawalRequestRepository {
+	return &WithdrawalRequestRepository{db: tx}
+}
+
+const withdrawalRequestSelect = `
+	SELECT wr.id, wr.id_nguoi_dung, u.ten, wr.amount_vnd, wr.note, wr.status, wr.reject_reason,
+	       wr.reviewed_by, reviewer.ten, wr.reviewed_at, wr.paid_by, wr.paid_at, wr.withdrawal_id,
+	       wr.created_at, wr.updated_at
+	FROM withdrawal_requests wr
+	JOIN nguoi_dung u ON wr.id_nguoi_dung = u.id
+	LEFT JOIN nguoi_dung reviewer ON wr.reviewed_by = reviewer.id
+`
+
+func scanWithdrawalRequest(row rowScanner) (*models.WithdrawalRequest, error) {
+	wr := &models.WithdrawalRequest{}
+	var rejectReason, reviewedBy, reviewedByName, paidBy, withdrawalID sql.NullString
+	var reviewedAt, paidAt sql.NullTime
+	err := row.Scan(
+		&wr.ID, &wr.UserID, &wr.UserName, &wr.AmountVND, &wr.Note, &wr.Status, &rejectReason,
+		&reviewedBy, &reviewedByName, &reviewedAt, &paidBy, &paidAt, &withdrawalID,
+		&wr.CreatedAt, &wr.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	wr.RejectReason = rejectReason.String
+	wr.ReviewedBy = nullStringPtr(reviewedBy)
+	wr.ReviewedByName = reviewedByName.String
+	if reviewedAt.Valid {
+		wr.ReviewedAt = &reviewedAt.Time
+	}
+	wr.PaidBy = nullStringPtr(paidBy)
+	if paidAt.Valid {
+		wr.PaidAt = &paidAt.Time
+	}
+	wr.WithdrawalID = nullStringPtr(withdrawalID)
+	return wr, nil
+}
+
+// Create lưu yêu cầu rút tiền mới (status pending)
+func (r *WithdrawalRequestRepository) Create(wr *models.WithdrawalRequest) error {
+	err := r.db.QueryRow(`
+		INSERT INTO withdrawal_requests (id_nguoi_dung, amount_vnd, note)
+		VALUES ($1, $2, $3)
+		RETURNING id, status, created_at, updated_at
+	`, wr.UserID, wr.AmountVND, wr.Note).Scan(&wr.ID, &wr.Status, &wr.CreatedAt, &wr.UpdatedAt)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi tạo yêu cầu rút tiền: %v", err)
+		return err
+	}
+	return nil
+}
+
+// FindByID tìm yêu cầu rút tiền theo ID, trả về sql.ErrNoRows nếu không có
+func (r *WithdrawalRequestRepository) FindByID(id string) (*models.WithdrawalRequest, error) {
+	return scanWithdrawalRequest(r.db.QueryRow(withdrawalRequestSelect+` WHERE wr.id = $1`, id))
+}
+
+// LockByID khóa yêu cầu rút tiền đến hết transaction rồi trả về (sql.ErrNoRows nếu không có)
+func (r *WithdrawalRequestRepository) LockByID(id string) (*models.WithdrawalRequest, error) {
+	return scanWithdrawalRequest(r.db.QueryRow(withdrawalRequestSelect+` WHERE wr.id = $1 FOR UPDATE OF wr`, id))
+}
+
+// GetAll lấy yêu cầu rút tiền theo filter, cũ nhất trước (hàng đợi duyệt)
+func (r *WithdrawalRequestRepository) GetAll(filter *models.WithdrawalRequestFilter) ([]*models.WithdrawalRequest, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("wr.status = $%d", len(args)))
+	}
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("wr.id_nguoi_dung = $%d", len(args)))
+	}
+	query := withdrawalRequestSelect
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY wr.created_at, wr.id"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi lấy yêu cầu rút tiền: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []*models.WithdrawalRequest{}
+	for rows.Next() {
+		wr, err := scanWithdrawalRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, wr)
+	}
+	return requests, rows.Err()
+}
+
+// SumReservedVND tổng tiền của các yêu cầu đang chờ duyệt / đã duyệt chưa chi của user (bỏ qua excludeID)
+// Số tiền này đã được giữ chỗ, không tính vào số dư khả dụng
+func (r *WithdrawalRequestRepository) SumReservedVND(userID, excludeID string) (money.VND, error) {
+	var reserved money.VND
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(amount_vnd), 0)
+		FROM withdrawal_requests
+		WHERE id_nguoi_dung = $1 AND status IN ('pending', 'approved') AND id <> $2
+	`, userID, excludeID).Scan(&reserved)
+	return reserved, err
+}
+
+// Approve duyệt yêu cầu đang chờ, trả về false nếu yêu cầu không còn ở status pending
+func (r *WithdrawalRequestRepository) Approve(id, reviewedBy string) (bool, error) {
+	return r.update(`
+		UPDATE withdrawal_requests
+		SET status = 'approved', reviewed_by = $2, reviewed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`, id, reviewedBy)
+}
+
+// Reject từ chối yêu cầu đang chờ hoặc đã duyệt chưa chi, trả về false nếu yêu cầu đã bị từ chối / đã chi
+func (r *WithdrawalRequestRepository) Reject(id, reason, reviewedBy string) (bool, error) {
+	return r.update(`
+		UPDATE withdrawal_requests
+		SET status = 'rejected', reject_reason = $2, reviewed_by = $3, reviewed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status IN ('pending', 'approved')
+	`, id, reason, reviewedBy)
+}
+
+// MarkPaid ghi nhận đã chi cho yêu cầu đã duyệt, trả về false nếu yêu cầu không còn ở status approved
+func (r *WithdrawalRequestRepository) MarkPaid(id, paidBy, withdrawalID string) (bool, error) {
+	return r.update(`
+		UPDATE withdrawal_requests
+		SET status = 'paid', paid_by = $2, paid_at = NOW(), withdrawal_id = $3, updated_at = NOW()
+		WHERE id = $1 AND status = 'approved'
+	`, id, paidBy, withdrawalID)
+}
+
+func (r *WithdrawalRequestRepository) update(query string, args ...interface{}) (bool, error) {
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		log.Printf("Repository - ❌ Lỗi cập nhật yêu cầu rút tiền: %v", err)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
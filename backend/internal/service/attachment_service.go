@@ -0,0 +1,208 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"fullstack-backend/pkg/upload"
+	"log"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// ErrAttachmentNotFound - Tệp đính kèm không tồn tại (hoặc không thuộc đơn hàng)
+	ErrAttachmentNotFound = errors.New("Không tìm thấy tệp đính kèm")
+	// ErrAttachmentAccessDenied - Người dùng không phải admin/người nhận kèo (hoặc không phải người upload khi xóa)
+	ErrAttachmentAccessDenied = errors.New("Bạn không có quyền với tệp đính kèm của đơn hàng này")
+)
+
+// attachmentMaxSize - Dung lượng tối đa của một tệp đính kèm
+const attachmentMaxSize = 10 * 1024 * 1024
+
+type AttachmentService struct {
+	attachmentRepo *repository.AttachmentRepository
+	betReceiptRepo *repository.BetReceiptRepository
+	historyRepo    *repository.BetReceiptHistoryRepository
+	policy         upload.Policy
+}
+
+// NewAttachmentService - storageDir: thư mục lưu file (config ATTACHMENT_DIR, không được serve public)
+func NewAttachmentService(attachmentRepo *repository.AttachmentRepository, betReceiptRepo *repository.BetReceiptRepository, historyRepo *repository.BetReceiptHistoryRepository, storageDir string) *AttachmentService {
+	return &AttachmentService{
+		attachmentRepo: attachmentRepo,
+		betReceiptRepo: betReceiptRepo,
+		historyRepo:    historyRepo,
+		policy: upload.Policy{
+			Dir:     storageDir,
+			MaxSize: attachmentMaxSize,
+			AllowedTypes: map[string]string{
+				"image/jpeg":      ".jpg",
+				"image/png":       ".png",
+				"image/gif":       ".gif",
+				"image/webp":      ".webp",
+				"application/pdf": ".pdf",
+			},
+			TypeLabel: "ảnh (JPEG, PNG, GIF, WEBP) hoặc PDF",
+		},
+	}
+}
+
+// checkAccess kiểm tra đơn hàng tồn tại và người dùng là admin hoặc người nhận kèo
+func (s *AttachmentService) checkAccess(betReceiptID, userID, role string) (*models.BetReceipt, error) {
+	betReceipt, err := s.betReceiptRepo.FindByID(betReceiptID)
+	if err == sql.ErrNoRows {
+		return nil, ErrBetReceiptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" && betReceipt.UserID != userID {
+		return nil, ErrAttachmentAccessDenied
+	}
+	return betReceipt, nil
+}
+
+// AddAttachment lưu file upload vào đơn hàng và ghi lịch sử ATTACHMENT
+// File không hợp lệ (sai loại, quá lớn) trả về ValidationError
+func (s *AttachmentService) AddAttachment(betReceiptID, userID, role, kind string, file *multipart.FileHeader) (*models.BetReceiptAttachment, error) {
+	if kind == "" {
+		kind = models.AttachmentKindProof
+	}
+	if !models.IsValidAttachmentKind(kind) {
+		return nil, newValidationError("Loại tệp đính kèm không hợp lệ (chỉ nhận proof, dispute, other)")
+	}
+	if _, err := s.checkAccess(betReceiptID, userID, role); err != nil {
+		return nil, err
+	}
+
+	saved, err := upload.Save(file, s.policy, betReceiptID)
+	if err != nil {
+		var uploadErr *upload.Error
+		if errors.As(err, &uploadErr) {
+			return nil, newValidationError(uploadErr.Message)
+		}
+		log.Printf("Service - ❌ Lỗi lưu tệp đính kèm: %v", err)
+		return nil, errors.New("Lỗi khi lưu file")
+	}
+
+	fileName := filepath.Base(file.Filename)
+	if len(fileName) > 255 {
+		fileName = fileName[len(fileName)-255:]
+	}
+	attachment := &models.BetReceiptAttachment{
+		BetReceiptID: betReceiptID,
+		Kind:         kind,
+		FileName:     fileName,
+		StoredName:   saved.Name,
+		ContentType:  saved.ContentType,
+		SizeBytes:    saved.Size,
+		UploadedBy:   &userID,
+	}
+
+	err = repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+		if err := s.attachmentRepo.WithTx(tx).Create(attachment); err != nil {
+			return err
+		}
+		return s.recordHistory(tx, attachment, &userID, false)
+	})
+	if err != nil {
+		// Không lưu được thông tin thì xóa file đã ghi
+		os.Remove(saved.Path)
+		log.Printf("Service - ❌ Lỗi lưu tệp đính kèm: %v", err)
+		return nil, errors.New("Lỗi khi lưu tệp đính kèm: " + err.Error())
+	}
+
+	log.Printf("Service - ✅ Đã thêm tệp đính kèm %s (%s, %d bytes) cho đơn hàng %s", attachment.ID, kind, attachment.SizeBytes, betReceiptID)
+	return attachment, nil
+}
+
+// GetAttachments lấy danh sách tệp đính kèm của đơn hàng (admin hoặc người nhận kèo)
+func (s *AttachmentService) GetAttachments(betReceiptID, userID, role string) ([]*models.BetReceiptAttachment, error) {
+	if _, err := s.checkAccess(betReceiptID, userID, role); err != nil {
+		return nil, err
+	}
+	return s.attachmentRepo.GetByBetReceiptID(betReceiptID)
+}
+
+// GetAttachmentFile lấy thông tin và đường dẫn file trên đĩa để tải về (admin hoặc người nhận kèo)
+func (s *AttachmentService) GetAttachmentFile(betReceiptID, id, userID, role string) (*models.BetReceiptAttachment, string, error) {
+	if _, err := s.checkAccess(betReceiptID, userID, role); err != nil {
+		return nil, "", err
+	}
+	attachment, err := s.findAttachment(betReceiptID, id)
+	if err != nil {
+		return nil, "", err
+	}
+	return attachment, filepath.Join(s.policy.Dir, attachment.StoredName), nil
+}
+
+// DeleteAttachment xóa tệp đính kèm (admin hoặc người đã upload) và ghi lịch sử ATTACHMENT
+func (s *AttachmentService) DeleteAttachment(betReceiptID, id, userID, role string) error {
+	if _, err := s.checkAccess(betReceiptID, userID, role); err != nil {
+		return err
+	}
+	attachment, err := s.findAttachment(betReceiptID, id)
+	if err != nil {
+		return err
+	}
+	if role != "admin" && (attachment.UploadedBy == nil || *attachment.UploadedBy != userID) {
+		return ErrAttachmentAccessDenied
+	}
+
+	err = repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+		if err := s.attachmentRepo.WithTx(tx).Delete(id); err != nil {
+			return err
+		}
+		return s.recordHistory(tx, attachment, &userID, true)
+	})
+	if err != nil {
+		log.Printf("Service - ❌ Lỗi xóa tệp đính kèm: %v", err)
+		return errors.New("Lỗi khi xóa tệp đính kèm: " + err.Error())
+	}
+
+	// Xóa file sau khi commit (nếu lỗi thì chỉ còn file mồ côi trên đĩa, dữ liệu vẫn nhất quán)
+	if err := os.Remove(filepath.Join(s.policy.Dir, attachment.StoredName)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Service - ⚠️ Không thể xóa file %s: %v", attachment.StoredName, err)
+	}
+	log.Printf("Service - ✅ Đã xóa tệp đính kèm %s của đơn hàng %s", id, betReceiptID)
+	return nil
+}
+
+func (s *AttachmentService) findAttachment(betReceiptID, id string) (*models.BetReceiptAttachment, error) {
+	attachment, err := s.attachmentRepo.FindByID(betReceiptID, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrAttachmentNotFound
+	}
+	return attachment, err
+}
+
+// recordHistory ghi lịch sử ATTACHMENT (thêm: new_data, xóa: old_data là thông tin tệp đính kèm)
+func (s *AttachmentService) recordHistory(tx *sql.Tx, attachment *models.BetReceiptAttachment, performedBy *string, deleted bool) error {
+	if s.historyRepo == nil {
+		return nil
+	}
+	data := map[string]interface{}{
+		"attachment_id": attachment.ID,
+		"kind":          attachment.Kind,
+		"file_name":     attachment.FileName,
+		"content_type":  attachment.ContentType,
+		"size_bytes":    attachment.SizeBytes,
+	}
+	historyReq := &models.CreateHistoryRequest{
+		BetReceiptID: attachment.BetReceiptID,
+		Action:       models.HistoryActionAttach,
+		PerformedBy:  performedBy,
+	}
+	if deleted {
+		historyReq.OldData = data
+		historyReq.Description = fmt.Sprintf("Xóa tệp đính kèm (%s): %s", attachment.Kind, attachment.FileName)
+	} else {
+		historyReq.NewData = data
+		historyReq.Description = fmt.Sprintf("Thêm tệp đính kèm (%s): %s", attachment.Kind, attachment.FileName)
+	}
+	return NewBetReceiptHistoryService(s.historyRepo.WithTx(tx)).CreateHistory(historyReq)
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"fullstack-backend/internal/models"
 	"fullstack-backend/internal/repository"
+	"fullstack-backend/pkg/pagination"
 	"fullstack-backend/pkg/utils"
 	"log"
 	"os"
@@ -188,17 +189,26 @@ func (s *AuthService) GetCurrentUser(userID string) (*models.User, error) {
 	return user, nil
 }
 
-// GetAllUsers - Lấy tất cả users (chỉ role = 'user', có phân trang)
-func (s *AuthService) GetAllUsers(limit, offset int) ([]*models.User, error) {
-	users, err := s.userRepo.GetAllUsers(limit, offset)
+// GetAllUsers - Lấy tất cả users (chỉ role = 'user', có phân trang theo offset hoặc cursor)
+func (s *AuthService) GetAllUsers(limit, offset int, cursorToken string) ([]*models.User, pagination.Page, error) {
+	pageReq, err := newPageRequest(limit, offset, cursorToken, "ten:asc", len(repository.UserCursorColumns))
 	if err != nil {
-		return nil, err
+		return nil, pagination.Page{}, err
 	}
+
+	users, err := s.userRepo.GetAllUsers(pageReq)
+	if err != nil {
+		return nil, pagination.Page{}, err
+	}
+	users, page := pagination.Paginate(users, pageReq, func(u *models.User) []interface{} {
+		return []interface{}{u.Name, u.ID}
+	})
+
 	// Không trả password
 	for _, user := range users {
 		user.Password = ""
 	}
-	return users, nil
+	return users, page, nil
 }
 
 // UpdateProfile - Cập nhật thông tin profile của user (chỉ cho phép đổi tên, không cho phép đổi email)
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"log"
+	"strings"
+)
+
+// maxBulkStatusItems - Số đơn hàng tối đa trong một lần bulk update status
+const maxBulkStatusItems = 500
+
+// errBulkStatusRejected - Có đơn hàng không qua kiểm tra, rollback toàn bộ transaction
+var errBulkStatusRejected = errors.New("bulk status rejected")
+
+// pendingStatusChange - Đơn hàng đã qua kiểm tra, chờ cập nhật trong transaction
+type pendingStatusChange struct {
+	betReceipt *models.BetReceipt
+	oldData    map[string]interface{}
+	oldStatus  string
+	result     *models.BulkStatusItemResult
+}
+
+// BulkUpdateBetReceiptStatus cập nhật status cho nhiều đơn hàng trong một transaction
+//  1. Khóa và kiểm tra tất cả đơn hàng (tồn tại, bước chuyển status, trường bắt buộc)
+//  2. Nếu có đơn hàng không hợp lệ: không cập nhật gì, trả về report kèm ValidationError
+//     (lỗi DB trong lúc cập nhật cũng rollback toàn bộ, report.Applied = false)
+//  3. Cập nhật status + ghi lịch sử cho từng đơn hàng, tính lại wallet một lần cho mỗi user bị ảnh hưởng
+func (s *BetReceiptService) BulkUpdateBetReceiptStatus(req *models.BulkUpdateBetReceiptStatusRequest, performedBy *string) (*models.BulkStatusReport, error) {
+	ids := uniqueIDs(req.IDs)
+	if len(ids) == 0 {
+		return nil, newValidationError("Danh sách ids không được để trống")
+	}
+	if len(ids) > maxBulkStatusItems {
+		return nil, newValidationError(fmt.Sprintf("Chỉ được cập nhật tối đa %d đơn hàng mỗi lần", maxBulkStatusItems))
+	}
+
+	statusReq := &req.UpdateBetReceiptStatusRequest
+	log.Printf("Service - Bulk cập nhật status %s cho %d đơn hàng", statusReq.Status, len(ids))
+
+	exchangeRate, err := s.GetCurrentExchangeRate()
+	if err != nil {
+		log.Printf("Service - ⚠️ Không thể lấy tỷ giá hiện tại, dùng giá trị mặc định 3550.0: %v", err)
+		exchangeRate = models.DefaultExchangeRate // Tỷ giá VND/CNY mặc định
+	}
+
+	// Biểu phí đang có hiệu lực (chỉ cần khi status mới là DONE hoặc HỦY BỎ), dùng chung cho tất cả đơn hàng
+	feeSchedule, err := s.feeScheduleForStatus(statusReq.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.BulkStatusReport{Total: len(ids)}
+
+	err = repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+		betReceiptRepo := s.betReceiptRepo.WithTx(tx)
+
+		// 1. Khóa các đơn hàng để không bị cập nhật đồng thời trong lúc kiểm tra
+		found, err := betReceiptRepo.LockByIDs(ids)
+		if err != nil {
+			return err
+		}
+
+		// 2. Kiểm tra tất cả đơn hàng trước khi cập nhật
+		report.Results = make([]*models.BulkStatusItemResult, 0, len(ids))
+		pending := make([]*pendingStatusChange, 0, len(ids))
+		for _, id := range ids {
+			result := &models.BulkStatusItemResult{ID: id, NewStatus: statusReq.Status}
+			report.Results = append(report.Results, result)
+
+			if !found[id] {
+				result.Error = "Không tìm thấy đơn hàng"
+				report.Failed++
+				continue
+			}
+
+			betReceipt, err := betReceiptRepo.FindByID(id)
+			if err != nil {
+				return err
+			}
+			result.OldStatus = betReceipt.Status
+
+			if !checkBulkStatusItem(betReceipt, statusReq, result) {
+				report.Failed++
+				continue
+			}
+
+			oldData, _ := betReceiptToMap(betReceipt)
+			pending = append(pending, &pendingStatusChange{
+				betReceipt: betReceipt,
+				oldData:    oldData,
+				oldStatus:  betReceipt.Status,
+				result:     result,
+			})
+		}
+
+		if report.Failed > 0 {
+			return errBulkStatusRejected
+		}
+
+		// 3. Cập nhật status và ghi lịch sử cho từng đơn hàng
+		var historyService *BetReceiptHistoryService
+		if s.historyRepo != nil {
+			historyService = NewBetReceiptHistoryService(s.historyRepo.WithTx(tx))
+		}
+
+		affectedUsers := []string{}
+		seenUsers := map[string]bool{}
+		for _, item := range pending {
+			if err := releaseClosedCompletedAt(item.betReceipt, betReceiptRepo.IsPeriodClosed); err != nil {
+				return err
+			}
+			if err := applyStatusChange(item.betReceipt, statusReq, exchangeRate, feeSchedule); err != nil {
+				item.result.Error = err.Error()
+				return err
+			}
+
+			if err := betReceiptRepo.UpdateStatus(item.betReceipt); err != nil {
+				item.result.Error = "Lỗi khi cập nhật status: " + err.Error()
+				return err
+			}
+
+			if statusAffectsWallet(item.oldStatus, statusReq.Status) && !seenUsers[item.betReceipt.UserID] {
+				seenUsers[item.betReceipt.UserID] = true
+				affectedUsers = append(affectedUsers, item.betReceipt.UserID)
+			}
+
+			if historyService != nil {
+				newData, _ := betReceiptToMap(item.betReceipt)
+				historyReq := &models.CreateHistoryRequest{
+					BetReceiptID:  item.betReceipt.ID,
+					Action:        models.HistoryActionUpdate,
+					PerformedBy:   performedBy,
+					OldData:       item.oldData,
+					NewData:       newData,
+					ChangedFields: repository.FindChangedFields(item.oldData, newData),
+					Description:   "Cập nhật status (hàng loạt): " + item.oldStatus + " -> " + statusReq.Status,
+				}
+				if err := historyService.CreateHistory(historyReq); err != nil {
+					item.result.Error = "Lỗi khi ghi lịch sử: " + err.Error()
+					return err
+				}
+			}
+		}
+
+		// 4. Tính lại wallet một lần cho mỗi user bị ảnh hưởng
+		walletRepo := s.walletRepo.WithTx(tx)
+		for _, userID := range affectedUsers {
+			if err := walletRepo.RecalculateTotalReceived(userID, exchangeRate); err != nil {
+				return fmt.Errorf("Lỗi khi cập nhật wallet cho user %s: %w", userID, err)
+			}
+		}
+
+		log.Printf("Service - ✅ Bulk cập nhật %d đơn hàng, tính lại wallet cho %d user", len(pending), len(affectedUsers))
+		return nil
+	})
+
+	if errors.Is(err, errBulkStatusRejected) {
+		log.Printf("Service - ❌ Bulk cập nhật status bị từ chối: %d/%d đơn hàng không hợp lệ", report.Failed, report.Total)
+		return report, newValidationError(fmt.Sprintf("Có %d đơn hàng không hợp lệ, không đơn hàng nào được cập nhật", report.Failed))
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		log.Printf("Service - ❌ Bulk cập nhật status bị từ chối (đã rollback): %v", err)
+		return report, err
+	}
+	if err != nil {
+		log.Printf("Service - ❌ Lỗi bulk cập nhật status (đã rollback): %v", err)
+		return report, errors.New("Lỗi khi cập nhật status hàng loạt: " + err.Error())
+	}
+
+	report.Applied = true
+	for _, result := range report.Results {
+		result.Success = true
+		report.Updated++
+	}
+	return report, nil
+}
+
+// checkBulkStatusItem kiểm tra một đơn hàng trong bulk update status (kỳ chưa khóa sổ, bước chuyển status, người nhận)
+// Trả về false và ghi lỗi (kèm Allowed/Field nếu có) vào result khi đơn hàng không hợp lệ
+func checkBulkStatusItem(betReceipt *models.BetReceipt, req *models.UpdateBetReceiptStatusRequest, result *models.BulkStatusItemResult) bool {
+	err := checkReceiptPeriodOpen(betReceipt)
+	if err == nil {
+		err = validateStatusTransition(betReceipt.Status, req)
+	}
+	if err == nil {
+		err = validateAssignee(betReceipt, req)
+	}
+	if err == nil {
+		return true
+	}
+
+	result.Error = err.Error()
+	var transitionErr *StatusTransitionError
+	if errors.As(err, &transitionErr) {
+		result.Allowed = transitionErr.Allowed
+	}
+	var fieldErr *StatusFieldRequiredError
+	if errors.As(err, &fieldErr) {
+		result.Field = fieldErr.Field
+	}
+	return false
+}
+
+// uniqueIDs bỏ ID rỗng và ID trùng, giữ nguyên thứ tự
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"fullstack-backend/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestUniqueIDs(t *testing.T) {
+	got := uniqueIDs([]string{" a ", "b", "", "a", "  ", "c", "b"})
+	want := []string{"a", "b", "c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("uniqueIDs() = %v, muốn %v", got, want)
+	}
+}
+
+func TestCheckBulkStatusItem(t *testing.T) {
+	tests := []struct {
+		name        string
+		betReceipt  models.BetReceipt
+		req         models.UpdateBetReceiptStatusRequest
+		wantOK      bool
+		wantField   string
+		wantAllowed bool
+	}{
+		{
+			name:       "đang thực hiện -> chờ chấp nhận",
+			betReceipt: models.BetReceipt{UserID: "worker", Status: models.BetReceiptStatusInProgress},
+			req:        models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusPending},
+			wantOK:     true,
+		},
+		{
+			name:        "bước chuyển không hợp lệ trả về các status được phép",
+			betReceipt:  models.BetReceipt{UserID: "worker", Status: models.BetReceiptStatusNew},
+			req:         models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusDone},
+			wantAllowed: true,
+		},
+		{
+			name:       "thiếu trường bắt buộc",
+			betReceipt: models.BetReceipt{UserID: "worker", Status: models.BetReceiptStatusPending},
+			req:        models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusCancelled},
+			wantField:  "actual_received_cny",
+		},
+		{
+			name:       "đơn hàng chưa có người nhận",
+			betReceipt: models.BetReceipt{Status: models.BetReceiptStatusNew},
+			req:        models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusInProgress},
+			wantField:  "user_id",
+		},
+		{
+			name:       "đơn hàng thuộc kỳ đã khóa sổ",
+			betReceipt: models.BetReceipt{UserID: "worker", Status: models.BetReceiptStatusInProgress, LockedPeriod: strPtr("2024-10")},
+			req:        models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusPending},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			betReceipt := tt.betReceipt
+			result := &models.BulkStatusItemResult{ID: "1", NewStatus: tt.req.Status}
+			ok := checkBulkStatusItem(&betReceipt, &tt.req, result)
+			if ok != tt.wantOK {
+				t.Fatalf("checkBulkStatusItem() = %v (error %q), muốn %v", ok, result.Error, tt.wantOK)
+			}
+			if ok {
+				if result.Error != "" {
+					t.Errorf("result.Error = %q, muốn rỗng", result.Error)
+				}
+				return
+			}
+			if result.Error == "" {
+				t.Error("result.Error rỗng, muốn có thông báo lỗi")
+			}
+			if result.Field != tt.wantField {
+				t.Errorf("result.Field = %q, muốn %q", result.Field, tt.wantField)
+			}
+			if (len(result.Allowed) > 0) != tt.wantAllowed {
+				t.Errorf("result.Allowed = %v, muốn có danh sách = %v", result.Allowed, tt.wantAllowed)
+			}
+		})
+	}
+}
+
+func TestStatusAffectsWallet(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		want     bool
+	}{
+		{name: "đang thực hiện -> chờ chấp nhận", from: models.BetReceiptStatusInProgress, to: models.BetReceiptStatusPending},
+		{name: "chờ chấp nhận -> DONE", from: models.BetReceiptStatusPending, to: models.BetReceiptStatusDone, want: true},
+		{name: "chờ chấp nhận -> HỦY BỎ", from: models.BetReceiptStatusPending, to: models.BetReceiptStatusCancelled, want: true},
+		{name: "chờ chấp nhận -> ĐỀN", from: models.BetReceiptStatusPending, to: models.BetReceiptStatusCompensation, want: true},
+		{name: "DONE -> chờ trọng tài", from: models.BetReceiptStatusDone, to: models.BetReceiptStatusWaitingRef, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusAffectsWallet(tt.from, tt.to); got != tt.want {
+				t.Errorf("statusAffectsWallet(%q, %q) = %v, muốn %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"fullstack-backend/pkg/secret"
+	"log"
+)
+
+// credentialRotateBatchSize - Số đơn hàng mã hóa lại trong một transaction
+const credentialRotateBatchSize = 200
+
+var (
+	// ErrBetReceiptNotFound - Đơn hàng không tồn tại
+	ErrBetReceiptNotFound = errors.New("Không tìm thấy đơn hàng")
+	// ErrCredentialAccessDenied - Người dùng không có quyền xem tài khoản/mật khẩu của đơn hàng
+	ErrCredentialAccessDenied = errors.New("Bạn không có quyền xem tài khoản/mật khẩu của đơn hàng này")
+)
+
+// sealCredentials mã hóa tài khoản/mật khẩu vào đơn hàng (AccountEncrypted, PasswordEncrypted)
+// và gán giá trị đã che để hiển thị (Account, Password)
+func (s *BetReceiptService) sealCredentials(betReceipt *models.BetReceipt, account, password string) error {
+	if err := s.sealAccount(betReceipt, account); err != nil {
+		return err
+	}
+	return s.sealPassword(betReceipt, password)
+}
+
+func (s *BetReceiptService) sealAccount(betReceipt *models.BetReceipt, account string) error {
+	encrypted, err := s.keyring.Encrypt(account)
+	if err != nil {
+		log.Printf("Service - ❌ Lỗi mã hóa tài khoản: %v", err)
+		return errors.New("Lỗi khi mã hóa tài khoản")
+	}
+	betReceipt.AccountEncrypted = encrypted
+	betReceipt.Account = secret.Mask(account)
+	betReceipt.AccountHash = s.accountIndex.Hash(account)
+	return nil
+}
+
+func (s *BetReceiptService) sealPassword(betReceipt *models.BetReceipt, password string) error {
+	encrypted, err := s.keyring.Encrypt(password)
+	if err != nil {
+		log.Printf("Service - ❌ Lỗi mã hóa mật khẩu: %v", err)
+		return errors.New("Lỗi khi mã hóa mật khẩu")
+	}
+	betReceipt.PasswordEncrypted = encrypted
+	betReceipt.Password = ""
+	if password != "" {
+		betReceipt.Password = secret.Masked
+	}
+	return nil
+}
+
+// RevealCredentials giải mã tài khoản/mật khẩu của đơn hàng
+// Chỉ admin hoặc người nhận kèo (chủ đơn hàng) được xem; mọi lần yêu cầu (kể cả bị từ chối) đều được ghi nhật ký
+// Nếu không ghi được nhật ký thì không trả về giá trị
+func (s *BetReceiptService) RevealCredentials(id string, userID, role string, req *models.RevealCredentialsRequest, ipAddress, userAgent string) (*models.BetReceiptCredentials, error) {
+	betReceipt, err := s.betReceiptRepo.FindByID(id)
+	if err != nil {
+		return nil, ErrBetReceiptNotFound
+	}
+
+	allowed := role == "admin" || betReceipt.UserID == userID
+	entry := &models.CredentialAccessLog{
+		BetReceiptID: id,
+		UserID:       &userID,
+		Allowed:      allowed,
+		Reason:       req.Reason,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+	}
+	if err := s.credentialAccessRepo.Create(entry); err != nil {
+		return nil, errors.New("Lỗi khi ghi nhật ký xem tài khoản")
+	}
+
+	if !allowed {
+		log.Printf("Service - ⚠️ Từ chối xem tài khoản/mật khẩu đơn hàng %s (user: %s, role: %s)", id, userID, role)
+		return nil, ErrCredentialAccessDenied
+	}
+
+	account, err := s.keyring.Decrypt(betReceipt.AccountEncrypted)
+	if err != nil {
+		log.Printf("Service - ❌ Lỗi giải mã tài khoản đơn hàng %s: %v", id, err)
+		return nil, errors.New("Lỗi khi giải mã tài khoản")
+	}
+	password, err := s.keyring.Decrypt(betReceipt.PasswordEncrypted)
+	if err != nil {
+		log.Printf("Service - ❌ Lỗi giải mã mật khẩu đơn hàng %s: %v", id, err)
+		return nil, errors.New("Lỗi khi giải mã mật khẩu")
+	}
+
+	log.Printf("Service - 🔑 User %s (role: %s) đã xem tài khoản/mật khẩu đơn hàng %s", userID, role, id)
+	return &models.BetReceiptCredentials{
+		BetReceiptID: id,
+		Account:      account,
+		Password:     password,
+	}, nil
+}
+
+// GetCredentialAccessLog lấy nhật ký xem tài khoản/mật khẩu của đơn hàng
+func (s *BetReceiptService) GetCredentialAccessLog(id string) ([]*models.CredentialAccessLog, error) {
+	return s.credentialAccessRepo.GetByBetReceiptID(id)
+}
+
+// RotateCredentials mã hóa tài khoản/mật khẩu còn là plaintext (dữ liệu cũ) và chuyển các giá trị
+// đang dùng master key cũ sang key hiện tại, trả về số đơn hàng đã cập nhật
+// Gọi khi khởi động server: sau khi thêm key mới vào đầu CREDENTIAL_KEYS, key cũ có thể bỏ đi khi hàm này chạy xong
+func (s *BetReceiptService) RotateCredentials() (int, error) {
+	total := 0
+	for {
+		updated := 0
+		err := repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+			betReceiptRepo := s.betReceiptRepo.WithTx(tx)
+			betReceipts, err := betReceiptRepo.LockCredentialsToRotate(s.keyring.ActiveKeyID(), credentialRotateBatchSize)
+			if err != nil {
+				return err
+			}
+
+			for _, b := range betReceipts {
+				if b.Account == "" && b.AccountEncrypted != "" && !secret.IsEncrypted(b.AccountEncrypted) {
+					b.Account = secret.Mask(b.AccountEncrypted)
+				}
+				if b.AccountEncrypted, err = s.keyring.Rotate(b.AccountEncrypted); err != nil {
+					return fmt.Errorf("đơn hàng %s: %w", b.ID, err)
+				}
+				if b.PasswordEncrypted, err = s.keyring.Rotate(b.PasswordEncrypted); err != nil {
+					return fmt.Errorf("đơn hàng %s: %w", b.ID, err)
+				}
+				if err := betReceiptRepo.UpdateCredentials(b); err != nil {
+					return err
+				}
+			}
+			updated = len(betReceipts)
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+
+		total += updated
+		if updated < credentialRotateBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("Service - 🔐 Đã mã hóa lại tài khoản/mật khẩu cho %d đơn hàng (key: %s)", total, s.keyring.ActiveKeyID())
+	}
+	return total, nil
+}
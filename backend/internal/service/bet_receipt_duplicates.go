@@ -0,0 +1,190 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"log"
+	"strings"
+	"time"
+)
+
+// accountIndexBatchSize - Số đơn hàng tính chỉ mục tài khoản trong một transaction
+const accountIndexBatchSize = 200
+
+// DuplicateError - Đơn hàng đang tạo/sửa trùng với đơn hàng đã có
+// RequiresForce = true: chỉ vướng quy tắc cảnh báo, gửi lại với force=true để vẫn lưu
+type DuplicateError struct {
+	Matches       []*models.DuplicateMatch
+	RequiresForce bool
+}
+
+func (e *DuplicateError) Error() string {
+	if e.RequiresForce {
+		return fmt.Sprintf("Đơn hàng nghi trùng với %d đơn hàng đã có, gửi lại với force=true nếu vẫn muốn lưu", len(e.Matches))
+	}
+	return fmt.Sprintf("Đơn hàng trùng với %d đơn hàng đã có, không thể lưu", len(e.Matches))
+}
+
+// checkDuplicates kiểm tra đơn hàng candidate theo các quy tắc trùng được bật trong rules
+// betReceiptRepo phải chạy trong transaction (WithTx): khóa trùng được giữ đến khi commit
+// excludeID: ID của chính đơn hàng khi sửa ("" khi tạo); receivedAt = nil: đơn hàng tạo hôm nay
+// Trả về *DuplicateError nếu vướng quy tắc reject, hoặc vướng quy tắc warn mà không có force
+func (s *BetReceiptService) checkDuplicates(betReceiptRepo *repository.BetReceiptRepository, candidate *models.BetReceipt, excludeID string, receivedAt *time.Time, rules []string, force bool) error {
+	type check struct {
+		rule string
+		key  string
+		find func() ([]*models.DuplicateMatch, error)
+	}
+	checks := []check{}
+	for _, rule := range rules {
+		if !s.duplicateRules.Enabled(rule) {
+			continue
+		}
+		switch rule {
+		case models.DuplicateRuleOrderCode:
+			orderCode := strings.ToLower(strings.TrimSpace(candidate.OrderCode))
+			if orderCode == "" {
+				continue
+			}
+			checks = append(checks, check{rule, rule + ":" + orderCode, func() ([]*models.DuplicateMatch, error) {
+				return betReceiptRepo.FindDuplicatesByOrderCode(orderCode, excludeID)
+			}})
+		case models.DuplicateRuleTaskAccountDay:
+			taskCode := strings.ToLower(strings.TrimSpace(candidate.TaskCode))
+			if taskCode == "" || candidate.AccountHash == "" {
+				continue
+			}
+			day := time.Now()
+			if receivedAt != nil {
+				day = *receivedAt
+			}
+			key := rule + ":" + taskCode + "|" + candidate.AccountHash + "|" + day.Format("2006-01-02")
+			checks = append(checks, check{rule, key, func() ([]*models.DuplicateMatch, error) {
+				return betReceiptRepo.FindDuplicatesByTaskAccountDay(taskCode, candidate.AccountHash, receivedAt, excludeID)
+			}})
+		}
+	}
+	if len(checks) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(checks))
+	for i, c := range checks {
+		keys[i] = c.key
+	}
+	if err := betReceiptRepo.LockDuplicateKeys(keys); err != nil {
+		return err
+	}
+
+	dupErr := &DuplicateError{}
+	rejected := false
+	for _, c := range checks {
+		matches, err := c.find()
+		if err != nil {
+			return err
+		}
+		mode := s.duplicateRules[c.rule]
+		for _, m := range matches {
+			m.Rule = c.rule
+			m.Mode = mode
+		}
+		if len(matches) > 0 && mode == models.DuplicateModeReject {
+			rejected = true
+		}
+		dupErr.Matches = append(dupErr.Matches, matches...)
+	}
+
+	switch {
+	case len(dupErr.Matches) == 0:
+		return nil
+	case rejected:
+		return dupErr
+	case !force:
+		dupErr.RequiresForce = true
+		return dupErr
+	}
+	log.Printf("Service - ⚠️ Vẫn lưu đơn hàng nghi trùng với %d đơn hàng đã có (force=true)", len(dupErr.Matches))
+	return nil
+}
+
+// duplicateRulesForUpdate trả về các quy tắc trùng cần kiểm tra khi sửa đơn hàng:
+// chỉ kiểm tra lại khi trường tham gia quy tắc thay đổi (sửa ghi chú của đơn hàng cũ đang trùng không bị chặn)
+func duplicateRulesForUpdate(old, updated *models.BetReceipt) []string {
+	rules := []string{}
+	if !strings.EqualFold(strings.TrimSpace(old.OrderCode), strings.TrimSpace(updated.OrderCode)) {
+		rules = append(rules, models.DuplicateRuleOrderCode)
+	}
+	if !strings.EqualFold(strings.TrimSpace(old.TaskCode), strings.TrimSpace(updated.TaskCode)) || old.AccountHash != updated.AccountHash {
+		rules = append(rules, models.DuplicateRuleTaskAccountDay)
+	}
+	return rules
+}
+
+// GetDuplicateReport lấy các nhóm đơn hàng đang nghi trùng nhau (admin)
+// rule rỗng: tất cả quy tắc đang bật
+func (s *BetReceiptService) GetDuplicateReport(rule string) ([]*models.DuplicateGroup, error) {
+	rules := []string{models.DuplicateRuleOrderCode, models.DuplicateRuleTaskAccountDay}
+	if rule != "" {
+		if _, ok := s.duplicateRules[rule]; !ok {
+			return nil, newValidationError(fmt.Sprintf("Quy tắc trùng không hợp lệ: %s (chỉ nhận %s, %s)", rule, models.DuplicateRuleOrderCode, models.DuplicateRuleTaskAccountDay))
+		}
+		rules = []string{rule}
+	}
+
+	groups := []*models.DuplicateGroup{}
+	for _, r := range rules {
+		if rule == "" && !s.duplicateRules.Enabled(r) {
+			continue
+		}
+		ruleGroups, err := s.betReceiptRepo.GetDuplicateGroups(r)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, ruleGroups...)
+	}
+	return groups, nil
+}
+
+// BackfillAccountHashes tính chỉ mục mù cho tài khoản của các đơn hàng chưa có (dữ liệu trước migration 027),
+// trả về số đơn hàng đã cập nhật. Gọi khi khởi động server, sau RotateCredentials
+func (s *BetReceiptService) BackfillAccountHashes() (int, error) {
+	total := 0
+	afterID := ""
+	for {
+		var batch []*models.BetReceipt
+		err := repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+			betReceiptRepo := s.betReceiptRepo.WithTx(tx)
+			var err error
+			batch, err = betReceiptRepo.LockAccountsToIndex(afterID, accountIndexBatchSize)
+			if err != nil {
+				return err
+			}
+			for _, b := range batch {
+				account, err := s.keyring.Decrypt(b.AccountEncrypted)
+				if err != nil {
+					return fmt.Errorf("đơn hàng %s: %w", b.ID, err)
+				}
+				if err := betReceiptRepo.UpdateAccountHash(b.ID, s.accountIndex.Hash(account)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+
+		total += len(batch)
+		if len(batch) < accountIndexBatchSize {
+			break
+		}
+		afterID = batch[len(batch)-1].ID
+	}
+
+	if total > 0 {
+		log.Printf("Service - 🔐 Đã tính chỉ mục tài khoản cho %d đơn hàng", total)
+	}
+	return total, nil
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"fullstack-backend/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestDuplicateRulesForUpdate(t *testing.T) {
+	old := models.BetReceipt{OrderCode: "DH01", TaskCode: "NV01", AccountHash: "hash-a", Notes: "cũ"}
+
+	tests := []struct {
+		name    string
+		updated models.BetReceipt
+		want    []string
+	}{
+		{name: "chỉ sửa ghi chú", updated: models.BetReceipt{OrderCode: "DH01", TaskCode: "NV01", AccountHash: "hash-a", Notes: "mới"}, want: []string{}},
+		{name: "mã khác hoa thường và khoảng trắng", updated: models.BetReceipt{OrderCode: " dh01 ", TaskCode: "nv01", AccountHash: "hash-a"}, want: []string{}},
+		{name: "đổi mã đơn hàng", updated: models.BetReceipt{OrderCode: "DH02", TaskCode: "NV01", AccountHash: "hash-a"}, want: []string{models.DuplicateRuleOrderCode}},
+		{name: "đổi mã nhiệm vụ", updated: models.BetReceipt{OrderCode: "DH01", TaskCode: "NV02", AccountHash: "hash-a"}, want: []string{models.DuplicateRuleTaskAccountDay}},
+		{name: "đổi tài khoản", updated: models.BetReceipt{OrderCode: "DH01", TaskCode: "NV01", AccountHash: "hash-b"}, want: []string{models.DuplicateRuleTaskAccountDay}},
+		{
+			name:    "đổi cả mã đơn hàng và tài khoản",
+			updated: models.BetReceipt{OrderCode: "DH02", TaskCode: "NV01", AccountHash: "hash-b"},
+			want:    []string{models.DuplicateRuleOrderCode, models.DuplicateRuleTaskAccountDay},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := duplicateRulesForUpdate(&old, &tt.updated); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("duplicateRulesForUpdate() = %v, muốn %v", got, tt.want)
+			}
+		})
+	}
+}
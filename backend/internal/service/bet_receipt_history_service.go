@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fullstack-backend/internal/models"
 	"fullstack-backend/internal/repository"
+	"fullstack-backend/pkg/pagination"
 	"log"
 )
 
@@ -68,9 +69,21 @@ func (s *BetReceiptHistoryService) CreateHistory(req *models.CreateHistoryReques
 	return nil
 }
 
-// GetAllHistories lấy tất cả lịch sử (có phân trang)
-func (s *BetReceiptHistoryService) GetAllHistories(limit, offset int) ([]*models.BetReceiptHistory, error) {
-	return s.historyRepo.GetAll(limit, offset)
+// GetAllHistories lấy tất cả lịch sử (có phân trang theo offset hoặc cursor)
+func (s *BetReceiptHistoryService) GetAllHistories(limit, offset int, cursorToken string) ([]*models.BetReceiptHistory, pagination.Page, error) {
+	pageReq, err := newPageRequest(limit, offset, cursorToken, "created_at:asc", len(repository.HistoryCursorColumns))
+	if err != nil {
+		return nil, pagination.Page{}, err
+	}
+
+	histories, err := s.historyRepo.GetAll(pageReq)
+	if err != nil {
+		return nil, pagination.Page{}, err
+	}
+	histories, page := pagination.Paginate(histories, pageReq, func(h *models.BetReceiptHistory) []interface{} {
+		return []interface{}{h.CreatedAt, h.ID}
+	})
+	return histories, page, nil
 }
 
 // GetHistoriesByBetReceiptID lấy lịch sử theo bet_receipt_id
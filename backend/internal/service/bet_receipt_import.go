@@ -0,0 +1,294 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"fullstack-backend/pkg/money"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// maxImportRows - Số dòng dữ liệu tối đa trong một file import
+const maxImportRows = 5000
+
+// importRow - Một dòng hợp lệ, chờ tạo đơn hàng
+type importRow struct {
+	row  int
+	req  *models.CreateBetReceiptRequest
+	user *models.User
+}
+
+// ImportBetReceipts kiểm tra và tạo đơn hàng từ các dòng của file bảng tính (dòng đầu tiên là tiêu đề)
+// dryRun = true: chỉ kiểm tra từng dòng, trả về lỗi theo dòng, không ghi DB
+// dryRun = false: nếu tất cả dòng hợp lệ thì tạo đơn hàng trong một transaction,
+// STT tăng dần theo thứ tự dòng trong file và mỗi đơn hàng có một bản ghi lịch sử CREATE
+// Có dòng lỗi thì không tạo đơn hàng nào (trả về report kèm ValidationError)
+func (s *BetReceiptService) ImportBetReceipts(rows [][]string, dryRun bool, performedBy *string) (*models.BetReceiptImportReport, error) {
+	report := &models.BetReceiptImportReport{
+		DryRun: dryRun,
+		Errors: []*models.BetReceiptImportError{},
+	}
+
+	// 1. Tìm dòng tiêu đề (dòng không trống đầu tiên) và xác định vị trí các cột
+	headerIndex := -1
+	for i, row := range rows {
+		if !isBlankRow(row) {
+			headerIndex = i
+			break
+		}
+	}
+	if headerIndex < 0 {
+		return nil, newValidationError("File không có dữ liệu")
+	}
+
+	columns, err := mapImportColumns(rows[headerIndex])
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Kiểm tra từng dòng dữ liệu
+	users := map[string]*models.User{}
+	userErrors := map[string]string{}
+	valid := []*importRow{}
+	for i := headerIndex + 1; i < len(rows); i++ {
+		if isBlankRow(rows[i]) {
+			continue
+		}
+		report.TotalRows++
+		if report.TotalRows > maxImportRows {
+			return nil, newValidationError(fmt.Sprintf("File có quá nhiều dòng (tối đa %d dòng)", maxImportRows))
+		}
+
+		rowNumber := i + 1 // Số dòng hiển thị trong Excel (bắt đầu từ 1)
+		req, rowErrors := parseImportRow(rows[i], columns, rowNumber)
+
+		// Tìm người dùng giống CreateBetReceipt (tên khớp chính xác), cache theo tên
+		var user *models.User
+		if req.UserName != "" {
+			if cached, ok := users[req.UserName]; ok {
+				user = cached
+			} else if message, ok := userErrors[req.UserName]; ok {
+				rowErrors = append(rowErrors, &models.BetReceiptImportError{Row: rowNumber, Column: models.ImportColumnUserName, Message: message})
+			} else {
+				found, err := s.findUserByExactName(req.UserName)
+				var validationErr *ValidationError
+				if errors.As(err, &validationErr) {
+					userErrors[req.UserName] = err.Error()
+					rowErrors = append(rowErrors, &models.BetReceiptImportError{Row: rowNumber, Column: models.ImportColumnUserName, Message: err.Error()})
+				} else if err != nil {
+					return nil, err
+				} else {
+					users[req.UserName] = found
+					user = found
+				}
+			}
+		}
+
+		if len(rowErrors) > 0 {
+			report.Errors = append(report.Errors, rowErrors...)
+			continue
+		}
+		valid = append(valid, &importRow{row: rowNumber, req: req, user: user})
+	}
+	report.ValidRows = len(valid)
+
+	if report.TotalRows == 0 {
+		return nil, newValidationError("File không có dòng dữ liệu nào")
+	}
+
+	log.Printf("Service - Import đơn hàng: %d dòng, %d hợp lệ, %d lỗi, dry_run=%v",
+		report.TotalRows, report.ValidRows, len(report.Errors), dryRun)
+
+	if dryRun {
+		return report, nil
+	}
+	if len(report.Errors) > 0 {
+		return report, newValidationError(fmt.Sprintf("Có %d lỗi trong file, không đơn hàng nào được tạo", len(report.Errors)))
+	}
+
+	// 3. Tạo tất cả đơn hàng trong một transaction, theo thứ tự dòng trong file
+	created := make([]*models.BetReceipt, 0, len(valid))
+	err = repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+		betReceiptRepo := s.betReceiptRepo.WithTx(tx)
+
+		var historyService *BetReceiptHistoryService
+		if s.historyRepo != nil {
+			historyService = NewBetReceiptHistoryService(s.historyRepo.WithTx(tx))
+		}
+
+		for _, item := range valid {
+			betReceipt, err := s.newBetReceipt(item.req, item.user)
+			if err != nil {
+				return fmt.Errorf("dòng %d: %w", item.row, err)
+			}
+			// Bộ đếm STT bị khóa đến hết transaction nên STT liên tiếp theo thứ tự file
+			if err := s.createBetReceipt(betReceiptRepo, betReceipt); err != nil {
+				return fmt.Errorf("dòng %d: %w", item.row, err)
+			}
+			betReceipt.UserName = item.user.Name
+
+			if historyService != nil {
+				newData, _ := betReceiptToMap(betReceipt)
+				historyReq := &models.CreateHistoryRequest{
+					BetReceiptID: betReceipt.ID,
+					Action:       models.HistoryActionCreate,
+					PerformedBy:  performedBy,
+					NewData:      newData,
+					Description:  fmt.Sprintf("Import từ file (dòng %d)", item.row),
+				}
+				if err := historyService.CreateHistory(historyReq); err != nil {
+					return fmt.Errorf("dòng %d: lỗi ghi lịch sử: %w", item.row, err)
+				}
+			}
+
+			created = append(created, betReceipt)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Service - ❌ Lỗi import đơn hàng (đã rollback): %v", err)
+		return nil, errors.New("Lỗi khi import đơn hàng: " + err.Error())
+	}
+
+	report.Committed = true
+	report.Created = created
+	log.Printf("Service - ✅ Đã import %d đơn hàng", len(created))
+	return report, nil
+}
+
+// normalizeImportHeader chuẩn hóa tiêu đề cột: chữ thường, gộp khoảng trắng
+func normalizeImportHeader(header string) string {
+	return strings.ToLower(strings.Join(strings.Fields(header), " "))
+}
+
+// mapImportColumns xác định vị trí các cột từ dòng tiêu đề
+// Trả về map tên cột (tên JSON) -> chỉ số cột, ValidationError nếu thiếu cột bắt buộc
+func mapImportColumns(header []string) (map[string]int, error) {
+	aliases := map[string]string{}
+	for column, headers := range models.BetReceiptImportColumns {
+		aliases[column] = column
+		for _, h := range headers {
+			aliases[normalizeImportHeader(h)] = column
+		}
+	}
+
+	columns := map[string]int{}
+	for i, h := range header {
+		column, ok := aliases[normalizeImportHeader(h)]
+		if !ok {
+			continue // Bỏ qua cột không dùng (vd: STT, Tiến độ)
+		}
+		if _, exists := columns[column]; exists {
+			return nil, newValidationError(fmt.Sprintf("Cột '%s' bị lặp lại trong dòng tiêu đề", h))
+		}
+		columns[column] = i
+	}
+
+	missing := []string{}
+	for _, column := range models.BetReceiptImportRequiredColumns {
+		if _, ok := columns[column]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, newValidationError("Thiếu cột bắt buộc: " + strings.Join(missing, ", "))
+	}
+
+	return columns, nil
+}
+
+// parseImportRow chuyển một dòng thành CreateBetReceiptRequest và kiểm tra các trường
+func parseImportRow(row []string, columns map[string]int, rowNumber int) (*models.CreateBetReceiptRequest, []*models.BetReceiptImportError) {
+	value := func(column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var rowErrors []*models.BetReceiptImportError
+	addError := func(column, message string) {
+		rowErrors = append(rowErrors, &models.BetReceiptImportError{Row: rowNumber, Column: column, Message: message})
+	}
+
+	req := &models.CreateBetReceiptRequest{
+		UserName:  value(models.ImportColumnUserName),
+		TaskCode:  value(models.ImportColumnTaskCode),
+		OrderCode: value(models.ImportColumnOrderCode),
+		Notes:     value(models.ImportColumnNotes),
+		Account:   value(models.ImportColumnAccount),
+		Password:  value(models.ImportColumnPassword),
+		Region:    value(models.ImportColumnRegion),
+	}
+
+	if req.UserName == "" {
+		addError(models.ImportColumnUserName, "Thiếu tên người dùng")
+	}
+	if req.TaskCode == "" {
+		addError(models.ImportColumnTaskCode, "Thiếu mã nhiệm vụ")
+	}
+
+	// Loại kèo: chấp nhận không phân biệt hoa thường, lưu theo giá trị chuẩn
+	switch betType := value(models.ImportColumnBetType); strings.ToLower(betType) {
+	case strings.ToLower(models.BetTypeWeb):
+		req.BetType = models.BetTypeWeb
+	case strings.ToLower(models.BetTypeExternal):
+		req.BetType = models.BetTypeExternal
+	case "":
+		addError(models.ImportColumnBetType, "Thiếu loại kèo")
+	default:
+		addError(models.ImportColumnBetType, "Loại kèo '"+betType+"' không hợp lệ. Phải là 'web' hoặc 'Kèo ngoài'")
+	}
+
+	if amountStr := value(models.ImportColumnWebBetAmountCNY); amountStr == "" {
+		addError(models.ImportColumnWebBetAmountCNY, "Thiếu tiền kèo web")
+	} else if amount, err := money.ParseCNY(normalizeImportNumber(amountStr)); err != nil {
+		addError(models.ImportColumnWebBetAmountCNY, "Tiền kèo web '"+amountStr+"' không phải là số")
+	} else if amount <= 0 {
+		addError(models.ImportColumnWebBetAmountCNY, "Tiền kèo web phải lớn hơn 0")
+	} else {
+		req.WebBetAmountCNY = amount
+	}
+
+	if hoursStr := value(models.ImportColumnCompletedHours); hoursStr != "" {
+		hours, err := strconv.ParseFloat(normalizeImportNumber(hoursStr), 64)
+		if err != nil || hours < 0 || hours != float64(int(hours)) {
+			addError(models.ImportColumnCompletedHours, "Thời gian hoàn thành '"+hoursStr+"' phải là số giờ nguyên không âm")
+		} else {
+			completedHours := int(hours)
+			req.CompletedHours = &completedHours
+		}
+	}
+
+	return req, rowErrors
+}
+
+// normalizeImportNumber chuẩn hóa số từ ô bảng tính về dạng thập phân dùng dấu chấm
+// - "1,234.5" và "1,000": dấu phẩy phân cách hàng nghìn
+// - "12,5": dấu phẩy thập phân (khi không có dấu chấm và phần sau dấu phẩy không phải 3 chữ số)
+func normalizeImportNumber(value string) string {
+	value = strings.ReplaceAll(value, " ", "")
+	if i := strings.LastIndex(value, ","); i >= 0 {
+		if strings.Contains(value, ".") || len(value)-i-1 == 3 {
+			value = strings.ReplaceAll(value, ",", "")
+		} else {
+			value = strings.Replace(value, ",", ".", 1)
+		}
+	}
+	return value
+}
+
+// isBlankRow kiểm tra dòng không có ô nào có dữ liệu
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
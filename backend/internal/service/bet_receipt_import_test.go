@@ -0,0 +1,115 @@
+package service
+
+import (
+	"errors"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/pkg/money"
+	"testing"
+)
+
+func TestMapImportColumns(t *testing.T) {
+	columns, err := mapImportColumns([]string{"STT", " Tên ", "MÃ  NHIỆM VỤ", "Loại kèo", "Tiền kèo web (tệ)", "web_bet_amount_cny_khac", "Số giờ"})
+	if err != nil {
+		t.Fatalf("mapImportColumns() error = %v", err)
+	}
+	want := map[string]int{
+		models.ImportColumnUserName:        1,
+		models.ImportColumnTaskCode:        2,
+		models.ImportColumnBetType:         3,
+		models.ImportColumnWebBetAmountCNY: 4,
+		models.ImportColumnCompletedHours:  6,
+	}
+	if len(columns) != len(want) {
+		t.Errorf("mapImportColumns() = %v, muốn %v", columns, want)
+	}
+	for column, i := range want {
+		if columns[column] != i {
+			t.Errorf("mapImportColumns()[%s] = %d, muốn %d", column, columns[column], i)
+		}
+	}
+
+	invalid := []struct {
+		name   string
+		header []string
+	}{
+		{name: "cột bị lặp lại", header: []string{"tên", "người dùng", "mã nhiệm vụ", "loại kèo", "tiền kèo"}},
+		{name: "thiếu cột bắt buộc", header: []string{"tên", "mã nhiệm vụ", "loại kèo"}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mapImportColumns(tt.header)
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("mapImportColumns(%v) error = %v, muốn *ValidationError", tt.header, err)
+			}
+		})
+	}
+}
+
+func TestParseImportRow(t *testing.T) {
+	columns := map[string]int{
+		models.ImportColumnUserName:        0,
+		models.ImportColumnTaskCode:        1,
+		models.ImportColumnBetType:         2,
+		models.ImportColumnWebBetAmountCNY: 3,
+		models.ImportColumnCompletedHours:  4,
+	}
+
+	tests := []struct {
+		name        string
+		row         []string
+		wantColumns []string // Các cột bị lỗi, theo thứ tự
+	}{
+		{name: "dòng hợp lệ", row: []string{"An", "NV01", "WEB", "1,234.5", "3"}},
+		{name: "không có cột số giờ", row: []string{"An", "NV01", "Kèo ngoài", "100"}},
+		{name: "thiếu tên và mã nhiệm vụ", row: []string{" ", "", "web", "100"}, wantColumns: []string{models.ImportColumnUserName, models.ImportColumnTaskCode}},
+		{name: "loại kèo không hợp lệ", row: []string{"An", "NV01", "live", "100"}, wantColumns: []string{models.ImportColumnBetType}},
+		{name: "tiền kèo không phải là số", row: []string{"An", "NV01", "web", "1e3"}, wantColumns: []string{models.ImportColumnWebBetAmountCNY}},
+		{name: "tiền kèo bằng 0", row: []string{"An", "NV01", "web", "0"}, wantColumns: []string{models.ImportColumnWebBetAmountCNY}},
+		{name: "số giờ lẻ", row: []string{"An", "NV01", "web", "100", "1,5"}, wantColumns: []string{models.ImportColumnCompletedHours}},
+		{name: "số giờ âm", row: []string{"An", "NV01", "web", "100", "-2"}, wantColumns: []string{models.ImportColumnCompletedHours}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, rowErrors := parseImportRow(tt.row, columns, 2)
+			if len(rowErrors) != len(tt.wantColumns) {
+				t.Fatalf("parseImportRow() có %d lỗi, muốn %d", len(rowErrors), len(tt.wantColumns))
+			}
+			for i, rowErr := range rowErrors {
+				if rowErr.Row != 2 || rowErr.Column != tt.wantColumns[i] {
+					t.Errorf("lỗi %d = dòng %d cột %s, muốn dòng 2 cột %s", i, rowErr.Row, rowErr.Column, tt.wantColumns[i])
+				}
+			}
+			if req == nil {
+				t.Fatal("parseImportRow() request = nil")
+			}
+		})
+	}
+
+	req, _ := parseImportRow([]string{"An", "NV01", "WEB", "1,234.5", "3"}, columns, 2)
+	if req.BetType != models.BetTypeWeb {
+		t.Errorf("BetType = %q, muốn %q", req.BetType, models.BetTypeWeb)
+	}
+	if want, _ := money.ParseCNY("1234.5"); req.WebBetAmountCNY != want {
+		t.Errorf("WebBetAmountCNY = %s, muốn %s", req.WebBetAmountCNY, want)
+	}
+	if req.CompletedHours == nil || *req.CompletedHours != 3 {
+		t.Errorf("CompletedHours = %v, muốn 3", req.CompletedHours)
+	}
+}
+
+func TestNormalizeImportNumber(t *testing.T) {
+	tests := map[string]string{
+		"1,234.5":  "1234.5",
+		"1,000":    "1000",
+		"12,5":     "12.5",
+		"1 000,25": "1000.25",
+		"100":      "100",
+	}
+	for input, want := range tests {
+		if got := normalizeImportNumber(input); got != want {
+			t.Errorf("normalizeImportNumber(%q) = %q, muốn %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"fullstack-backend/internal/models"
+	"log"
+	"strings"
+)
+
+// QuoteBetReceipt báo giá "Công thực nhận" cho loại kèo và giá kèo, không ghi DB
+// Dùng cùng công thức, biểu phí đang có hiệu lực và tỷ giá hiện tại như khi đơn hàng chuyển status:
+// - DONE (mặc định): tính phí trên giá kèo
+// - HỦY BỎ: tính phí trên tiền thực nhận (actual_received_cny), tiền thực nhận = 0 thì không có phí
+func (s *BetReceiptService) QuoteBetReceipt(req *models.BetReceiptQuoteRequest) (*models.BetReceiptQuote, error) {
+	if req.BetType != models.BetTypeWeb && req.BetType != models.BetTypeExternal {
+		return nil, newValidationError("Loại kèo không hợp lệ. Phải là 'web' hoặc 'Kèo ngoài'")
+	}
+	if req.WebBetAmountCNY <= 0 {
+		return nil, newValidationError("Tiền kèo web phải lớn hơn 0")
+	}
+
+	status := strings.TrimSpace(req.Status)
+	if status == "" {
+		status = models.BetReceiptStatusDone
+	}
+	amount := req.WebBetAmountCNY
+	switch status {
+	case models.BetReceiptStatusDone:
+	case models.BetReceiptStatusCancelled:
+		// Kiểm tra tiền thực nhận giống PATCH /bet-receipts/:id/status (*StatusFieldRequiredError)
+		if err := validateStatusFields(&models.UpdateBetReceiptStatusRequest{Status: status, ActualReceivedCNY: req.ActualReceivedCNY}); err != nil {
+			return nil, err
+		}
+		amount = *req.ActualReceivedCNY
+	default:
+		return nil, newValidationError("Chỉ báo giá cho status '" + models.BetReceiptStatusDone + "' hoặc '" + models.BetReceiptStatusCancelled + "'")
+	}
+
+	quote := &models.BetReceiptQuote{
+		BetType:         req.BetType,
+		Status:          status,
+		WebBetAmountCNY: req.WebBetAmountCNY,
+	}
+	if amount > 0 {
+		schedule, err := s.feeScheduleForStatus(status)
+		if err != nil {
+			return nil, err
+		}
+		quote.FeeBreakdown, err = calculateFeeBreakdown(schedule, req.BetType, amount)
+		if err != nil {
+			return nil, err
+		}
+		quote.FeeScheduleVersion = &schedule.Version
+		quote.FeeScheduleName = schedule.Name
+	}
+
+	exchangeRate, err := s.GetCurrentExchangeRate()
+	if err != nil {
+		return nil, err
+	}
+	quote.ExchangeRate = exchangeRate
+	quote.NetVND, err = quote.NetCNY.ToVND(exchangeRate)
+	if err != nil {
+		return nil, newValidationError(err.Error())
+	}
+
+	log.Printf("Service - 📊 Báo giá - Loại kèo: %s, Status: %s, Tiền tính phí: %s, Công thực nhận: %s (%s VND, tỷ giá %s)",
+		quote.BetType, quote.Status, quote.AmountCNY, quote.NetCNY, quote.NetVND, quote.ExchangeRate)
+	return quote, nil
+}
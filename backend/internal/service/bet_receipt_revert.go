@@ -0,0 +1,173 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"log"
+)
+
+// ErrHistoryNotFound - Bản ghi lịch sử không tồn tại hoặc không thuộc về đơn hàng
+var ErrHistoryNotFound = errors.New("Không tìm thấy bản ghi lịch sử của đơn hàng")
+
+// RevertBetReceipt hoàn tác đơn hàng về phiên bản lưu trong bản ghi lịch sử historyID
+// snapshot = "old" (mặc định): trạng thái trước thay đổi đó; "new": trạng thái sau thay đổi đó
+// Hoàn tác là thao tác sửa dữ liệu của admin nên không kiểm tra bước chuyển status,
+// nhưng phiên bản đích phải hợp lệ với dữ liệu hiện tại (người dùng còn tồn tại, đủ trường bắt buộc của status, biểu phí còn tồn tại)
+// Ghi đè dữ liệu, tính lại wallet và ghi lịch sử REVERT trong cùng một transaction
+func (s *BetReceiptService) RevertBetReceipt(id, historyID, snapshot string, performedBy *string) (*models.BetReceipt, error) {
+	log.Printf("Service - Hoàn tác đơn hàng ID: %s về lịch sử %s (%s)", id, historyID, snapshot)
+
+	if snapshot == "" {
+		snapshot = models.HistorySnapshotOld
+	}
+	if snapshot != models.HistorySnapshotOld && snapshot != models.HistorySnapshotNew {
+		return nil, newValidationError("snapshot chỉ nhận 'old' hoặc 'new'")
+	}
+	if s.historyRepo == nil {
+		return nil, ErrHistoryNotFound
+	}
+
+	history, err := s.historyRepo.GetByID(historyID)
+	if err != nil {
+		return nil, errors.New("Lỗi khi lấy lịch sử: " + err.Error())
+	}
+	if history == nil || history.BetReceiptID != id {
+		return nil, ErrHistoryNotFound
+	}
+
+	data := history.OldData
+	if snapshot == models.HistorySnapshotNew {
+		data = history.NewData
+	}
+	if data == "" {
+		return nil, newValidationError(fmt.Sprintf("Bản ghi lịch sử %s (%s) không có dữ liệu '%s_data' để hoàn tác", historyID, history.Action, snapshot))
+	}
+	target := &models.BetReceipt{}
+	if err := json.Unmarshal([]byte(data), target); err != nil {
+		return nil, newValidationError("Dữ liệu lịch sử không hợp lệ: " + err.Error())
+	}
+
+	exchangeRate, err := s.GetCurrentExchangeRate()
+	if err != nil {
+		exchangeRate = models.DefaultExchangeRate
+	}
+
+	var reverted *models.BetReceipt
+	err = repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+		betReceiptRepo := s.betReceiptRepo.WithTx(tx)
+
+		// Khóa đơn hàng để không bị sửa đồng thời trong lúc hoàn tác
+		found, err := betReceiptRepo.LockByIDs([]string{id})
+		if err != nil {
+			return err
+		}
+		if !found[id] {
+			return ErrBetReceiptNotFound
+		}
+		current, err := betReceiptRepo.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if err := checkReceiptPeriodOpen(current); err != nil {
+			return err
+		}
+
+		if err := s.validateRevertTarget(target); err != nil {
+			return err
+		}
+
+		// Chỉ lấy các trường dữ liệu từ phiên bản cũ; ID, STT, tài khoản/mật khẩu giữ nguyên
+		target.ID = current.ID
+		if err := betReceiptRepo.UpdateFromSnapshot(target); err != nil {
+			return err
+		}
+		reverted, err = betReceiptRepo.FindByID(id)
+		if err != nil {
+			return err
+		}
+
+		// Tính lại wallet của người dùng cũ và mới (phiên bản cũ có thể thuộc người dùng khác)
+		walletRepo := s.walletRepo.WithTx(tx)
+		recalculated := map[string]bool{}
+		for _, b := range []*models.BetReceipt{current, reverted} {
+			if !isProcessedStatus(b.Status) || recalculated[b.UserID] {
+				continue
+			}
+			if err := walletRepo.RecalculateTotalReceived(b.UserID, exchangeRate); err != nil {
+				return err
+			}
+			recalculated[b.UserID] = true
+		}
+
+		oldData, _ := betReceiptToMap(current)
+		newData, _ := betReceiptToMap(reverted)
+		historyReq := &models.CreateHistoryRequest{
+			BetReceiptID:  id,
+			Action:        models.HistoryActionRevert,
+			PerformedBy:   performedBy,
+			OldData:       oldData,
+			NewData:       newData,
+			ChangedFields: repository.FindChangedFields(oldData, newData),
+			Description: fmt.Sprintf("Hoàn tác về phiên bản %s của lịch sử %s (%s lúc %s)",
+				snapshot, historyID, history.Action, history.CreatedAt.Format("2006-01-02 15:04:05")),
+		}
+		return NewBetReceiptHistoryService(s.historyRepo.WithTx(tx)).CreateHistory(historyReq)
+	})
+	if err != nil {
+		var validationErr *ValidationError
+		if errors.Is(err, ErrBetReceiptNotFound) || errors.As(err, &validationErr) {
+			return nil, err
+		}
+		log.Printf("Service - ❌ Lỗi hoàn tác đơn hàng: %v", err)
+		return nil, errors.New("Lỗi khi hoàn tác đơn hàng: " + err.Error())
+	}
+
+	log.Printf("Service - ✅ Đã hoàn tác đơn hàng ID: %s, status: %s", id, reverted.Status)
+	return reverted, nil
+}
+
+// validateRevertTarget kiểm tra phiên bản đích còn hợp lệ với dữ liệu hiện tại, trả về ValidationError nếu không
+func (s *BetReceiptService) validateRevertTarget(target *models.BetReceipt) error {
+	if target.UserID == "" {
+		// Phiên bản đơn hàng trong pool (chưa có người nhận)
+		if target.Status != models.BetReceiptStatusNew {
+			return newValidationError("Không thể hoàn tác: đơn hàng chưa có người nhận chỉ được ở status '" + models.BetReceiptStatusNew + "'")
+		}
+	} else if _, err := s.userRepo.FindByID(target.UserID); err != nil {
+		if err == sql.ErrNoRows {
+			return newValidationError("Không thể hoàn tác: người dùng của phiên bản này không còn tồn tại")
+		}
+		return err
+	}
+	if target.BetType != models.BetTypeWeb && target.BetType != models.BetTypeExternal {
+		return newValidationError("Không thể hoàn tác: loại kèo '" + target.BetType + "' không hợp lệ")
+	}
+	if !models.IsValidBetReceiptStatus(target.Status) {
+		return newValidationError("Không thể hoàn tác: status '" + target.Status + "' không hợp lệ")
+	}
+
+	cancelReason := target.CancelReason
+	statusReq := &models.UpdateBetReceiptStatusRequest{
+		Status:            target.Status,
+		ActualReceivedCNY: &target.ActualReceivedCNY,
+		CompensationCNY:   &target.CompensationCNY,
+		CancelReason:      &cancelReason,
+	}
+	if err := validateStatusFields(statusReq); err != nil {
+		return newValidationError("Không thể hoàn tác: " + err.Error())
+	}
+
+	if target.FeeScheduleVersion != nil {
+		if _, err := findFeeSchedule(s.feeScheduleRepo, *target.FeeScheduleVersion); err != nil {
+			if err == ErrFeeScheduleNotFound {
+				return newValidationError(fmt.Sprintf("Không thể hoàn tác: biểu phí version %d không còn tồn tại", *target.FeeScheduleVersion))
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"fmt"
+	"fullstack-backend/internal/models"
+	"strings"
+)
+
+// StatusTransitionError - Lỗi khi chuyển status không nằm trong bảng models.BetReceiptStatusTransitions
+type StatusTransitionError struct {
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (e *StatusTransitionError) Error() string {
+	if !models.IsValidBetReceiptStatus(e.To) {
+		return fmt.Sprintf("Status '%s' không hợp lệ", e.To)
+	}
+	if len(e.Allowed) == 0 {
+		return fmt.Sprintf("Không thể chuyển status từ '%s' sang '%s'", e.From, e.To)
+	}
+	return fmt.Sprintf("Không thể chuyển status từ '%s' sang '%s'. Các status được phép: %s",
+		e.From, e.To, strings.Join(e.Allowed, ", "))
+}
+
+// StatusFieldRequiredError - Lỗi khi thiếu trường bắt buộc cho status đích
+type StatusFieldRequiredError struct {
+	Status  string
+	Field   string
+	Message string
+}
+
+func (e *StatusFieldRequiredError) Error() string {
+	return e.Message
+}
+
+// validateStatusTransition kiểm tra bước chuyển status và các trường bắt buộc của status đích
+// Trả về *StatusTransitionError hoặc *StatusFieldRequiredError
+func validateStatusTransition(from string, req *models.UpdateBetReceiptStatusRequest) error {
+	if !models.CanTransitionBetReceiptStatus(from, req.Status) {
+		return &StatusTransitionError{
+			From:    from,
+			To:      req.Status,
+			Allowed: models.BetReceiptStatusTransitions[from],
+		}
+	}
+	return validateStatusFields(req)
+}
+
+// validateAssignee kiểm tra đơn hàng trong pool (chưa có người nhận) chỉ được giữ status "Đơn hàng mới"
+// Trả về *StatusFieldRequiredError
+func validateAssignee(betReceipt *models.BetReceipt, req *models.UpdateBetReceiptStatusRequest) error {
+	if betReceipt.UserID == "" && req.Status != models.BetReceiptStatusNew {
+		return &StatusFieldRequiredError{
+			Status:  req.Status,
+			Field:   "user_id",
+			Message: "Đơn hàng chưa có người nhận, phải được nhận hoặc giao cho người dùng trước khi chuyển status",
+		}
+	}
+	return nil
+}
+
+// validateStatusFields kiểm tra các trường bắt buộc của status đích (không kiểm tra bước chuyển)
+// Trả về *StatusFieldRequiredError
+func validateStatusFields(req *models.UpdateBetReceiptStatusRequest) error {
+	switch req.Status {
+	case models.BetReceiptStatusCancelled:
+		// Status = "HỦY BỎ": Yêu cầu nhập ActualReceivedCNY
+		if req.ActualReceivedCNY == nil {
+			return &StatusFieldRequiredError{
+				Status:  req.Status,
+				Field:   "actual_received_cny",
+				Message: "Khi chọn status 'Hủy bỏ', phải nhập 'Tiền kèo thực nhận' (ActualReceivedCNY)",
+			}
+		}
+		if *req.ActualReceivedCNY < 0 {
+			return &StatusFieldRequiredError{
+				Status:  req.Status,
+				Field:   "actual_received_cny",
+				Message: "Tiền kèo thực nhận không được âm",
+			}
+		}
+	case models.BetReceiptStatusCompensation:
+		// Status = "ĐỀN": Yêu cầu nhập CompensationCNY và CancelReason (lý do đền)
+		if req.CompensationCNY == nil {
+			return &StatusFieldRequiredError{
+				Status:  req.Status,
+				Field:   "compensation_cny",
+				Message: "Khi chọn status 'Đền', phải nhập 'Tiền đền' (CompensationCNY)",
+			}
+		}
+		if *req.CompensationCNY <= 0 {
+			return &StatusFieldRequiredError{
+				Status:  req.Status,
+				Field:   "compensation_cny",
+				Message: "Tiền đền phải lớn hơn 0",
+			}
+		}
+		if req.CancelReason == nil || *req.CancelReason == "" {
+			return &StatusFieldRequiredError{
+				Status:  req.Status,
+				Field:   "cancel_reason",
+				Message: "Khi chọn status 'Đền', phải nhập 'Lý do đền' (CancelReason)",
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetAllowedTransitions trả về status hiện tại và các status có thể chuyển tới của đơn hàng
+func (s *BetReceiptService) GetAllowedTransitions(id string) (string, []models.AllowedStatusTransition, error) {
+	betReceipt, err := s.betReceiptRepo.FindByID(id)
+	if err != nil {
+		return "", nil, err
+	}
+	return betReceipt.Status, models.GetAllowedStatusTransitions(betReceipt.Status), nil
+}
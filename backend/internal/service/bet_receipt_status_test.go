@@ -0,0 +1,169 @@
+package service
+
+import (
+	"errors"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/pkg/money"
+	"testing"
+)
+
+func cnyPtr(v int64) *money.CNY {
+	c := money.CNYFromInt(v)
+	return &c
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestValidateStatusTransition(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      string
+		req       models.UpdateBetReceiptStatusRequest
+		wantError string // "" = hợp lệ, "transition" = *StatusTransitionError, "field" = *StatusFieldRequiredError
+		wantField string
+	}{
+		{
+			name: "mới -> đang thực hiện",
+			from: models.BetReceiptStatusNew,
+			req:  models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusInProgress},
+		},
+		{
+			name:      "mới -> done không được phép",
+			from:      models.BetReceiptStatusNew,
+			req:       models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusDone},
+			wantError: "transition",
+		},
+		{
+			name:      "status không hợp lệ",
+			from:      models.BetReceiptStatusInProgress,
+			req:       models.UpdateBetReceiptStatusRequest{Status: "KHÔNG TỒN TẠI"},
+			wantError: "transition",
+		},
+		{
+			name:      "done chỉ được mở tranh chấp",
+			from:      models.BetReceiptStatusDone,
+			req:       models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusInProgress},
+			wantError: "transition",
+		},
+		{
+			name: "done -> chờ trọng tài",
+			from: models.BetReceiptStatusDone,
+			req:  models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusWaitingRef},
+		},
+		{
+			name: "hủy bỏ -> hủy bỏ để sửa số tiền",
+			from: models.BetReceiptStatusCancelled,
+			req:  models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusCancelled, ActualReceivedCNY: cnyPtr(1000)},
+		},
+		{
+			name:      "bước chuyển hợp lệ nhưng thiếu trường bắt buộc",
+			from:      models.BetReceiptStatusInProgress,
+			req:       models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusCancelled},
+			wantError: "field",
+			wantField: "actual_received_cny",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			err := validateStatusTransition(tt.from, &req)
+
+			var transitionErr *StatusTransitionError
+			var fieldErr *StatusFieldRequiredError
+			switch tt.wantError {
+			case "":
+				if err != nil {
+					t.Fatalf("validateStatusTransition() error = %v, muốn nil", err)
+				}
+			case "transition":
+				if !errors.As(err, &transitionErr) {
+					t.Fatalf("validateStatusTransition() error = %v, muốn *StatusTransitionError", err)
+				}
+				if transitionErr.From != tt.from || transitionErr.To != tt.req.Status {
+					t.Errorf("StatusTransitionError = %s -> %s, muốn %s -> %s", transitionErr.From, transitionErr.To, tt.from, tt.req.Status)
+				}
+			case "field":
+				if !errors.As(err, &fieldErr) {
+					t.Fatalf("validateStatusTransition() error = %v, muốn *StatusFieldRequiredError", err)
+				}
+				if fieldErr.Field != tt.wantField {
+					t.Errorf("StatusFieldRequiredError.Field = %q, muốn %q", fieldErr.Field, tt.wantField)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateStatusFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       models.UpdateBetReceiptStatusRequest
+		wantField string // "" = hợp lệ
+	}{
+		{
+			name: "status không có trường bắt buộc",
+			req:  models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusDone},
+		},
+		{
+			name:      "hủy bỏ thiếu tiền thực nhận",
+			req:       models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusCancelled},
+			wantField: "actual_received_cny",
+		},
+		{
+			name:      "hủy bỏ tiền thực nhận âm",
+			req:       models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusCancelled, ActualReceivedCNY: cnyPtr(-1)},
+			wantField: "actual_received_cny",
+		},
+		{
+			name: "hủy bỏ tiền thực nhận bằng 0",
+			req:  models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusCancelled, ActualReceivedCNY: cnyPtr(0)},
+		},
+		{
+			name:      "đền thiếu tiền đền",
+			req:       models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusCompensation, CancelReason: strPtr("trễ hạn")},
+			wantField: "compensation_cny",
+		},
+		{
+			name:      "đền tiền đền bằng 0",
+			req:       models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusCompensation, CompensationCNY: cnyPtr(0), CancelReason: strPtr("trễ hạn")},
+			wantField: "compensation_cny",
+		},
+		{
+			name:      "đền thiếu lý do",
+			req:       models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusCompensation, CompensationCNY: cnyPtr(500)},
+			wantField: "cancel_reason",
+		},
+		{
+			name:      "đền lý do rỗng",
+			req:       models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusCompensation, CompensationCNY: cnyPtr(500), CancelReason: strPtr("")},
+			wantField: "cancel_reason",
+		},
+		{
+			name: "đền đủ trường",
+			req:  models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusCompensation, CompensationCNY: cnyPtr(500), CancelReason: strPtr("trễ hạn")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			err := validateStatusFields(&req)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("validateStatusFields() error = %v, muốn nil", err)
+				}
+				return
+			}
+			var fieldErr *StatusFieldRequiredError
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("validateStatusFields() error = %v, muốn *StatusFieldRequiredError", err)
+			}
+			if fieldErr.Field != tt.wantField || fieldErr.Status != tt.req.Status {
+				t.Errorf("StatusFieldRequiredError = {%q, %q}, muốn {%q, %q}", fieldErr.Status, fieldErr.Field, tt.req.Status, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"fullstack-backend/pkg/pagination"
+	"log"
+)
+
+// GetDeletedBetReceipts lấy đơn hàng trong thùng rác (đã xóa mềm), cùng filter/phân trang với GetAllBetReceipts
+func (s *BetReceiptService) GetDeletedBetReceipts(filter *models.BetReceiptFilter) ([]*models.BetReceipt, int, pagination.Page, error) {
+	filter.Deleted = true
+	return s.GetAllBetReceipts(filter)
+}
+
+// RestoreBetReceipt khôi phục đơn hàng từ thùng rác
+// Khôi phục, ghi lịch sử RESTORE và tính lại wallet (nếu đơn hàng đã xử lý) trong cùng một transaction
+func (s *BetReceiptService) RestoreBetReceipt(id string, performedBy *string) (*models.BetReceipt, error) {
+	log.Printf("Service - Khôi phục đơn hàng ID: %s", id)
+
+	var restored *models.BetReceipt
+	err := repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+		betReceiptRepo := s.betReceiptRepo.WithTx(tx)
+
+		deleted, err := betReceiptRepo.FindDeletedByID(id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrBetReceiptNotFound
+			}
+			return err
+		}
+		if err := checkReceiptPeriodOpen(deleted); err != nil {
+			return err
+		}
+
+		found, err := betReceiptRepo.Restore(id)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrBetReceiptNotFound
+		}
+
+		restored, err = betReceiptRepo.FindByID(id)
+		if err != nil {
+			return err
+		}
+
+		if s.historyRepo != nil {
+			newData, _ := betReceiptToMap(restored)
+			historyReq := &models.CreateHistoryRequest{
+				BetReceiptID: id,
+				Action:       models.HistoryActionRestore,
+				PerformedBy:  performedBy,
+				NewData:      newData,
+				Description:  "Khôi phục đơn hàng từ thùng rác",
+			}
+			if err := NewBetReceiptHistoryService(s.historyRepo.WithTx(tx)).CreateHistory(historyReq); err != nil {
+				return err
+			}
+		}
+
+		// Đơn hàng đã xử lý được tính lại vào wallet như trước khi xóa
+		if isProcessedStatus(restored.Status) {
+			if err := s.walletRepo.WithTx(tx).RecalculateTotalReceived(restored.UserID, models.DefaultExchangeRate); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrBetReceiptNotFound) {
+			return nil, err
+		}
+		log.Printf("Service - ❌ Lỗi khôi phục đơn hàng: %v", err)
+		return nil, errors.New("Lỗi khi khôi phục đơn hàng: " + err.Error())
+	}
+
+	log.Printf("Service - ✅ Đã khôi phục đơn hàng ID: %s (STT: %s)", id, restored.STTCode)
+	return restored, nil
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"fullstack-backend/pkg/pagination"
+	"log"
+)
+
+var (
+	// ErrBetReceiptAlreadyClaimed - Đơn hàng không còn trong pool (đã có người nhận trước, hoặc không còn "Đơn hàng mới")
+	ErrBetReceiptAlreadyClaimed = errors.New("Đơn hàng đã có người nhận hoặc không còn trong pool")
+	// ErrClaimAccessDenied - Người dùng không được nhận/trả/xem lịch sử nhận của đơn hàng
+	ErrClaimAccessDenied = errors.New("Bạn không có quyền nhận/trả đơn hàng này")
+)
+
+// ClaimService - Pool đơn hàng mới: người nhận kèo tự nhận/trả đơn, admin giao/thu hồi đơn
+type ClaimService struct {
+	betReceiptService *BetReceiptService
+	betReceiptRepo    *repository.BetReceiptRepository
+	userRepo          *repository.UserRepository
+	walletRepo        *repository.WalletRepository
+	historyRepo       *repository.BetReceiptHistoryRepository
+	claimRepo         *repository.ClaimRepository
+	maxOpenClaims     int // Số đơn đang mở tối đa của một người khi tự nhận (config MAX_OPEN_CLAIMS, 0 = không giới hạn)
+}
+
+func NewClaimService(betReceiptService *BetReceiptService, betReceiptRepo *repository.BetReceiptRepository, userRepo *repository.UserRepository, walletRepo *repository.WalletRepository, historyRepo *repository.BetReceiptHistoryRepository, claimRepo *repository.ClaimRepository, maxOpenClaims int) *ClaimService {
+	return &ClaimService{
+		betReceiptService: betReceiptService,
+		betReceiptRepo:    betReceiptRepo,
+		userRepo:          userRepo,
+		walletRepo:        walletRepo,
+		historyRepo:       historyRepo,
+		claimRepo:         claimRepo,
+		maxOpenClaims:     maxOpenClaims,
+	}
+}
+
+// GetPool lấy đơn hàng trong pool (chưa có người nhận, "Đơn hàng mới"), cùng filter/phân trang với GetAllBetReceipts
+func (s *ClaimService) GetPool(filter *models.BetReceiptFilter) ([]*models.BetReceipt, int, pagination.Page, error) {
+	filter.Unassigned = true
+	filter.UserID = nil
+	filter.Statuses = []string{models.BetReceiptStatusNew}
+	return s.betReceiptService.GetAllBetReceipts(filter)
+}
+
+// ClaimBetReceipt người nhận kèo (role "user") tự nhận đơn hàng trong pool
+// Ai nhận trước được trước; không được vượt quá maxOpenClaims đơn đang mở
+func (s *ClaimService) ClaimBetReceipt(id, userID, role string) (*models.BetReceipt, error) {
+	if role != "user" {
+		return nil, ErrClaimAccessDenied
+	}
+
+	var claimed *models.BetReceipt
+	err := repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+		betReceiptRepo := s.betReceiptRepo.WithTx(tx)
+
+		// Các lần nhận đồng thời của cùng một người chạy lần lượt, để đếm số đơn đang mở chính xác
+		if err := betReceiptRepo.LockClaimsOfUser(userID); err != nil {
+			return err
+		}
+		if s.maxOpenClaims > 0 {
+			open, err := betReceiptRepo.CountOpenByUser(userID)
+			if err != nil {
+				return err
+			}
+			if open >= s.maxOpenClaims {
+				return newValidationError(fmt.Sprintf("Bạn đang có %d đơn hàng chưa xử lý xong (tối đa %d), hãy hoàn thành bớt trước khi nhận thêm", open, s.maxOpenClaims))
+			}
+		}
+
+		ok, err := betReceiptRepo.Claim(id, userID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if _, err := betReceiptRepo.FindByID(id); err == sql.ErrNoRows {
+				return ErrBetReceiptNotFound
+			}
+			return ErrBetReceiptAlreadyClaimed
+		}
+
+		claimed, err = betReceiptRepo.FindByID(id)
+		if err != nil {
+			return err
+		}
+		previous := *claimed
+		if err := s.betReceiptService.reallocatePoolSTTCode(betReceiptRepo, claimed); err != nil {
+			return err
+		}
+		previous.UserID = ""
+		previous.UserName = ""
+		return s.recordClaim(tx, models.ClaimActionClaim, &previous, claimed, &userID)
+	})
+	if err != nil {
+		return nil, s.wrapError("nhận đơn hàng", err)
+	}
+
+	log.Printf("Service - ✅ Người dùng %s đã nhận đơn hàng %s (STT: %s)", userID, id, claimed.STTCode)
+	return claimed, nil
+}
+
+// ReleaseBetReceipt người nhận kèo trả đơn hàng đã nhận về pool (chỉ khi còn "Đơn hàng mới")
+func (s *ClaimService) ReleaseBetReceipt(id, userID string) (*models.BetReceipt, error) {
+	return s.reassign(id, "", &userID, func(current *models.BetReceipt) (string, error) {
+		return checkRelease(current, userID)
+	})
+}
+
+// AssignBetReceipt admin giao/giao lại đơn hàng cho userID ("" = thu hồi về pool, chỉ khi còn "Đơn hàng mới")
+// Đơn hàng đã xử lý được tính lại wallet cho cả người nhận cũ và mới
+func (s *ClaimService) AssignBetReceipt(id, userID string, performedBy *string) (*models.BetReceipt, error) {
+	if userID != "" {
+		if _, err := s.userRepo.FindByID(userID); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, newValidationError("Người dùng được giao không tồn tại")
+			}
+			return nil, err
+		}
+	}
+	return s.reassign(id, userID, performedBy, func(current *models.BetReceipt) (string, error) {
+		return checkAssign(current, userID)
+	})
+}
+
+// checkRelease kiểm tra người nhận kèo userID được trả đơn hàng, trả về loại thao tác ghi vào lịch sử nhận đơn
+func checkRelease(current *models.BetReceipt, userID string) (string, error) {
+	if current.UserID != userID {
+		return "", ErrClaimAccessDenied
+	}
+	return models.ClaimActionRelease, nil
+}
+
+// checkAssign kiểm tra admin được giao đơn hàng cho userID ("" = thu hồi), trả về loại thao tác ghi vào lịch sử nhận đơn
+func checkAssign(current *models.BetReceipt, userID string) (string, error) {
+	if current.UserID == userID {
+		return "", newValidationError("Đơn hàng đã được giao cho người dùng này")
+	}
+	if userID == "" {
+		return models.ClaimActionUnassign, nil
+	}
+	return models.ClaimActionAssign, nil
+}
+
+// checkReturnToPool kiểm tra đơn hàng được trả về pool (userID = ""): chỉ khi còn "Đơn hàng mới"
+func checkReturnToPool(current *models.BetReceipt, userID string) error {
+	if userID == "" && current.Status != models.BetReceiptStatusNew {
+		return newValidationError("Chỉ đơn hàng ở trạng thái '" + models.BetReceiptStatusNew + "' mới được trả về pool")
+	}
+	return nil
+}
+
+// reassign đổi người nhận kèo của đơn hàng trong transaction (khóa đơn hàng)
+// check kiểm tra quyền trên dữ liệu hiện tại và trả về loại thao tác ghi vào lịch sử nhận đơn
+func (s *ClaimService) reassign(id, userID string, performedBy *string, check func(current *models.BetReceipt) (string, error)) (*models.BetReceipt, error) {
+	var updated *models.BetReceipt
+	err := repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+		betReceiptRepo := s.betReceiptRepo.WithTx(tx)
+
+		found, err := betReceiptRepo.LockByIDs([]string{id})
+		if err != nil {
+			return err
+		}
+		if !found[id] {
+			return ErrBetReceiptNotFound
+		}
+		current, err := betReceiptRepo.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if err := checkReceiptPeriodOpen(current); err != nil {
+			return err
+		}
+
+		action, err := check(current)
+		if err != nil {
+			return err
+		}
+		if err := checkReturnToPool(current, userID); err != nil {
+			return err
+		}
+
+		if err := betReceiptRepo.Assign(id, userID); err != nil {
+			return err
+		}
+		updated, err = betReceiptRepo.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if err := s.betReceiptService.reallocatePoolSTTCode(betReceiptRepo, updated); err != nil {
+			return err
+		}
+
+		// Đơn hàng đã xử lý: chuyển tiền nhận được từ wallet người cũ sang người mới
+		if isProcessedStatus(current.Status) {
+			exchangeRate, _ := s.betReceiptService.GetCurrentExchangeRate()
+			walletRepo := s.walletRepo.WithTx(tx)
+			for _, affectedUserID := range []string{current.UserID, userID} {
+				if err := walletRepo.RecalculateTotalReceived(affectedUserID, exchangeRate); err != nil {
+					return err
+				}
+			}
+		}
+
+		return s.recordClaim(tx, action, current, updated, performedBy)
+	})
+	if err != nil {
+		return nil, s.wrapError("đổi người nhận đơn hàng", err)
+	}
+
+	log.Printf("Service - ✅ Đã đổi người nhận đơn hàng %s sang '%s'", id, userID)
+	return updated, nil
+}
+
+// GetClaimHistory lấy lịch sử nhận/trả/giao của đơn hàng (admin hoặc người đang nhận đơn)
+func (s *ClaimService) GetClaimHistory(id, userID, role string) ([]*models.BetReceiptClaim, error) {
+	betReceipt, err := s.betReceiptRepo.FindByID(id)
+	if err == sql.ErrNoRows {
+		return nil, ErrBetReceiptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" && betReceipt.UserID != userID {
+		return nil, ErrClaimAccessDenied
+	}
+	return s.claimRepo.GetByBetReceiptID(id)
+}
+
+// recordClaim ghi lịch sử nhận đơn và lịch sử đơn hàng (UPDATE) trong transaction tx
+func (s *ClaimService) recordClaim(tx *sql.Tx, action string, before, after *models.BetReceipt, performedBy *string) error {
+	claim := &models.BetReceiptClaim{
+		BetReceiptID: after.ID,
+		Action:       action,
+		PerformedBy:  performedBy,
+	}
+	if before.UserID != "" {
+		claim.FromUserID = &before.UserID
+	}
+	if after.UserID != "" {
+		claim.ToUserID = &after.UserID
+	}
+	if err := s.claimRepo.WithTx(tx).Create(claim); err != nil {
+		return err
+	}
+
+	if s.historyRepo == nil {
+		return nil
+	}
+	oldData, _ := betReceiptToMap(before)
+	newData, _ := betReceiptToMap(after)
+	historyReq := &models.CreateHistoryRequest{
+		BetReceiptID:  after.ID,
+		Action:        models.HistoryActionUpdate,
+		PerformedBy:   performedBy,
+		OldData:       oldData,
+		NewData:       newData,
+		ChangedFields: repository.FindChangedFields(oldData, newData),
+		Description:   claimDescriptions[action],
+	}
+	return NewBetReceiptHistoryService(s.historyRepo.WithTx(tx)).CreateHistory(historyReq)
+}
+
+var claimDescriptions = map[string]string{
+	models.ClaimActionClaim:    "Người nhận kèo tự nhận đơn hàng từ pool",
+	models.ClaimActionRelease:  "Người nhận kèo trả đơn hàng về pool",
+	models.ClaimActionAssign:   "Admin giao đơn hàng",
+	models.ClaimActionUnassign: "Admin thu hồi đơn hàng về pool",
+}
+
+// wrapError giữ nguyên các lỗi handler cần phân biệt (404, 403, 409, 400), các lỗi khác bọc thông báo chung
+func (s *ClaimService) wrapError(operation string, err error) error {
+	var validationErr *ValidationError
+	if errors.Is(err, ErrBetReceiptNotFound) || errors.Is(err, ErrBetReceiptAlreadyClaimed) ||
+		errors.Is(err, ErrClaimAccessDenied) || errors.As(err, &validationErr) {
+		return err
+	}
+	log.Printf("Service - ❌ Lỗi %s: %v", operation, err)
+	return errors.New("Lỗi khi " + operation + ": " + err.Error())
+}
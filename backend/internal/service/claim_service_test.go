@@ -0,0 +1,104 @@
+package service
+
+import (
+	"errors"
+	"fullstack-backend/internal/models"
+	"testing"
+)
+
+func TestCheckRelease(t *testing.T) {
+	current := &models.BetReceipt{UserID: "worker", Status: models.BetReceiptStatusNew}
+
+	action, err := checkRelease(current, "worker")
+	if err != nil || action != models.ClaimActionRelease {
+		t.Errorf("checkRelease() người nhận = %q, %v, muốn %q, nil", action, err, models.ClaimActionRelease)
+	}
+	if _, err := checkRelease(current, "other"); !errors.Is(err, ErrClaimAccessDenied) {
+		t.Errorf("checkRelease() người khác error = %v, muốn %v", err, ErrClaimAccessDenied)
+	}
+}
+
+func TestCheckAssign(t *testing.T) {
+	tests := []struct {
+		name       string
+		current    models.BetReceipt
+		userID     string
+		wantAction string
+	}{
+		{name: "giao đơn trong pool", current: models.BetReceipt{}, userID: "worker", wantAction: models.ClaimActionAssign},
+		{name: "giao lại cho người khác", current: models.BetReceipt{UserID: "worker"}, userID: "other", wantAction: models.ClaimActionAssign},
+		{name: "thu hồi về pool", current: models.BetReceipt{UserID: "worker"}, userID: "", wantAction: models.ClaimActionUnassign},
+		{name: "giao cho người nhận hiện tại", current: models.BetReceipt{UserID: "worker"}, userID: "worker"},
+		{name: "thu hồi đơn đang trong pool", current: models.BetReceipt{}, userID: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, err := checkAssign(&tt.current, tt.userID)
+			if tt.wantAction == "" {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Errorf("checkAssign() error = %v, muốn *ValidationError", err)
+				}
+				return
+			}
+			if err != nil || action != tt.wantAction {
+				t.Errorf("checkAssign() = %q, %v, muốn %q, nil", action, err, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestCheckReturnToPool(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		userID  string
+		wantErr bool
+	}{
+		{name: "trả đơn hàng mới về pool", status: models.BetReceiptStatusNew},
+		{name: "trả đơn đang thực hiện về pool", status: models.BetReceiptStatusInProgress, wantErr: true},
+		{name: "trả đơn DONE về pool", status: models.BetReceiptStatusDone, wantErr: true},
+		{name: "giao đơn DONE cho người khác", status: models.BetReceiptStatusDone, userID: "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkReturnToPool(&models.BetReceipt{UserID: "worker", Status: tt.status}, tt.userID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkReturnToPool() error = %v, muốn lỗi = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckAssigneeName(t *testing.T) {
+	tests := []struct {
+		name        string
+		userName    string
+		currentName string
+		wantErr     bool
+	}{
+		{name: "giữ nguyên người nhận", userName: "An", currentName: "An"},
+		{name: "đơn trong pool, user_name rỗng", userName: "", currentName: ""},
+		{name: "đổi sang người khác", userName: "Bình", currentName: "An", wantErr: true},
+		{name: "giao đơn trong pool", userName: "An", currentName: "", wantErr: true},
+		{name: "bỏ người nhận", userName: "", currentName: "An", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAssigneeName(tt.userName, tt.currentName)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("checkAssigneeName(%q, %q) error = %v, muốn nil", tt.userName, tt.currentName, err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("checkAssigneeName(%q, %q) error = %v, muốn *ValidationError", tt.userName, tt.currentName, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	// ErrCommentNotFound - Bình luận không tồn tại, không thuộc đơn hàng hoặc là ghi chú nội bộ mà người dùng không được xem
+	ErrCommentNotFound = errors.New("Không tìm thấy bình luận")
+	// ErrCommentAccessDenied - Không có quyền xem/viết/sửa/xóa bình luận
+	ErrCommentAccessDenied = errors.New("Bạn không có quyền với bình luận của đơn hàng này")
+)
+
+type CommentService struct {
+	commentRepo      *repository.CommentRepository
+	betReceiptRepo   *repository.BetReceiptRepository
+	userRepo         *repository.UserRepository
+	notificationRepo *repository.NotificationRepository
+	editWindow       time.Duration // Thời hạn người viết được sửa/xóa bình luận (config COMMENT_EDIT_WINDOW)
+}
+
+func NewCommentService(commentRepo *repository.CommentRepository, betReceiptRepo *repository.BetReceiptRepository, userRepo *repository.UserRepository, notificationRepo *repository.NotificationRepository, editWindow time.Duration) *CommentService {
+	return &CommentService{
+		commentRepo:      commentRepo,
+		betReceiptRepo:   betReceiptRepo,
+		userRepo:         userRepo,
+		notificationRepo: notificationRepo,
+		editWindow:       editWindow,
+	}
+}
+
+// checkAccess kiểm tra đơn hàng tồn tại và người dùng là admin hoặc người nhận kèo
+func (s *CommentService) checkAccess(betReceiptID, userID, role string) (*models.BetReceipt, error) {
+	betReceipt, err := s.betReceiptRepo.FindByID(betReceiptID)
+	if err == sql.ErrNoRows {
+		return nil, ErrBetReceiptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" && betReceipt.UserID != userID {
+		return nil, ErrCommentAccessDenied
+	}
+	return betReceipt, nil
+}
+
+// AddComment thêm bình luận vào đơn hàng (admin hoặc người nhận kèo)
+// Ghi chú nội bộ chỉ admin được viết; người được @mention phải xem được bình luận và nhận thông báo
+func (s *CommentService) AddComment(betReceiptID, userID, role string, req *models.CreateBetReceiptCommentRequest) (*models.BetReceiptComment, error) {
+	betReceipt, err := s.checkAccess(betReceiptID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if req.Internal && role != "admin" {
+		return nil, ErrCommentAccessDenied
+	}
+	content, err := validateCommentContent(req.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	var mentioned *models.User
+	if req.MentionedUserID != nil && *req.MentionedUserID != "" {
+		mentioned, err = s.userRepo.FindByID(*req.MentionedUserID)
+		if err == sql.ErrNoRows {
+			return nil, newValidationError("Người dùng được nhắc đến không tồn tại")
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Chỉ nhắc đến người xem được bình luận: admin, hoặc người nhận kèo nếu không phải ghi chú nội bộ
+		if mentioned.Role != "admin" && (req.Internal || mentioned.ID != betReceipt.UserID) {
+			return nil, newValidationError("Chỉ có thể nhắc đến admin hoặc người nhận kèo của đơn hàng (ghi chú nội bộ: chỉ admin)")
+		}
+	}
+
+	comment := &models.BetReceiptComment{
+		BetReceiptID: betReceiptID,
+		AuthorID:     &userID,
+		Content:      content,
+		Internal:     req.Internal,
+	}
+	if mentioned != nil {
+		comment.MentionedUserID = &mentioned.ID
+	}
+
+	err = repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+		if err := s.commentRepo.WithTx(tx).Create(comment); err != nil {
+			return err
+		}
+		if mentioned == nil || mentioned.ID == userID {
+			return nil
+		}
+		notification := &models.Notification{
+			UserID:       mentioned.ID,
+			Type:         models.NotificationTypeCommentMention,
+			Title:        fmt.Sprintf("Bạn được nhắc đến trong đơn hàng #%s (%s)", betReceipt.STTCode, betReceipt.TaskCode),
+			Content:      content,
+			BetReceiptID: &betReceipt.ID,
+		}
+		return s.notificationRepo.WithTx(tx).Create(notification)
+	})
+	if err != nil {
+		log.Printf("Service - ❌ Lỗi thêm bình luận: %v", err)
+		return nil, errors.New("Lỗi khi thêm bình luận: " + err.Error())
+	}
+
+	created, err := s.commentRepo.FindByID(betReceiptID, comment.ID)
+	if err != nil {
+		return comment, nil
+	}
+	log.Printf("Service - ✅ Đã thêm bình luận %s cho đơn hàng %s", comment.ID, betReceiptID)
+	return created, nil
+}
+
+// GetComments lấy bình luận của đơn hàng (admin hoặc người nhận kèo), ghi chú nội bộ chỉ trả về cho admin
+func (s *CommentService) GetComments(betReceiptID, userID, role string) ([]*models.BetReceiptComment, error) {
+	if _, err := s.checkAccess(betReceiptID, userID, role); err != nil {
+		return nil, err
+	}
+	return s.commentRepo.GetByBetReceiptID(betReceiptID, role == "admin")
+}
+
+// UpdateComment sửa nội dung bình luận (chỉ người viết, trong thời hạn sửa)
+func (s *CommentService) UpdateComment(betReceiptID, id, userID, role string, req *models.UpdateBetReceiptCommentRequest) (*models.BetReceiptComment, error) {
+	comment, err := s.findComment(betReceiptID, id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if comment.AuthorID == nil || *comment.AuthorID != userID {
+		return nil, ErrCommentAccessDenied
+	}
+	if err := s.checkEditWindow(comment); err != nil {
+		return nil, err
+	}
+	content, err := validateCommentContent(req.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.commentRepo.UpdateContent(id, content); err != nil {
+		return nil, errors.New("Lỗi khi sửa bình luận: " + err.Error())
+	}
+	return s.commentRepo.FindByID(betReceiptID, id)
+}
+
+// DeleteComment xóa bình luận (admin bất cứ lúc nào, người viết trong thời hạn sửa)
+func (s *CommentService) DeleteComment(betReceiptID, id, userID, role string) error {
+	comment, err := s.findComment(betReceiptID, id, userID, role)
+	if err != nil {
+		return err
+	}
+	if role != "admin" {
+		if comment.AuthorID == nil || *comment.AuthorID != userID {
+			return ErrCommentAccessDenied
+		}
+		if err := s.checkEditWindow(comment); err != nil {
+			return err
+		}
+	}
+
+	if err := s.commentRepo.Delete(id); err != nil {
+		return errors.New("Lỗi khi xóa bình luận: " + err.Error())
+	}
+	log.Printf("Service - ✅ Đã xóa bình luận %s của đơn hàng %s", id, betReceiptID)
+	return nil
+}
+
+// findComment lấy bình luận người dùng được xem (ghi chú nội bộ coi như không tồn tại với người không phải admin)
+func (s *CommentService) findComment(betReceiptID, id, userID, role string) (*models.BetReceiptComment, error) {
+	if _, err := s.checkAccess(betReceiptID, userID, role); err != nil {
+		return nil, err
+	}
+	comment, err := s.commentRepo.FindByID(betReceiptID, id)
+	if err == sql.ErrNoRows || (err == nil && comment.Internal && role != "admin") {
+		return nil, ErrCommentNotFound
+	}
+	return comment, err
+}
+
+func (s *CommentService) checkEditWindow(comment *models.BetReceiptComment) error {
+	if time.Since(comment.CreatedAt) > s.editWindow {
+		return newValidationError(fmt.Sprintf("Chỉ có thể sửa/xóa bình luận trong vòng %s sau khi viết", s.editWindow))
+	}
+	return nil
+}
+
+func validateCommentContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", newValidationError("Nội dung bình luận không được để trống")
+	}
+	if utf8.RuneCountInString(content) > models.CommentMaxLength {
+		return "", newValidationError(fmt.Sprintf("Nội dung bình luận không được vượt quá %d ký tự", models.CommentMaxLength))
+	}
+	return content, nil
+}
@@ -1,9 +1,11 @@
 package service
 
 import (
+	"database/sql"
 	"errors"
 	"fullstack-backend/internal/models"
 	"fullstack-backend/internal/repository"
+	"fullstack-backend/pkg/pagination"
 	"log"
 )
 
@@ -25,7 +27,7 @@ func NewDepositService(depositRepo *repository.DepositRepository, userRepo *repo
 // req.UserName: tên người dùng (từ cột ten trong nguoi_dung)
 // req.AmountVND: số tiền VND cần nạp
 func (s *DepositService) CreateDeposit(req *models.CreateDepositRequest) (*models.Deposit, error) {
-	log.Printf("Service - Nạp tiền cho user_name: %s, AmountVND: %.2f", req.UserName, req.AmountVND)
+	log.Printf("Service - Nạp tiền cho user_name: %s, AmountVND: %s", req.UserName, req.AmountVND)
 
 	// 1. Tìm người dùng theo tên
 	users, err := s.userRepo.FindByName(req.UserName)
@@ -57,33 +59,47 @@ func (s *DepositService) CreateDeposit(req *models.CreateDepositRequest) (*model
 		Notes:     req.Notes,
 	}
 
-	if err := s.depositRepo.Create(deposit); err != nil {
-		log.Printf("Service - ❌ Lỗi tạo deposit: %v", err)
-		return nil, errors.New("Lỗi khi tạo deposit: " + err.Error())
-	}
-
-	// 3. Cập nhật wallet: cộng amountVND vào tong_coc_vnd và tính lại so_du_hien_tai_vnd
-	if err := s.walletRepo.AddToTotalDepositVND(foundUser.ID, req.AmountVND); err != nil {
-		log.Printf("Service - ❌ Lỗi cập nhật wallet: %v", err)
-		return nil, errors.New("Lỗi khi cập nhật wallet: " + err.Error())
+	// 3. Tạo deposit record và cập nhật wallet trong cùng một transaction
+	// (ghi bút toán deposit vào sổ cái và tính lại tong_coc_vnd, so_du_hien_tai_vnd)
+	err = repository.RunInTx(s.depositRepo.GetDB(), func(tx *sql.Tx) error {
+		if err := s.depositRepo.WithTx(tx).Create(deposit); err != nil {
+			log.Printf("Service - ❌ Lỗi tạo deposit: %v", err)
+			return errors.New("Lỗi khi tạo deposit: " + err.Error())
+		}
+		if err := s.walletRepo.WithTx(tx).RecalculateDeposits(foundUser.ID); err != nil {
+			log.Printf("Service - ❌ Lỗi cập nhật wallet: %v", err)
+			return errors.New("Lỗi khi cập nhật wallet: " + err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	log.Printf("Service - ✅ Đã nạp tiền thành công cho user ID: %s, AmountVND: %.2f",
+	log.Printf("Service - ✅ Đã nạp tiền thành công cho user ID: %s, AmountVND: %s",
 		foundUser.ID, req.AmountVND)
 
 	return deposit, nil
 }
 
-// GetAllDeposits lấy tất cả lịch sử nạp tiền
-func (s *DepositService) GetAllDeposits() ([]repository.DepositWithUser, error) {
-	log.Printf("Service - Lấy tất cả lịch sử nạp tiền")
+// GetAllDeposits lấy lịch sử nạp tiền (limit = 0 và không có cursor thì lấy tất cả)
+func (s *DepositService) GetAllDeposits(limit, offset int, cursorToken string) ([]repository.DepositWithUser, pagination.Page, error) {
+	log.Printf("Service - Lấy lịch sử nạp tiền (limit=%d, offset=%d)", limit, offset)
 
-	deposits, err := s.depositRepo.GetAll()
+	pageReq, err := newPageRequest(limit, offset, cursorToken, "thoi_gian_tao:desc", len(repository.DepositCursorColumns))
+	if err != nil {
+		return nil, pagination.Page{}, err
+	}
+
+	deposits, err := s.depositRepo.GetAll(pageReq)
 	if err != nil {
 		log.Printf("Service - ❌ Lỗi lấy danh sách deposits: %v", err)
-		return nil, err
+		return nil, pagination.Page{}, err
 	}
+	deposits, page := pagination.Paginate(deposits, pageReq, func(d repository.DepositWithUser) []interface{} {
+		return []interface{}{d.CreatedAt, d.ID}
+	})
 
 	log.Printf("Service - ✅ Đã lấy %d deposits", len(deposits))
-	return deposits, nil
+	return deposits, page, nil
 }
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrDisputeNotFound - Tranh chấp không tồn tại
+	ErrDisputeNotFound = errors.New("Không tìm thấy tranh chấp")
+	// ErrDisputeAccessDenied - Không có quyền mở/xem/quyết định tranh chấp
+	ErrDisputeAccessDenied = errors.New("Bạn không có quyền với tranh chấp này")
+	// ErrDisputeClosed - Tranh chấp đã có quyết định, không giao trọng tài/quyết định lại được
+	ErrDisputeClosed = errors.New("Tranh chấp đã có quyết định")
+	// ErrDisputeAlreadyOpen - Đơn hàng đã có tranh chấp đang mở
+	ErrDisputeAlreadyOpen = errors.New("Đơn hàng đã có tranh chấp đang mở")
+	// ErrDisputeFinalStatusAdminOnly - Chỉ admin được mở tranh chấp cho đơn hàng đã hoàn tất (DONE, HỦY BỎ, ĐỀN)
+	ErrDisputeFinalStatusAdminOnly = errors.New("Chỉ admin được mở tranh chấp cho đơn hàng đã hoàn tất")
+)
+
+// DisputeService - Tranh chấp (trọng tài) cho đơn hàng "CHỜ TRỌNG TÀI"
+// Mở tranh chấp và quyết định của trọng tài đều đổi status qua cùng logic với BetReceiptService.UpdateBetReceiptStatus
+// (trong transaction ghi tranh chấp), để công thực nhận, wallet và lịch sử đơn hàng được xử lý như cập nhật status thông thường
+type DisputeService struct {
+	disputeRepo       *repository.DisputeRepository
+	betReceiptService *BetReceiptService
+	betReceiptRepo    *repository.BetReceiptRepository
+	userRepo          *repository.UserRepository
+	attachmentRepo    *repository.AttachmentRepository
+	notificationRepo  *repository.NotificationRepository
+}
+
+func NewDisputeService(disputeRepo *repository.DisputeRepository, betReceiptService *BetReceiptService, betReceiptRepo *repository.BetReceiptRepository, userRepo *repository.UserRepository, attachmentRepo *repository.AttachmentRepository, notificationRepo *repository.NotificationRepository) *DisputeService {
+	return &DisputeService{
+		disputeRepo:       disputeRepo,
+		betReceiptService: betReceiptService,
+		betReceiptRepo:    betReceiptRepo,
+		userRepo:          userRepo,
+		attachmentRepo:    attachmentRepo,
+		notificationRepo:  notificationRepo,
+	}
+}
+
+// OpenDispute mở tranh chấp cho đơn hàng (admin hoặc người nhận kèo) và chuyển đơn hàng sang "CHỜ TRỌNG TÀI"
+// Đơn hàng đã ở "CHỜ TRỌNG TÀI" (chuyển status thủ công trước đó) chỉ được ghi thêm tranh chấp
+// Đơn hàng đã hoàn tất chỉ admin được mở tranh chấp (xem checkCanOpenDispute)
+func (s *DisputeService) OpenDispute(betReceiptID, userID, role string, req *models.OpenDisputeRequest) (*models.BetReceiptDispute, error) {
+	betReceipt, err := s.betReceiptRepo.FindByID(betReceiptID)
+	if err == sql.ErrNoRows {
+		return nil, ErrBetReceiptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCanOpenDispute(betReceipt, userID, role); err != nil {
+		return nil, err
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, newValidationError("Lý do tranh chấp không được để trống")
+	}
+
+	statusReq := &models.UpdateBetReceiptStatusRequest{Status: models.BetReceiptStatusWaitingRef}
+	needsTransition := betReceipt.Status != models.BetReceiptStatusWaitingRef
+	if needsTransition {
+		if err := validateStatusTransition(betReceipt.Status, statusReq); err != nil {
+			return nil, err
+		}
+		if err := validateAssignee(betReceipt, statusReq); err != nil {
+			return nil, err
+		}
+	}
+
+	dispute := &models.BetReceiptDispute{
+		BetReceiptID:   betReceiptID,
+		PreviousStatus: betReceipt.Status,
+		OpenedBy:       &userID,
+		Reason:         reason,
+		Evidence:       strings.TrimSpace(req.Evidence),
+	}
+	if betReceipt.UserID != "" {
+		dispute.WorkerID = &betReceipt.UserID
+	}
+	// Ghi tranh chấp và chuyển status trong cùng một transaction (không chuyển được status thì không có tranh chấp)
+	// Unique index idx_bet_receipt_disputes_open chặn hai tranh chấp đang mở cùng lúc cho một đơn hàng
+	var createErr error
+	err = repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+		if err := s.disputeRepo.WithTx(tx).Create(dispute); err != nil {
+			createErr = err
+			return err
+		}
+		if needsTransition {
+			_, err := s.betReceiptService.updateBetReceiptStatusTx(tx, betReceiptID, statusReq, &userID)
+			return err
+		}
+		return nil
+	})
+	if createErr != nil {
+		if _, findErr := s.disputeRepo.FindOpenByBetReceiptID(betReceiptID); findErr == nil {
+			return nil, ErrDisputeAlreadyOpen
+		}
+		return nil, errors.New("Lỗi khi mở tranh chấp: " + createErr.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Service - ✅ Đã mở tranh chấp %s cho đơn hàng %s (STT: %s)", dispute.ID, betReceiptID, betReceipt.STTCode)
+	return s.disputeRepo.FindByID(dispute.ID)
+}
+
+// checkCanOpenDispute kiểm tra quyền mở tranh chấp: admin hoặc người nhận kèo của đơn hàng
+// Người nhận kèo không được mở tranh chấp cho đơn hàng đã hoàn tất (DONE, HỦY BỎ, ĐỀN): chuyển sang "CHỜ TRỌNG TÀI"
+// đảo công thực nhận đã ghi vào wallet, người nhận kèo có thể tự đảo khoản đền/hủy của mình
+func checkCanOpenDispute(betReceipt *models.BetReceipt, userID, role string) error {
+	if role == "admin" {
+		return nil
+	}
+	if betReceipt.UserID != userID {
+		return ErrDisputeAccessDenied
+	}
+	if isProcessedStatus(betReceipt.Status) {
+		return ErrDisputeFinalStatusAdminOnly
+	}
+	return nil
+}
+
+// AssignReferee admin giao trọng tài (phải là admin) cho tranh chấp đang mở, trọng tài nhận thông báo
+func (s *DisputeService) AssignReferee(id, refereeID string) (*models.BetReceiptDispute, error) {
+	dispute, err := s.findDispute(id)
+	if err != nil {
+		return nil, err
+	}
+	if !dispute.IsOpen() {
+		return nil, ErrDisputeClosed
+	}
+
+	referee, err := s.userRepo.FindByID(refereeID)
+	if err == sql.ErrNoRows {
+		return nil, newValidationError("Trọng tài không tồn tại")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if referee.Role != "admin" {
+		return nil, newValidationError("Trọng tài phải là admin")
+	}
+
+	ok, err := s.disputeRepo.AssignReferee(id, refereeID)
+	if err != nil {
+		return nil, errors.New("Lỗi khi giao trọng tài: " + err.Error())
+	}
+	if !ok {
+		return nil, ErrDisputeClosed
+	}
+
+	s.notify(&models.Notification{
+		UserID:       refereeID,
+		Type:         models.NotificationTypeDisputeAssigned,
+		Title:        fmt.Sprintf("Bạn được giao làm trọng tài đơn hàng #%s (%s)", dispute.STTCode, dispute.TaskCode),
+		Content:      dispute.Reason,
+		BetReceiptID: &dispute.BetReceiptID,
+	})
+
+	log.Printf("Service - ✅ Đã giao trọng tài %s cho tranh chấp %s", refereeID, id)
+	return s.disputeRepo.FindByID(id)
+}
+
+// ResolveDispute trọng tài quyết định tranh chấp: DONE, HỦY BỎ (amount = tiền thực nhận) hoặc ĐỀN (amount = tiền đền, note = lý do đền)
+// Chỉ trọng tài được giao quyết định; tranh chấp chưa giao trọng tài thì admin bất kỳ quyết định
+func (s *DisputeService) ResolveDispute(id, userID, role string, req *models.ResolveDisputeRequest) (*models.BetReceiptDispute, error) {
+	dispute, err := s.findDispute(id)
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" || (dispute.RefereeID != nil && *dispute.RefereeID != userID) {
+		return nil, ErrDisputeAccessDenied
+	}
+	if !dispute.IsOpen() {
+		return nil, ErrDisputeClosed
+	}
+
+	statusReq, err := buildDecisionStatusRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ghi quyết định và áp dụng status cho đơn hàng trong cùng một transaction
+	note := strings.TrimSpace(req.Note)
+	err = repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+		betReceiptRepo := s.betReceiptRepo.WithTx(tx)
+		found, err := betReceiptRepo.LockByIDs([]string{dispute.BetReceiptID})
+		if err != nil {
+			return err
+		}
+		if !found[dispute.BetReceiptID] {
+			return ErrBetReceiptNotFound
+		}
+		betReceipt, err := betReceiptRepo.FindByID(dispute.BetReceiptID)
+		if err != nil {
+			return err
+		}
+		if betReceipt.Status != models.BetReceiptStatusWaitingRef {
+			return newValidationError("Đơn hàng không còn ở trạng thái '" + models.BetReceiptStatusWaitingRef + "', không thể áp dụng quyết định")
+		}
+
+		// Có điều kiện decided_at IS NULL để hai quyết định đồng thời không cùng đổi status
+		ok, err := s.disputeRepo.WithTx(tx).Resolve(id, req.Decision, req.AmountCNY, note, &userID)
+		if err != nil {
+			return errors.New("Lỗi khi ghi quyết định tranh chấp: " + err.Error())
+		}
+		if !ok {
+			return ErrDisputeClosed
+		}
+
+		_, err = s.betReceiptService.updateBetReceiptStatusTx(tx, dispute.BetReceiptID, statusReq, &userID)
+		return err
+	})
+	if err != nil {
+		log.Printf("Service - ❌ Áp dụng quyết định tranh chấp %s thất bại (đã rollback): %v", id, err)
+		return nil, err
+	}
+
+	if dispute.WorkerID != nil {
+		s.notify(&models.Notification{
+			UserID:       *dispute.WorkerID,
+			Type:         models.NotificationTypeDisputeResolved,
+			Title:        fmt.Sprintf("Tranh chấp đơn hàng #%s (%s) đã có quyết định: %s", dispute.STTCode, dispute.TaskCode, req.Decision),
+			Content:      note,
+			BetReceiptID: &dispute.BetReceiptID,
+		})
+	}
+
+	log.Printf("Service - ✅ Tranh chấp %s đã được quyết định: %s", id, req.Decision)
+	return s.disputeRepo.FindByID(id)
+}
+
+// buildDecisionStatusRequest chuyển quyết định của trọng tài thành request cập nhật status
+func buildDecisionStatusRequest(req *models.ResolveDisputeRequest) (*models.UpdateBetReceiptStatusRequest, error) {
+	statusReq := &models.UpdateBetReceiptStatusRequest{Status: req.Decision}
+	switch req.Decision {
+	case models.BetReceiptStatusDone:
+		if req.AmountCNY != nil {
+			return nil, newValidationError("Quyết định DONE không nhận số tiền (tiền thực nhận bằng tiền kèo web)")
+		}
+	case models.BetReceiptStatusCancelled:
+		if req.AmountCNY == nil {
+			return nil, newValidationError("Quyết định HỦY BỎ phải có số tiền thực nhận (amount_cny)")
+		}
+		statusReq.ActualReceivedCNY = req.AmountCNY
+	case models.BetReceiptStatusCompensation:
+		if req.AmountCNY == nil {
+			return nil, newValidationError("Quyết định ĐỀN phải có số tiền đền (amount_cny)")
+		}
+		note := strings.TrimSpace(req.Note)
+		statusReq.CompensationCNY = req.AmountCNY
+		statusReq.CancelReason = &note
+	default:
+		return nil, newValidationError(fmt.Sprintf("Quyết định không hợp lệ: %s (chỉ nhận %s, %s, %s)", req.Decision,
+			models.BetReceiptStatusDone, models.BetReceiptStatusCancelled, models.BetReceiptStatusCompensation))
+	}
+	return statusReq, validateStatusFields(statusReq)
+}
+
+// GetDispute lấy chi tiết tranh chấp kèm tệp bằng chứng (admin hoặc người nhận kèo của tranh chấp)
+func (s *DisputeService) GetDispute(id, userID, role string) (*models.BetReceiptDispute, error) {
+	dispute, err := s.findDispute(id)
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" && (dispute.WorkerID == nil || *dispute.WorkerID != userID) {
+		return nil, ErrDisputeAccessDenied
+	}
+
+	attachments, err := s.attachmentRepo.GetByBetReceiptID(dispute.BetReceiptID)
+	if err != nil {
+		return nil, err
+	}
+	for _, attachment := range attachments {
+		if attachment.Kind == models.AttachmentKindDispute {
+			dispute.Attachments = append(dispute.Attachments, attachment)
+		}
+	}
+	return dispute, nil
+}
+
+// GetBetReceiptDisputes lấy các tranh chấp của đơn hàng (admin hoặc người nhận kèo)
+func (s *DisputeService) GetBetReceiptDisputes(betReceiptID, userID, role string) ([]*models.BetReceiptDispute, error) {
+	betReceipt, err := s.betReceiptRepo.FindByID(betReceiptID)
+	if err == sql.ErrNoRows {
+		return nil, ErrBetReceiptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" && betReceipt.UserID != userID {
+		return nil, ErrDisputeAccessDenied
+	}
+	return s.disputeRepo.GetByBetReceiptID(betReceiptID)
+}
+
+// GetDisputes lấy danh sách tranh chấp theo filter (admin)
+func (s *DisputeService) GetDisputes(filter *models.DisputeFilter) ([]*models.BetReceiptDispute, error) {
+	return s.disputeRepo.GetAll(filter)
+}
+
+// GetWorkerReport thống kê tranh chấp theo người nhận kèo, tranh chấp mở trong [from, to) (admin)
+func (s *DisputeService) GetWorkerReport(from, to *time.Time) ([]*models.DisputeWorkerReport, error) {
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, newValidationError("Ngày bắt đầu phải trước ngày kết thúc")
+	}
+	return s.disputeRepo.ReportByWorker(from, to)
+}
+
+func (s *DisputeService) findDispute(id string) (*models.BetReceiptDispute, error) {
+	dispute, err := s.disputeRepo.FindByID(id)
+	if err == sql.ErrNoRows {
+		return nil, ErrDisputeNotFound
+	}
+	return dispute, err
+}
+
+// notify gửi thông báo, lỗi chỉ ghi log (không làm hỏng thao tác đã thực hiện)
+func (s *DisputeService) notify(notification *models.Notification) {
+	if err := s.notificationRepo.Create(notification); err != nil {
+		log.Printf("Service - ⚠️ Không thể gửi thông báo tranh chấp cho user %s: %v", notification.UserID, err)
+	}
+}
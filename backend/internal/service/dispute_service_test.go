@@ -0,0 +1,34 @@
+package service
+
+import (
+	"errors"
+	"fullstack-backend/internal/models"
+	"testing"
+)
+
+func TestCheckCanOpenDispute(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		userID  string
+		role    string
+		wantErr error
+	}{
+		{name: "người nhận kèo, đơn đang thực hiện", status: models.BetReceiptStatusInProgress, userID: "worker", role: "user"},
+		{name: "người nhận kèo, đơn chờ chấp nhận", status: models.BetReceiptStatusPending, userID: "worker", role: "user"},
+		{name: "người khác", status: models.BetReceiptStatusInProgress, userID: "other", role: "user", wantErr: ErrDisputeAccessDenied},
+		{name: "người nhận kèo, đơn DONE", status: models.BetReceiptStatusDone, userID: "worker", role: "user", wantErr: ErrDisputeFinalStatusAdminOnly},
+		{name: "người nhận kèo, đơn ĐỀN", status: models.BetReceiptStatusCompensation, userID: "worker", role: "user", wantErr: ErrDisputeFinalStatusAdminOnly},
+		{name: "người nhận kèo, đơn HỦY BỎ", status: models.BetReceiptStatusCancelled, userID: "worker", role: "user", wantErr: ErrDisputeFinalStatusAdminOnly},
+		{name: "admin, đơn DONE", status: models.BetReceiptStatusDone, userID: "admin", role: "admin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			betReceipt := &models.BetReceipt{UserID: "worker", Status: tt.status}
+			if err := checkCanOpenDispute(betReceipt, tt.userID, tt.role); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkCanOpenDispute() error = %v, muốn %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,63 +1,177 @@
 package service
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fullstack-backend/internal/models"
 	"fullstack-backend/internal/repository"
+	"fullstack-backend/pkg/money"
+	"fullstack-backend/pkg/pagination"
+	"fullstack-backend/pkg/secret"
 	"log"
 	"time"
 )
 
 type BetReceiptService struct {
-	betReceiptRepo *repository.BetReceiptRepository
-	userRepo       *repository.UserRepository
-	walletRepo     *repository.WalletRepository
-	historyRepo    *repository.BetReceiptHistoryRepository
+	betReceiptRepo       *repository.BetReceiptRepository
+	userRepo             *repository.UserRepository
+	walletRepo           *repository.WalletRepository
+	historyRepo          *repository.BetReceiptHistoryRepository
+	credentialAccessRepo *repository.CredentialAccessRepository
+	feeScheduleRepo      *repository.FeeScheduleRepository
+	commentRepo          *repository.CommentRepository
+	taskCodeRepo         *repository.TaskCodeRepository
+	keyring              *secret.Keyring       // Master key mã hóa tài khoản/mật khẩu
+	accountIndex         *secret.BlindIndex    // Chỉ mục mù của tài khoản (phát hiện đơn hàng trùng)
+	sttScheme            models.STTScheme      // Cách đánh mã STT hiển thị (config STT_SCHEME)
+	duplicateRules       models.DuplicateRules // Quy tắc phát hiện đơn hàng trùng (config DUPLICATE_RULES)
 }
 
-func NewBetReceiptService(betReceiptRepo *repository.BetReceiptRepository, userRepo *repository.UserRepository, walletRepo *repository.WalletRepository, historyRepo *repository.BetReceiptHistoryRepository) *BetReceiptService {
+func NewBetReceiptService(betReceiptRepo *repository.BetReceiptRepository, userRepo *repository.UserRepository, walletRepo *repository.WalletRepository, historyRepo *repository.BetReceiptHistoryRepository, credentialAccessRepo *repository.CredentialAccessRepository, feeScheduleRepo *repository.FeeScheduleRepository, commentRepo *repository.CommentRepository, taskCodeRepo *repository.TaskCodeRepository, keyring *secret.Keyring, accountIndex *secret.BlindIndex, sttScheme models.STTScheme, duplicateRules models.DuplicateRules) *BetReceiptService {
 	return &BetReceiptService{
-		betReceiptRepo: betReceiptRepo,
-		userRepo:       userRepo,
-		walletRepo:     walletRepo,
-		historyRepo:    historyRepo,
+		betReceiptRepo:       betReceiptRepo,
+		userRepo:             userRepo,
+		walletRepo:           walletRepo,
+		historyRepo:          historyRepo,
+		credentialAccessRepo: credentialAccessRepo,
+		feeScheduleRepo:      feeScheduleRepo,
+		commentRepo:          commentRepo,
+		taskCodeRepo:         taskCodeRepo,
+		keyring:              keyring,
+		accountIndex:         accountIndex,
+		sttScheme:            sttScheme,
+		duplicateRules:       duplicateRules,
 	}
 }
 
 // CreateBetReceipt tạo đơn hàng (thông tin nhận kèo) mới
-func (s *BetReceiptService) CreateBetReceipt(req *models.CreateBetReceiptRequest) (*models.BetReceipt, error) {
+// Mã nhiệm vụ có trong danh mục: bet_type, web_bet_amount_cny, completed_hours bỏ trống được lấy từ giá trị mặc định
+// Đơn hàng trùng theo quy tắc reject bị từ chối, trùng theo quy tắc warn chỉ được tạo khi force = true (*DuplicateError)
+func (s *BetReceiptService) CreateBetReceipt(req *models.CreateBetReceiptRequest, force bool) (*models.BetReceipt, error) {
 	log.Printf("Service - Tạo đơn hàng cho user_name: %s", req.UserName)
 
-	// 1. Tìm người dùng theo tên (tìm chính xác tên)
-	users, err := s.userRepo.FindByName(req.UserName)
+	// 1. Tìm người dùng theo tên (tìm chính xác tên), không có tên = đưa vào pool cho người nhận kèo tự nhận
+	var foundUser *models.User
+	if req.UserName != "" {
+		var err error
+		foundUser, err = s.findUserByExactName(req.UserName)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Service - ✅ Tìm thấy người dùng: %s (%s), ID: %s", foundUser.Name, foundUser.Email, foundUser.ID)
+	} else {
+		log.Printf("Service - Đơn hàng chưa có người nhận, đưa vào pool")
+	}
+
+	// 2. Điền giá trị mặc định từ danh mục mã nhiệm vụ, kiểm tra loại kèo và tiền kèo hợp lệ
+	if err := applyTaskCodeDefaults(s.taskCodeRepo, req); err != nil {
+		return nil, err
+	}
+	if req.WebBetAmountCNY == 0 {
+		return nil, newValidationError("Tiền kèo web là bắt buộc (mã nhiệm vụ không có tiền kèo mặc định)")
+	}
+	if req.BetType != models.BetTypeWeb && req.BetType != models.BetTypeExternal {
+		return nil, errors.New("Loại kèo không hợp lệ. Phải là 'web' hoặc 'Kèo ngoài'")
+	}
+
+	// 3-5. Tạo đơn hàng (thông tin nhận kèo)
+	betReceipt, err := s.newBetReceipt(req, foundUser)
 	if err != nil {
-		log.Printf("Service - ❌ Lỗi khi tìm người dùng: %v", err)
-		return nil, errors.New("Lỗi khi tìm kiếm người dùng")
+		return nil, err
 	}
 
-	// Lọc để tìm user có tên chính xác (phải khớp hoàn toàn, phân biệt hoa thường)
-	var foundUser *models.User
-	for _, u := range users {
-		// So sánh chính xác (case-sensitive) - tên nhập vào phải khớp hoàn toàn với tên trong DB
-		if u.Name == req.UserName {
-			foundUser = u
-			break
+	err = repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+		betReceiptRepo := s.betReceiptRepo.WithTx(tx)
+		if err := s.checkDuplicates(betReceiptRepo, betReceipt, "", nil, []string{models.DuplicateRuleOrderCode, models.DuplicateRuleTaskAccountDay}, force); err != nil {
+			return err
 		}
+		return s.createBetReceipt(betReceiptRepo, betReceipt)
+	})
+	var dupErr *DuplicateError
+	if errors.As(err, &dupErr) {
+		log.Printf("Service - ❌ Đơn hàng trùng: %v", err)
+		return nil, err
+	}
+	if err != nil {
+		log.Printf("Service - ❌ Lỗi tạo đơn hàng: %v", err)
+		return nil, errors.New("Lỗi khi tạo đơn hàng: " + err.Error())
 	}
 
-	if foundUser == nil {
-		log.Printf("Service - ❌ Không tìm thấy người dùng với tên: %s", req.UserName)
-		return nil, errors.New("Tên người dùng '" + req.UserName + "' không có trong hệ thống")
+	// Set UserName để trả về trong response (không cần query lại từ DB)
+	if foundUser != nil {
+		betReceipt.UserName = foundUser.Name
 	}
 
-	log.Printf("Service - ✅ Tìm thấy người dùng: %s (%s), ID: %s", foundUser.Name, foundUser.Email, foundUser.ID)
+	log.Printf("Service - ✅ Đơn hàng đã được tạo với ID: %s, STT: %d (%s), UserName: %s", betReceipt.ID, betReceipt.STT, betReceipt.STTCode, betReceipt.UserName)
 
-	// 2. Kiểm tra loại kèo hợp lệ
-	if req.BetType != models.BetTypeWeb && req.BetType != models.BetTypeExternal {
-		return nil, errors.New("Loại kèo không hợp lệ. Phải là 'web' hoặc 'Kèo ngoài'")
+	return betReceipt, nil
+}
+
+// createBetReceipt cấp STT (bộ đếm toàn cục + bộ đếm theo STT_SCHEME) rồi lưu đơn hàng
+// betReceiptRepo phải chạy trong transaction (WithTx): bộ đếm bị khóa đến khi commit
+// và số đã cấp được trả lại nếu lưu lỗi
+func (s *BetReceiptService) createBetReceipt(betReceiptRepo *repository.BetReceiptRepository, betReceipt *models.BetReceipt) error {
+	stt, err := betReceiptRepo.NextSTT(models.STTScopeGlobal)
+	if err != nil {
+		return err
+	}
+	betReceipt.STT = stt
+
+	// Luôn khóa bộ đếm toàn cục trước bộ đếm theo scheme để các transaction không deadlock
+	now := time.Now()
+	number := stt
+	if scope := s.sttScheme.Scope(betReceipt.UserID, now); scope != models.STTScopeGlobal {
+		if number, err = betReceiptRepo.NextSTT(scope); err != nil {
+			return err
+		}
+	}
+	userCode, err := s.sttUserCode(betReceiptRepo, betReceipt.UserID)
+	if err != nil {
+		return err
+	}
+	betReceipt.STTCode = s.sttScheme.Code(userCode, now, number)
+
+	return betReceiptRepo.Create(betReceipt)
+}
+
+// sttUserCode lấy mã ngắn của người nhận cho scheme "user" ("" nếu scheme khác hoặc đơn hàng trong pool)
+func (s *BetReceiptService) sttUserCode(betReceiptRepo *repository.BetReceiptRepository, userID string) (string, error) {
+	if s.sttScheme != models.STTSchemeUser || userID == "" {
+		return "", nil
+	}
+	return betReceiptRepo.UserCode(userID)
+}
+
+// reallocatePoolSTTCode cấp lại mã STT theo người nhận cho đơn hàng tạo trong pool ("POOL-0001") của scheme "user"
+// khi đơn hàng được nhận/giao lần đầu; betReceiptRepo phải chạy trong transaction đã khóa đơn hàng
+// Đơn hàng đã có mã theo người nhận giữ nguyên mã khi trả về pool hoặc giao lại
+func (s *BetReceiptService) reallocatePoolSTTCode(betReceiptRepo *repository.BetReceiptRepository, betReceipt *models.BetReceipt) error {
+	if betReceipt.UserID == "" || !s.sttScheme.IsPoolCode(betReceipt.STTCode) {
+		return nil
+	}
+	now := time.Now()
+	number, err := betReceiptRepo.NextSTT(s.sttScheme.Scope(betReceipt.UserID, now))
+	if err != nil {
+		return err
+	}
+	userCode, err := s.sttUserCode(betReceiptRepo, betReceipt.UserID)
+	if err != nil {
+		return err
+	}
+	code := s.sttScheme.Code(userCode, now, number)
+	if err := betReceiptRepo.UpdateSTTCode(betReceipt.ID, code); err != nil {
+		return err
 	}
+	log.Printf("Service - 🔢 Cấp lại mã STT đơn hàng %s: %s -> %s", betReceipt.ID, betReceipt.STTCode, code)
+	betReceipt.STTCode = code
+	return nil
+}
 
+// newBetReceipt tạo đơn hàng mới (status "Đơn hàng mới") từ request, chưa lưu DB
+// user = nil: đơn hàng chưa có người nhận (pool)
+// Tài khoản/mật khẩu được mã hóa ngay tại đây
+func (s *BetReceiptService) newBetReceipt(req *models.CreateBetReceiptRequest, user *models.User) (*models.BetReceipt, error) {
 	// 3. Đặt trạng thái mặc định là "Đơn hàng mới"
 	status := models.BetReceiptStatusNew
 
@@ -71,14 +185,11 @@ func (s *BetReceiptService) CreateBetReceipt(req *models.CreateBetReceiptRequest
 
 	// 5. Tạo đơn hàng (thông tin nhận kèo)
 	betReceipt := &models.BetReceipt{
-		UserID:             foundUser.ID,
 		TaskCode:           req.TaskCode,
 		BetType:            req.BetType,
 		WebBetAmountCNY:    req.WebBetAmountCNY,
 		OrderCode:          req.OrderCode,
 		Notes:              req.Notes,
-		Account:            req.Account,
-		Password:           req.Password,
 		Region:             req.Region,
 		Status:             status,
 		CompletedHours:     req.CompletedHours, // Lưu thời gian hoàn thành ban đầu
@@ -87,33 +198,159 @@ func (s *BetReceiptService) CreateBetReceipt(req *models.CreateBetReceiptRequest
 		CompensationCNY:    0,
 		ActualAmountCNY:    0,
 	}
+	if user != nil {
+		betReceipt.UserID = user.ID
+	}
+	if err := s.sealCredentials(betReceipt, req.Account, req.Password); err != nil {
+		return nil, err
+	}
+	return betReceipt, nil
+}
 
-	if err := s.betReceiptRepo.Create(betReceipt); err != nil {
-		log.Printf("Service - ❌ Lỗi tạo đơn hàng: %v", err)
-		return nil, errors.New("Lỗi khi tạo đơn hàng: " + err.Error())
+// findUserByExactName tìm người dùng có tên khớp chính xác (phân biệt hoa thường) với userName
+func (s *BetReceiptService) findUserByExactName(userName string) (*models.User, error) {
+	users, err := s.userRepo.FindByName(userName)
+	if err != nil {
+		log.Printf("Service - ❌ Lỗi khi tìm người dùng: %v", err)
+		return nil, errors.New("Lỗi khi tìm kiếm người dùng")
 	}
 
-	// Set UserName để trả về trong response (không cần query lại từ DB)
-	betReceipt.UserName = foundUser.Name
+	// Lọc để tìm user có tên chính xác (phải khớp hoàn toàn, phân biệt hoa thường)
+	for _, u := range users {
+		// So sánh chính xác (case-sensitive) - tên nhập vào phải khớp hoàn toàn với tên trong DB
+		if u.Name == userName {
+			return u, nil
+		}
+	}
 
-	log.Printf("Service - ✅ Đơn hàng đã được tạo với ID: %s, STT: %d, UserName: %s", betReceipt.ID, betReceipt.STT, betReceipt.UserName)
+	log.Printf("Service - ❌ Không tìm thấy người dùng với tên: %s", userName)
+	return nil, newValidationError("Tên người dùng '" + userName + "' không có trong hệ thống")
+}
 
-	return betReceipt, nil
+// GetAllBetReceipts lấy danh sách đơn hàng (thông tin nhận kèo) theo filter
+// Trả về danh sách theo trang, tổng số đơn hàng khớp filter và thông tin cursor (next_cursor/prev_cursor)
+func (s *BetReceiptService) GetAllBetReceipts(filter *models.BetReceiptFilter) ([]*models.BetReceipt, int, pagination.Page, error) {
+	if err := validateBetReceiptFilter(filter); err != nil {
+		return nil, 0, pagination.Page{}, err
+	}
+
+	// Cursor chỉ hỗ trợ các trường sắp xếp NOT NULL (stt, received_at, ...)
+	pageReq := pagination.Request{
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+		Sort:   betReceiptSortKey(filter),
+	}
+	keyFn := repository.BetReceiptCursorKey(filter.SortBy)
+	if filter.CursorToken != "" {
+		if keyFn == nil {
+			return nil, 0, pagination.Page{}, newValidationError("Không hỗ trợ cursor khi sắp xếp theo trường '" + filter.SortBy + "'")
+		}
+		keyCount := len(keyFn(&models.BetReceipt{}))
+		cursor, err := pagination.Decode(filter.CursorToken, pageReq.Sort, keyCount)
+		if err != nil {
+			return nil, 0, pagination.Page{}, newValidationError(err.Error())
+		}
+		filter.Cursor = cursor
+		pageReq.Cursor = cursor
+	}
+
+	// Lấy thêm 1 dòng để biết còn trang tiếp theo hay không
+	fetchFilter := *filter
+	fetchFilter.Limit = pageReq.FetchLimit()
+
+	betReceipts, err := s.betReceiptRepo.GetAll(&fetchFilter)
+	if err != nil {
+		return nil, 0, pagination.Page{}, err
+	}
+
+	total, err := s.betReceiptRepo.CountAll(filter)
+	if err != nil {
+		return nil, 0, pagination.Page{}, err
+	}
+
+	if keyFn == nil {
+		// Trường sắp xếp có thể NULL: chỉ phân trang bằng offset
+		if len(betReceipts) > filter.Limit {
+			betReceipts = betReceipts[:filter.Limit]
+		}
+		return betReceipts, total, pagination.Page{Limit: filter.Limit}, nil
+	}
+
+	betReceipts, page := pagination.Paginate(betReceipts, pageReq, keyFn)
+	return betReceipts, total, page, nil
 }
 
-// GetAllBetReceipts lấy tất cả đơn hàng (thông tin nhận kèo)
-// Nếu userID != nil, chỉ lấy đơn hàng của user đó
-func (s *BetReceiptService) GetAllBetReceipts(limit, offset int, userID *string) ([]*models.BetReceipt, error) {
-	return s.betReceiptRepo.GetAll(limit, offset, userID)
+// validateBetReceiptFilter kiểm tra status, loại kèo và trường sắp xếp trong filter
+func validateBetReceiptFilter(filter *models.BetReceiptFilter) error {
+	for _, status := range filter.Statuses {
+		if !models.IsValidBetReceiptStatus(status) {
+			return newValidationError("Status '" + status + "' không hợp lệ")
+		}
+	}
+	if filter.BetType != "" && filter.BetType != models.BetTypeWeb && filter.BetType != models.BetTypeExternal {
+		return newValidationError("Loại kèo không hợp lệ. Phải là 'web' hoặc 'Kèo ngoài'")
+	}
+	if filter.SortBy != "" && !repository.IsValidBetReceiptSortField(filter.SortBy) {
+		return newValidationError("Không hỗ trợ sắp xếp theo trường '" + filter.SortBy + "'")
+	}
+	return nil
+}
+
+// betReceiptSortKey trả về chuỗi mô tả kiểu sắp xếp (vd: "stt:asc") để gắn vào cursor
+func betReceiptSortKey(filter *models.BetReceiptFilter) string {
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = "stt"
+	}
+	if filter.SortDesc {
+		return sortBy + ":desc"
+	}
+	return sortBy + ":asc"
 }
 
 // GetBetReceiptByID lấy đơn hàng (thông tin nhận kèo) theo ID
-func (s *BetReceiptService) GetBetReceiptByID(id string) (*models.BetReceipt, error) {
-	return s.betReceiptRepo.FindByID(id)
+// withComments: kèm danh sách bình luận; includeInternal: kèm cả ghi chú nội bộ (chỉ admin)
+func (s *BetReceiptService) GetBetReceiptByID(id string, withComments, includeInternal bool) (*models.BetReceipt, error) {
+	betReceipt, err := s.betReceiptRepo.FindByID(id)
+	if err != nil || !withComments || s.commentRepo == nil {
+		return betReceipt, err
+	}
+	betReceipt.Comments, err = s.commentRepo.GetByBetReceiptID(id, includeInternal)
+	if err != nil {
+		return nil, err
+	}
+	return betReceipt, nil
+}
+
+// checkUnchangedAssignee từ chối đổi người nhận kèo qua user_name khi sửa đơn hàng: đổi người nhận phải qua
+// ClaimService.AssignBetReceipt (POST /bet-receipts/:id/assign) để ghi lịch sử nhận đơn, đánh lại mã STT của đơn
+// trong pool và chuyển công thực nhận sang wallet người mới. user_name trùng người nhận hiện tại được bỏ qua
+func (s *BetReceiptService) checkUnchangedAssignee(betReceipt *models.BetReceipt, userName *string) error {
+	if userName == nil {
+		return nil
+	}
+	currentName := ""
+	if betReceipt.UserID != "" {
+		user, err := s.userRepo.FindByID(betReceipt.UserID)
+		if err != nil {
+			return err
+		}
+		currentName = user.Name
+	}
+	return checkAssigneeName(*userName, currentName)
+}
+
+// checkAssigneeName kiểm tra tên người nhận gửi lên khi sửa đơn hàng trùng với người nhận hiện tại ("" = pool)
+func checkAssigneeName(userName, currentName string) error {
+	if userName != currentName {
+		return newValidationError("Không thể đổi người nhận khi sửa đơn hàng, hãy dùng chức năng giao đơn hàng")
+	}
+	return nil
 }
 
 // UpdateBetReceipt cập nhật các trường thông thường của đơn hàng (không phải status)
-func (s *BetReceiptService) UpdateBetReceipt(id string, req *models.UpdateBetReceiptRequest, performedBy *string) (*models.BetReceipt, error) {
+// Đổi mã đơn hàng, mã nhiệm vụ hoặc tài khoản được kiểm tra trùng như khi tạo (force cho quy tắc warn)
+func (s *BetReceiptService) UpdateBetReceipt(id string, req *models.UpdateBetReceiptRequest, performedBy *string, force bool) (*models.BetReceipt, error) {
 	log.Printf("Service - Cập nhật đơn hàng ID: %s", id)
 
 	// Kiểm tra đơn hàng có tồn tại không và lấy dữ liệu cũ
@@ -122,14 +359,62 @@ func (s *BetReceiptService) UpdateBetReceipt(id string, req *models.UpdateBetRec
 		log.Printf("Service - ❌ Không tìm thấy đơn hàng với ID: %s", id)
 		return nil, errors.New("Không tìm thấy đơn hàng")
 	}
+	if err := checkReceiptPeriodOpen(oldBetReceipt); err != nil {
+		return nil, err
+	}
 
 	// Validation
 	if req.BetType != nil && *req.BetType != models.BetTypeWeb && *req.BetType != models.BetTypeExternal {
 		return nil, errors.New("Loại kèo không hợp lệ. Phải là 'web' hoặc 'Kèo ngoài'")
 	}
+	if err := s.checkUnchangedAssignee(oldBetReceipt, req.UserName); err != nil {
+		return nil, err
+	}
+
+	// Cập nhật trong database (các trường thông thường và tài khoản/mật khẩu đã mã hóa trong cùng transaction)
+	err = repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+		betReceiptRepo := s.betReceiptRepo.WithTx(tx)
 
-	// Cập nhật trong database
-	if err := s.betReceiptRepo.Update(id, req); err != nil {
+		credentials := *oldBetReceipt
+		if req.Account != nil {
+			if err := s.sealAccount(&credentials, *req.Account); err != nil {
+				return err
+			}
+		}
+
+		// Kiểm tra trùng với mã đơn hàng/mã nhiệm vụ/tài khoản sau khi sửa
+		candidate := credentials
+		if req.OrderCode != nil {
+			candidate.OrderCode = *req.OrderCode
+		}
+		if req.TaskCode != nil {
+			candidate.TaskCode = *req.TaskCode
+		}
+		if rules := duplicateRulesForUpdate(oldBetReceipt, &candidate); len(rules) > 0 {
+			if err := s.checkDuplicates(betReceiptRepo, &candidate, id, &oldBetReceipt.ReceivedAt, rules, force); err != nil {
+				return err
+			}
+		}
+
+		if err := betReceiptRepo.Update(id, req); err != nil {
+			return err
+		}
+		if req.Account == nil && req.Password == nil {
+			return nil
+		}
+		if req.Password != nil {
+			if err := s.sealPassword(&credentials, *req.Password); err != nil {
+				return err
+			}
+		}
+		return betReceiptRepo.UpdateCredentials(&credentials)
+	})
+	var dupErr *DuplicateError
+	if errors.As(err, &dupErr) {
+		log.Printf("Service - ❌ Đơn hàng trùng: %v", err)
+		return nil, err
+	}
+	if err != nil {
 		log.Printf("Service - ❌ Lỗi cập nhật đơn hàng: %v", err)
 		return nil, errors.New("Lỗi khi cập nhật đơn hàng: " + err.Error())
 	}
@@ -141,19 +426,8 @@ func (s *BetReceiptService) UpdateBetReceipt(id string, req *models.UpdateBetRec
 		return nil, errors.New("Lỗi khi lấy thông tin đơn hàng")
 	}
 
-	// Lấy tên người dùng
-	if req.UserName != nil {
-		users, err := s.userRepo.FindByName(*req.UserName)
-		if err == nil {
-			for _, u := range users {
-				if u.Name == *req.UserName {
-					betReceipt.UserName = u.Name
-					break
-				}
-			}
-		}
-	} else {
-		// Nếu không cập nhật user_name, lấy từ database
+	// Lấy tên người dùng (đơn hàng trong pool không có người nhận)
+	if betReceipt.UserID != "" {
 		user, err := s.userRepo.FindByID(betReceipt.UserID)
 		if err == nil && user != nil {
 			betReceipt.UserName = user.Name
@@ -166,6 +440,10 @@ func (s *BetReceiptService) UpdateBetReceipt(id string, req *models.UpdateBetRec
 			oldData, _ := betReceiptToMap(oldBetReceipt)
 			newData, _ := betReceiptToMap(betReceipt)
 			changedFields := repository.FindChangedFields(oldData, newData)
+			// Mật khẩu luôn hiển thị "********" nên cần ghi nhận thay đổi riêng
+			if req.Password != nil {
+				changedFields["password"] = map[string]interface{}{"old": oldBetReceipt.Password, "new": betReceipt.Password}
+			}
 
 			historyReq := &models.CreateHistoryRequest{
 				BetReceiptID:  id,
@@ -187,34 +465,49 @@ func (s *BetReceiptService) UpdateBetReceipt(id string, req *models.UpdateBetRec
 	return betReceipt, nil
 }
 
-// DeleteBetReceipt xóa đơn hàng
+// DeleteBetReceipt xóa mềm đơn hàng (chuyển vào thùng rác) và tính lại wallet nếu đơn hàng đã ảnh hưởng đến wallet
+// Xóa mềm, ghi lịch sử DELETE và tính lại wallet trong cùng một transaction
 func (s *BetReceiptService) DeleteBetReceipt(id string, performedBy *string) error {
 	log.Printf("Service - Xóa đơn hàng ID: %s", id)
 
-	// Kiểm tra đơn hàng có tồn tại không
-	betReceipt, err := s.betReceiptRepo.FindByID(id)
-	if err != nil {
-		log.Printf("Service - ❌ Không tìm thấy đơn hàng với ID: %s", id)
-		return errors.New("Không tìm thấy đơn hàng")
-	}
-
 	// Nếu đơn hàng có status = DONE, HỦY BỎ, hoặc ĐỀN, cần tính lại wallet
-	const exchangeRate = 3550.0
-	oldStatus := betReceipt.Status
-	userID := betReceipt.UserID
+	exchangeRate := models.DefaultExchangeRate
 
-	// Lưu dữ liệu cũ để ghi log
-	oldData, _ := betReceiptToMap(betReceipt)
+	err := repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+		betReceiptRepo := s.betReceiptRepo.WithTx(tx)
 
-	// Xóa đơn hàng
-	if err := s.betReceiptRepo.Delete(id); err != nil {
-		log.Printf("Service - ❌ Lỗi xóa đơn hàng: %v", err)
-		return errors.New("Lỗi khi xóa đơn hàng: " + err.Error())
-	}
+		// Kiểm tra đơn hàng có tồn tại không (đơn đã ở trong thùng rác coi như không tồn tại)
+		found, err := betReceiptRepo.LockByIDs([]string{id})
+		if err != nil {
+			return err
+		}
+		if !found[id] {
+			log.Printf("Service - ❌ Không tìm thấy đơn hàng với ID: %s", id)
+			return ErrBetReceiptNotFound
+		}
+		betReceipt, err := betReceiptRepo.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if err := checkReceiptPeriodOpen(betReceipt); err != nil {
+			return err
+		}
 
-	// Ghi log lịch sử (DELETE)
-	if s.historyRepo != nil {
-		go func() {
+		// Lưu dữ liệu cũ để ghi log
+		oldData, _ := betReceiptToMap(betReceipt)
+
+		// Xóa mềm đơn hàng (chuyển vào thùng rác, có thể khôi phục)
+		deleted, err := betReceiptRepo.SoftDelete(id, performedBy)
+		if err != nil {
+			log.Printf("Service - ❌ Lỗi xóa đơn hàng: %v", err)
+			return errors.New("Lỗi khi xóa đơn hàng: " + err.Error())
+		}
+		if !deleted {
+			return ErrBetReceiptNotFound
+		}
+
+		// Ghi log lịch sử (DELETE)
+		if s.historyRepo != nil {
 			historyReq := &models.CreateHistoryRequest{
 				BetReceiptID: id,
 				Action:       models.HistoryActionDelete,
@@ -222,149 +515,187 @@ func (s *BetReceiptService) DeleteBetReceipt(id string, performedBy *string) err
 				OldData:      oldData,
 				Description:  "Xóa đơn hàng",
 			}
-
-			if err := s.createHistory(historyReq); err != nil {
-				log.Printf("Service - ⚠️ Không thể ghi lịch sử: %v", err)
+			if err := NewBetReceiptHistoryService(s.historyRepo.WithTx(tx)).CreateHistory(historyReq); err != nil {
+				return errors.New("Lỗi khi ghi lịch sử: " + err.Error())
 			}
-		}()
-	}
+		}
 
-	// Nếu đơn hàng đã có ảnh hưởng đến wallet (status = DONE, HỦY BỎ, hoặc ĐỀN), tính lại wallet
-	if oldStatus == models.BetReceiptStatusDone || oldStatus == models.BetReceiptStatusCancelled || oldStatus == models.BetReceiptStatusCompensation {
-		if err := s.walletRepo.RecalculateTotalReceived(userID, exchangeRate); err != nil {
-			log.Printf("Service - ❌ Lỗi tính lại wallet sau khi xóa: %v", err)
-			// Không return error vì đơn hàng đã bị xóa, chỉ log warning
-			log.Printf("Service - ⚠️ Đơn hàng đã bị xóa nhưng không thể tính lại wallet, cần tính thủ công")
-		} else {
-			log.Printf("Service - ✅ Đã tính lại wallet cho user ID: %s sau khi xóa đơn hàng", userID)
+		// Nếu đơn hàng đã có ảnh hưởng đến wallet (status = DONE, HỦY BỎ, hoặc ĐỀN), tính lại wallet
+		// (ghi bút toán đảo vào sổ cái), lỗi thì đơn hàng không bị xóa
+		if isProcessedStatus(betReceipt.Status) {
+			if err := s.walletRepo.WithTx(tx).RecalculateTotalReceived(betReceipt.UserID, exchangeRate); err != nil {
+				log.Printf("Service - ❌ Lỗi tính lại wallet sau khi xóa: %v", err)
+				return errors.New("Lỗi khi cập nhật wallet: " + err.Error())
+			}
+			log.Printf("Service - ✅ Đã tính lại wallet cho user ID: %s sau khi xóa đơn hàng", betReceipt.UserID)
 		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, ErrBetReceiptNotFound) {
+			log.Printf("Service - ❌ Xóa đơn hàng thất bại (đã rollback) cho ID: %s: %v", id, err)
+		}
+		return err
 	}
 
 	log.Printf("Service - ✅ Đã xóa đơn hàng thành công cho ID: %s", id)
 	return nil
 }
 
-// lookupPhiWeb tra cứu phí web dựa trên giá kèo (tệ)
-// Bảng tham chiếu:
-// < 20: 2
-// 20-50: 4
-// 51-100: 5
-// 101-150: 6
-// 151-200: 7
-// 201-250: 8
-// 251-300: 9
-// 301-350: 10
-// > 351: 11
-// >= 800: 20
-func lookupPhiWeb(giaKeo float64) float64 {
-	if giaKeo < 20 {
-		return 2
-	} else if giaKeo >= 20 && giaKeo <= 50 {
-		return 4
-	} else if giaKeo >= 51 && giaKeo <= 100 {
-		return 5
-	} else if giaKeo >= 101 && giaKeo <= 150 {
-		return 6
-	} else if giaKeo >= 151 && giaKeo <= 200 {
-		return 7
-	} else if giaKeo >= 201 && giaKeo <= 250 {
-		return 8
-	} else if giaKeo >= 251 && giaKeo <= 300 {
-		return 9
-	} else if giaKeo >= 301 && giaKeo <= 350 {
-		return 10
-	} else if giaKeo >= 800 {
-		return 20
-	} else {
-		// > 351 và < 800
-		return 11
+// calculateActualAmountCNY tính "Công thực nhận" (ActualAmountCNY) dựa trên loại kèo, giá kèo và biểu phí
+// Công thức:
+// - Kèo web: Tổng thực nhận = Giá kèo - Phí web (theo bảng phí web) - (Giá kèo × phí rút tiền web) - (Giá kèo × phí trung gian)
+// - Kèo ngoài: Tổng thực nhận = Giá kèo - 0 - (Giá kèo × phí rút tiền kèo ngoài) - (Giá kèo × phí trung gian)
+// Trả về ValidationError nếu số tiền vượt quá giới hạn khi tính phí
+func calculateActualAmountCNY(schedule *models.FeeSchedule, betType string, giaKeo money.CNY) (money.CNY, error) {
+	breakdown, err := calculateFeeBreakdown(schedule, betType, giaKeo)
+	if err == errInvalidBetType {
+		// Loại kèo không hợp lệ, trả về 0
+		log.Printf("Service - ⚠️ Loại kèo không hợp lệ: %s", betType)
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
 	}
+
+	log.Printf("Service - 📊 Tính Công thực nhận - Biểu phí: v%d, Loại kèo: %s, Giá kèo: %s, Phí web: %s, Phí rút tiền: %s, Phí trung gian: %s, Tổng thực nhận: %s",
+		schedule.Version, betType, giaKeo, breakdown.WebFeeCNY, breakdown.WithdrawalFeeCNY, breakdown.IntermediaryFeeCNY, breakdown.NetCNY)
+
+	return breakdown.NetCNY, nil
 }
 
-// calculateActualAmountCNY tính "Công thực nhận" (ActualAmountCNY) dựa trên loại kèo và giá kèo
-// Công thức:
-// - Kèo web: Tổng thực nhận = Giá kèo - Phí web - (Giá kèo × 2%) - (Giá kèo × 6%)
-// - Kèo ngoài: Tổng thực nhận = Giá kèo - 0 - (Giá kèo × 1%) - (Giá kèo × 6%)
-func calculateActualAmountCNY(betType string, giaKeo float64) float64 {
-	var phiWeb, phiRutTien, phiTrungGian float64
+// errInvalidBetType - Loại kèo không phải web hoặc kèo ngoài (calculateFeeBreakdown)
+var errInvalidBetType = errors.New("Loại kèo không hợp lệ. Phải là 'web' hoặc 'Kèo ngoài'")
+
+// calculateFeeBreakdown tính chi tiết các khoản phí của "Công thực nhận" (công thức ở calculateActualAmountCNY)
+// Trả về errInvalidBetType nếu loại kèo không hợp lệ, ValidationError nếu số tiền vượt quá giới hạn
+func calculateFeeBreakdown(schedule *models.FeeSchedule, betType string, giaKeo money.CNY) (models.FeeBreakdown, error) {
+	breakdown := models.FeeBreakdown{AmountCNY: giaKeo}
 
+	var withdrawalRate money.Rate
 	if betType == models.BetTypeWeb {
 		// Kèo web
-		phiWeb = lookupPhiWeb(giaKeo)
-		phiRutTien = giaKeo * 0.02   // 2%
-		phiTrungGian = giaKeo * 0.06 // 6%
+		breakdown.WebFeeCNY = schedule.WebFee(giaKeo)
+		withdrawalRate = schedule.WebWithdrawalFeeRate
 	} else if betType == models.BetTypeExternal {
 		// Kèo ngoài
-		phiWeb = 0
-		phiRutTien = giaKeo * 0.01   // 1%
-		phiTrungGian = giaKeo * 0.06 // 6%
+		breakdown.WebFeeCNY = 0
+		withdrawalRate = schedule.ExternalWithdrawalFeeRate
 	} else {
-		// Loại kèo không hợp lệ, trả về 0
-		log.Printf("Service - ⚠️ Loại kèo không hợp lệ: %s", betType)
-		return 0
+		return models.FeeBreakdown{}, errInvalidBetType
+	}
+
+	var err error
+	if breakdown.WithdrawalFeeCNY, err = giaKeo.MulRate(withdrawalRate); err != nil {
+		return models.FeeBreakdown{}, newValidationError(err.Error())
+	}
+	if breakdown.IntermediaryFeeCNY, err = giaKeo.MulRate(schedule.IntermediaryFeeRate); err != nil {
+		return models.FeeBreakdown{}, newValidationError(err.Error())
 	}
 
-	tongThucNhan := giaKeo - phiWeb - phiRutTien - phiTrungGian
-	log.Printf("Service - 📊 Tính Công thực nhận - Loại kèo: %s, Giá kèo: %.2f, Phí web: %.2f, Phí rút tiền: %.2f, Phí trung gian: %.2f, Tổng thực nhận: %.2f",
-		betType, giaKeo, phiWeb, phiRutTien, phiTrungGian, tongThucNhan)
+	breakdown.NetCNY = giaKeo - breakdown.WebFeeCNY - breakdown.WithdrawalFeeCNY - breakdown.IntermediaryFeeCNY
+	return breakdown, nil
+}
 
-	return tongThucNhan
+// feeScheduleForStatus lấy biểu phí đang có hiệu lực nếu status mới cần tính "Công thực nhận" theo công thức
+// (DONE, HỦY BỎ); các status khác trả về nil
+func (s *BetReceiptService) feeScheduleForStatus(status string) (*models.FeeSchedule, error) {
+	if status != models.BetReceiptStatusDone && status != models.BetReceiptStatusCancelled {
+		return nil, nil
+	}
+	schedule, err := findEffectiveFeeSchedule(s.feeScheduleRepo, time.Now())
+	if err != nil {
+		if err == ErrNoEffectiveFeeSchedule {
+			return nil, newValidationError(err.Error())
+		}
+		return nil, errors.New("Lỗi khi lấy biểu phí: " + err.Error())
+	}
+	return schedule, nil
 }
 
 // UpdateExchangeRateForProcessedOrders cập nhật tỷ giá cho tất cả đơn hàng đã xử lí (DONE, HỦY BỎ, ĐỀN)
 // Sau đó recalculate lại wallet cho tất cả users
-func (s *BetReceiptService) UpdateExchangeRateForProcessedOrders(newExchangeRate float64) error {
-	log.Printf("Service - 🔄 Bắt đầu cập nhật tỷ giá cho các đơn hàng đã xử lí, tỷ giá mới: %.2f", newExchangeRate)
-
-	// 1. Cập nhật tỷ giá hiện tại vào bảng current_exchange_rate
-	updateCurrentRateQuery := `
-		INSERT INTO current_exchange_rate (id, exchange_rate, updated_at)
-		VALUES (1, $1, CURRENT_TIMESTAMP)
-		ON CONFLICT (id) 
-		DO UPDATE SET 
-			exchange_rate = $1,
-			updated_at = CURRENT_TIMESTAMP
-	`
+// Tất cả chạy trong một transaction: lỗi ở bất kỳ bước nào (kể cả tính lại wallet của một user) sẽ rollback toàn bộ
+func (s *BetReceiptService) UpdateExchangeRateForProcessedOrders(newExchangeRate money.Rate) error {
+	log.Printf("Service - 🔄 Bắt đầu cập nhật tỷ giá cho các đơn hàng đã xử lí, tỷ giá mới: %s", newExchangeRate)
+
+	err := repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+		// 1. Cập nhật tỷ giá hiện tại vào bảng current_exchange_rate
+		updateCurrentRateQuery := `
+			INSERT INTO current_exchange_rate (id, exchange_rate, updated_at)
+			VALUES (1, $1, CURRENT_TIMESTAMP)
+			ON CONFLICT (id) 
+			DO UPDATE SET 
+				exchange_rate = $1,
+				updated_at = CURRENT_TIMESTAMP
+		`
+
+		if _, err := tx.Exec(updateCurrentRateQuery, newExchangeRate); err != nil {
+			log.Printf("Service - ❌ Lỗi cập nhật tỷ giá hiện tại: %v", err)
+			return err
+		}
 
-	_, err := s.betReceiptRepo.GetDB().Exec(updateCurrentRateQuery, newExchangeRate)
-	if err != nil {
-		log.Printf("Service - ❌ Lỗi cập nhật tỷ giá hiện tại: %v", err)
-		return err
-	}
+		log.Printf("Service - ✅ Đã cập nhật tỷ giá hiện tại thành %s", newExchangeRate)
 
-	log.Printf("Service - ✅ Đã cập nhật tỷ giá hiện tại thành %.2f", newExchangeRate)
+		// 2. Cập nhật tỷ giá cho tất cả đơn hàng có status DONE, HỦY BỎ, ĐỀN (trừ đơn hàng thuộc tháng đã khóa sổ)
+		updateOrdersQuery := `
+			UPDATE thong_tin_nhan_keo
+			SET exchange_rate = $1
+			WHERE tien_do_hoan_thanh IN ('DONE', 'HỦY BỎ', 'ĐỀN') AND locked_period IS NULL
+		`
 
-	// 2. Cập nhật tỷ giá cho tất cả đơn hàng có status DONE, HỦY BỎ, ĐỀN
-	updateOrdersQuery := `
-		UPDATE thong_tin_nhan_keo
-		SET exchange_rate = $1
-		WHERE tien_do_hoan_thanh IN ('DONE', 'HỦY BỎ', 'ĐỀN')
-	`
+		result, err := tx.Exec(updateOrdersQuery, newExchangeRate)
+		if err != nil {
+			log.Printf("Service - ❌ Lỗi cập nhật tỷ giá cho đơn hàng: %v", err)
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			log.Printf("Service - ⚠️ Không thể lấy số dòng bị ảnh hưởng: %v", err)
+		} else {
+			log.Printf("Service - ✅ Đã cập nhật tỷ giá cho %d đơn hàng", rowsAffected)
+		}
 
-	result, err := s.betReceiptRepo.GetDB().Exec(updateOrdersQuery, newExchangeRate)
+		// 3. Lấy danh sách tất cả user IDs có đơn hàng đã xử lí (bỏ qua đơn hàng trong pool chưa có người nhận)
+		userIDs, err := processedOrderUserIDs(tx)
+		if err != nil {
+			log.Printf("Service - ❌ Lỗi lấy danh sách user IDs: %v", err)
+			return err
+		}
+
+		log.Printf("Service - ✅ Tìm thấy %d users cần tính lại wallet", len(userIDs))
+
+		// 4. Recalculate wallet cho từng user (dùng tỷ giá riêng của từng đơn hàng)
+		walletRepo := s.walletRepo.WithTx(tx)
+		for _, userID := range userIDs {
+			if err := walletRepo.RecalculateWallet(userID, newExchangeRate); err != nil {
+				log.Printf("Service - ❌ Lỗi tính lại wallet cho user %s: %v", userID, err)
+				return errors.New("Lỗi khi tính lại wallet cho user " + userID + ": " + err.Error())
+			}
+			log.Printf("Service - ✅ Đã tính lại wallet cho user %s", userID)
+		}
+		return nil
+	})
 	if err != nil {
-		log.Printf("Service - ❌ Lỗi cập nhật tỷ giá cho đơn hàng: %v", err)
+		log.Printf("Service - ❌ Cập nhật tỷ giá thất bại (đã rollback): %v", err)
 		return err
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		log.Printf("Service - ⚠️ Không thể lấy số dòng bị ảnh hưởng: %v", err)
-	} else {
-		log.Printf("Service - ✅ Đã cập nhật tỷ giá cho %d đơn hàng", rowsAffected)
-	}
+	log.Printf("Service - ✅ Hoàn thành cập nhật tỷ giá và tính lại wallet")
+	return nil
+}
 
-	// 3. Lấy danh sách tất cả user IDs có đơn hàng đã xử lí
-	userIDsQuery := `
+// processedOrderUserIDs lấy các user ID (khác NULL) có đơn hàng đã xử lí
+// Đọc hết kết quả trước khi trả về để transaction chạy được câu lệnh tiếp theo
+func processedOrderUserIDs(tx *sql.Tx) ([]string, error) {
+	rows, err := tx.Query(`
 		SELECT DISTINCT id_nguoi_dung
 		FROM thong_tin_nhan_keo
-		WHERE tien_do_hoan_thanh IN ('DONE', 'HỦY BỎ', 'ĐỀN')
-	`
-
-	rows, err := s.betReceiptRepo.GetDB().Query(userIDsQuery)
+		WHERE tien_do_hoan_thanh IN ('DONE', 'HỦY BỎ', 'ĐỀN') AND id_nguoi_dung IS NOT NULL
+	`)
 	if err != nil {
-		log.Printf("Service - ❌ Lỗi lấy danh sách user IDs: %v", err)
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -372,90 +703,181 @@ func (s *BetReceiptService) UpdateExchangeRateForProcessedOrders(newExchangeRate
 	for rows.Next() {
 		var userID string
 		if err := rows.Scan(&userID); err != nil {
-			log.Printf("Service - ⚠️ Lỗi scan user ID: %v", err)
-			continue
+			return nil, err
 		}
 		userIDs = append(userIDs, userID)
 	}
-
-	log.Printf("Service - ✅ Tìm thấy %d users cần tính lại wallet", len(userIDs))
-
-	// 4. Recalculate wallet cho từng user (dùng tỷ giá riêng của từng đơn hàng)
-	for _, userID := range userIDs {
-		if err := s.walletRepo.RecalculateWallet(userID, newExchangeRate); err != nil {
-			log.Printf("Service - ⚠️ Lỗi tính lại wallet cho user %s: %v", userID, err)
-			// Tiếp tục với user khác dù có lỗi
-			continue
-		}
-		log.Printf("Service - ✅ Đã tính lại wallet cho user %s", userID)
-	}
-
-	log.Printf("Service - ✅ Hoàn thành cập nhật tỷ giá và tính lại wallet")
-	return nil
+	return userIDs, rows.Err()
 }
 
 // GetCurrentExchangeRate lấy tỷ giá hiện tại từ bảng current_exchange_rate
-func (s *BetReceiptService) GetCurrentExchangeRate() (float64, error) {
+func (s *BetReceiptService) GetCurrentExchangeRate() (money.Rate, error) {
 	query := `
 		SELECT exchange_rate
 		FROM current_exchange_rate
 		WHERE id = 1
 	`
 
-	var exchangeRate float64
+	var exchangeRate money.Rate
 	err := s.betReceiptRepo.GetDB().QueryRow(query).Scan(&exchangeRate)
 	if err != nil {
 		log.Printf("Service - ❌ Lỗi lấy tỷ giá hiện tại: %v", err)
 		// Nếu không tìm thấy, trả về giá trị mặc định
-		return 3550.0, nil
+		return models.DefaultExchangeRate, nil
 	}
 
-	log.Printf("Service - ✅ Tỷ giá hiện tại: %.2f", exchangeRate)
+	log.Printf("Service - ✅ Tỷ giá hiện tại: %s", exchangeRate)
 	return exchangeRate, nil
 }
 
 // UpdateBetReceiptStatus cập nhật status của đơn hàng
 // Khi status = "DONE", tự động tính "Công thực nhận" (ActualAmountCNY)
+// Cập nhật status, tính lại wallet (sổ cái) và ghi lịch sử trong cùng một transaction:
+// bước nào lỗi thì tất cả đều rollback (không còn trường hợp status đã lưu nhưng wallet chưa cập nhật)
 func (s *BetReceiptService) UpdateBetReceiptStatus(id string, req *models.UpdateBetReceiptStatusRequest, performedBy *string) (*models.BetReceipt, error) {
 	log.Printf("Service - Cập nhật status cho đơn hàng ID: %s, Status mới: %s", id, req.Status)
 
-	// 1. Lấy thông tin đơn hàng hiện tại
-	betReceipt, err := s.betReceiptRepo.FindByID(id)
+	var betReceipt *models.BetReceipt
+	err := repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+		var err error
+		betReceipt, err = s.updateBetReceiptStatusTx(tx, id, req, performedBy)
+		return err
+	})
 	if err != nil {
-		log.Printf("Service - ❌ Không tìm thấy đơn hàng với ID: %s", id)
-		return nil, errors.New("Không tìm thấy đơn hàng")
+		log.Printf("Service - ❌ Cập nhật status thất bại (đã rollback) cho đơn hàng ID: %s: %v", id, err)
+		return nil, err
 	}
 
-	// Lưu dữ liệu cũ để ghi log
-	oldBetReceiptData, _ := betReceiptToMap(betReceipt)
+	log.Printf("Service - ✅ Đã cập nhật status thành công cho đơn hàng ID: %s", id)
+	return betReceipt, nil
+}
 
-	// 2. Xử lý "Công thực nhận" và cập nhật wallet
+// updateBetReceiptStatusTx cập nhật status, tính lại wallet và ghi lịch sử trong transaction tx của người gọi
+// (vd: quyết định tranh chấp được ghi cùng transaction với đổi status đơn hàng)
+func (s *BetReceiptService) updateBetReceiptStatusTx(tx *sql.Tx, id string, req *models.UpdateBetReceiptStatusRequest, performedBy *string) (*models.BetReceipt, error) {
 	// Lấy tỷ giá hiện tại từ bảng current_exchange_rate
 	exchangeRate, err := s.GetCurrentExchangeRate()
 	if err != nil {
 		log.Printf("Service - ⚠️ Không thể lấy tỷ giá hiện tại, dùng giá trị mặc định 3550.0: %v", err)
-		exchangeRate = 3550.0 // Tỷ giá VND/CNY mặc định
+		exchangeRate = models.DefaultExchangeRate // Tỷ giá VND/CNY mặc định
+	}
+
+	betReceiptRepo := s.betReceiptRepo.WithTx(tx)
+
+	// 1. Khóa và lấy thông tin đơn hàng hiện tại (không bị cập nhật đồng thời trong lúc kiểm tra)
+	found, err := betReceiptRepo.LockByIDs([]string{id})
+	if err != nil {
+		return nil, err
+	}
+	if !found[id] {
+		log.Printf("Service - ❌ Không tìm thấy đơn hàng với ID: %s", id)
+		return nil, ErrBetReceiptNotFound
+	}
+	betReceipt, err := betReceiptRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReceiptPeriodOpen(betReceipt); err != nil {
+		return nil, err
+	}
+
+	// 1.5. Kiểm tra bước chuyển status theo bảng models.BetReceiptStatusTransitions
+	// và các trường bắt buộc của status đích (tránh nhảy từ DONE về "Đơn hàng mới" làm mất dữ liệu tài chính)
+	if err := validateStatusTransition(betReceipt.Status, req); err != nil {
+		log.Printf("Service - ❌ Chuyển status không hợp lệ cho đơn hàng ID: %s: %v", id, err)
+		return nil, err
+	}
+	if err := validateAssignee(betReceipt, req); err != nil {
+		return nil, err
+	}
+
+	// Lưu dữ liệu cũ để ghi log
+	oldBetReceiptData, _ := betReceiptToMap(betReceipt)
+
+	// 2. Biểu phí đang có hiệu lực (chỉ cần khi status mới là DONE hoặc HỦY BỎ)
+	feeSchedule, err := s.feeScheduleForStatus(req.Status)
+	if err != nil {
+		log.Printf("Service - ❌ Không thể lấy biểu phí cho đơn hàng ID: %s: %v", id, err)
+		return nil, err
 	}
 
 	// Lưu status cũ để kiểm tra xem có cần tính lại wallet không
 	oldStatus := betReceipt.Status
 
+	// 3-4. Tính các trường tài chính và thời gian hoàn thành theo status mới
+	if err := releaseClosedCompletedAt(betReceipt, betReceiptRepo.IsPeriodClosed); err != nil {
+		return nil, err
+	}
+	if err := applyStatusChange(betReceipt, req, exchangeRate, feeSchedule); err != nil {
+		return nil, err
+	}
+
+	// 5. Lưu vào database
+	if err := betReceiptRepo.UpdateStatus(betReceipt); err != nil {
+		log.Printf("Service - ❌ Lỗi cập nhật status: %v", err)
+		return nil, errors.New("Lỗi khi cập nhật status: " + err.Error())
+	}
+
+	// 6. Tính lại wallet SAU KHI đã update status (cùng transaction nên thấy status mới)
+	// - Status mới = DONE, HỦY BỎ, hoặc ĐỀN (DONE và HỦY BỎ cộng tiền, ĐỀN trừ tiền)
+	// - Status cũ = DONE, HỦY BỎ, hoặc ĐỀN và status mới ≠ DONE, ≠ HỦY BỎ, và ≠ ĐỀN (tính lại wallet)
+	if statusAffectsWallet(oldStatus, req.Status) {
+		// Ghi sổ cái "Công thực nhận" của đơn hàng (ĐỀN có ActualAmountCNY âm nên sẽ tự động trừ đi)
+		// và tính lại wallet từ sổ cái
+		if err := s.walletRepo.WithTx(tx).RecalculateTotalReceived(betReceipt.UserID, exchangeRate); err != nil {
+			log.Printf("Service - ❌ Lỗi tính lại wallet: %v", err)
+			return nil, errors.New("Lỗi khi cập nhật wallet: " + err.Error())
+		}
+		log.Printf("Service - ✅ Đã tính lại wallet cho user ID: %s từ tất cả bet receipts có status = DONE, HỦY BỎ, hoặc ĐỀN",
+			betReceipt.UserID)
+	}
+
+	// 7. Ghi log lịch sử (UPDATE status)
+	if s.historyRepo != nil {
+		newBetReceiptData, _ := betReceiptToMap(betReceipt)
+		historyReq := &models.CreateHistoryRequest{
+			BetReceiptID:  id,
+			Action:        models.HistoryActionUpdate,
+			PerformedBy:   performedBy,
+			OldData:       oldBetReceiptData,
+			NewData:       newBetReceiptData,
+			ChangedFields: repository.FindChangedFields(oldBetReceiptData, newBetReceiptData),
+			Description:   "Cập nhật status: " + oldStatus + " -> " + req.Status,
+		}
+		if err := NewBetReceiptHistoryService(s.historyRepo.WithTx(tx)).CreateHistory(historyReq); err != nil {
+			log.Printf("Service - ❌ Lỗi ghi lịch sử: %v", err)
+			return nil, errors.New("Lỗi khi ghi lịch sử: " + err.Error())
+		}
+	}
+	return betReceipt, nil
+}
+
+// applyStatusChange áp dụng status mới lên đơn hàng (chưa lưu DB):
+// tính "Công thực nhận", tiền thực nhận, tiền đền, tỷ giá và thời gian hoàn thành theo status mới
+// req phải đã qua validateStatusTransition; feeSchedule là biểu phí có hiệu lực (bắt buộc khi status mới là DONE hoặc HỦY BỎ)
+// Version biểu phí đã dùng được lưu vào FeeScheduleVersion (nil nếu không tính theo công thức)
+// Trả về ValidationError nếu số tiền vượt quá giới hạn khi tính phí
+func applyStatusChange(betReceipt *models.BetReceipt, req *models.UpdateBetReceiptStatusRequest, exchangeRate money.Rate, feeSchedule *models.FeeSchedule) error {
+	id := betReceipt.ID
+	oldStatus := betReceipt.Status
+	betReceipt.FeeScheduleVersion = nil
+
 	// 3. Xử lý theo từng status
 	if req.Status == models.BetReceiptStatusDone {
 		// Status = "DONE": Set ActualReceivedCNY = WebBetAmountCNY ban đầu và tính ActualAmountCNY
 		betReceipt.ActualReceivedCNY = betReceipt.WebBetAmountCNY // ActualReceivedCNY = WebBetAmountCNY khi DONE
-		actualAmountCNY := calculateActualAmountCNY(betReceipt.BetType, betReceipt.WebBetAmountCNY)
+		actualAmountCNY, err := calculateActualAmountCNY(feeSchedule, betReceipt.BetType, betReceipt.WebBetAmountCNY)
+		if err != nil {
+			return err
+		}
 		betReceipt.ActualAmountCNY = actualAmountCNY
+		betReceipt.FeeScheduleVersion = &feeSchedule.Version
 		// Lưu tỷ giá hiện tại khi đơn hàng chuyển sang DONE
 		betReceipt.ExchangeRate = exchangeRate
-		log.Printf("Service - ✅ Status = DONE, set ActualReceivedCNY = WebBetAmountCNY = %.2f, Công thực nhận: %.2f, Tỷ giá: %.2f cho đơn hàng ID: %s",
+		log.Printf("Service - ✅ Status = DONE, set ActualReceivedCNY = WebBetAmountCNY = %s, Công thực nhận: %s, Tỷ giá: %s cho đơn hàng ID: %s",
 			betReceipt.WebBetAmountCNY, actualAmountCNY, betReceipt.ExchangeRate, id)
 	} else if req.Status == models.BetReceiptStatusCancelled {
-		// Status = "HỦY BỎ": Yêu cầu nhập ActualReceivedCNY
-		if req.ActualReceivedCNY == nil {
-			return nil, errors.New("Khi chọn status 'Hủy bỏ', phải nhập 'Tiền kèo thực nhận' (ActualReceivedCNY)")
-		}
-
+		// Status = "HỦY BỎ": ActualReceivedCNY đã được kiểm tra trong validateStatusTransition
 		actualReceivedCNY := *req.ActualReceivedCNY
 		betReceipt.ActualReceivedCNY = actualReceivedCNY
 		// KHÔNG thay đổi WebBetAmountCNY (giữ nguyên giá trị ban đầu)
@@ -466,28 +888,20 @@ func (s *BetReceiptService) UpdateBetReceiptStatus(id string, req *models.Update
 			betReceipt.ActualAmountCNY = 0
 			log.Printf("Service - ℹ️ Status = HỦY BỎ, ActualReceivedCNY = 0, set ActualAmountCNY = 0 cho đơn hàng ID: %s", id)
 		} else {
-			actualAmountCNY := calculateActualAmountCNY(betReceipt.BetType, actualReceivedCNY)
+			actualAmountCNY, err := calculateActualAmountCNY(feeSchedule, betReceipt.BetType, actualReceivedCNY)
+			if err != nil {
+				return err
+			}
 			betReceipt.ActualAmountCNY = actualAmountCNY
+			betReceipt.FeeScheduleVersion = &feeSchedule.Version
 			// Lưu tỷ giá hiện tại khi đơn hàng chuyển sang HỦY BỎ
 			betReceipt.ExchangeRate = exchangeRate
-			log.Printf("Service - ✅ Status = HỦY BỎ, ActualReceivedCNY = %.2f, Công thực nhận: %.2f, Tỷ giá: %.2f cho đơn hàng ID: %s",
+			log.Printf("Service - ✅ Status = HỦY BỎ, ActualReceivedCNY = %s, Công thực nhận: %s, Tỷ giá: %s cho đơn hàng ID: %s",
 				actualReceivedCNY, actualAmountCNY, betReceipt.ExchangeRate, id)
 		}
 	} else if req.Status == models.BetReceiptStatusCompensation {
-		// Status = "ĐỀN": Yêu cầu nhập CompensationCNY và CancelReason (lý do đền)
-		if req.CompensationCNY == nil {
-			return nil, errors.New("Khi chọn status 'Đền', phải nhập 'Tiền đền' (CompensationCNY)")
-		}
-		if req.CancelReason == nil || *req.CancelReason == "" {
-			return nil, errors.New("Khi chọn status 'Đền', phải nhập 'Lý do đền' (CancelReason)")
-		}
-
+		// Status = "ĐỀN": CompensationCNY (> 0) và CancelReason đã được kiểm tra trong validateStatusTransition
 		compensationCNY := *req.CompensationCNY
-		// Validation: Tiền đền phải > 0
-		if compensationCNY <= 0 {
-			return nil, errors.New("Tiền đền phải lớn hơn 0")
-		}
-
 		betReceipt.CompensationCNY = compensationCNY
 		betReceipt.CancelReason = *req.CancelReason
 		// KHÔNG thay đổi WebBetAmountCNY và ActualReceivedCNY (giữ nguyên giá trị)
@@ -496,7 +910,7 @@ func (s *BetReceiptService) UpdateBetReceiptStatus(id string, req *models.Update
 		// Lưu tỷ giá hiện tại khi đơn hàng chuyển sang ĐỀN
 		betReceipt.ExchangeRate = exchangeRate
 		betReceipt.ActualAmountCNY = -compensationCNY // Giá trị ÂM để trừ tiền
-		log.Printf("Service - ✅ Status = ĐỀN, CompensationCNY = %.2f, ActualAmountCNY (âm): %.2f cho đơn hàng ID: %s",
+		log.Printf("Service - ✅ Status = ĐỀN, CompensationCNY = %s, ActualAmountCNY (âm): %s cho đơn hàng ID: %s",
 			compensationCNY, betReceipt.ActualAmountCNY, id)
 		log.Printf("Service - ✅ Status = ĐỀN, Lý do đền: %s cho đơn hàng ID: %s", betReceipt.CancelReason, id)
 	} else {
@@ -566,55 +980,21 @@ func (s *BetReceiptService) UpdateBetReceiptStatus(id string, req *models.Update
 	// Giữ nguyên giá trị TimeRemainingHours từ DB hiện tại (không thay đổi)
 	log.Printf("Service - ℹ️ Giữ nguyên Deadline (thoi_gian_con_lai_gio) cho đơn hàng ID: %s khi chuyển sang status: %s", id, req.Status)
 
-	// 5. Cập nhật status vào database TRƯỚC (để khi tính lại wallet, status đã được update)
+	// 5. Cập nhật status (lưu DB TRƯỚC khi tính lại wallet để wallet thấy status mới)
 	betReceipt.Status = req.Status
+	return nil
+}
 
-	// 5. Lưu vào database
-	if err := s.betReceiptRepo.UpdateStatus(betReceipt); err != nil {
-		log.Printf("Service - ❌ Lỗi cập nhật status: %v", err)
-		return nil, errors.New("Lỗi khi cập nhật status: " + err.Error())
-	}
-
-	// 6. Tính lại wallet SAU KHI đã update status vào database
-	// - Status mới = DONE, HỦY BỎ, hoặc ĐỀN (DONE và HỦY BỎ cộng tiền, ĐỀN trừ tiền)
-	// - Status cũ = DONE, HỦY BỎ, hoặc ĐỀN và status mới ≠ DONE, ≠ HỦY BỎ, và ≠ ĐỀN (tính lại wallet)
-	if req.Status == models.BetReceiptStatusDone || req.Status == models.BetReceiptStatusCancelled || req.Status == models.BetReceiptStatusCompensation ||
-		oldStatus == models.BetReceiptStatusDone || oldStatus == models.BetReceiptStatusCancelled || oldStatus == models.BetReceiptStatusCompensation {
-		// Tính lại tổng "Công thực nhận" từ tất cả bet receipts có status = "DONE", "HỦY BỎ", hoặc "ĐỀN"
-		// (ĐỀN có ActualAmountCNY âm nên sẽ tự động trừ đi)
-		// và cập nhật wallet theo tổng này (đảm bảo wallet luôn phản ánh đúng tổng từ database)
-		if err := s.walletRepo.RecalculateTotalReceived(betReceipt.UserID, exchangeRate); err != nil {
-			log.Printf("Service - ❌ Lỗi tính lại wallet: %v", err)
-			return nil, errors.New("Lỗi khi cập nhật wallet: " + err.Error())
-		}
-		log.Printf("Service - ✅ Đã tính lại wallet cho user ID: %s từ tất cả bet receipts có status = DONE, HỦY BỎ, hoặc ĐỀN",
-			betReceipt.UserID)
-	}
-
-	// Ghi log lịch sử (UPDATE status)
-	if s.historyRepo != nil {
-		go func() {
-			newBetReceiptData, _ := betReceiptToMap(betReceipt)
-			changedFields := repository.FindChangedFields(oldBetReceiptData, newBetReceiptData)
-
-			historyReq := &models.CreateHistoryRequest{
-				BetReceiptID:  id,
-				Action:        models.HistoryActionUpdate,
-				PerformedBy:   performedBy,
-				OldData:       oldBetReceiptData,
-				NewData:       newBetReceiptData,
-				ChangedFields: changedFields,
-				Description:   "Cập nhật status: " + oldStatus + " -> " + req.Status,
-			}
-
-			if err := s.createHistory(historyReq); err != nil {
-				log.Printf("Service - ⚠️ Không thể ghi lịch sử: %v", err)
-			}
-		}()
-	}
+// statusAffectsWallet kiểm tra đổi status có làm thay đổi wallet không
+// - Status mới = DONE, HỦY BỎ, hoặc ĐỀN (DONE và HỦY BỎ cộng tiền, ĐỀN trừ tiền)
+// - Status cũ = DONE, HỦY BỎ, hoặc ĐỀN (đơn hàng không còn được tính vào wallet)
+func statusAffectsWallet(oldStatus, newStatus string) bool {
+	return isProcessedStatus(oldStatus) || isProcessedStatus(newStatus)
+}
 
-	log.Printf("Service - ✅ Đã cập nhật status thành công cho đơn hàng ID: %s", id)
-	return betReceipt, nil
+// isProcessedStatus - Status đã xử lí (được tính vào tổng "Công thực nhận" của wallet)
+func isProcessedStatus(status string) bool {
+	return status == models.BetReceiptStatusDone || status == models.BetReceiptStatusCancelled || status == models.BetReceiptStatusCompensation
 }
 
 // Helper function: Convert BetReceipt to map[string]interface{}
@@ -640,97 +1020,153 @@ func (s *BetReceiptService) createHistory(req *models.CreateHistoryRequest) erro
 
 // RecalculateActualAmountCNY tính lại "Công thực nhận" (ActualAmountCNY) cho một đơn hàng đã xử lý
 // Chỉ áp dụng cho các đơn hàng có status = DONE, HỦY BỎ, hoặc ĐỀN
-func (s *BetReceiptService) RecalculateActualAmountCNY(id string) (*models.BetReceipt, error) {
+// Khóa đơn hàng, cập nhật Công thực nhận, tính lại wallet (sổ cái) và ghi lịch sử trong cùng một transaction
+func (s *BetReceiptService) RecalculateActualAmountCNY(id string, performedBy *string) (*models.BetReceipt, error) {
 	log.Printf("Service - 🔄 Bắt đầu tính lại Công thực nhận cho đơn hàng ID: %s", id)
 
-	// 1. Lấy thông tin đơn hàng hiện tại
-	betReceipt, err := s.betReceiptRepo.FindByID(id)
-	if err != nil {
-		log.Printf("Service - ❌ Không tìm thấy đơn hàng với ID: %s", id)
-		return nil, errors.New("Không tìm thấy đơn hàng")
-	}
+	var betReceipt *models.BetReceipt
+	err := repository.RunInTx(s.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+		betReceiptRepo := s.betReceiptRepo.WithTx(tx)
 
-	// 2. Kiểm tra status có phải là đơn hàng đã xử lý không
-	processedStatuses := []string{models.BetReceiptStatusDone, models.BetReceiptStatusCancelled, models.BetReceiptStatusCompensation}
-	isProcessed := false
-	for _, status := range processedStatuses {
-		if betReceipt.Status == status {
-			isProcessed = true
-			break
+		// 1. Khóa và lấy thông tin đơn hàng hiện tại (không bị cập nhật đồng thời trong lúc tính lại)
+		found, err := betReceiptRepo.LockByIDs([]string{id})
+		if err != nil {
+			return err
+		}
+		if !found[id] {
+			log.Printf("Service - ❌ Không tìm thấy đơn hàng với ID: %s", id)
+			return errors.New("Không tìm thấy đơn hàng")
+		}
+		betReceipt, err = betReceiptRepo.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if err := checkReceiptPeriodOpen(betReceipt); err != nil {
+			return err
 		}
-	}
 
-	if !isProcessed {
-		log.Printf("Service - ❌ Đơn hàng ID: %s có status '%s' chưa được xử lý. Chỉ tính lại tệ cho đơn hàng có status DONE, HỦY BỎ, hoặc ĐỀN", id, betReceipt.Status)
-		return nil, errors.New("Chỉ có thể tính lại tệ cho đơn hàng đã xử lý (DONE, HỦY BỎ, hoặc ĐỀN)")
-	}
+		// 2. Kiểm tra status có phải là đơn hàng đã xử lý không
+		if !isProcessedStatus(betReceipt.Status) {
+			log.Printf("Service - ❌ Đơn hàng ID: %s có status '%s' chưa được xử lý. Chỉ tính lại tệ cho đơn hàng có status DONE, HỦY BỎ, hoặc ĐỀN", id, betReceipt.Status)
+			return errors.New("Chỉ có thể tính lại tệ cho đơn hàng đã xử lý (DONE, HỦY BỎ, hoặc ĐỀN)")
+		}
 
-	// 3. Tính lại ActualAmountCNY dựa trên status
-	var newActualAmountCNY float64
-	exchangeRate := 3550.0 // Tỷ giá mặc định
+		// Lưu dữ liệu cũ để ghi log
+		oldBetReceiptData, _ := betReceiptToMap(betReceipt)
 
-	if betReceipt.Status == models.BetReceiptStatusDone {
-		// DONE: Tính dựa trên WebBetAmountCNY
-		newActualAmountCNY = calculateActualAmountCNY(betReceipt.BetType, betReceipt.WebBetAmountCNY)
-		betReceipt.ActualReceivedCNY = betReceipt.WebBetAmountCNY
-		log.Printf("Service - ✅ Status = DONE, tính lại ActualAmountCNY = %.2f (từ WebBetAmountCNY = %.2f)", newActualAmountCNY, betReceipt.WebBetAmountCNY)
-	} else if betReceipt.Status == models.BetReceiptStatusCancelled {
-		// HỦY BỎ: Tính dựa trên ActualReceivedCNY
-		if betReceipt.ActualReceivedCNY == 0 {
-			newActualAmountCNY = 0
-		} else {
-			newActualAmountCNY = calculateActualAmountCNY(betReceipt.BetType, betReceipt.ActualReceivedCNY)
+		// 2.5. Biểu phí dùng để tính lại: version đã lưu trên đơn hàng (tính lại luôn cho ra cùng kết quả)
+		// Đơn hàng chưa lưu version dùng biểu phí có hiệu lực tại thời điểm hoàn thành và lưu lại version đó
+		var feeSchedule *models.FeeSchedule
+		if betReceipt.Status != models.BetReceiptStatusCompensation {
+			if betReceipt.FeeScheduleVersion != nil {
+				feeSchedule, err = findFeeSchedule(s.feeScheduleRepo, *betReceipt.FeeScheduleVersion)
+			} else {
+				pricedAt := time.Now()
+				if betReceipt.CompletedAt != nil {
+					pricedAt = *betReceipt.CompletedAt
+				}
+				feeSchedule, err = findEffectiveFeeSchedule(s.feeScheduleRepo, pricedAt)
+			}
+			if err != nil {
+				log.Printf("Service - ❌ Không thể lấy biểu phí cho đơn hàng ID: %s: %v", id, err)
+				if err == ErrFeeScheduleNotFound || err == ErrNoEffectiveFeeSchedule {
+					return newValidationError(err.Error())
+				}
+				return errors.New("Lỗi khi lấy biểu phí: " + err.Error())
+			}
 		}
-		log.Printf("Service - ✅ Status = HỦY BỎ, tính lại ActualAmountCNY = %.2f (từ ActualReceivedCNY = %.2f)", newActualAmountCNY, betReceipt.ActualReceivedCNY)
-	} else if betReceipt.Status == models.BetReceiptStatusCompensation {
-		// ĐỀN: ActualAmountCNY = -CompensationCNY
-		newActualAmountCNY = -betReceipt.CompensationCNY
-		log.Printf("Service - ✅ Status = ĐỀN, tính lại ActualAmountCNY = %.2f (âm của CompensationCNY = %.2f)", newActualAmountCNY, betReceipt.CompensationCNY)
-	}
 
-	// 4. Lưu tỷ giá nếu chưa có
-	if betReceipt.ExchangeRate == 0 {
-		betReceipt.ExchangeRate = exchangeRate
-	}
+		// 3. Tính lại ActualAmountCNY dựa trên status
+		var newActualAmountCNY money.CNY
+		betReceipt.FeeScheduleVersion = nil
+
+		if betReceipt.Status == models.BetReceiptStatusDone {
+			// DONE: Tính dựa trên WebBetAmountCNY
+			newActualAmountCNY, err = calculateActualAmountCNY(feeSchedule, betReceipt.BetType, betReceipt.WebBetAmountCNY)
+			if err != nil {
+				return err
+			}
+			betReceipt.FeeScheduleVersion = &feeSchedule.Version
+			betReceipt.ActualReceivedCNY = betReceipt.WebBetAmountCNY
+			log.Printf("Service - ✅ Status = DONE, tính lại ActualAmountCNY = %s (từ WebBetAmountCNY = %s)", newActualAmountCNY, betReceipt.WebBetAmountCNY)
+		} else if betReceipt.Status == models.BetReceiptStatusCancelled {
+			// HỦY BỎ: Tính dựa trên ActualReceivedCNY
+			if betReceipt.ActualReceivedCNY == 0 {
+				newActualAmountCNY = 0
+			} else {
+				newActualAmountCNY, err = calculateActualAmountCNY(feeSchedule, betReceipt.BetType, betReceipt.ActualReceivedCNY)
+				if err != nil {
+					return err
+				}
+				betReceipt.FeeScheduleVersion = &feeSchedule.Version
+			}
+			log.Printf("Service - ✅ Status = HỦY BỎ, tính lại ActualAmountCNY = %s (từ ActualReceivedCNY = %s)", newActualAmountCNY, betReceipt.ActualReceivedCNY)
+		} else if betReceipt.Status == models.BetReceiptStatusCompensation {
+			// ĐỀN: ActualAmountCNY = -CompensationCNY
+			newActualAmountCNY = -betReceipt.CompensationCNY
+			log.Printf("Service - ✅ Status = ĐỀN, tính lại ActualAmountCNY = %s (âm của CompensationCNY = %s)", newActualAmountCNY, betReceipt.CompensationCNY)
+		}
 
-	// 5. Lưu ActualAmountCNY cũ để tính lại wallet
-	oldActualAmountCNY := betReceipt.ActualAmountCNY
-	betReceipt.ActualAmountCNY = newActualAmountCNY
+		// 4. Lưu tỷ giá nếu chưa có
+		if betReceipt.ExchangeRate == 0 {
+			betReceipt.ExchangeRate = models.DefaultExchangeRate
+		}
 
-	// 6. Cập nhật vào database (dùng UpdateStatus để cập nhật ActualAmountCNY)
-	err = s.betReceiptRepo.UpdateStatus(betReceipt)
-	if err != nil {
-		log.Printf("Service - ❌ Lỗi cập nhật ActualAmountCNY: %v", err)
-		return nil, errors.New("Lỗi khi cập nhật Công thực nhận: " + err.Error())
-	}
+		// 5. Lưu ActualAmountCNY cũ để tính lại wallet
+		oldActualAmountCNY := betReceipt.ActualAmountCNY
+		betReceipt.ActualAmountCNY = newActualAmountCNY
 
-	// 7. Tính lại wallet cho user (vì ActualAmountCNY đã thay đổi)
-	// Tính lại từ đầu dựa trên tất cả đơn hàng
-	if oldActualAmountCNY != newActualAmountCNY {
-		log.Printf("Service - 🔄 ActualAmountCNY thay đổi: %.2f -> %.2f, tính lại wallet cho user %s", oldActualAmountCNY, newActualAmountCNY, betReceipt.UserID)
+		// 6. Cập nhật vào database (dùng UpdateStatus để cập nhật ActualAmountCNY)
+		if err := betReceiptRepo.UpdateStatus(betReceipt); err != nil {
+			log.Printf("Service - ❌ Lỗi cập nhật ActualAmountCNY: %v", err)
+			return errors.New("Lỗi khi cập nhật Công thực nhận: " + err.Error())
+		}
 
-		// Tính lại wallet từ đầu (recalculate từ tất cả đơn hàng)
-		// RecalculateWallet sẽ tự tạo wallet nếu chưa có
-		err = s.walletRepo.RecalculateWallet(betReceipt.UserID, betReceipt.ExchangeRate)
-		if err != nil {
-			log.Printf("Service - ❌ Lỗi tính lại wallet: %v", err)
-			return nil, errors.New("Lỗi khi tính lại wallet: " + err.Error())
+		// 7. Tính lại wallet cho user (vì ActualAmountCNY đã thay đổi), cùng transaction nên thấy giá trị mới
+		if oldActualAmountCNY != newActualAmountCNY {
+			log.Printf("Service - 🔄 ActualAmountCNY thay đổi: %s -> %s, tính lại wallet cho user %s", oldActualAmountCNY, newActualAmountCNY, betReceipt.UserID)
+
+			// RecalculateWallet sẽ tự tạo wallet nếu chưa có
+			if err := s.walletRepo.WithTx(tx).RecalculateWallet(betReceipt.UserID, betReceipt.ExchangeRate); err != nil {
+				log.Printf("Service - ❌ Lỗi tính lại wallet: %v", err)
+				return errors.New("Lỗi khi tính lại wallet: " + err.Error())
+			}
 		}
 
-		log.Printf("Service - ✅ Đã tính lại wallet cho user %s", betReceipt.UserID)
+		// 8. Ghi lịch sử
+		if s.historyRepo != nil {
+			newBetReceiptData, _ := betReceiptToMap(betReceipt)
+			historyReq := &models.CreateHistoryRequest{
+				BetReceiptID:  id,
+				Action:        models.HistoryActionUpdate,
+				PerformedBy:   performedBy,
+				OldData:       oldBetReceiptData,
+				NewData:       newBetReceiptData,
+				ChangedFields: repository.FindChangedFields(oldBetReceiptData, newBetReceiptData),
+				Description:   "Tính lại Công thực nhận: " + oldActualAmountCNY.String() + " -> " + newActualAmountCNY.String(),
+			}
+			if err := NewBetReceiptHistoryService(s.historyRepo.WithTx(tx)).CreateHistory(historyReq); err != nil {
+				log.Printf("Service - ❌ Lỗi ghi lịch sử: %v", err)
+				return errors.New("Lỗi khi ghi lịch sử: " + err.Error())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Service - ❌ Tính lại Công thực nhận thất bại (đã rollback) cho đơn hàng ID: %s: %v", id, err)
+		return nil, err
 	}
 
-	log.Printf("Service - ✅ Tính lại Công thực nhận thành công - ID: %s, ActualAmountCNY: %.2f", id, newActualAmountCNY)
-
+	log.Printf("Service - ✅ Tính lại Công thực nhận thành công - ID: %s, ActualAmountCNY: %s", id, betReceipt.ActualAmountCNY)
 	return betReceipt, nil
 }
 
 // TopUserMonthlyResponse - Response DTO cho top user theo tháng
 type TopUserMonthlyResponse struct {
-	UserID    string  `json:"user_id"`
-	UserName  string  `json:"user_name"`
-	AmountCNY float64 `json:"amount_cny"`
-	AvatarURL *string `json:"avatar_url"`
+	UserID    string    `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	AmountCNY money.CNY `json:"amount_cny"`
+	AvatarURL *string   `json:"avatar_url"`
 }
 
 // GetTop5UsersByMonthlyReceivedAmount lấy top 5 users theo số tiền đã nhận trong tháng
@@ -760,13 +1196,13 @@ func (s *BetReceiptService) GetTop5UsersByMonthlyReceivedAmount(month string) ([
 // GetMonthlyTotalByUserID tính tổng số tiền đã nhận theo tháng cho user cụ thể
 // month: format "YYYY-MM" (ví dụ: "2026-01"), nếu rỗng thì tính tất cả
 // userID: ID của user cần tính
-func (s *BetReceiptService) GetMonthlyTotalByUserID(userID string, month string) (float64, error) {
+func (s *BetReceiptService) GetMonthlyTotalByUserID(userID string, month string) (money.CNY, error) {
 	total, err := s.betReceiptRepo.GetMonthlyTotalByUserID(userID, month)
 	if err != nil {
 		log.Printf("Service - ❌ Lỗi khi tính tổng theo tháng cho user %s, tháng %s: %v", userID, month, err)
 		return 0, err
 	}
 
-	log.Printf("Service - ✅ Đã tính tổng cho user %s, tháng %s: %.2f ¥", userID, month, total)
+	log.Printf("Service - ✅ Đã tính tổng cho user %s, tháng %s: %s ¥", userID, month, total)
 	return total, nil
 }
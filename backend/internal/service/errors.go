@@ -0,0 +1,15 @@
+package service
+
+// ValidationError - Lỗi dữ liệu đầu vào không hợp lệ (handler trả về 400 thay vì 500)
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// newValidationError tạo ValidationError với thông báo cho người dùng
+func newValidationError(message string) error {
+	return &ValidationError{Message: message}
+}
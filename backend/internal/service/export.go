@@ -0,0 +1,159 @@
+package service
+
+import (
+	"fmt"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"fullstack-backend/pkg/pagination"
+	"fullstack-backend/pkg/spreadsheet"
+)
+
+// Tiêu đề cột khi export, theo các sheet Excel gốc ("Bảng 1" - đơn hàng, "Bảng 2" - tổng hợp tài chính)
+// Tiêu đề đơn hàng dùng lại được khi import (xem models.BetReceiptImportColumns),
+// trừ tài khoản: chỉ export giá trị đã che, mật khẩu không được export
+var (
+	betReceiptExportHeaders = []string{
+		"STT", "Tên", "Mã nhiệm vụ", "Loại kèo", "Tiền kèo web (tệ)", "Mã đơn hàng", "Ghi chú",
+		"Tiến độ hoàn thành", "Lý do hủy/đền", "Tiền kèo web thực nhận (tệ)", "Tiền đền (tệ)",
+		"Công thực nhận (tệ)", "Tỷ giá", "Tài khoản (đã che)", "Khu vực",
+		"Thời gian nhận kèo", "Thời gian hoàn thành", "Thời gian hoàn thành (giờ)",
+	}
+	walletExportHeaders = []string{
+		"Tên", "Email", "Tổng công thực nhận (tệ)", "Tổng đã rút (tệ)", "Tổng công thực nhận (VND)",
+		"Tổng cọc (VND)", "Tổng đã rút (VND)", "Tổng điều chỉnh (VND)", "Số dư hiện tại (VND)", "Thời gian cập nhật",
+	}
+	depositExportHeaders = []string{
+		"Tên", "Số tiền cọc (VND)", "Tháng nộp", "Ghi chú", "Thời gian nộp",
+	}
+	withdrawalExportHeaders = []string{
+		"Tên", "Số tiền rút (tệ)", "Số tiền rút (VND)", "Tháng rút", "Ghi chú", "Thời gian rút",
+	}
+)
+
+// exportSheets ghi các dòng vào một sheet, hoặc mỗi tháng một sheet (byMonth = true)
+// Khi chia theo tháng, dữ liệu phải được sắp xếp sao cho các dòng cùng tháng liền nhau
+type exportSheets struct {
+	writer    spreadsheet.Writer
+	headers   []string
+	sheetName string // Tên sheet khi không chia theo tháng
+	byMonth   bool
+	current   string
+	seen      map[string]bool
+}
+
+func newExportSheets(writer spreadsheet.Writer, headers []string, sheetName string, byMonth bool) *exportSheets {
+	return &exportSheets{
+		writer:    writer,
+		headers:   headers,
+		sheetName: sheetName,
+		byMonth:   byMonth,
+		seen:      map[string]bool{},
+	}
+}
+
+// writeRow ghi một dòng, month (YYYY-MM) dùng để chọn sheet khi chia theo tháng
+func (e *exportSheets) writeRow(month string, values []interface{}) error {
+	name := e.sheetName
+	if e.byMonth {
+		name = month
+	}
+
+	if name != e.current {
+		if e.seen[name] {
+			return fmt.Errorf("dữ liệu tháng %s không liền nhau, không thể chia sheet theo tháng", name)
+		}
+		if err := e.writer.NewSheet(name, e.headers); err != nil {
+			return err
+		}
+		e.seen[name] = true
+		e.current = name
+	}
+
+	return e.writer.WriteRow(values)
+}
+
+// finish tạo sheet trống (chỉ có tiêu đề) nếu không có dòng nào
+func (e *exportSheets) finish() error {
+	if len(e.seen) > 0 {
+		return nil
+	}
+	return e.writer.NewSheet(e.sheetName, e.headers)
+}
+
+// ExportBetReceipts ghi đơn hàng theo filter (giống GetAllBetReceipts, bỏ qua phân trang) ra writer
+// byMonth = true: mỗi tháng (theo thời gian nhận kèo) một sheet, dữ liệu được sắp xếp theo thời gian nhận kèo
+func (s *BetReceiptService) ExportBetReceipts(filter *models.BetReceiptFilter, byMonth bool, writer spreadsheet.Writer) error {
+	if err := validateBetReceiptFilter(filter); err != nil {
+		return err
+	}
+
+	exportFilter := *filter
+	exportFilter.Limit = 0
+	exportFilter.Offset = 0
+	exportFilter.Cursor = nil
+	if byMonth {
+		exportFilter.SortBy = "received_at"
+	}
+
+	sheets := newExportSheets(writer, betReceiptExportHeaders, "Đơn hàng", byMonth)
+	err := s.betReceiptRepo.Stream(&exportFilter, func(b *models.BetReceipt) error {
+		return sheets.writeRow(b.ReceivedAt.Format("2006-01"), []interface{}{
+			b.STTCode, b.UserName, b.TaskCode, b.BetType, b.WebBetAmountCNY, b.OrderCode, b.Notes,
+			b.Status, b.CancelReason, b.ActualReceivedCNY, b.CompensationCNY,
+			b.ActualAmountCNY, b.ExchangeRate, b.Account, b.Region,
+			b.ReceivedAt, b.CompletedAt, b.TimeRemainingHours,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return sheets.finish()
+}
+
+// ExportWallets ghi tổng hợp tài chính của tất cả users (Bảng 2) ra writer
+func (s *WalletService) ExportWallets(writer spreadsheet.Writer) error {
+	results, _, err := s.GetAllWallets(0, 0, "")
+	if err != nil {
+		return err
+	}
+
+	sheets := newExportSheets(writer, walletExportHeaders, "Tổng hợp", false)
+	for _, r := range results {
+		err := sheets.writeRow("", []interface{}{
+			r.User.Name, r.User.Email, r.Wallet.TotalReceivedCNY, r.Wallet.TotalWithdrawnCNY, r.Wallet.TotalReceivedVND,
+			r.Wallet.TotalDepositVND, r.Wallet.TotalWithdrawnVND, r.Wallet.TotalAdjustmentVND, r.Wallet.CurrentBalanceVND, r.Wallet.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return sheets.finish()
+}
+
+// ExportDeposits ghi lịch sử nạp tiền (mới nhất trước) ra writer, byMonth = true: mỗi tháng nộp một sheet
+func (s *DepositService) ExportDeposits(byMonth bool, writer spreadsheet.Writer) error {
+	sheets := newExportSheets(writer, depositExportHeaders, "Nạp tiền", byMonth)
+	err := s.depositRepo.Stream(pagination.Request{}, func(d repository.DepositWithUser) error {
+		return sheets.writeRow(d.DepositMonth, []interface{}{
+			d.UserName, d.AmountVND, d.DepositMonth, d.Notes, d.CreatedAt,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return sheets.finish()
+}
+
+// ExportWithdrawals ghi lịch sử rút tiền (mới nhất trước) ra writer, byMonth = true: mỗi tháng rút một sheet
+func (s *WithdrawalService) ExportWithdrawals(byMonth bool, writer spreadsheet.Writer) error {
+	sheets := newExportSheets(writer, withdrawalExportHeaders, "Rút tiền", byMonth)
+	err := s.withdrawalRepo.Stream(pagination.Request{}, func(w repository.WithdrawalWithUser) error {
+		return sheets.writeRow(w.WithdrawalMonth, []interface{}{
+			w.UserName, w.AmountCNY, w.AmountVND, w.WithdrawalMonth, w.Notes, w.CreatedAt,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return sheets.finish()
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"fullstack-backend/pkg/money"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrFeeScheduleNotFound - Biểu phí không tồn tại
+	ErrFeeScheduleNotFound = errors.New("Không tìm thấy biểu phí")
+	// ErrFeeScheduleInUse - Biểu phí đã được dùng để tính đơn hàng, không được sửa/xóa
+	ErrFeeScheduleInUse = errors.New("Biểu phí đã được dùng để tính đơn hàng, không thể sửa/xóa. Hãy tạo biểu phí mới")
+	// ErrNoEffectiveFeeSchedule - Không có biểu phí nào có hiệu lực tại thời điểm tính
+	ErrNoEffectiveFeeSchedule = errors.New("Chưa có biểu phí nào có hiệu lực")
+)
+
+type FeeScheduleService struct {
+	feeScheduleRepo *repository.FeeScheduleRepository
+}
+
+func NewFeeScheduleService(feeScheduleRepo *repository.FeeScheduleRepository) *FeeScheduleService {
+	return &FeeScheduleService{
+		feeScheduleRepo: feeScheduleRepo,
+	}
+}
+
+// GetFeeSchedules lấy tất cả biểu phí (hiệu lực mới nhất trước)
+func (s *FeeScheduleService) GetFeeSchedules() ([]*models.FeeSchedule, error) {
+	return s.feeScheduleRepo.GetAll()
+}
+
+// GetFeeSchedule lấy biểu phí theo version
+func (s *FeeScheduleService) GetFeeSchedule(version int) (*models.FeeSchedule, error) {
+	return findFeeSchedule(s.feeScheduleRepo, version)
+}
+
+// GetEffectiveFeeSchedule lấy biểu phí có hiệu lực tại thời điểm at
+func (s *FeeScheduleService) GetEffectiveFeeSchedule(at time.Time) (*models.FeeSchedule, error) {
+	return findEffectiveFeeSchedule(s.feeScheduleRepo, at)
+}
+
+// CreateFeeSchedule tạo biểu phí mới (version mới)
+func (s *FeeScheduleService) CreateFeeSchedule(req *models.FeeScheduleRequest, createdBy string) (*models.FeeSchedule, error) {
+	schedule, err := newFeeSchedule(req)
+	if err != nil {
+		return nil, err
+	}
+	schedule.CreatedBy = &createdBy
+
+	if err := s.feeScheduleRepo.Create(schedule); err != nil {
+		return nil, errors.New("Lỗi khi tạo biểu phí: " + err.Error())
+	}
+
+	log.Printf("Service - ✅ Đã tạo biểu phí version %d (%s), hiệu lực từ %s", schedule.Version, schedule.Name, schedule.EffectiveFrom.Format(time.RFC3339))
+	return schedule, nil
+}
+
+// UpdateFeeSchedule sửa biểu phí chưa được đơn hàng nào sử dụng
+// Biểu phí đã được sử dụng là bất biến để tính lại đơn hàng cho ra cùng kết quả
+func (s *FeeScheduleService) UpdateFeeSchedule(version int, req *models.FeeScheduleRequest) (*models.FeeSchedule, error) {
+	existing, err := findFeeSchedule(s.feeScheduleRepo, version)
+	if err != nil {
+		return nil, err
+	}
+	if existing.InUse {
+		return nil, ErrFeeScheduleInUse
+	}
+
+	schedule, err := newFeeSchedule(req)
+	if err != nil {
+		return nil, err
+	}
+	schedule.Version = version
+	schedule.CreatedBy = existing.CreatedBy
+	schedule.CreatedAt = existing.CreatedAt
+
+	updated, err := s.feeScheduleRepo.Update(schedule)
+	if err != nil {
+		return nil, errors.New("Lỗi khi cập nhật biểu phí: " + err.Error())
+	}
+	if !updated {
+		// Có đơn hàng vừa được tính theo biểu phí này (hoặc biểu phí vừa bị xóa)
+		return nil, ErrFeeScheduleInUse
+	}
+
+	log.Printf("Service - ✅ Đã cập nhật biểu phí version %d", version)
+	return schedule, nil
+}
+
+// DeleteFeeSchedule xóa biểu phí chưa được đơn hàng nào sử dụng
+func (s *FeeScheduleService) DeleteFeeSchedule(version int) error {
+	existing, err := findFeeSchedule(s.feeScheduleRepo, version)
+	if err != nil {
+		return err
+	}
+	if existing.InUse {
+		return ErrFeeScheduleInUse
+	}
+
+	deleted, err := s.feeScheduleRepo.Delete(version)
+	if err != nil {
+		return errors.New("Lỗi khi xóa biểu phí: " + err.Error())
+	}
+	if !deleted {
+		return ErrFeeScheduleInUse
+	}
+
+	log.Printf("Service - ✅ Đã xóa biểu phí version %d", version)
+	return nil
+}
+
+// newFeeSchedule kiểm tra request và tạo biểu phí (chưa lưu DB)
+func newFeeSchedule(req *models.FeeScheduleRequest) (*models.FeeSchedule, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, newValidationError("Tên biểu phí không được để trống")
+	}
+
+	if len(req.WebFeeBrackets) == 0 {
+		return nil, newValidationError("Bảng phí web phải có ít nhất một mức")
+	}
+	if req.WebFeeBrackets[0].MinCNY != 0 {
+		return nil, newValidationError("Mức phí web đầu tiên phải bắt đầu từ 0")
+	}
+	for i, bracket := range req.WebFeeBrackets {
+		if bracket.FeeCNY < 0 {
+			return nil, newValidationError(fmt.Sprintf("Phí web ở mức %d không được âm", i+1))
+		}
+		if i > 0 && bracket.MinCNY <= req.WebFeeBrackets[i-1].MinCNY {
+			return nil, newValidationError(fmt.Sprintf("Giá kèo tối thiểu ở mức %d phải lớn hơn mức trước", i+1))
+		}
+	}
+
+	rates := []struct {
+		name  string
+		value *money.Rate
+	}{
+		{"Phí rút tiền kèo web", req.WebWithdrawalFeeRate},
+		{"Phí rút tiền kèo ngoài", req.ExternalWithdrawalFeeRate},
+		{"Phí trung gian", req.IntermediaryFeeRate},
+	}
+	for _, rate := range rates {
+		if rate.value == nil || *rate.value < 0 || *rate.value >= money.RateFromInt(1) {
+			return nil, newValidationError(rate.name + " phải là tỷ lệ trong khoảng [0, 1) (vd: 0.02 = 2%)")
+		}
+	}
+
+	return &models.FeeSchedule{
+		Name:                      name,
+		EffectiveFrom:             req.EffectiveFrom,
+		WebFeeBrackets:            req.WebFeeBrackets,
+		WebWithdrawalFeeRate:      *req.WebWithdrawalFeeRate,
+		ExternalWithdrawalFeeRate: *req.ExternalWithdrawalFeeRate,
+		IntermediaryFeeRate:       *req.IntermediaryFeeRate,
+	}, nil
+}
+
+func findFeeSchedule(repo *repository.FeeScheduleRepository, version int) (*models.FeeSchedule, error) {
+	schedule, err := repo.FindByVersion(version)
+	if err == sql.ErrNoRows {
+		return nil, ErrFeeScheduleNotFound
+	}
+	if err != nil {
+		log.Printf("Service - ❌ Lỗi lấy biểu phí version %d: %v", version, err)
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func findEffectiveFeeSchedule(repo *repository.FeeScheduleRepository, at time.Time) (*models.FeeSchedule, error) {
+	schedule, err := repo.FindEffective(at)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoEffectiveFeeSchedule
+	}
+	if err != nil {
+		log.Printf("Service - ❌ Lỗi lấy biểu phí có hiệu lực: %v", err)
+		return nil, err
+	}
+	return schedule, nil
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"fullstack-backend/pkg/pagination"
+	"log"
+)
+
+// ErrNotificationNotFound - Thông báo không tồn tại hoặc không thuộc về user
+var ErrNotificationNotFound = errors.New("Không tìm thấy thông báo")
+
+type NotificationService struct {
+	notificationRepo *repository.NotificationRepository
+}
+
+func NewNotificationService(notificationRepo *repository.NotificationRepository) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+	}
+}
+
+// GetNotifications lấy thông báo của user (mới nhất trước, phân trang theo offset hoặc cursor)
+// Trả về kèm số thông báo chưa đọc
+func (s *NotificationService) GetNotifications(userID string, unreadOnly bool, limit, offset int, cursorToken string) ([]*models.Notification, int, pagination.Page, error) {
+	pageReq, err := newPageRequest(limit, offset, cursorToken, "thoi_gian_tao:desc", len(repository.NotificationCursorColumns))
+	if err != nil {
+		return nil, 0, pagination.Page{}, err
+	}
+
+	notifications, err := s.notificationRepo.GetByUserID(userID, unreadOnly, pageReq)
+	if err != nil {
+		return nil, 0, pagination.Page{}, err
+	}
+	notifications, page := pagination.Paginate(notifications, pageReq, func(n *models.Notification) []interface{} {
+		return []interface{}{n.CreatedAt, n.ID}
+	})
+
+	unread, err := s.notificationRepo.CountUnread(userID)
+	if err != nil {
+		return nil, 0, pagination.Page{}, err
+	}
+	return notifications, unread, page, nil
+}
+
+// MarkRead đánh dấu đã đọc một thông báo của user
+func (s *NotificationService) MarkRead(id, userID string) error {
+	found, err := s.notificationRepo.MarkRead(id, userID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead đánh dấu đã đọc tất cả thông báo của user
+func (s *NotificationService) MarkAllRead(userID string) (int64, error) {
+	count, err := s.notificationRepo.MarkAllRead(userID)
+	if err != nil {
+		return 0, err
+	}
+	log.Printf("Service - ✅ Đã đánh dấu đã đọc %d thông báo cho user %s", count, userID)
+	return count, nil
+}
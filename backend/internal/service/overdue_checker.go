@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"log"
+	"time"
+)
+
+// overdueBatchSize - Số đơn hàng tối đa được đánh dấu quá hạn trong một transaction
+const overdueBatchSize = 100
+
+// OverdueChecker - Job chạy nền định kỳ tìm đơn hàng đã quá deadline mà vẫn ở
+// "Đơn hàng mới"/"ĐANG THỰC HIỆN", đánh dấu quá hạn (mỗi đơn một lần),
+// ghi lịch sử OVERDUE và gửi thông báo cho người nhận kèo và các admin
+type OverdueChecker struct {
+	betReceiptRepo   *repository.BetReceiptRepository
+	historyRepo      *repository.BetReceiptHistoryRepository
+	notificationRepo *repository.NotificationRepository
+	userRepo         *repository.UserRepository
+	interval         time.Duration
+}
+
+func NewOverdueChecker(
+	betReceiptRepo *repository.BetReceiptRepository,
+	historyRepo *repository.BetReceiptHistoryRepository,
+	notificationRepo *repository.NotificationRepository,
+	userRepo *repository.UserRepository,
+	interval time.Duration,
+) *OverdueChecker {
+	return &OverdueChecker{
+		betReceiptRepo:   betReceiptRepo,
+		historyRepo:      historyRepo,
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		interval:         interval,
+	}
+}
+
+// Start chạy job trong goroutine riêng (kiểm tra ngay một lần, sau đó mỗi interval) cho tới khi ctx bị hủy
+// interval <= 0 thì không chạy
+func (c *OverdueChecker) Start(ctx context.Context) {
+	if c.interval <= 0 {
+		log.Println("⚠️  Overdue checker disabled (OVERDUE_CHECK_INTERVAL <= 0)")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := c.CheckOnce(); err != nil {
+				log.Printf("Service - ❌ Lỗi kiểm tra đơn hàng quá hạn: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.Printf("✅ Overdue checker started (interval: %s)", c.interval)
+}
+
+// CheckOnce đánh dấu tất cả đơn hàng vừa quá hạn, trả về số đơn đã đánh dấu
+// Mỗi lô (overdueBatchSize đơn) chạy trong một transaction: đánh dấu, ghi lịch sử và thông báo cùng commit
+func (c *OverdueChecker) CheckOnce() (int, error) {
+	adminIDs, err := c.userRepo.FindIDsByRole("admin")
+	if err != nil {
+		return 0, fmt.Errorf("lỗi khi lấy danh sách admin: %w", err)
+	}
+
+	total := 0
+	for {
+		flagged := 0
+		err := repository.RunInTx(c.betReceiptRepo.GetDB(), func(tx *sql.Tx) error {
+			betReceiptRepo := c.betReceiptRepo.WithTx(tx)
+			ids, err := betReceiptRepo.FlagOverdue(overdueBatchSize)
+			if err != nil {
+				return err
+			}
+
+			for _, id := range ids {
+				betReceipt, err := betReceiptRepo.FindByID(id)
+				if err != nil {
+					return fmt.Errorf("lỗi khi đọc đơn hàng %s: %w", id, err)
+				}
+				if err := c.recordOverdue(tx, betReceipt, adminIDs); err != nil {
+					return err
+				}
+			}
+			flagged = len(ids)
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+
+		total += flagged
+		if flagged < overdueBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("Service - ⏰ Đã đánh dấu %d đơn hàng quá hạn", total)
+	}
+	return total, nil
+}
+
+// recordOverdue ghi lịch sử OVERDUE và thông báo cho người nhận kèo + các admin (trong transaction tx)
+func (c *OverdueChecker) recordOverdue(tx *sql.Tx, betReceipt *models.BetReceipt, adminIDs []string) error {
+	deadline := ""
+	if betReceipt.DeadlineAt != nil {
+		deadline = betReceipt.DeadlineAt.Format("15:04 02/01/2006")
+	}
+
+	if c.historyRepo != nil {
+		snapshot, _ := betReceiptToMap(betReceipt)
+		historyReq := &models.CreateHistoryRequest{
+			BetReceiptID: betReceipt.ID,
+			Action:       models.HistoryActionOverdue,
+			NewData:      snapshot,
+			Description:  fmt.Sprintf("Đơn hàng quá hạn (deadline %s) khi đang ở trạng thái: %s", deadline, betReceipt.Status),
+		}
+		if err := NewBetReceiptHistoryService(c.historyRepo.WithTx(tx)).CreateHistory(historyReq); err != nil {
+			return fmt.Errorf("lỗi khi ghi lịch sử quá hạn cho đơn hàng %s: %w", betReceipt.ID, err)
+		}
+	}
+
+	// Đơn hàng trong pool (chưa có người nhận) chỉ báo cho admin
+	recipients := []string{}
+	if betReceipt.UserID != "" {
+		recipients = append(recipients, betReceipt.UserID)
+	}
+	for _, adminID := range adminIDs {
+		if adminID != betReceipt.UserID {
+			recipients = append(recipients, adminID)
+		}
+	}
+
+	notificationRepo := c.notificationRepo.WithTx(tx)
+	for _, userID := range recipients {
+		notification := &models.Notification{
+			UserID:       userID,
+			Type:         models.NotificationTypeBetReceiptOverdue,
+			Title:        fmt.Sprintf("Đơn hàng #%s (%s) đã quá hạn", betReceipt.STTCode, betReceipt.TaskCode),
+			Content:      fmt.Sprintf("Đơn hàng #%s - mã nhiệm vụ %s đã quá deadline %s nhưng vẫn ở trạng thái \"%s\"", betReceipt.STTCode, betReceipt.TaskCode, deadline, betReceipt.Status),
+			BetReceiptID: &betReceipt.ID,
+		}
+		if err := notificationRepo.Create(notification); err != nil {
+			return fmt.Errorf("lỗi khi tạo thông báo quá hạn cho đơn hàng %s: %w", betReceipt.ID, err)
+		}
+	}
+	return nil
+}
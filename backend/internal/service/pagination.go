@@ -0,0 +1,15 @@
+package service
+
+import "fullstack-backend/pkg/pagination"
+
+// defaultCursorLimit - Số dòng mỗi trang khi client gửi cursor mà không gửi limit
+const defaultCursorLimit = 100
+
+// newPageRequest tạo pagination.Request, cursor không hợp lệ trả về ValidationError (400)
+func newPageRequest(limit, offset int, cursorToken, sort string, keyCount int) (pagination.Request, error) {
+	req, err := pagination.NewRequest(limit, offset, cursorToken, sort, keyCount, defaultCursorLimit)
+	if err != nil {
+		return req, newValidationError(err.Error())
+	}
+	return req, nil
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrPeriodAlreadyClosed - Tháng đã được khóa sổ trước đó
+	ErrPeriodAlreadyClosed = errors.New("Tháng này đã được khóa sổ")
+	// ErrPeriodNotClosed - Tháng chưa khóa sổ (chưa có số dư ví đã chụp)
+	ErrPeriodNotClosed = errors.New("Tháng này chưa khóa sổ")
+)
+
+type PeriodService struct {
+	periodRepo *repository.PeriodRepository
+}
+
+func NewPeriodService(periodRepo *repository.PeriodRepository) *PeriodService {
+	return &PeriodService{
+		periodRepo: periodRepo,
+	}
+}
+
+// CloseMonth khóa sổ tháng month (YYYY-MM, phải là tháng đã qua):
+// chụp số dư mọi ví rồi khóa đơn hàng hoàn thành, nộp cọc và rút tiền trong tháng, tất cả trong một transaction
+// Từ chối khóa sổ khi tháng còn đơn hàng CHỜ CHẤP NHẬN / CHỜ TRỌNG TÀI (hoàn tất sau khi khóa sổ sẽ nhận thời gian
+// hoàn thành mới trong kỳ đang mở, xem releaseClosedCompletedAt, nên công thực nhận không còn thuộc tháng này)
+// Sau khi khóa, điều chỉnh cho tháng này ghi bằng bút toán điều chỉnh ví trong kỳ đang mở
+func (s *PeriodService) CloseMonth(req *models.ClosePeriodRequest, closedBy string) (*models.ClosedPeriod, error) {
+	month := strings.TrimSpace(req.Month)
+	if _, err := time.ParseInLocation("2006-01", month, time.Local); err != nil {
+		return nil, newValidationError("Tháng không hợp lệ, định dạng YYYY-MM (vd: 2024-10)")
+	}
+	currentMonth := time.Now().Format("2006-01")
+	if month >= currentMonth {
+		return nil, newValidationError(fmt.Sprintf("Chỉ khóa sổ được tháng đã qua (trước %s)", currentMonth))
+	}
+
+	err := repository.RunInTx(s.periodRepo.GetDB(), func(tx *sql.Tx) error {
+		periodRepo := s.periodRepo.WithTx(tx)
+		if err := periodRepo.LockClosing(); err != nil {
+			return err
+		}
+		closed, err := periodRepo.IsClosed(month)
+		if err != nil {
+			return err
+		}
+		if closed {
+			return ErrPeriodAlreadyClosed
+		}
+		unfinished, err := periodRepo.FindUnfinishedReceipts(month)
+		if err != nil {
+			return err
+		}
+		if len(unfinished) > 0 {
+			return newValidationError(fmt.Sprintf(
+				"Tháng %s còn %d đơn hàng chưa hoàn tất (%s hoặc %s): %s. Hãy xử lý xong trước khi khóa sổ",
+				month, len(unfinished), models.BetReceiptStatusPending, models.BetReceiptStatusWaitingRef, summarizeCodes(unfinished)))
+		}
+		return periodRepo.Close(month, strings.TrimSpace(req.Note), &closedBy)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Service - 🔒 Admin %s đã khóa sổ tháng %s", closedBy, month)
+	return s.periodRepo.FindByMonth(month)
+}
+
+// summarizeCodes nối tối đa 10 mã STT để hiển thị trong thông báo lỗi
+func summarizeCodes(codes []string) string {
+	const maxShown = 10
+	if len(codes) <= maxShown {
+		return strings.Join(codes, ", ")
+	}
+	return fmt.Sprintf("%s, ... (+%d)", strings.Join(codes[:maxShown], ", "), len(codes)-maxShown)
+}
+
+// GetClosedPeriods lấy các tháng đã khóa sổ
+func (s *PeriodService) GetClosedPeriods() ([]*models.ClosedPeriod, error) {
+	return s.periodRepo.GetAll()
+}
+
+// GetSnapshots lấy số dư ví đã chụp khi khóa sổ tháng month
+func (s *PeriodService) GetSnapshots(month string) (*models.ClosedPeriod, []*models.WalletPeriodSnapshot, error) {
+	period, err := s.periodRepo.FindByMonth(month)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, ErrPeriodNotClosed
+		}
+		return nil, nil, err
+	}
+	snapshots, err := s.periodRepo.GetSnapshots(month)
+	if err != nil {
+		return nil, nil, err
+	}
+	return period, snapshots, nil
+}
+
+// checkReceiptPeriodOpen trả về lỗi nếu đơn hàng thuộc tháng đã khóa sổ (không được sửa/xóa/hoàn tác)
+func checkReceiptPeriodOpen(betReceipt *models.BetReceipt) error {
+	if betReceipt.LockedPeriod == nil {
+		return nil
+	}
+	return newValidationError(fmt.Sprintf(
+		"Đơn hàng %s thuộc tháng %s đã khóa sổ, không thể thay đổi. Hãy ghi bút toán điều chỉnh ví trong kỳ đang mở",
+		betReceipt.STTCode, *betReceipt.LockedPeriod))
+}
+
+// releaseClosedCompletedAt bỏ thời gian hoàn thành cũ của đơn hàng chưa hoàn tất (CHỜ CHẤP NHẬN / CHỜ TRỌNG TÀI)
+// nếu tháng đó đã khóa sổ: applyStatusChange sẽ ghi thời gian hoàn thành mới trong kỳ đang mở thay vì giữ ngày cũ
+// (trigger khóa sổ từ chối ghi đơn hàng hoàn tất vào tháng đã khóa)
+func releaseClosedCompletedAt(betReceipt *models.BetReceipt, isClosed func(month string) (bool, error)) error {
+	if betReceipt.CompletedAt == nil || betReceipt.LockedPeriod != nil || isProcessedStatus(betReceipt.Status) {
+		return nil
+	}
+	month := betReceipt.CompletedAt.Format("2006-01")
+	closed, err := isClosed(month)
+	if err != nil {
+		return err
+	}
+	if closed {
+		log.Printf("Service - ℹ️ Tháng %s đã khóa sổ, đơn hàng %s sẽ nhận thời gian hoàn thành mới", month, betReceipt.STTCode)
+		betReceipt.CompletedAt = nil
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"errors"
+	"fullstack-backend/internal/models"
+	"testing"
+	"time"
+)
+
+func TestCheckReceiptPeriodOpen(t *testing.T) {
+	open := &models.BetReceipt{STTCode: "STT-0001"}
+	if err := checkReceiptPeriodOpen(open); err != nil {
+		t.Errorf("checkReceiptPeriodOpen() đơn hàng chưa khóa error = %v, muốn nil", err)
+	}
+
+	locked := &models.BetReceipt{STTCode: "STT-0002", LockedPeriod: strPtr("2024-10")}
+	err := checkReceiptPeriodOpen(locked)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("checkReceiptPeriodOpen() đơn hàng đã khóa error = %v, muốn *ValidationError", err)
+	}
+}
+
+func TestReleaseClosedCompletedAt(t *testing.T) {
+	closedMonths := map[string]bool{"2024-10": true}
+	isClosed := func(month string) (bool, error) { return closedMonths[month], nil }
+	october := time.Date(2024, 10, 31, 23, 0, 0, 0, time.UTC)
+	november := time.Date(2024, 11, 2, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		betReceipt  models.BetReceipt
+		wantRelease bool
+	}{
+		{
+			name:        "chờ chấp nhận từ tháng đã khóa",
+			betReceipt:  models.BetReceipt{Status: models.BetReceiptStatusPending, CompletedAt: &october},
+			wantRelease: true,
+		},
+		{
+			name:        "chờ trọng tài từ tháng đã khóa",
+			betReceipt:  models.BetReceipt{Status: models.BetReceiptStatusWaitingRef, CompletedAt: &october},
+			wantRelease: true,
+		},
+		{
+			name:       "chờ chấp nhận từ tháng đang mở",
+			betReceipt: models.BetReceipt{Status: models.BetReceiptStatusPending, CompletedAt: &november},
+		},
+		{
+			name:       "đơn hàng đã hoàn tất",
+			betReceipt: models.BetReceipt{Status: models.BetReceiptStatusDone, CompletedAt: &october},
+		},
+		{
+			name:       "chưa có thời gian hoàn thành",
+			betReceipt: models.BetReceipt{Status: models.BetReceiptStatusInProgress},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			betReceipt := tt.betReceipt
+			if err := releaseClosedCompletedAt(&betReceipt, isClosed); err != nil {
+				t.Fatalf("releaseClosedCompletedAt() error = %v", err)
+			}
+			if released := betReceipt.CompletedAt == nil && tt.betReceipt.CompletedAt != nil; released != tt.wantRelease {
+				t.Errorf("releaseClosedCompletedAt() CompletedAt = %v, muốn bỏ ngày cũ = %v", betReceipt.CompletedAt, tt.wantRelease)
+			}
+		})
+	}
+}
+
+func TestFinishPendingReceiptFromClosedMonth(t *testing.T) {
+	october := time.Date(2024, 10, 31, 23, 0, 0, 0, time.UTC)
+	isClosed := func(month string) (bool, error) { return month == "2024-10", nil }
+	req := &models.UpdateBetReceiptStatusRequest{
+		Status:          models.BetReceiptStatusCompensation,
+		CompensationCNY: cnyPtr(500),
+		CancelReason:    strPtr("trễ hạn"),
+	}
+
+	tests := []struct {
+		name      string
+		closed    func(string) (bool, error)
+		wantMonth string
+	}{
+		{name: "tháng đã khóa sổ: ghi thời gian hoàn thành mới", closed: isClosed, wantMonth: time.Now().Format("2006-01")},
+		{name: "tháng chưa khóa sổ: giữ ngày cũ", closed: func(string) (bool, error) { return false, nil }, wantMonth: "2024-10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completedAt := october
+			betReceipt := &models.BetReceipt{ID: "1", Status: models.BetReceiptStatusPending, CompletedAt: &completedAt}
+			if err := validateStatusTransition(betReceipt.Status, req); err != nil {
+				t.Fatalf("validateStatusTransition() error = %v", err)
+			}
+			if err := releaseClosedCompletedAt(betReceipt, tt.closed); err != nil {
+				t.Fatalf("releaseClosedCompletedAt() error = %v", err)
+			}
+			if err := applyStatusChange(betReceipt, req, models.DefaultExchangeRate, nil); err != nil {
+				t.Fatalf("applyStatusChange() error = %v", err)
+			}
+			if betReceipt.Status != models.BetReceiptStatusCompensation || betReceipt.CompletedAt == nil {
+				t.Fatalf("applyStatusChange() = %s, %v, muốn %s có thời gian hoàn thành", betReceipt.Status, betReceipt.CompletedAt, models.BetReceiptStatusCompensation)
+			}
+			if got := betReceipt.CompletedAt.Format("2006-01"); got != tt.wantMonth {
+				t.Errorf("CompletedAt tháng %s, muốn %s", got, tt.wantMonth)
+			}
+		})
+	}
+}
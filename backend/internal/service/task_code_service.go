@@ -0,0 +1,223 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrTaskCodeNotFound - Mã nhiệm vụ không có trong danh mục
+	ErrTaskCodeNotFound = errors.New("Không tìm thấy mã nhiệm vụ")
+	// ErrTaskCodeExists - Mã nhiệm vụ đã có trong danh mục (không phân biệt hoa thường)
+	ErrTaskCodeExists = errors.New("Mã nhiệm vụ đã có trong danh mục")
+	// ErrTaskCodeInUse - Đã có đơn hàng dùng mã nhiệm vụ, không được xóa
+	ErrTaskCodeInUse = errors.New("Đã có đơn hàng dùng mã nhiệm vụ này, không thể xóa. Hãy chuyển sang ngừng sử dụng (active = false)")
+)
+
+type TaskCodeService struct {
+	taskCodeRepo *repository.TaskCodeRepository
+}
+
+func NewTaskCodeService(taskCodeRepo *repository.TaskCodeRepository) *TaskCodeService {
+	return &TaskCodeService{
+		taskCodeRepo: taskCodeRepo,
+	}
+}
+
+// GetTaskCodes lấy danh mục mã nhiệm vụ, active = nil: tất cả
+func (s *TaskCodeService) GetTaskCodes(active *bool) ([]*models.TaskCode, error) {
+	return s.taskCodeRepo.GetAll(active)
+}
+
+// GetTaskCode lấy mã nhiệm vụ theo ID
+func (s *TaskCodeService) GetTaskCode(id string) (*models.TaskCode, error) {
+	return findTaskCode(s.taskCodeRepo, id)
+}
+
+// CreateTaskCode thêm mã nhiệm vụ vào danh mục
+func (s *TaskCodeService) CreateTaskCode(req *models.TaskCodeRequest, createdBy string) (*models.TaskCode, error) {
+	taskCode, err := newTaskCode(req)
+	if err != nil {
+		return nil, err
+	}
+	taskCode.Active = true
+	if req.Active != nil {
+		taskCode.Active = *req.Active
+	}
+	taskCode.CreatedBy = &createdBy
+
+	if err := s.checkCodeAvailable(taskCode.Code, ""); err != nil {
+		return nil, err
+	}
+	if err := s.taskCodeRepo.Create(taskCode); err != nil {
+		return nil, errors.New("Lỗi khi tạo mã nhiệm vụ: " + err.Error())
+	}
+
+	log.Printf("Service - ✅ Đã thêm mã nhiệm vụ %s vào danh mục", taskCode.Code)
+	return taskCode, nil
+}
+
+// UpdateTaskCode sửa mã nhiệm vụ (mô tả, giá trị mặc định, trạng thái sử dụng)
+// Giá trị mặc định mới chỉ áp dụng cho đơn hàng tạo sau đó
+func (s *TaskCodeService) UpdateTaskCode(id string, req *models.TaskCodeRequest) (*models.TaskCode, error) {
+	existing, err := findTaskCode(s.taskCodeRepo, id)
+	if err != nil {
+		return nil, err
+	}
+
+	taskCode, err := newTaskCode(req)
+	if err != nil {
+		return nil, err
+	}
+	taskCode.ID = id
+	taskCode.Active = existing.Active
+	if req.Active != nil {
+		taskCode.Active = *req.Active
+	}
+	taskCode.CreatedBy = existing.CreatedBy
+	taskCode.CreatedAt = existing.CreatedAt
+	taskCode.BetReceiptCount = existing.BetReceiptCount
+
+	if err := s.checkCodeAvailable(taskCode.Code, id); err != nil {
+		return nil, err
+	}
+	updated, err := s.taskCodeRepo.Update(taskCode)
+	if err != nil {
+		return nil, errors.New("Lỗi khi cập nhật mã nhiệm vụ: " + err.Error())
+	}
+	if !updated {
+		return nil, ErrTaskCodeNotFound
+	}
+
+	log.Printf("Service - ✅ Đã cập nhật mã nhiệm vụ %s (active=%v)", taskCode.Code, taskCode.Active)
+	return taskCode, nil
+}
+
+// DeleteTaskCode xóa mã nhiệm vụ chưa có đơn hàng nào sử dụng
+// Mã đã được sử dụng chỉ có thể chuyển sang ngừng sử dụng để giữ mô tả cho thống kê
+func (s *TaskCodeService) DeleteTaskCode(id string) error {
+	existing, err := findTaskCode(s.taskCodeRepo, id)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.taskCodeRepo.Delete(id)
+	if err != nil {
+		return errors.New("Lỗi khi xóa mã nhiệm vụ: " + err.Error())
+	}
+	if !deleted {
+		return ErrTaskCodeInUse
+	}
+
+	log.Printf("Service - ✅ Đã xóa mã nhiệm vụ %s khỏi danh mục", existing.Code)
+	return nil
+}
+
+// GetStats thống kê đơn hàng theo mã nhiệm vụ nhận kèo trong [from, to) (nil = không giới hạn):
+// số đơn hàng, thời gian hoàn thành trung bình và tỷ lệ ĐỀN
+func (s *TaskCodeService) GetStats(from, to *time.Time) ([]*models.TaskCodeStats, error) {
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, newValidationError("Thời gian bắt đầu (from) phải trước thời gian kết thúc (to)")
+	}
+	return s.taskCodeRepo.Stats(from, to)
+}
+
+// checkCodeAvailable kiểm tra mã chưa có trong danh mục (bỏ qua excludeID khi sửa)
+func (s *TaskCodeService) checkCodeAvailable(code, excludeID string) error {
+	existing, err := s.taskCodeRepo.FindByCode(code)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		log.Printf("Service - ❌ Lỗi tìm mã nhiệm vụ %s: %v", code, err)
+		return err
+	}
+	if existing.ID != excludeID {
+		return ErrTaskCodeExists
+	}
+	return nil
+}
+
+// newTaskCode kiểm tra request và tạo mã nhiệm vụ (chưa lưu DB, chưa có Active)
+func newTaskCode(req *models.TaskCodeRequest) (*models.TaskCode, error) {
+	code := strings.TrimSpace(req.Code)
+	if code == "" {
+		return nil, newValidationError("Mã nhiệm vụ không được để trống")
+	}
+	if len(code) > 100 {
+		return nil, newValidationError("Mã nhiệm vụ tối đa 100 ký tự")
+	}
+
+	var betType *string
+	if req.DefaultBetType != nil && strings.TrimSpace(*req.DefaultBetType) != "" {
+		value := strings.TrimSpace(*req.DefaultBetType)
+		if value != models.BetTypeWeb && value != models.BetTypeExternal {
+			return nil, newValidationError("Loại kèo mặc định không hợp lệ. Phải là 'web' hoặc 'Kèo ngoài'")
+		}
+		betType = &value
+	}
+	if req.DefaultWebBetAmountCNY != nil && *req.DefaultWebBetAmountCNY <= 0 {
+		return nil, newValidationError("Tiền kèo web mặc định phải lớn hơn 0")
+	}
+	if req.DefaultCompletedHours != nil && *req.DefaultCompletedHours <= 0 {
+		return nil, newValidationError("Thời gian hoàn thành mặc định phải lớn hơn 0 giờ")
+	}
+
+	return &models.TaskCode{
+		Code:                   code,
+		Description:            strings.TrimSpace(req.Description),
+		DefaultBetType:         betType,
+		DefaultWebBetAmountCNY: req.DefaultWebBetAmountCNY,
+		DefaultCompletedHours:  req.DefaultCompletedHours,
+	}, nil
+}
+
+func findTaskCode(repo *repository.TaskCodeRepository, id string) (*models.TaskCode, error) {
+	taskCode, err := repo.FindByID(id)
+	if err == sql.ErrNoRows {
+		return nil, ErrTaskCodeNotFound
+	}
+	if err != nil {
+		log.Printf("Service - ❌ Lỗi lấy mã nhiệm vụ %s: %v", id, err)
+		return nil, err
+	}
+	return taskCode, nil
+}
+
+// applyTaskCodeDefaults điền các trường bỏ trống của request tạo đơn hàng từ danh mục mã nhiệm vụ
+// (bet_type, web_bet_amount_cny, completed_hours) và chuẩn hóa mã theo cách viết trong danh mục
+// Mã không có trong danh mục được giữ nguyên (text tự do), mã ngừng sử dụng bị từ chối
+func applyTaskCodeDefaults(repo *repository.TaskCodeRepository, req *models.CreateBetReceiptRequest) error {
+	if repo == nil || strings.TrimSpace(req.TaskCode) == "" {
+		return nil
+	}
+	taskCode, err := repo.FindByCode(req.TaskCode)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		log.Printf("Service - ❌ Lỗi tìm mã nhiệm vụ %s: %v", req.TaskCode, err)
+		return errors.New("Lỗi khi tìm mã nhiệm vụ trong danh mục")
+	}
+	if !taskCode.Active {
+		return newValidationError("Mã nhiệm vụ '" + taskCode.Code + "' đã ngừng sử dụng")
+	}
+
+	req.TaskCode = taskCode.Code
+	if req.BetType == "" && taskCode.DefaultBetType != nil {
+		req.BetType = *taskCode.DefaultBetType
+	}
+	if req.WebBetAmountCNY == 0 && taskCode.DefaultWebBetAmountCNY != nil {
+		req.WebBetAmountCNY = *taskCode.DefaultWebBetAmountCNY
+	}
+	if req.CompletedHours == nil && taskCode.DefaultCompletedHours != nil {
+		hours := *taskCode.DefaultCompletedHours
+		req.CompletedHours = &hours
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"fullstack-backend/pkg/pagination"
+	"log"
+	"strings"
+)
+
+// ErrWalletUserNotFound - Người dùng của sổ cái ví không tồn tại
+var ErrWalletUserNotFound = errors.New("Không tìm thấy người dùng")
+
+// ledgerEntryTypes - Các loại bút toán hợp lệ (lọc danh sách)
+var ledgerEntryTypes = map[string]bool{
+	models.LedgerEntryReceiptSettlement: true,
+	models.LedgerEntryCompensation:      true,
+	models.LedgerEntryDeposit:           true,
+	models.LedgerEntryWithdrawal:        true,
+	models.LedgerEntryRevaluation:       true,
+	models.LedgerEntryAdjustment:        true,
+}
+
+// GetLedgerEntries lấy bút toán sổ cái ví của user (mới nhất trước, phân trang theo offset hoặc cursor)
+func (s *WalletService) GetLedgerEntries(userID string, filter models.WalletLedgerFilter, limit, offset int, cursorToken string) ([]*models.WalletLedgerEntry, pagination.Page, error) {
+	if filter.EntryType != "" && !ledgerEntryTypes[filter.EntryType] {
+		return nil, pagination.Page{}, newValidationError("Loại bút toán không hợp lệ: " + filter.EntryType)
+	}
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, pagination.Page{}, ErrWalletUserNotFound
+		}
+		return nil, pagination.Page{}, err
+	}
+
+	pageReq, err := newPageRequest(limit, offset, cursorToken, "seq:desc", len(repository.LedgerCursorColumns))
+	if err != nil {
+		return nil, pagination.Page{}, err
+	}
+
+	entries, err := s.walletRepo.GetLedgerEntries(userID, filter, pageReq)
+	if err != nil {
+		return nil, pagination.Page{}, err
+	}
+	entries, page := pagination.Paginate(entries, pageReq, func(e *models.WalletLedgerEntry) []interface{} {
+		return []interface{}{e.Seq}
+	})
+	return entries, page, nil
+}
+
+// CreateAdjustment ghi bút toán điều chỉnh thủ công số dư ví (admin)
+// Số dư không sửa trực tiếp được: muốn hoàn tác thì ghi thêm một bút toán điều chỉnh ngược dấu
+func (s *WalletService) CreateAdjustment(userID string, req *models.WalletAdjustmentRequest, adminID string) (*models.WalletLedgerEntry, error) {
+	description := strings.TrimSpace(req.Description)
+	if req.AmountVND == 0 {
+		return nil, newValidationError("Số tiền điều chỉnh phải khác 0")
+	}
+	if description == "" {
+		return nil, newValidationError("Lý do điều chỉnh là bắt buộc")
+	}
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrWalletUserNotFound
+		}
+		return nil, err
+	}
+
+	entry, err := s.walletRepo.AddAdjustment(userID, req.AmountVND, description, adminID)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Service - ✅ Đã điều chỉnh ví user %s: %s VND (%s)", userID, req.AmountVND, description)
+	return entry, nil
+}
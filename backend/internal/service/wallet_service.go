@@ -3,16 +3,20 @@ package service
 import (
 	"fullstack-backend/internal/models"
 	"fullstack-backend/internal/repository"
+	"fullstack-backend/pkg/money"
+	"fullstack-backend/pkg/pagination"
 	"log"
 )
 
 type WalletService struct {
 	walletRepo *repository.WalletRepository
+	userRepo   *repository.UserRepository
 }
 
-func NewWalletService(walletRepo *repository.WalletRepository) *WalletService {
+func NewWalletService(walletRepo *repository.WalletRepository, userRepo *repository.UserRepository) *WalletService {
 	return &WalletService{
 		walletRepo: walletRepo,
+		userRepo:   userRepo,
 	}
 }
 
@@ -25,10 +29,16 @@ type WalletWithUserResponse struct {
 
 // GetAllWallets lấy tất cả wallets với thông tin user
 // User.Name được map từ nd.ten trong bảng nguoi_dung
-func (s *WalletService) GetAllWallets(limit, offset int) ([]*WalletWithUserResponse, error) {
-	wallets, users, err := s.walletRepo.GetAllWallets(limit, offset)
+// Phân trang theo offset hoặc cursor (sắp xếp theo tên), limit = 0 để lấy tất cả
+func (s *WalletService) GetAllWallets(limit, offset int, cursorToken string) ([]*WalletWithUserResponse, pagination.Page, error) {
+	pageReq, err := newPageRequest(limit, offset, cursorToken, "ten:asc", len(repository.WalletCursorColumns))
 	if err != nil {
-		return nil, err
+		return nil, pagination.Page{}, err
+	}
+
+	wallets, users, err := s.walletRepo.GetAllWallets(pageReq)
+	if err != nil {
+		return nil, pagination.Page{}, err
 	}
 
 	// Kết hợp wallets và users thành response
@@ -40,25 +50,28 @@ func (s *WalletService) GetAllWallets(limit, offset int) ([]*WalletWithUserRespo
 		}
 	}
 
-	return results, nil
+	results, page := pagination.Paginate(results, pageReq, func(r *WalletWithUserResponse) []interface{} {
+		return []interface{}{r.User.Name, r.User.ID}
+	})
+	return results, page, nil
 }
 
 // GetTotalCurrentBalanceVND lấy tổng so_du_hien_tai_vnd từ tất cả wallets
-func (s *WalletService) GetTotalCurrentBalanceVND() (float64, error) {
+func (s *WalletService) GetTotalCurrentBalanceVND() (money.VND, error) {
 	return s.walletRepo.GetTotalCurrentBalanceVND()
 }
 
 // RecalculateWallet tính toán lại wallet từ dữ liệu thực tế trong database
 // exchangeRate: Tỷ giá VND/CNY (mặc định 3550)
-func (s *WalletService) RecalculateWallet(userID string, exchangeRate float64) error {
+func (s *WalletService) RecalculateWallet(userID string, exchangeRate money.Rate) error {
 	return s.walletRepo.RecalculateWallet(userID, exchangeRate)
 }
 
 // RecalculateAllWallets tính toán lại tất cả wallets từ dữ liệu thực tế trong database
 // exchangeRate: Tỷ giá VND/CNY (mặc định 3550)
-func (s *WalletService) RecalculateAllWallets(exchangeRate float64) error {
+func (s *WalletService) RecalculateAllWallets(exchangeRate money.Rate) error {
 	// Lấy tất cả wallets với user info
-	results, err := s.GetAllWallets(10000, 0) // Lấy tối đa 10000 users
+	results, _, err := s.GetAllWallets(0, 0, "") // Lấy tất cả users
 	if err != nil {
 		return err
 	}
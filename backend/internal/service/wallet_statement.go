@@ -0,0 +1,97 @@
+package service
+
+import (
+	"database/sql"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/pkg/pagination"
+	"log"
+	"sort"
+	"time"
+)
+
+// GetStatement lập sao kê ví của user cho tháng month (YYYY-MM, rỗng = tháng hiện tại) từ sổ cái
+// Số dư đầu kỳ = tổng bút toán trước ngày đầu tháng, số dư cuối kỳ = đầu kỳ + tổng phát sinh trong tháng
+// Bút toán trong tháng được xếp theo thời gian, số dư sau mỗi bút toán tính lũy kế từ số dư đầu kỳ
+func (s *WalletService) GetStatement(userID, month string) (*models.WalletStatement, error) {
+	if month == "" {
+		month = time.Now().Format("2006-01")
+	}
+	start, err := time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		return nil, newValidationError("Tháng không hợp lệ, định dạng YYYY-MM (vd: 2024-10)")
+	}
+	end := start.AddDate(0, 1, 0)
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrWalletUserNotFound
+		}
+		return nil, err
+	}
+
+	opening, err := s.walletRepo.GetLedgerBalanceVND(userID, start)
+	if err != nil {
+		return nil, err
+	}
+	filter := models.WalletLedgerFilter{From: &start, To: &end}
+	entries, err := s.walletRepo.GetLedgerEntries(userID, filter, pagination.Request{})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+		}
+		return entries[i].Seq < entries[j].Seq
+	})
+
+	statement := &models.WalletStatement{
+		UserID:            userID,
+		UserName:          user.Name,
+		Month:             month,
+		PeriodStart:       start,
+		PeriodEnd:         end,
+		OpeningBalanceVND: opening,
+		Receipts:          &models.StatementSection{Entries: []*models.WalletLedgerEntry{}},
+		Compensations:     &models.StatementSection{Entries: []*models.WalletLedgerEntry{}},
+		Deposits:          &models.StatementSection{Entries: []*models.WalletLedgerEntry{}},
+		Withdrawals:       &models.StatementSection{Entries: []*models.WalletLedgerEntry{}},
+		Adjustments:       &models.StatementSection{Entries: []*models.WalletLedgerEntry{}},
+	}
+
+	balance := opening
+	for _, entry := range entries {
+		balance += entry.AmountVND
+		entry.BalanceAfterVND = balance
+
+		var section *models.StatementSection
+		switch entry.EntryType {
+		case models.LedgerEntryCompensation:
+			section = statement.Compensations
+		case models.LedgerEntryDeposit:
+			section = statement.Deposits
+		case models.LedgerEntryWithdrawal:
+			section = statement.Withdrawals
+		case models.LedgerEntryAdjustment:
+			section = statement.Adjustments
+		default: // receipt_settlement, revaluation
+			section = statement.Receipts
+		}
+		section.Entries = append(section.Entries, entry)
+		addToSubtotal(&section.Subtotal, entry)
+		addToSubtotal(&statement.Total, entry)
+	}
+	statement.ClosingBalanceVND = balance
+
+	log.Printf("Service - 📄 Sao kê ví user %s tháng %s: đầu kỳ %s VND, %d bút toán, cuối kỳ %s VND",
+		userID, month, opening, len(entries), balance)
+	return statement, nil
+}
+
+// addToSubtotal cộng một bút toán vào cộng phát sinh
+func addToSubtotal(subtotal *models.StatementSubtotal, entry *models.WalletLedgerEntry) {
+	subtotal.Count++
+	subtotal.AmountCNY += entry.AmountCNY
+	subtotal.AmountVND += entry.AmountVND
+}
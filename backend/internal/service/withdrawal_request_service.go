@@ -0,0 +1,235 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"fullstack-backend/internal/models"
+	"fullstack-backend/internal/repository"
+	"fullstack-backend/pkg/money"
+	"log"
+	"strings"
+)
+
+var (
+	// ErrWithdrawalRequestNotFound - Không tìm thấy yêu cầu rút tiền
+	ErrWithdrawalRequestNotFound = errors.New("Không tìm thấy yêu cầu rút tiền")
+	// ErrWithdrawalRequestState - Yêu cầu rút tiền không ở status cho phép thao tác (đã duyệt / từ chối / chi trước đó)
+	ErrWithdrawalRequestState = errors.New("Yêu cầu rút tiền không ở trạng thái cho phép thao tác này")
+)
+
+// checkAvailableBalance khóa ví của user rồi kiểm tra amount không vượt số dư khả dụng
+// Số dư khả dụng = số dư ví - tổng các yêu cầu rút tiền đang chờ duyệt / đã duyệt chưa chi (trừ excludeID)
+// Phải gọi trong transaction để số dư không đổi cho đến khi ghi xong
+func (s *WithdrawalService) checkAvailableBalance(tx *sql.Tx, userID string, amount money.VND, excludeID string) error {
+	if amount <= 0 {
+		return newValidationError("Số tiền rút phải lớn hơn 0")
+	}
+	walletRepo := s.walletRepo.WithTx(tx)
+	if err := walletRepo.LockWallet(userID); err != nil {
+		return err
+	}
+	wallet, err := walletRepo.GetWalletByUserID(userID)
+	if err != nil {
+		return err
+	}
+	var balance money.VND
+	if wallet != nil {
+		balance = wallet.CurrentBalanceVND
+	}
+	reserved, err := s.requestRepo.WithTx(tx).SumReservedVND(userID, excludeID)
+	if err != nil {
+		return err
+	}
+	return checkWithinAvailable(amount, balance, reserved)
+}
+
+// checkWithinAvailable kiểm tra amount không vượt số dư khả dụng = balance - reserved
+// (reserved: tổng các yêu cầu rút tiền khác đang chờ duyệt / đã duyệt chưa chi)
+func checkWithinAvailable(amount, balance, reserved money.VND) error {
+	if available := balance - reserved; amount > available {
+		return newValidationError(fmt.Sprintf(
+			"Số tiền rút %s VND vượt số dư khả dụng %s VND (số dư %s VND, đang chờ rút %s VND)",
+			amount, available, balance, reserved))
+	}
+	return nil
+}
+
+// CreateWithdrawalRequest user gửi yêu cầu rút tiền (status pending), không vượt số dư khả dụng
+// Yêu cầu giữ chỗ số tiền nhưng chưa trừ ví, ví chỉ bị trừ khi admin chi tiền (PayWithdrawalRequest)
+func (s *WithdrawalService) CreateWithdrawalRequest(userID string, req *models.CreateWithdrawalRequestRequest) (*models.WithdrawalRequest, error) {
+	log.Printf("Service - Yêu cầu rút tiền của user %s: %s VND", userID, req.AmountVND)
+
+	wr := &models.WithdrawalRequest{
+		UserID:    userID,
+		AmountVND: req.AmountVND,
+		Note:      strings.TrimSpace(req.Note),
+	}
+	err := repository.RunInTx(s.withdrawalRepo.GetDB(), func(tx *sql.Tx) error {
+		if err := s.checkAvailableBalance(tx, userID, req.AmountVND, ""); err != nil {
+			return err
+		}
+		return s.requestRepo.WithTx(tx).Create(wr)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Service - ✅ Đã tạo yêu cầu rút tiền %s", wr.ID)
+	return s.GetWithdrawalRequest(wr.ID)
+}
+
+// GetWithdrawalRequests lấy yêu cầu rút tiền theo filter (hàng đợi duyệt: status = pending)
+func (s *WithdrawalService) GetWithdrawalRequests(filter *models.WithdrawalRequestFilter) ([]*models.WithdrawalRequest, error) {
+	if filter.Status != "" && !models.IsValidWithdrawalRequestStatus(filter.Status) {
+		return nil, newValidationError("Tham số status không hợp lệ (pending, approved, rejected, paid)")
+	}
+	return s.requestRepo.GetAll(filter)
+}
+
+// GetWithdrawalRequest lấy yêu cầu rút tiền theo ID
+func (s *WithdrawalService) GetWithdrawalRequest(id string) (*models.WithdrawalRequest, error) {
+	wr, err := s.requestRepo.FindByID(id)
+	if err == sql.ErrNoRows {
+		return nil, ErrWithdrawalRequestNotFound
+	}
+	return wr, err
+}
+
+// ApproveWithdrawalRequest admin duyệt yêu cầu đang chờ (kiểm tra lại số dư khả dụng tại thời điểm duyệt)
+func (s *WithdrawalService) ApproveWithdrawalRequest(id, adminID string) (*models.WithdrawalRequest, error) {
+	var wr *models.WithdrawalRequest
+	err := repository.RunInTx(s.withdrawalRepo.GetDB(), func(tx *sql.Tx) error {
+		var err error
+		wr, err = s.lockWithdrawalRequest(tx, id, models.WithdrawalRequestStatusPending)
+		if err != nil {
+			return err
+		}
+		if err := s.checkAvailableBalance(tx, wr.UserID, wr.AmountVND, wr.ID); err != nil {
+			return err
+		}
+		_, err = s.requestRepo.WithTx(tx).Approve(id, adminID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Service - ✅ Admin %s đã duyệt yêu cầu rút tiền %s", adminID, id)
+	s.notify(&models.Notification{
+		UserID:  wr.UserID,
+		Type:    models.NotificationTypeWithdrawalApproved,
+		Title:   "Yêu cầu rút tiền đã được duyệt",
+		Content: fmt.Sprintf("Yêu cầu rút %s VND của bạn đã được duyệt, đang chờ chi tiền", wr.AmountVND),
+	})
+	return s.GetWithdrawalRequest(id)
+}
+
+// RejectWithdrawalRequest admin từ chối yêu cầu đang chờ hoặc đã duyệt chưa chi (bắt buộc có lý do)
+func (s *WithdrawalService) RejectWithdrawalRequest(id, reason, adminID string) (*models.WithdrawalRequest, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, newValidationError("Lý do từ chối không được để trống")
+	}
+
+	wr, err := s.GetWithdrawalRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	rejected, err := s.requestRepo.Reject(id, reason, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if !rejected {
+		return nil, fmt.Errorf("%w (status hiện tại: %s)", ErrWithdrawalRequestState, wr.Status)
+	}
+
+	log.Printf("Service - ✅ Admin %s đã từ chối yêu cầu rút tiền %s: %s", adminID, id, reason)
+	s.notify(&models.Notification{
+		UserID:  wr.UserID,
+		Type:    models.NotificationTypeWithdrawalRejected,
+		Title:   "Yêu cầu rút tiền bị từ chối",
+		Content: fmt.Sprintf("Yêu cầu rút %s VND của bạn bị từ chối. Lý do: %s", wr.AmountVND, reason),
+	})
+	return s.GetWithdrawalRequest(id)
+}
+
+// PayWithdrawalRequest admin ghi nhận đã chi tiền cho yêu cầu đã duyệt:
+// ghi lich_su_rut_tien, trừ ví (bút toán withdrawal) và chuyển yêu cầu sang paid trong cùng một transaction
+func (s *WithdrawalService) PayWithdrawalRequest(id string, req *models.PayWithdrawalRequestRequest, adminID string) (*models.WithdrawalRequest, error) {
+	var wr *models.WithdrawalRequest
+	err := repository.RunInTx(s.withdrawalRepo.GetDB(), func(tx *sql.Tx) error {
+		var err error
+		wr, err = s.lockWithdrawalRequest(tx, id, models.WithdrawalRequestStatusApproved)
+		if err != nil {
+			return err
+		}
+		_, err = s.payLocked(tx, wr, req, adminID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Service - ✅ Admin %s đã chi yêu cầu rút tiền %s (%s VND)", adminID, id, wr.AmountVND)
+	s.notify(&models.Notification{
+		UserID:  wr.UserID,
+		Type:    models.NotificationTypeWithdrawalPaid,
+		Title:   "Yêu cầu rút tiền đã được chi",
+		Content: fmt.Sprintf("Đã chi %s VND cho yêu cầu rút tiền của bạn", wr.AmountVND),
+	})
+	return s.GetWithdrawalRequest(id)
+}
+
+// payLocked chi tiền cho yêu cầu đã duyệt wr (đã khóa trong tx): kiểm tra lại số dư khả dụng,
+// ghi lich_su_rut_tien, trừ ví (bút toán withdrawal) và chuyển yêu cầu sang paid
+func (s *WithdrawalService) payLocked(tx *sql.Tx, wr *models.WithdrawalRequest, req *models.PayWithdrawalRequestRequest, adminID string) (*models.Withdrawal, error) {
+	// Số dư có thể đã giảm sau khi duyệt (vd: tiền đền), không cho chi làm ví âm
+	if err := s.checkAvailableBalance(tx, wr.UserID, wr.AmountVND, wr.ID); err != nil {
+		return nil, err
+	}
+
+	withdrawal := &models.Withdrawal{
+		UserID:    wr.UserID,
+		AmountVND: wr.AmountVND,
+		Notes:     strings.TrimSpace(req.Notes),
+	}
+	if req.AmountCNY != nil {
+		withdrawal.AmountCNY = *req.AmountCNY
+	}
+	if withdrawal.Notes == "" {
+		withdrawal.Notes = wr.Note
+	}
+	if err := s.withdrawalRepo.WithTx(tx).Create(withdrawal); err != nil {
+		return nil, fmt.Errorf("Lỗi khi tạo withdrawal: %w", err)
+	}
+	if err := s.walletRepo.WithTx(tx).RecalculateWithdrawals(wr.UserID); err != nil {
+		return nil, fmt.Errorf("Lỗi khi cập nhật wallet: %w", err)
+	}
+	if _, err := s.requestRepo.WithTx(tx).MarkPaid(wr.ID, adminID, withdrawal.ID); err != nil {
+		return nil, err
+	}
+	return withdrawal, nil
+}
+
+// lockWithdrawalRequest khóa yêu cầu rút tiền trong tx và kiểm tra đang ở status expected
+func (s *WithdrawalService) lockWithdrawalRequest(tx *sql.Tx, id, expected string) (*models.WithdrawalRequest, error) {
+	wr, err := s.requestRepo.WithTx(tx).LockByID(id)
+	if err == sql.ErrNoRows {
+		return nil, ErrWithdrawalRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if wr.Status != expected {
+		return nil, fmt.Errorf("%w (status hiện tại: %s, cần: %s)", ErrWithdrawalRequestState, wr.Status, expected)
+	}
+	return wr, nil
+}
+
+// notify gửi thông báo, lỗi chỉ ghi log (không làm hỏng thao tác đã thực hiện)
+func (s *WithdrawalService) notify(notification *models.Notification) {
+	if err := s.notificationRepo.Create(notification); err != nil {
+		log.Printf("Service - ⚠️ Không thể gửi thông báo rút tiền cho user %s: %v", notification.UserID, err)
+	}
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"errors"
+	"fullstack-backend/pkg/money"
+	"testing"
+)
+
+func TestCheckWithinAvailable(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   money.VND
+		balance  money.VND
+		reserved money.VND
+		wantErr  bool
+	}{
+		{name: "đủ số dư, chưa có yêu cầu khác", amount: 500000, balance: 1000000},
+		{name: "rút đúng bằng số dư khả dụng", amount: 600000, balance: 1000000, reserved: 400000},
+		{name: "vượt số dư khả dụng do yêu cầu đang chờ", amount: 600001, balance: 1000000, reserved: 400000, wantErr: true},
+		{name: "yêu cầu khác đã giữ hết số dư", amount: 1, balance: 1000000, reserved: 1000000, wantErr: true},
+		{name: "số dư âm", amount: 1, balance: -50000, wantErr: true},
+		{name: "vượt số dư", amount: 1000001, balance: 1000000, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkWithinAvailable(tt.amount, tt.balance, tt.reserved)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("checkWithinAvailable(%s, %s, %s) error = %v, muốn nil", tt.amount, tt.balance, tt.reserved, err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("checkWithinAvailable(%s, %s, %s) error = %v, muốn *ValidationError", tt.amount, tt.balance, tt.reserved, err)
+			}
+		})
+	}
+}
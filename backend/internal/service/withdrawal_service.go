@@ -1,32 +1,40 @@
 package service
 
 import (
+	"database/sql"
 	"fmt"
 	"fullstack-backend/internal/models"
 	"fullstack-backend/internal/repository"
+	"fullstack-backend/pkg/pagination"
 	"log"
+	"strings"
 )
 
 type WithdrawalService struct {
-	withdrawalRepo *repository.WithdrawalRepository
-	userRepo       *repository.UserRepository
-	walletRepo     *repository.WalletRepository
+	withdrawalRepo   *repository.WithdrawalRepository
+	requestRepo      *repository.WithdrawalRequestRepository
+	userRepo         *repository.UserRepository
+	walletRepo       *repository.WalletRepository
+	notificationRepo *repository.NotificationRepository
 }
 
-func NewWithdrawalService(withdrawalRepo *repository.WithdrawalRepository, userRepo *repository.UserRepository, walletRepo *repository.WalletRepository) *WithdrawalService {
+func NewWithdrawalService(withdrawalRepo *repository.WithdrawalRepository, requestRepo *repository.WithdrawalRequestRepository, userRepo *repository.UserRepository, walletRepo *repository.WalletRepository, notificationRepo *repository.NotificationRepository) *WithdrawalService {
 	return &WithdrawalService{
-		withdrawalRepo: withdrawalRepo,
-		userRepo:       userRepo,
-		walletRepo:     walletRepo,
+		withdrawalRepo:   withdrawalRepo,
+		requestRepo:      requestRepo,
+		userRepo:         userRepo,
+		walletRepo:       walletRepo,
+		notificationRepo: notificationRepo,
 	}
 }
 
-// CreateWithdrawal tạo record rút tiền và cập nhật wallet
+// CreateWithdrawal ghi nhận lần chi tiền admin thực hiện trực tiếp (không qua user gửi yêu cầu)
+// Vẫn đi qua quy trình yêu cầu rút tiền trong cùng một transaction: tạo yêu cầu -> duyệt -> chi,
+// nên có cùng kiểm tra số dư khả dụng và chỉ bước chi mới trừ ví
 // req.UserName: tên người dùng (từ cột ten trong nguoi_dung)
-// req.AmountVND: số tiền VND cần rút
-// Lưu ý: Cho phép rút tiền ngay cả khi số dư không đủ (số dư có thể âm)
-func (s *WithdrawalService) CreateWithdrawal(req *models.CreateWithdrawalRequest) (*models.Withdrawal, error) {
-	log.Printf("Service - Rút tiền cho user_name: %s, AmountVND: %.2f", req.UserName, req.AmountVND)
+// req.AmountVND: số tiền VND cần rút, không được vượt số dư khả dụng (số dư ví không bị âm)
+func (s *WithdrawalService) CreateWithdrawal(req *models.CreateWithdrawalRequest, adminID string) (*models.Withdrawal, error) {
+	log.Printf("Service - Rút tiền cho user_name: %s, AmountVND: %s", req.UserName, req.AmountVND)
 
 	// 1. Tìm người dùng theo tên
 	users, err := s.userRepo.FindByName(req.UserName)
@@ -51,65 +59,69 @@ func (s *WithdrawalService) CreateWithdrawal(req *models.CreateWithdrawalRequest
 
 	log.Printf("Service - ✅ Tìm thấy người dùng: %s (%s), ID: %s", foundUser.Name, foundUser.Email, foundUser.ID)
 
-	// 2. Lấy thông tin wallet (nếu có) để log số dư hiện tại
-	wallet, err := s.walletRepo.GetWalletByUserID(foundUser.ID)
-	if err != nil {
-		log.Printf("Service - ⚠️ Lỗi khi lấy wallet: %v (sẽ tự động tạo khi rút tiền)", err)
-	} else if wallet != nil {
-		log.Printf("Service - 💰 Số dư hiện tại: %.2f VND, Số tiền rút: %.2f VND", wallet.CurrentBalanceVND, req.AmountVND)
-	} else {
-		log.Printf("Service - 💰 Wallet chưa tồn tại, sẽ tự động tạo khi rút tiền. Số tiền rút: %.2f VND", req.AmountVND)
-	}
-
-	// 3. Tạo withdrawal record
-	var amountCNY float64
-	if req.AmountCNY != nil {
-		amountCNY = *req.AmountCNY
-	}
-
-	withdrawal := &models.Withdrawal{
+	// 2. Tạo yêu cầu, duyệt và chi trong cùng một transaction
+	// (ghi bút toán withdrawal vào sổ cái và tính lại tong_da_rut_vnd, so_du_hien_tai_vnd khi chi)
+	wr := &models.WithdrawalRequest{
 		UserID:    foundUser.ID,
-		AmountCNY: amountCNY,
 		AmountVND: req.AmountVND,
-		Notes:     req.Notes,
-	}
-
-	if err := s.withdrawalRepo.Create(withdrawal); err != nil {
-		log.Printf("Service - ❌ Lỗi tạo withdrawal: %v", err)
-		return nil, fmt.Errorf("Lỗi khi tạo withdrawal: %w", err)
+		Note:      strings.TrimSpace(req.Notes),
 	}
-
-	// 4. Cập nhật wallet: cộng amountVND vào tong_da_rut_vnd và tính lại so_du_hien_tai_vnd
-	// Method này sẽ tự động tạo wallet nếu chưa có
-	if err := s.walletRepo.AddToTotalWithdrawnVND(foundUser.ID, req.AmountVND); err != nil {
-		log.Printf("Service - ❌ Lỗi cập nhật wallet: %v", err)
-		return nil, fmt.Errorf("Lỗi khi cập nhật wallet: %w", err)
+	var withdrawal *models.Withdrawal
+	err = repository.RunInTx(s.withdrawalRepo.GetDB(), func(tx *sql.Tx) error {
+		requestRepo := s.requestRepo.WithTx(tx)
+		if err := s.checkAvailableBalance(tx, foundUser.ID, req.AmountVND, ""); err != nil {
+			return err
+		}
+		if err := requestRepo.Create(wr); err != nil {
+			return fmt.Errorf("Lỗi khi tạo yêu cầu rút tiền: %w", err)
+		}
+		if _, err := requestRepo.Approve(wr.ID, adminID); err != nil {
+			return fmt.Errorf("Lỗi khi duyệt yêu cầu rút tiền: %w", err)
+		}
+		var err error
+		withdrawal, err = s.payLocked(tx, wr, &models.PayWithdrawalRequestRequest{
+			AmountCNY: req.AmountCNY,
+			Notes:     req.Notes,
+		}, adminID)
+		return err
+	})
+	if err != nil {
+		log.Printf("Service - ❌ Rút tiền thất bại (đã rollback) cho user ID: %s: %v", foundUser.ID, err)
+		return nil, err
 	}
 
-	// 5. Lấy lại wallet để log số dư mới
+	// 3. Lấy lại wallet để log số dư mới
 	updatedWallet, err := s.walletRepo.GetWalletByUserID(foundUser.ID)
 	if err == nil && updatedWallet != nil {
-		log.Printf("Service - ✅ Đã rút tiền thành công cho user ID: %s, AmountVND: %.2f",
-			foundUser.ID, req.AmountVND)
-		log.Printf("Service - 💰 Số dư mới: %.2f VND", updatedWallet.CurrentBalanceVND)
+		log.Printf("Service - ✅ Đã rút tiền thành công cho user ID: %s, AmountVND: %s (yêu cầu %s)",
+			foundUser.ID, req.AmountVND, wr.ID)
+		log.Printf("Service - 💰 Số dư mới: %s VND", updatedWallet.CurrentBalanceVND)
 	} else {
-		log.Printf("Service - ✅ Đã rút tiền thành công cho user ID: %s, AmountVND: %.2f",
-			foundUser.ID, req.AmountVND)
+		log.Printf("Service - ✅ Đã rút tiền thành công cho user ID: %s, AmountVND: %s (yêu cầu %s)",
+			foundUser.ID, req.AmountVND, wr.ID)
 	}
 
 	return withdrawal, nil
 }
 
-// GetAllWithdrawals lấy tất cả lịch sử rút tiền
-func (s *WithdrawalService) GetAllWithdrawals() ([]repository.WithdrawalWithUser, error) {
-	log.Printf("Service - Lấy tất cả lịch sử rút tiền")
+// GetAllWithdrawals lấy lịch sử rút tiền (limit = 0 và không có cursor thì lấy tất cả)
+func (s *WithdrawalService) GetAllWithdrawals(limit, offset int, cursorToken string) ([]repository.WithdrawalWithUser, pagination.Page, error) {
+	log.Printf("Service - Lấy lịch sử rút tiền (limit=%d, offset=%d)", limit, offset)
 
-	withdrawals, err := s.withdrawalRepo.GetAll()
+	pageReq, err := newPageRequest(limit, offset, cursorToken, "thoi_gian_tao:desc", len(repository.WithdrawalCursorColumns))
+	if err != nil {
+		return nil, pagination.Page{}, err
+	}
+
+	withdrawals, err := s.withdrawalRepo.GetAll(pageReq)
 	if err != nil {
 		log.Printf("Service - ❌ Lỗi lấy danh sách withdrawals: %v", err)
-		return nil, err
+		return nil, pagination.Page{}, err
 	}
+	withdrawals, page := pagination.Paginate(withdrawals, pageReq, func(w repository.WithdrawalWithUser) []interface{} {
+		return []interface{}{w.CreatedAt, w.ID}
+	})
 
 	log.Printf("Service - ✅ Đã lấy %d withdrawals", len(withdrawals))
-	return withdrawals, nil
+	return withdrawals, page, nil
 }
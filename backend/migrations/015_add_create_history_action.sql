@@ -0,0 +1,24 @@
+-- Migration: Cho phép lưu lịch sử CREATE trong bet_receipt_history
+-- Created: 2026
+-- Description: Đơn hàng tạo từ file import (CSV/XLSX) cần ghi lịch sử CREATE để biết ai đã import
+
+-- Bước 1: Tìm và drop constraint cũ của cột action
+DO $$
+DECLARE
+    constraint_name text;
+BEGIN
+    SELECT conname INTO constraint_name
+    FROM pg_constraint
+    WHERE conrelid = 'bet_receipt_history'::regclass
+      AND contype = 'c'
+      AND pg_get_constraintdef(oid) LIKE '%action%';
+
+    IF constraint_name IS NOT NULL THEN
+        EXECUTE format('ALTER TABLE bet_receipt_history DROP CONSTRAINT %I', constraint_name);
+    END IF;
+END $$;
+
+-- Bước 2: Tạo lại constraint với giá trị CREATE
+ALTER TABLE bet_receipt_history
+ADD CONSTRAINT bet_receipt_history_action_check
+CHECK (action IN ('CREATE', 'UPDATE', 'DELETE'));
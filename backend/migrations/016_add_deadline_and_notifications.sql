@@ -0,0 +1,55 @@
+-- Migration: Theo dõi deadline đơn hàng và thông báo quá hạn
+-- Created: 2026
+-- Description: han_hoan_thanh = thoi_gian_nhan_keo + thoi_gian_con_lai_gio giờ (cột tính toán, tự cập nhật khi sửa deadline)
+--              thoi_gian_bao_qua_han = thời điểm job kiểm tra đánh dấu đơn hàng quá hạn (mỗi đơn chỉ báo một lần)
+--              Bảng thong_bao lưu thông báo cho người dùng (đơn hàng quá hạn, ...)
+
+-- Bước 1: Thêm cột deadline và cột đánh dấu quá hạn
+ALTER TABLE thong_tin_nhan_keo
+ADD COLUMN IF NOT EXISTS han_hoan_thanh TIMESTAMP
+    GENERATED ALWAYS AS (thoi_gian_nhan_keo + thoi_gian_con_lai_gio * INTERVAL '1 hour') STORED;
+
+ALTER TABLE thong_tin_nhan_keo
+ADD COLUMN IF NOT EXISTS thoi_gian_bao_qua_han TIMESTAMP;
+
+-- Index cho job kiểm tra quá hạn và filter theo deadline
+CREATE INDEX IF NOT EXISTS idx_thong_tin_nhan_keo_han_hoan_thanh ON thong_tin_nhan_keo(han_hoan_thanh);
+
+-- Bước 2: Cho phép lưu lịch sử OVERDUE
+DO $$
+DECLARE
+    constraint_name text;
+BEGIN
+    SELECT conname INTO constraint_name
+    FROM pg_constraint
+    WHERE conrelid = 'bet_receipt_history'::regclass
+      AND contype = 'c'
+      AND pg_get_constraintdef(oid) LIKE '%action%';
+
+    IF constraint_name IS NOT NULL THEN
+        EXECUTE format('ALTER TABLE bet_receipt_history DROP CONSTRAINT %I', constraint_name);
+    END IF;
+END $$;
+
+ALTER TABLE bet_receipt_history
+ADD CONSTRAINT bet_receipt_history_action_check
+CHECK (action IN ('CREATE', 'UPDATE', 'DELETE', 'OVERDUE'));
+
+-- Bước 3: Bảng thông báo
+CREATE TABLE IF NOT EXISTS thong_bao (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    id_nguoi_dung VARCHAR(36) NOT NULL REFERENCES nguoi_dung(id) ON DELETE CASCADE, -- Người nhận thông báo
+    loai VARCHAR(30) NOT NULL,                                   -- Loại thông báo (vd: BET_RECEIPT_OVERDUE)
+    tieu_de VARCHAR(255) NOT NULL,                               -- Tiêu đề
+    noi_dung TEXT,                                               -- Nội dung
+    id_don_hang VARCHAR(36),                                     -- Đơn hàng liên quan (có thể đã bị xóa)
+    da_doc BOOLEAN NOT NULL DEFAULT FALSE,                       -- Đã đọc chưa
+    thoi_gian_tao TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_thong_bao_id_nguoi_dung ON thong_bao(id_nguoi_dung, thoi_gian_tao DESC);
+CREATE INDEX IF NOT EXISTS idx_thong_bao_chua_doc ON thong_bao(id_nguoi_dung) WHERE da_doc = FALSE;
+
+COMMENT ON TABLE thong_bao IS 'Thông báo cho người dùng (đơn hàng quá hạn, ...)';
+COMMENT ON COLUMN thong_tin_nhan_keo.han_hoan_thanh IS 'Deadline = thoi_gian_nhan_keo + thoi_gian_con_lai_gio giờ';
+COMMENT ON COLUMN thong_tin_nhan_keo.thoi_gian_bao_qua_han IS 'Thời điểm job đánh dấu đơn hàng quá hạn (NULL = chưa báo)';
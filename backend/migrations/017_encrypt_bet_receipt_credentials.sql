@@ -0,0 +1,83 @@
+-- Migration: Mã hóa tài khoản/mật khẩu của đơn hàng
+-- Created: 2026
+-- Description: tai_khoan, mat_khau được lưu dạng mã hóa (enc:v1:...) nên cần kiểu TEXT
+--              Dữ liệu plaintext cũ được mã hóa khi server khởi động (xem BetReceiptService.RotateCredentials)
+--              Lịch sử đơn hàng (bet_receipt_history) không còn lưu giá trị thật: che giá trị trong các snapshot cũ
+--              Bảng bet_receipt_credential_access lưu nhật ký xem tài khoản/mật khẩu (audit)
+
+-- Bước 1: Đổi kiểu cột sang TEXT (chuỗi mã hóa dài hơn 100 ký tự)
+ALTER TABLE thong_tin_nhan_keo ALTER COLUMN tai_khoan TYPE TEXT;
+ALTER TABLE thong_tin_nhan_keo ALTER COLUMN mat_khau TYPE TEXT;
+
+-- tai_khoan_che: tài khoản đã che để hiển thị trong danh sách mà không cần giải mã
+ALTER TABLE thong_tin_nhan_keo ADD COLUMN IF NOT EXISTS tai_khoan_che VARCHAR(100);
+
+COMMENT ON COLUMN thong_tin_nhan_keo.tai_khoan IS 'Tài khoản sử dụng cho kèo (mã hóa enc:v1:...)';
+COMMENT ON COLUMN thong_tin_nhan_keo.mat_khau IS 'Mật khẩu tài khoản (mã hóa enc:v1:...)';
+COMMENT ON COLUMN thong_tin_nhan_keo.tai_khoan_che IS 'Tài khoản đã che để hiển thị (vd: ab****yz)';
+
+-- Bước 2: Che tài khoản hiện có và che tài khoản/mật khẩu trong snapshot lịch sử cũ
+-- Cách che tài khoản phải khớp với secret.Mask: giữ 2 ký tự đầu và 2 ký tự cuối, chuỗi <= 4 ký tự che toàn bộ
+CREATE OR REPLACE FUNCTION pg_temp.mask_account(account TEXT) RETURNS TEXT AS $$
+    SELECT CASE WHEN account IS NULL OR account = '' THEN account
+                WHEN char_length(account) <= 4 THEN '****'
+                ELSE left(account, 2) || '****' || right(account, 2) END;
+$$ LANGUAGE sql IMMUTABLE;
+
+UPDATE thong_tin_nhan_keo
+SET tai_khoan_che = pg_temp.mask_account(tai_khoan)
+WHERE tai_khoan IS NOT NULL AND tai_khoan <> '' AND tai_khoan NOT LIKE 'enc:v1:%';
+
+CREATE OR REPLACE FUNCTION pg_temp.mask_credentials(data JSONB) RETURNS JSONB AS $$
+BEGIN
+    IF data IS NULL OR jsonb_typeof(data) <> 'object' THEN
+        RETURN data;
+    END IF;
+
+    IF COALESCE(data->>'account', '') <> '' THEN
+        data := jsonb_set(data, '{account}', to_jsonb(pg_temp.mask_account(data->>'account')));
+    END IF;
+
+    IF COALESCE(data->>'password', '') <> '' THEN
+        data := jsonb_set(data, '{password}', '"********"');
+    END IF;
+
+    RETURN data;
+END;
+$$ LANGUAGE plpgsql;
+
+UPDATE bet_receipt_history
+SET old_data = pg_temp.mask_credentials(old_data),
+    new_data = pg_temp.mask_credentials(new_data),
+    changed_fields = CASE
+        WHEN changed_fields IS NULL THEN NULL
+        ELSE changed_fields
+            || CASE WHEN changed_fields ? 'account'
+                    THEN jsonb_build_object('account', jsonb_build_object(
+                        'old', (pg_temp.mask_credentials(jsonb_build_object('account', changed_fields->'account'->>'old')))->'account',
+                        'new', (pg_temp.mask_credentials(jsonb_build_object('account', changed_fields->'account'->>'new')))->'account'))
+                    ELSE '{}'::jsonb END
+            || CASE WHEN changed_fields ? 'password'
+                    THEN '{"password": {"old": "********", "new": "********"}}'::jsonb
+                    ELSE '{}'::jsonb END
+    END
+WHERE old_data ?| ARRAY['account', 'password']
+   OR new_data ?| ARRAY['account', 'password']
+   OR changed_fields ?| ARRAY['account', 'password'];
+
+-- Bước 3: Nhật ký xem tài khoản/mật khẩu
+CREATE TABLE IF NOT EXISTS bet_receipt_credential_access (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    bet_receipt_id VARCHAR(36) NOT NULL,                                  -- Đơn hàng (có thể đã bị xóa)
+    user_id VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL,     -- Người yêu cầu xem
+    allowed BOOLEAN NOT NULL,                                             -- Có được phép xem không
+    reason TEXT,                                                          -- Lý do xem (người dùng nhập)
+    ip_address VARCHAR(64),
+    user_agent TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bet_receipt_credential_access_bet_receipt_id ON bet_receipt_credential_access(bet_receipt_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_bet_receipt_credential_access_user_id ON bet_receipt_credential_access(user_id);
+
+COMMENT ON TABLE bet_receipt_credential_access IS 'Nhật ký xem tài khoản/mật khẩu của đơn hàng (kể cả lần bị từ chối)';
@@ -0,0 +1,68 @@
+-- Migration: Biểu phí có phiên bản (thay cho bảng phí web và tỷ lệ phí hard-code trong code)
+-- Created: 2026
+-- Description: Mỗi biểu phí là một phiên bản (version) có thời điểm bắt đầu hiệu lực (effective_from)
+--              Khi đơn hàng được xử lý (DONE/HỦY BỎ), "Công thực nhận" được tính theo biểu phí đang có hiệu lực
+--              và version được lưu vào thong_tin_nhan_keo.fee_schedule_version để tính lại cho ra cùng kết quả
+--              Biểu phí đã được đơn hàng sử dụng thì không được sửa/xóa (tạo version mới thay thế)
+
+-- Bước 1: Bảng biểu phí
+CREATE TABLE IF NOT EXISTS fee_schedules (
+    version SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    effective_from TIMESTAMP NOT NULL,                                     -- Thời điểm bắt đầu có hiệu lực
+    web_fee_brackets JSONB NOT NULL,                                       -- Bảng phí web: [{"min_cny": 0, "fee_cny": 2}, ...] (min tăng dần)
+    web_withdrawal_fee_rate DECIMAL(6, 4) NOT NULL,                        -- Phí rút tiền kèo web (tỷ lệ, vd: 0.02 = 2%)
+    external_withdrawal_fee_rate DECIMAL(6, 4) NOT NULL,                   -- Phí rút tiền kèo ngoài (tỷ lệ)
+    intermediary_fee_rate DECIMAL(6, 4) NOT NULL,                          -- Phí trung gian (tỷ lệ, áp dụng cho cả 2 loại kèo)
+    created_by VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (web_withdrawal_fee_rate >= 0 AND web_withdrawal_fee_rate < 1),
+    CHECK (external_withdrawal_fee_rate >= 0 AND external_withdrawal_fee_rate < 1),
+    CHECK (intermediary_fee_rate >= 0 AND intermediary_fee_rate < 1)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fee_schedules_effective_from ON fee_schedules(effective_from DESC);
+
+COMMENT ON TABLE fee_schedules IS 'Biểu phí dùng để tính Công thực nhận (có phiên bản, hiệu lực theo thời gian)';
+
+-- Bước 2: Biểu phí version 1 = bảng phí đang hard-code (lookupPhiWeb, 2%/1% phí rút tiền, 6% phí trung gian)
+-- Có hiệu lực từ đầu để các đơn hàng cũ được tính lại như trước
+-- Lưu ý: bảng cũ có khoảng trống giữa các mức (vd: 50 < giá < 51 rơi vào mức 11),
+--        theo biểu phí mới giá trị lẻ thuộc về mức bên dưới (50.5 -> 4)
+INSERT INTO fee_schedules (
+    version, name, effective_from, web_fee_brackets,
+    web_withdrawal_fee_rate, external_withdrawal_fee_rate, intermediary_fee_rate
+)
+VALUES (
+    1, 'Biểu phí ban đầu', TIMESTAMP '2000-01-01 00:00:00',
+    '[
+        {"min_cny": 0, "fee_cny": 2},
+        {"min_cny": 20, "fee_cny": 4},
+        {"min_cny": 51, "fee_cny": 5},
+        {"min_cny": 101, "fee_cny": 6},
+        {"min_cny": 151, "fee_cny": 7},
+        {"min_cny": 201, "fee_cny": 8},
+        {"min_cny": 251, "fee_cny": 9},
+        {"min_cny": 301, "fee_cny": 10},
+        {"min_cny": 351, "fee_cny": 11},
+        {"min_cny": 800, "fee_cny": 20}
+    ]'::jsonb,
+    0.02, 0.01, 0.06
+)
+ON CONFLICT (version) DO NOTHING;
+
+SELECT setval(pg_get_serial_sequence('fee_schedules', 'version'), GREATEST((SELECT MAX(version) FROM fee_schedules), 1));
+
+-- Bước 3: Lưu version biểu phí đã dùng để tính Công thực nhận của đơn hàng
+ALTER TABLE thong_tin_nhan_keo ADD COLUMN IF NOT EXISTS fee_schedule_version INTEGER REFERENCES fee_schedules(version);
+
+CREATE INDEX IF NOT EXISTS idx_thong_tin_nhan_keo_fee_schedule_version ON thong_tin_nhan_keo(fee_schedule_version);
+
+COMMENT ON COLUMN thong_tin_nhan_keo.fee_schedule_version IS 'Version biểu phí đã dùng để tính Công thực nhận (NULL nếu không tính theo công thức, vd: ĐỀN)';
+
+-- Đơn hàng đã xử lý trước migration được tính theo biểu phí version 1
+UPDATE thong_tin_nhan_keo
+SET fee_schedule_version = 1
+WHERE fee_schedule_version IS NULL
+  AND (tien_do_hoan_thanh = 'DONE' OR (tien_do_hoan_thanh = 'HỦY BỎ' AND tien_keo_web_thuc_nhan_te <> 0));
@@ -0,0 +1,55 @@
+-- Migration: Tiền tệ chính xác (không còn sai số float)
+-- Created: 2026
+-- Description: Code Go dùng kiểu số nguyên theo đơn vị nhỏ nhất (pkg/money) thay cho float64:
+--              - Tệ: 2 chữ số thập phân (giữ nguyên DECIMAL(15, 2))
+--              - VND: làm tròn đến đồng (DECIMAL(15, 0))
+--              - Tỷ giá: 4 chữ số thập phân (DECIMAL(12, 4))
+--              Tổng VND của ví được tính lại bằng cách làm tròn VND từng đơn hàng trước khi cộng,
+--              để tính lại nhiều lần vẫn ra cùng một kết quả (hết lệch số dư)
+
+-- Bước 1: Tỷ giá 4 chữ số thập phân
+ALTER TABLE thong_tin_nhan_keo
+ALTER COLUMN exchange_rate TYPE DECIMAL(12, 4);
+
+ALTER TABLE current_exchange_rate
+ALTER COLUMN exchange_rate TYPE DECIMAL(12, 4);
+
+-- Bước 2: Cột VND làm tròn đến đồng (ROUND của PostgreSQL làm tròn nửa lên, xa số 0 - giống pkg/money)
+ALTER TABLE lich_su_nop_tien
+ALTER COLUMN so_tien_coc_vnd TYPE DECIMAL(15, 0) USING ROUND(so_tien_coc_vnd, 0);
+
+ALTER TABLE lich_su_rut_tien
+ALTER COLUMN so_tien_rut_vnd TYPE DECIMAL(15, 0) USING ROUND(so_tien_rut_vnd, 0);
+
+ALTER TABLE tien_keo
+ALTER COLUMN tong_cong_thuc_nhan_vnd TYPE DECIMAL(15, 0) USING ROUND(tong_cong_thuc_nhan_vnd, 0),
+ALTER COLUMN tong_coc_vnd TYPE DECIMAL(15, 0) USING ROUND(tong_coc_vnd, 0),
+ALTER COLUMN tong_da_rut_vnd TYPE DECIMAL(15, 0) USING ROUND(tong_da_rut_vnd, 0),
+ALTER COLUMN so_du_hien_tai_vnd TYPE DECIMAL(15, 0) USING ROUND(so_du_hien_tai_vnd, 0);
+
+-- Bước 3: Tính lại tổng của tất cả ví (cùng công thức với WalletRepository.RecalculateWallet)
+UPDATE tien_keo tk
+SET tong_cong_thuc_nhan_te = totals.received_cny,
+    tong_cong_thuc_nhan_vnd = totals.received_vnd,
+    tong_coc_vnd = totals.deposit_vnd,
+    tong_da_rut_vnd = totals.withdrawn_vnd,
+    so_du_hien_tai_vnd = totals.received_vnd + totals.deposit_vnd - totals.withdrawn_vnd,
+    thoi_gian_cap_nhat = CURRENT_TIMESTAMP
+FROM (
+    SELECT
+        u.id AS user_id,
+        COALESCE((
+            SELECT SUM(cong_thuc_nhan_te)
+            FROM thong_tin_nhan_keo
+            WHERE id_nguoi_dung = u.id AND tien_do_hoan_thanh IN ('DONE', 'HỦY BỎ', 'ĐỀN')
+        ), 0) AS received_cny,
+        COALESCE((
+            SELECT SUM(ROUND(cong_thuc_nhan_te * COALESCE(exchange_rate, 3550), 0))
+            FROM thong_tin_nhan_keo
+            WHERE id_nguoi_dung = u.id AND tien_do_hoan_thanh IN ('DONE', 'HỦY BỎ', 'ĐỀN')
+        ), 0) AS received_vnd,
+        COALESCE((SELECT SUM(so_tien_coc_vnd) FROM lich_su_nop_tien WHERE id_nguoi_dung = u.id), 0) AS deposit_vnd,
+        COALESCE((SELECT SUM(so_tien_rut_vnd) FROM lich_su_rut_tien WHERE id_nguoi_dung = u.id), 0) AS withdrawn_vnd
+    FROM nguoi_dung u
+) totals
+WHERE tk.id_nguoi_dung = totals.user_id;
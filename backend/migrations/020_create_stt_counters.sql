@@ -0,0 +1,71 @@
+-- Migration: Cấp STT bằng bảng bộ đếm (không còn trùng STT khi tạo đơn hàng đồng thời)
+-- Created: 2026
+-- Description: Trước đây STT = MAX(stt) + 1 rồi INSERT ở lệnh riêng, 2 admin tạo đơn cùng lúc có thể nhận cùng một STT
+--              Nay STT được cấp từ bảng stt_counters (INSERT ... ON CONFLICT DO UPDATE ... RETURNING khóa dòng bộ đếm
+--              đến hết transaction), nên không trùng và không nhảy số khi transaction bị rollback
+--              - stt: số thứ tự toàn cục (bộ đếm 'global'), dùng để sắp xếp
+--              - ma_stt: mã STT hiển thị theo config STT_SCHEME (global: "12", monthly: "2026-10-0001", user: "U001-0001")
+--              - ma_nguoi_dung: mã ngắn duy nhất của người dùng ("U001") dùng trong mã STT theo người dùng,
+--                cấp từ sequence theo thứ tự tạo tài khoản
+--              Đơn hàng cũ được đánh lại STT liên tục từ 1 (giữ thứ tự cũ), mã STT = STT
+--              Đổi STT_SCHEME chỉ áp dụng cho đơn hàng tạo sau đó, mã STT của đơn hàng cũ giữ nguyên
+
+-- Bước 1: Bảng bộ đếm (scope: 'global', 'month:2026-10', 'user:<id người dùng>')
+CREATE TABLE IF NOT EXISTS stt_counters (
+    scope VARCHAR(100) PRIMARY KEY,
+    last_value INTEGER NOT NULL DEFAULT 0,                  -- Số đã cấp gần nhất
+    thoi_gian_cap_nhat TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Bước 2: Đánh lại STT của đơn hàng cũ (bỏ trùng và khoảng trống, giữ thứ tự STT/thời gian nhận kèo cũ)
+WITH numbered AS (
+    SELECT id, ROW_NUMBER() OVER (ORDER BY stt, thoi_gian_nhan_keo, id) AS new_stt
+    FROM thong_tin_nhan_keo
+)
+UPDATE thong_tin_nhan_keo ttnk
+SET stt = numbered.new_stt
+FROM numbered
+WHERE ttnk.id = numbered.id AND ttnk.stt IS DISTINCT FROM numbered.new_stt;
+
+-- Bước 3: Mã STT hiển thị
+ALTER TABLE thong_tin_nhan_keo
+ADD COLUMN IF NOT EXISTS ma_stt VARCHAR(50);
+
+UPDATE thong_tin_nhan_keo
+SET ma_stt = stt::text
+WHERE ma_stt IS NULL;
+
+ALTER TABLE thong_tin_nhan_keo
+ALTER COLUMN ma_stt SET NOT NULL;
+
+-- Bước 4: Đảm bảo không trùng ở mức DB
+CREATE UNIQUE INDEX IF NOT EXISTS idx_thong_tin_nhan_keo_stt ON thong_tin_nhan_keo(stt);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_thong_tin_nhan_keo_ma_stt ON thong_tin_nhan_keo(ma_stt);
+
+-- Bước 5: Mã ngắn của người dùng (scheme "user")
+CREATE SEQUENCE IF NOT EXISTS nguoi_dung_ma_nguoi_dung_seq;
+
+ALTER TABLE nguoi_dung ADD COLUMN IF NOT EXISTS ma_nguoi_dung VARCHAR(20);
+
+UPDATE nguoi_dung nd
+SET ma_nguoi_dung = numbered.code
+FROM (
+    SELECT id, 'U' || LPAD(nextval('nguoi_dung_ma_nguoi_dung_seq')::text, 3, '0') AS code
+    FROM (SELECT id FROM nguoi_dung WHERE ma_nguoi_dung IS NULL ORDER BY thoi_gian_tao, id) ordered
+) numbered
+WHERE nd.id = numbered.id;
+
+ALTER TABLE nguoi_dung
+    ALTER COLUMN ma_nguoi_dung SET DEFAULT 'U' || LPAD(nextval('nguoi_dung_ma_nguoi_dung_seq')::text, 3, '0'),
+    ALTER COLUMN ma_nguoi_dung SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_nguoi_dung_ma_nguoi_dung ON nguoi_dung(ma_nguoi_dung);
+
+-- Bước 6: Khởi tạo bộ đếm toàn cục từ STT lớn nhất hiện có
+INSERT INTO stt_counters (scope, last_value)
+SELECT 'global', COALESCE(MAX(stt), 0) FROM thong_tin_nhan_keo
+ON CONFLICT (scope) DO UPDATE SET last_value = EXCLUDED.last_value, thoi_gian_cap_nhat = NOW();
+
+COMMENT ON TABLE stt_counters IS 'Bộ đếm cấp STT đơn hàng (global, theo tháng, theo người dùng)';
+COMMENT ON COLUMN thong_tin_nhan_keo.ma_stt IS 'Mã STT hiển thị theo STT_SCHEME (vd: 2026-10-0001)';
+COMMENT ON COLUMN nguoi_dung.ma_nguoi_dung IS 'Mã ngắn của người dùng (vd: U001), dùng trong mã STT theo người dùng';
@@ -0,0 +1,38 @@
+-- Migration: Xóa mềm đơn hàng (thùng rác) và khôi phục
+-- Created: 2026
+-- Description: Xóa đơn hàng không còn DELETE khỏi bảng mà chỉ ghi deleted_at/deleted_by
+--              Đơn hàng đã xóa không tính vào danh sách, tổng tiền, ví; admin xem trong thùng rác và có thể khôi phục
+--              (khôi phục thì ví được tính lại như trước khi xóa)
+
+-- Bước 1: Cột xóa mềm
+ALTER TABLE thong_tin_nhan_keo
+ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+ALTER TABLE thong_tin_nhan_keo
+ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL;
+
+-- Index cho thùng rác (phần lớn đơn hàng chưa xóa nên chỉ index các đơn đã xóa)
+CREATE INDEX IF NOT EXISTS idx_thong_tin_nhan_keo_deleted_at ON thong_tin_nhan_keo(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Bước 2: Cho phép lưu lịch sử RESTORE
+DO $$
+DECLARE
+    constraint_name text;
+BEGIN
+    SELECT conname INTO constraint_name
+    FROM pg_constraint
+    WHERE conrelid = 'bet_receipt_history'::regclass
+      AND contype = 'c'
+      AND pg_get_constraintdef(oid) LIKE '%action%';
+
+    IF constraint_name IS NOT NULL THEN
+        EXECUTE format('ALTER TABLE bet_receipt_history DROP CONSTRAINT %I', constraint_name);
+    END IF;
+END $$;
+
+ALTER TABLE bet_receipt_history
+ADD CONSTRAINT bet_receipt_history_action_check
+CHECK (action IN ('CREATE', 'UPDATE', 'DELETE', 'RESTORE', 'OVERDUE'));
+
+COMMENT ON COLUMN thong_tin_nhan_keo.deleted_at IS 'Thời gian xóa mềm (NULL = chưa xóa)';
+COMMENT ON COLUMN thong_tin_nhan_keo.deleted_by IS 'Người xóa đơn hàng';
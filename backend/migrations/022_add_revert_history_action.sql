@@ -0,0 +1,23 @@
+-- Migration: Cho phép lưu lịch sử REVERT trong bet_receipt_history
+-- Created: 2026
+-- Description: Đơn hàng có thể được hoàn tác về một phiên bản trong lịch sử (old_data/new_data của bản ghi lịch sử)
+--              Mỗi lần hoàn tác ghi một bản ghi REVERT (kèm ID bản ghi lịch sử được dùng trong description)
+
+DO $$
+DECLARE
+    constraint_name text;
+BEGIN
+    SELECT conname INTO constraint_name
+    FROM pg_constraint
+    WHERE conrelid = 'bet_receipt_history'::regclass
+      AND contype = 'c'
+      AND pg_get_constraintdef(oid) LIKE '%action%';
+
+    IF constraint_name IS NOT NULL THEN
+        EXECUTE format('ALTER TABLE bet_receipt_history DROP CONSTRAINT %I', constraint_name);
+    END IF;
+END $$;
+
+ALTER TABLE bet_receipt_history
+ADD CONSTRAINT bet_receipt_history_action_check
+CHECK (action IN ('CREATE', 'UPDATE', 'DELETE', 'RESTORE', 'REVERT', 'OVERDUE'));
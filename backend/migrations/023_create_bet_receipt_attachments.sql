@@ -0,0 +1,42 @@
+-- Migration: Tệp đính kèm (bằng chứng) cho đơn hàng
+-- Created: 2026
+-- Description: Ảnh/PDF bằng chứng khi xử lý tranh chấp ("CHỜ TRỌNG TÀI", "ĐỀN") được upload vào đơn hàng
+--              File lưu trên đĩa (config ATTACHMENT_DIR, không public), chỉ tải về qua API có kiểm tra quyền
+--              Thêm/xóa tệp đính kèm được ghi vào lịch sử đơn hàng (action ATTACHMENT)
+
+-- Bước 1: Bảng tệp đính kèm
+CREATE TABLE IF NOT EXISTS bet_receipt_attachments (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    bet_receipt_id VARCHAR(36) NOT NULL REFERENCES thong_tin_nhan_keo(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('proof', 'dispute', 'other')), -- Loại: bằng chứng / tranh chấp / khác
+    file_name VARCHAR(255) NOT NULL,                                         -- Tên file gốc (hiển thị, tải về)
+    stored_name VARCHAR(255) NOT NULL,                                       -- Tên file lưu trên đĩa (ngẫu nhiên)
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    uploaded_by VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bet_receipt_attachments_bet_receipt_id ON bet_receipt_attachments(bet_receipt_id, created_at);
+
+-- Bước 2: Cho phép lưu lịch sử ATTACHMENT
+DO $$
+DECLARE
+    constraint_name text;
+BEGIN
+    SELECT conname INTO constraint_name
+    FROM pg_constraint
+    WHERE conrelid = 'bet_receipt_history'::regclass
+      AND contype = 'c'
+      AND pg_get_constraintdef(oid) LIKE '%action%';
+
+    IF constraint_name IS NOT NULL THEN
+        EXECUTE format('ALTER TABLE bet_receipt_history DROP CONSTRAINT %I', constraint_name);
+    END IF;
+END $$;
+
+ALTER TABLE bet_receipt_history
+ADD CONSTRAINT bet_receipt_history_action_check
+CHECK (action IN ('CREATE', 'UPDATE', 'DELETE', 'RESTORE', 'REVERT', 'ATTACHMENT', 'OVERDUE'));
+
+COMMENT ON TABLE bet_receipt_attachments IS 'Tệp đính kèm (ảnh/PDF bằng chứng) của đơn hàng';
@@ -0,0 +1,20 @@
+-- Migration: Bình luận (comment thread) cho đơn hàng
+-- Created: 2026
+-- Description: ghi_chu chỉ là một ô text bị ghi đè, admin và người nhận kèo ghi đè ghi chú của nhau
+--              Mỗi đơn hàng có một danh sách bình luận: người viết, thời gian, @mention một người dùng (gửi thông báo),
+--              chỉ sửa/xóa được trong thời hạn (config COMMENT_EDIT_WINDOW), ghi chú nội bộ chỉ admin xem được
+
+CREATE TABLE IF NOT EXISTS bet_receipt_comments (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    bet_receipt_id VARCHAR(36) NOT NULL REFERENCES thong_tin_nhan_keo(id) ON DELETE CASCADE,
+    author_id VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL,         -- Người viết
+    content TEXT NOT NULL,                                                      -- Nội dung
+    mentioned_user_id VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL, -- Người được @mention (nullable)
+    internal BOOLEAN NOT NULL DEFAULT FALSE,                                    -- Ghi chú nội bộ (chỉ admin xem)
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    edited_at TIMESTAMP                                                         -- Lần sửa cuối (NULL = chưa sửa)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bet_receipt_comments_bet_receipt_id ON bet_receipt_comments(bet_receipt_id, created_at);
+
+COMMENT ON TABLE bet_receipt_comments IS 'Bình luận của đơn hàng (internal = ghi chú nội bộ chỉ admin xem)';
@@ -0,0 +1,32 @@
+-- Migration: Pool đơn hàng mới cho người nhận kèo tự nhận
+-- Created: 2026
+-- Description: Đơn hàng "Đơn hàng mới" chưa có người nhận (id_nguoi_dung NULL) nằm trong pool
+--              Người dùng role "user" xem pool và tự nhận đơn (ai nhận trước được trước, tối đa MAX_OPEN_CLAIMS đơn đang mở)
+--              Admin vẫn giao/giao lại/thu hồi đơn hàng; mọi lần nhận/giao/trả đơn được ghi vào bet_receipt_claims
+
+-- Bước 1: Cho phép đơn hàng chưa có người nhận (chỉ khi còn ở trạng thái "Đơn hàng mới")
+ALTER TABLE thong_tin_nhan_keo ALTER COLUMN id_nguoi_dung DROP NOT NULL;
+
+ALTER TABLE thong_tin_nhan_keo DROP CONSTRAINT IF EXISTS thong_tin_nhan_keo_assignee_check;
+ALTER TABLE thong_tin_nhan_keo
+ADD CONSTRAINT thong_tin_nhan_keo_assignee_check
+CHECK (id_nguoi_dung IS NOT NULL OR tien_do_hoan_thanh = 'Đơn hàng mới');
+
+CREATE INDEX IF NOT EXISTS idx_thong_tin_nhan_keo_pool ON thong_tin_nhan_keo(stt)
+WHERE id_nguoi_dung IS NULL AND deleted_at IS NULL;
+
+-- Bước 2: Lịch sử nhận/giao đơn hàng
+CREATE TABLE IF NOT EXISTS bet_receipt_claims (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    bet_receipt_id VARCHAR(36) NOT NULL REFERENCES thong_tin_nhan_keo(id) ON DELETE CASCADE,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('CLAIM', 'RELEASE', 'ASSIGN', 'UNASSIGN')),
+    from_user_id VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL,  -- Người nhận trước đó (NULL = pool)
+    to_user_id VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL,    -- Người nhận mới (NULL = trả về pool)
+    performed_by VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL,  -- Người thực hiện
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bet_receipt_claims_bet_receipt_id ON bet_receipt_claims(bet_receipt_id, created_at);
+
+COMMENT ON COLUMN thong_tin_nhan_keo.id_nguoi_dung IS 'Người nhận kèo (NULL = đơn hàng mới trong pool, chưa có người nhận)';
+COMMENT ON TABLE bet_receipt_claims IS 'Lịch sử nhận/trả/giao đơn hàng (CLAIM, RELEASE, ASSIGN, UNASSIGN)';
@@ -0,0 +1,29 @@
+-- Migration: Tranh chấp (trọng tài) cho đơn hàng "CHỜ TRỌNG TÀI"
+-- Created: 2026
+-- Description: Mở tranh chấp chuyển đơn hàng sang "CHỜ TRỌNG TÀI" và lưu người mở, lý do, bằng chứng
+--              Admin giao trọng tài; trọng tài quyết định DONE / HỦY BỎ (tiền thực nhận) / ĐỀN (tiền đền),
+--              quyết định được áp dụng qua luồng cập nhật status thông thường (tính công thực nhận, wallet)
+--              Mỗi đơn hàng chỉ có tối đa một tranh chấp đang mở
+
+CREATE TABLE IF NOT EXISTS bet_receipt_disputes (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    bet_receipt_id VARCHAR(36) NOT NULL REFERENCES thong_tin_nhan_keo(id) ON DELETE CASCADE,
+    worker_id VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL,    -- Người nhận kèo của đơn hàng lúc mở tranh chấp
+    previous_status VARCHAR(30) NOT NULL,                                  -- Status trước khi mở tranh chấp
+    opened_by VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL,    -- Người mở tranh chấp
+    reason TEXT NOT NULL,                                                  -- Lý do tranh chấp
+    evidence TEXT,                                                         -- Mô tả bằng chứng (file: tệp đính kèm kind = 'dispute')
+    referee_id VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL,   -- Trọng tài được giao
+    decision VARCHAR(30) CHECK (decision IN ('DONE', 'HỦY BỎ', 'ĐỀN')),    -- Quyết định (NULL = đang mở)
+    decision_amount_cny DECIMAL(15, 2),                                    -- HỦY BỎ: tiền thực nhận, ĐỀN: tiền đền
+    decision_note TEXT,                                                    -- Ghi chú quyết định (ĐỀN: lý do đền)
+    decided_by VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL,
+    decided_at TIMESTAMP,                                                  -- Thời điểm quyết định (NULL = đang mở)
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bet_receipt_disputes_bet_receipt_id ON bet_receipt_disputes(bet_receipt_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_bet_receipt_disputes_worker_id ON bet_receipt_disputes(worker_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bet_receipt_disputes_open ON bet_receipt_disputes(bet_receipt_id) WHERE decided_at IS NULL;
+
+COMMENT ON TABLE bet_receipt_disputes IS 'Tranh chấp (trọng tài) của đơn hàng, decided_at NULL = đang mở';
@@ -0,0 +1,20 @@
+-- Migration: Phát hiện đơn hàng trùng
+-- Created: 2026
+-- Description: Quy tắc trùng (config DUPLICATE_RULES):
+--              - order_code: cùng mã đơn hàng (không phân biệt hoa thường)
+--              - task_account_day: cùng mã nhiệm vụ + tài khoản trong cùng một ngày nhận kèo
+--              Tài khoản được mã hóa với DEK ngẫu nhiên nên không so sánh trực tiếp được:
+--              tai_khoan_hash lưu chỉ mục mù HMAC-SHA256 của tài khoản (key CREDENTIAL_INDEX_KEY)
+--              Đơn hàng cũ được điền tai_khoan_hash khi server khởi động (BetReceiptService.BackfillAccountHashes)
+
+ALTER TABLE thong_tin_nhan_keo ADD COLUMN IF NOT EXISTS tai_khoan_hash VARCHAR(64);
+
+COMMENT ON COLUMN thong_tin_nhan_keo.tai_khoan_hash IS 'Chỉ mục mù (HMAC-SHA256, hex) của tài khoản để phát hiện đơn hàng trùng, NULL = chưa tính hoặc không có tài khoản';
+
+CREATE INDEX IF NOT EXISTS idx_thong_tin_nhan_keo_order_code_lower
+    ON thong_tin_nhan_keo (LOWER(TRIM(ma_don_hang)))
+    WHERE deleted_at IS NULL AND COALESCE(TRIM(ma_don_hang), '') <> '';
+
+CREATE INDEX IF NOT EXISTS idx_thong_tin_nhan_keo_task_account_day
+    ON thong_tin_nhan_keo (LOWER(TRIM(ma_nhiem_vu)), tai_khoan_hash, (thoi_gian_nhan_keo::date))
+    WHERE deleted_at IS NULL AND tai_khoan_hash IS NOT NULL;
@@ -0,0 +1,32 @@
+-- Migration: Danh mục mã nhiệm vụ
+-- Created: 2026
+-- Description: ma_nhiem_vu của đơn hàng vẫn là text tự do (vd: "lb3-kc1", "kc4-96-ct"),
+--              danh mục lưu mô tả và giá trị mặc định của từng mã nhiệm vụ hay dùng
+--              Tạo đơn hàng với mã có trong danh mục (đang dùng) mà bỏ trống bet_type / web_bet_amount_cny /
+--              completed_hours thì các trường này được điền từ giá trị mặc định
+--              Mã khớp không phân biệt hoa thường và khoảng trắng hai đầu (giống quy tắc trùng task_account_day)
+
+CREATE TABLE IF NOT EXISTS task_codes (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    code VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    default_bet_type VARCHAR(20),                      -- 'web' hoặc 'Kèo ngoài', NULL = không có mặc định
+    default_web_bet_amount_cny DECIMAL(15, 2),         -- Tiền kèo web mặc định (tệ), NULL = không có mặc định
+    default_completed_hours INTEGER,                   -- Thời gian hoàn thành mặc định (số giờ), NULL = không có mặc định
+    active BOOLEAN NOT NULL DEFAULT TRUE,              -- FALSE = ngừng sử dụng, không nhận đơn hàng mới
+    created_by VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (default_bet_type IS NULL OR default_bet_type IN ('web', 'Kèo ngoài')),
+    CHECK (default_web_bet_amount_cny IS NULL OR default_web_bet_amount_cny > 0),
+    CHECK (default_completed_hours IS NULL OR default_completed_hours > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_codes_code_lower ON task_codes (LOWER(TRIM(code)));
+
+COMMENT ON TABLE task_codes IS 'Danh mục mã nhiệm vụ: mô tả và giá trị mặc định khi tạo đơn hàng';
+
+-- Thống kê theo mã nhiệm vụ nhóm đơn hàng theo LOWER(TRIM(ma_nhiem_vu))
+CREATE INDEX IF NOT EXISTS idx_thong_tin_nhan_keo_task_code_lower
+    ON thong_tin_nhan_keo (LOWER(TRIM(ma_nhiem_vu)))
+    WHERE deleted_at IS NULL;
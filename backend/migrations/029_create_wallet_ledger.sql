@@ -0,0 +1,133 @@
+-- Migration: Sổ cái ví phía sau tien_keo
+-- Created: 2026
+-- Description: tien_keo chỉ lưu số tổng bị ghi đè nên không biết vì sao số dư thay đổi
+--              wallet_ledger_entries là sổ cái chỉ ghi thêm (không sửa/xóa): mỗi bút toán là một dòng thay đổi số dư
+--              ví của người dùng (ghi đơn, không có tài khoản đối ứng), số tiền mang dấu (dương = ví tăng, âm = ví giảm),
+--              loại bút toán (entry_type) và nguồn (source_type, source_id) cho biết vì sao số dư thay đổi
+--              - receipt_settlement: Công thực nhận của đơn hàng DONE/HỦY BỎ
+--              - compensation: tiền đền (ĐỀN, số âm)
+--              - deposit / withdrawal: nộp cọc / rút tiền
+--              - revaluation: đánh giá lại VND khi tỷ giá của đơn hàng thay đổi
+--              - adjustment: điều chỉnh thủ công (admin)
+--              Đơn hàng đổi status / bị xóa / đổi người nhận được ghi bằng bút toán đảo hoặc bút toán chênh lệch,
+--              tien_keo trở thành bảng tổng hợp tính lại từ sổ cái (WalletRepository.projectWallet)
+
+CREATE TABLE IF NOT EXISTS wallet_ledger_entries (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    seq BIGSERIAL NOT NULL UNIQUE,                                        -- Thứ tự ghi sổ (tính số dư lũy kế)
+    id_nguoi_dung VARCHAR(36) NOT NULL REFERENCES nguoi_dung(id) ON DELETE CASCADE,
+    entry_type VARCHAR(30) NOT NULL
+        CHECK (entry_type IN ('receipt_settlement', 'compensation', 'deposit', 'withdrawal', 'revaluation', 'adjustment')),
+    amount_cny DECIMAL(15, 2) NOT NULL DEFAULT 0,                         -- Số tiền tệ (có dấu theo phía ví)
+    amount_vnd DECIMAL(15, 0) NOT NULL DEFAULT 0,                         -- Số tiền VND (có dấu theo phía ví)
+    exchange_rate DECIMAL(12, 4),                                         -- Tỷ giá dùng để quy đổi (NULL nếu chỉ có VND)
+    source_type VARCHAR(20) NOT NULL
+        CHECK (source_type IN ('bet_receipt', 'deposit', 'withdrawal', 'manual')),
+    source_id VARCHAR(36),                                                -- ID đơn hàng / nộp cọc / rút tiền (NULL nếu manual)
+    description TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (amount_cny <> 0 OR amount_vnd <> 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_ledger_entries_user_seq ON wallet_ledger_entries(id_nguoi_dung, seq);
+CREATE INDEX IF NOT EXISTS idx_wallet_ledger_entries_source ON wallet_ledger_entries(source_type, source_id);
+CREATE INDEX IF NOT EXISTS idx_wallet_ledger_entries_created_at ON wallet_ledger_entries(created_at);
+
+COMMENT ON TABLE wallet_ledger_entries IS 'Sổ cái ví (chỉ ghi thêm): mọi thay đổi số dư tien_keo kèm nguồn và tỷ giá';
+
+-- Chỉ ghi thêm: không cho sửa/xóa bút toán (trừ khi xóa người dùng, ON DELETE CASCADE chạy trong trigger của FK)
+CREATE OR REPLACE FUNCTION wallet_ledger_entries_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND pg_trigger_depth() > 1 THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'wallet_ledger_entries chỉ được ghi thêm, hãy ghi bút toán đảo thay vì % bút toán', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_wallet_ledger_entries_append_only ON wallet_ledger_entries;
+CREATE TRIGGER trg_wallet_ledger_entries_append_only
+    BEFORE UPDATE OR DELETE ON wallet_ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION wallet_ledger_entries_append_only();
+
+-- Tổng điều chỉnh thủ công của ví (số dư = công thực nhận + cọc - đã rút + điều chỉnh)
+ALTER TABLE tien_keo ADD COLUMN IF NOT EXISTS tong_dieu_chinh_vnd DECIMAL(15, 0) NOT NULL DEFAULT 0;
+
+-- Số dư đầu kỳ: ghi sổ dữ liệu hiện có (cùng công thức với WalletRepository.RecalculateWallet)
+-- Chỉ chạy khi sổ cái còn trống để migration chạy lại không ghi trùng
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM wallet_ledger_entries) THEN
+        RETURN;
+    END IF;
+
+    -- Một câu INSERT sắp theo thời gian gốc để seq (số dư lũy kế) khớp với thứ tự created_at
+    -- mà sao kê tháng và số dư khóa sổ dùng
+    INSERT INTO wallet_ledger_entries (
+        id_nguoi_dung, entry_type, amount_cny, amount_vnd, exchange_rate, source_type, source_id, description, created_at
+    )
+    SELECT id_nguoi_dung, entry_type, amount_cny, amount_vnd, exchange_rate, source_type, source_id, description, created_at
+    FROM (
+        SELECT id_nguoi_dung,
+               CASE WHEN tien_do_hoan_thanh = 'ĐỀN' THEN 'compensation' ELSE 'receipt_settlement' END AS entry_type,
+               cong_thuc_nhan_te AS amount_cny,
+               ROUND(cong_thuc_nhan_te * COALESCE(exchange_rate, 3550), 0) AS amount_vnd,
+               COALESCE(exchange_rate, 3550) AS exchange_rate,
+               'bet_receipt' AS source_type, id AS source_id,
+               'Số dư đầu kỳ: đơn hàng ' || COALESCE(ma_stt, stt::text) || ' (' || tien_do_hoan_thanh || ')' AS description,
+               COALESCE(thoi_gian_hoan_thanh, thoi_gian_cap_nhat, NOW()) AS created_at
+        FROM thong_tin_nhan_keo
+        WHERE id_nguoi_dung IS NOT NULL AND deleted_at IS NULL
+          AND tien_do_hoan_thanh IN ('DONE', 'HỦY BỎ', 'ĐỀN')
+          AND (cong_thuc_nhan_te <> 0 OR ROUND(cong_thuc_nhan_te * COALESCE(exchange_rate, 3550), 0) <> 0)
+
+        UNION ALL
+
+        SELECT id_nguoi_dung, 'deposit', 0, so_tien_coc_vnd, NULL, 'deposit', id,
+               'Số dư đầu kỳ: nộp cọc tháng ' || thang_nop, thoi_gian_tao
+        FROM lich_su_nop_tien
+        WHERE so_tien_coc_vnd <> 0
+
+        UNION ALL
+
+        SELECT id_nguoi_dung, 'withdrawal', -COALESCE(so_tien_rut_te, 0), -so_tien_rut_vnd, NULL, 'withdrawal', id,
+               'Số dư đầu kỳ: rút tiền tháng ' || thang_rut, thoi_gian_tao
+        FROM lich_su_rut_tien
+        WHERE so_tien_rut_vnd <> 0 OR COALESCE(so_tien_rut_te, 0) <> 0
+    ) opening
+    ORDER BY created_at, source_id;
+END $$;
+
+-- Tính lại tien_keo từ sổ cái (cùng công thức với WalletRepository.projectWallet)
+INSERT INTO tien_keo (
+    id_nguoi_dung, tong_cong_thuc_nhan_te, tong_da_rut_te, tong_cong_thuc_nhan_vnd,
+    tong_coc_vnd, tong_da_rut_vnd, tong_dieu_chinh_vnd, so_du_hien_tai_vnd, thoi_gian_cap_nhat
+)
+SELECT id_nguoi_dung,
+       COALESCE(SUM(amount_cny) FILTER (WHERE entry_type IN ('receipt_settlement', 'compensation', 'revaluation')), 0),
+       COALESCE(-SUM(amount_cny) FILTER (WHERE entry_type = 'withdrawal'), 0),
+       COALESCE(SUM(amount_vnd) FILTER (WHERE entry_type IN ('receipt_settlement', 'compensation', 'revaluation')), 0),
+       COALESCE(SUM(amount_vnd) FILTER (WHERE entry_type = 'deposit'), 0),
+       COALESCE(-SUM(amount_vnd) FILTER (WHERE entry_type = 'withdrawal'), 0),
+       COALESCE(SUM(amount_vnd) FILTER (WHERE entry_type = 'adjustment'), 0),
+       COALESCE(SUM(amount_vnd), 0),
+       NOW()
+FROM wallet_ledger_entries
+GROUP BY id_nguoi_dung
+ON CONFLICT (id_nguoi_dung) DO UPDATE SET
+    tong_cong_thuc_nhan_te = EXCLUDED.tong_cong_thuc_nhan_te,
+    tong_da_rut_te = EXCLUDED.tong_da_rut_te,
+    tong_cong_thuc_nhan_vnd = EXCLUDED.tong_cong_thuc_nhan_vnd,
+    tong_coc_vnd = EXCLUDED.tong_coc_vnd,
+    tong_da_rut_vnd = EXCLUDED.tong_da_rut_vnd,
+    tong_dieu_chinh_vnd = EXCLUDED.tong_dieu_chinh_vnd,
+    so_du_hien_tai_vnd = EXCLUDED.so_du_hien_tai_vnd,
+    thoi_gian_cap_nhat = EXCLUDED.thoi_gian_cap_nhat;
+
+-- Ví chưa có bút toán nào: số tổng về 0 (giống tính lại từ sổ cái trống)
+UPDATE tien_keo tk
+SET tong_cong_thuc_nhan_te = 0, tong_da_rut_te = 0, tong_cong_thuc_nhan_vnd = 0,
+    tong_coc_vnd = 0, tong_da_rut_vnd = 0, tong_dieu_chinh_vnd = 0, so_du_hien_tai_vnd = 0,
+    thoi_gian_cap_nhat = NOW()
+WHERE NOT EXISTS (SELECT 1 FROM wallet_ledger_entries e WHERE e.id_nguoi_dung = tk.id_nguoi_dung);
@@ -0,0 +1,110 @@
+-- Migration: Khóa sổ theo tháng
+-- Created: 2026
+-- Description: Đơn hàng DONE từ nhiều tháng trước vẫn sửa/xóa được, đổi tỷ giá còn ghi đè exchange_rate của mọi đơn hàng đã xử lí
+--              Admin khóa sổ một tháng đã qua (PeriodService.CloseMonth):
+--              - closed_periods: các tháng đã khóa sổ
+--              - wallet_period_snapshots: số dư đầu kỳ / phát sinh / cuối kỳ của mọi ví tại thời điểm khóa sổ (tính từ sổ cái)
+--              - locked_period: đơn hàng (chưa xóa) đã hoàn tất (DONE, HỦY BỎ, ĐỀN), nộp cọc, rút tiền (theo thời gian tạo) trong tháng bị khóa
+--              Bản ghi đã khóa không sửa/xóa được (trigger), điều chỉnh sau đó ghi bằng bút toán adjustment trong kỳ đang mở
+
+CREATE TABLE IF NOT EXISTS closed_periods (
+    month VARCHAR(7) PRIMARY KEY CHECK (month ~ '^[0-9]{4}-(0[1-9]|1[0-2])$'), -- Tháng khóa sổ (YYYY-MM)
+    note TEXT NOT NULL DEFAULT '',
+    locked_receipts INTEGER NOT NULL DEFAULT 0,                                -- Số đơn hàng bị khóa
+    locked_deposits INTEGER NOT NULL DEFAULT 0,                                -- Số lần nộp cọc bị khóa
+    locked_withdrawals INTEGER NOT NULL DEFAULT 0,                             -- Số lần rút tiền bị khóa
+    closed_by VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL,
+    closed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE closed_periods IS 'Các tháng đã khóa sổ: bản ghi thuộc tháng này không sửa/xóa được';
+
+CREATE TABLE IF NOT EXISTS wallet_period_snapshots (
+    month VARCHAR(7) NOT NULL REFERENCES closed_periods(month) ON DELETE CASCADE,
+    id_nguoi_dung VARCHAR(36) NOT NULL REFERENCES nguoi_dung(id) ON DELETE CASCADE,
+    opening_balance_vnd DECIMAL(15, 0) NOT NULL DEFAULT 0,  -- Số dư đầu kỳ
+    received_cny DECIMAL(15, 2) NOT NULL DEFAULT 0,         -- Công thực nhận trong kỳ (receipt_settlement + revaluation)
+    received_vnd DECIMAL(15, 0) NOT NULL DEFAULT 0,
+    compensation_cny DECIMAL(15, 2) NOT NULL DEFAULT 0,     -- Tiền đền trong kỳ (số âm)
+    compensation_vnd DECIMAL(15, 0) NOT NULL DEFAULT 0,
+    deposit_vnd DECIMAL(15, 0) NOT NULL DEFAULT 0,          -- Nộp cọc trong kỳ
+    withdrawn_cny DECIMAL(15, 2) NOT NULL DEFAULT 0,        -- Rút tiền trong kỳ (số âm)
+    withdrawn_vnd DECIMAL(15, 0) NOT NULL DEFAULT 0,
+    adjustment_vnd DECIMAL(15, 0) NOT NULL DEFAULT 0,       -- Điều chỉnh thủ công trong kỳ
+    closing_balance_vnd DECIMAL(15, 0) NOT NULL DEFAULT 0,  -- Số dư cuối kỳ
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (month, id_nguoi_dung)
+);
+
+COMMENT ON TABLE wallet_period_snapshots IS 'Số dư ví của từng user tại thời điểm khóa sổ tháng';
+
+ALTER TABLE thong_tin_nhan_keo ADD COLUMN IF NOT EXISTS locked_period VARCHAR(7);
+ALTER TABLE lich_su_nop_tien ADD COLUMN IF NOT EXISTS locked_period VARCHAR(7);
+ALTER TABLE lich_su_rut_tien ADD COLUMN IF NOT EXISTS locked_period VARCHAR(7);
+
+CREATE INDEX IF NOT EXISTS idx_thong_tin_nhan_keo_locked_period ON thong_tin_nhan_keo(locked_period) WHERE locked_period IS NOT NULL;
+
+-- Đơn hàng đã khóa: chỉ cho đổi các cột kỹ thuật (xoay vòng khóa mã hóa tài khoản, chỉ mục mù, cờ quá hạn)
+-- Đơn hàng chưa khóa: không được ghi đơn hàng đã hoàn tất (chưa xóa) với thời gian hoàn thành trong tháng đã khóa sổ
+-- (vd: hoàn tác về phiên bản cũ, import, khôi phục từ thùng rác); đơn hàng đang chờ và đơn hàng đã xóa vẫn cập nhật được
+CREATE OR REPLACE FUNCTION thong_tin_nhan_keo_period_lock() RETURNS TRIGGER AS $$
+DECLARE
+    technical_columns TEXT[] := ARRAY['tai_khoan', 'mat_khau', 'tai_khoan_che', 'tai_khoan_hash',
+                                      'thoi_gian_bao_qua_han', 'thoi_gian_cap_nhat'];
+BEGIN
+    IF TG_OP <> 'INSERT' AND OLD.locked_period IS NOT NULL THEN
+        IF TG_OP = 'DELETE' THEN
+            -- Xóa người dùng (ON DELETE CASCADE chạy trong trigger của FK)
+            IF pg_trigger_depth() > 1 THEN
+                RETURN OLD;
+            END IF;
+            RAISE EXCEPTION 'Đơn hàng % thuộc tháng % đã khóa sổ, không thể xóa', OLD.ma_stt, OLD.locked_period;
+        END IF;
+        IF (to_jsonb(NEW) - technical_columns) IS DISTINCT FROM (to_jsonb(OLD) - technical_columns) THEN
+            RAISE EXCEPTION 'Đơn hàng % thuộc tháng % đã khóa sổ, không thể sửa', OLD.ma_stt, OLD.locked_period;
+        END IF;
+        RETURN NEW;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    IF NEW.locked_period IS NULL AND NEW.deleted_at IS NULL AND NEW.thoi_gian_hoan_thanh IS NOT NULL
+        AND NEW.tien_do_hoan_thanh IN ('DONE', 'HỦY BỎ', 'ĐỀN')
+        AND EXISTS (SELECT 1 FROM closed_periods WHERE month = TO_CHAR(NEW.thoi_gian_hoan_thanh, 'YYYY-MM')) THEN
+        RAISE EXCEPTION 'Tháng % đã khóa sổ, không thể ghi đơn hàng % hoàn thành trong tháng này',
+            TO_CHAR(NEW.thoi_gian_hoan_thanh, 'YYYY-MM'), NEW.ma_stt;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_thong_tin_nhan_keo_period_lock ON thong_tin_nhan_keo;
+CREATE TRIGGER trg_thong_tin_nhan_keo_period_lock
+    BEFORE INSERT OR UPDATE OR DELETE ON thong_tin_nhan_keo
+    FOR EACH ROW EXECUTE FUNCTION thong_tin_nhan_keo_period_lock();
+
+-- Nộp cọc / rút tiền đã khóa: không sửa/xóa (trừ ON DELETE CASCADE khi xóa người dùng)
+CREATE OR REPLACE FUNCTION wallet_transaction_period_lock() RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.locked_period IS NULL THEN
+        IF TG_OP = 'DELETE' THEN
+            RETURN OLD;
+        END IF;
+        RETURN NEW;
+    END IF;
+    IF TG_OP = 'DELETE' AND pg_trigger_depth() > 1 THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'Bản ghi % (bảng %) thuộc tháng % đã khóa sổ, không thể %', OLD.id, TG_TABLE_NAME, OLD.locked_period, TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_lich_su_nop_tien_period_lock ON lich_su_nop_tien;
+CREATE TRIGGER trg_lich_su_nop_tien_period_lock
+    BEFORE UPDATE OR DELETE ON lich_su_nop_tien
+    FOR EACH ROW EXECUTE FUNCTION wallet_transaction_period_lock();
+
+DROP TRIGGER IF EXISTS trg_lich_su_rut_tien_period_lock ON lich_su_rut_tien;
+CREATE TRIGGER trg_lich_su_rut_tien_period_lock
+    BEFORE UPDATE OR DELETE ON lich_su_rut_tien
+    FOR EACH ROW EXECUTE FUNCTION wallet_transaction_period_lock();
@@ -0,0 +1,30 @@
+-- Migration: Yêu cầu rút tiền và duyệt rút tiền
+-- Created: 2026
+-- Description: Trước đây admin tạo lich_su_rut_tien là trừ ví ngay, số dư có thể âm
+--              User gửi yêu cầu rút tiền (không vượt số dư khả dụng), yêu cầu đi qua các bước:
+--              pending (chờ duyệt) -> approved (đã duyệt) / rejected (từ chối, có lý do) -> paid (đã chi)
+--              Chỉ khi chi tiền (paid) mới ghi lich_su_rut_tien và trừ ví, lich_su_rut_tien chỉ chứa các lần đã chi
+--              Số dư khả dụng = số dư ví - tổng yêu cầu đang chờ duyệt / đã duyệt chưa chi
+
+CREATE TABLE IF NOT EXISTS withdrawal_requests (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    id_nguoi_dung VARCHAR(36) NOT NULL REFERENCES nguoi_dung(id) ON DELETE CASCADE,
+    amount_vnd DECIMAL(15, 0) NOT NULL CHECK (amount_vnd > 0),          -- Số tiền yêu cầu rút (VND)
+    note TEXT NOT NULL DEFAULT '',                                       -- Ghi chú của user
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'rejected', 'paid')),
+    reject_reason TEXT,                                                  -- Lý do từ chối (bắt buộc khi rejected)
+    reviewed_by VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL, -- Admin duyệt / từ chối
+    reviewed_at TIMESTAMP,
+    paid_by VARCHAR(36) REFERENCES nguoi_dung(id) ON DELETE SET NULL,     -- Admin chi tiền
+    paid_at TIMESTAMP,
+    withdrawal_id VARCHAR(36) REFERENCES lich_su_rut_tien(id) ON DELETE SET NULL, -- Lần rút tiền đã ghi khi chi
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (status <> 'rejected' OR reject_reason IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_withdrawal_requests_status ON withdrawal_requests(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_withdrawal_requests_user ON withdrawal_requests(id_nguoi_dung, created_at);
+
+COMMENT ON TABLE withdrawal_requests IS 'Yêu cầu rút tiền của user: pending -> approved/rejected -> paid, chỉ paid mới trừ ví';
@@ -0,0 +1,238 @@
+package money
+
+// Tiền tệ dạng số nguyên theo đơn vị nhỏ nhất (không dùng float64 để tránh sai số làm tròn)
+// Quy tắc làm tròn theo từng loại tiền (làm tròn nửa lên, xa số 0 - giống ROUND(numeric) của PostgreSQL):
+// - CNY: 2 chữ số thập phân (đơn vị: 0.01 tệ)
+// - VND: không có phần lẻ (đơn vị: 1 đồng)
+// - Rate (tỷ lệ phí, tỷ giá): 4 chữ số thập phân
+// Giá trị được đọc/ghi với DB (NUMERIC) và JSON dưới dạng chuỗi/số thập phân chính xác
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	cnyScale  = 2
+	vndScale  = 0
+	rateScale = 4
+)
+
+var pow10 = [...]int64{1, 10, 100, 1000, 10000, 100000, 1000000}
+
+// decimalPattern - Số thập phân thường: chỉ chữ số, dấu và dấu chấm
+// (big.Rat còn nhận hex "0x10", nhị phân "0b11", dấu gạch "1_000", số mũ "1e3" - không phải số tiền hợp lệ)
+var decimalPattern = regexp.MustCompile(`^[+-]?\d+(\.\d+)?$`)
+
+// CNY - Số tiền tệ, lưu theo 0.01 tệ (vd: 12.34 tệ = CNY(1234))
+type CNY int64
+
+// VND - Số tiền VND, lưu theo đồng
+type VND int64
+
+// Rate - Tỷ lệ phí hoặc tỷ giá, 4 chữ số thập phân (vd: 2% = 0.02 = Rate(200), tỷ giá 3550 = Rate(35500000))
+type Rate int64
+
+// CNYFromInt tạo số tiền tệ chẵn (không có phần lẻ)
+func CNYFromInt(units int64) CNY {
+	return CNY(units * 100)
+}
+
+// RateFromInt tạo tỷ lệ/tỷ giá chẵn (vd: tỷ giá 3550)
+func RateFromInt(units int64) Rate {
+	return Rate(units * pow10[rateScale])
+}
+
+// ParseCNY đọc số tiền tệ từ chuỗi thập phân ("12.345" -> 12.35)
+func ParseCNY(s string) (CNY, error) {
+	v, err := parseDecimal(s, cnyScale)
+	return CNY(v), err
+}
+
+// ParseVND đọc số tiền VND từ chuỗi thập phân ("1000.5" -> 1001)
+func ParseVND(s string) (VND, error) {
+	v, err := parseDecimal(s, vndScale)
+	return VND(v), err
+}
+
+// ParseRate đọc tỷ lệ/tỷ giá từ chuỗi thập phân ("0.02", "3550")
+func ParseRate(s string) (Rate, error) {
+	v, err := parseDecimal(s, rateScale)
+	return Rate(v), err
+}
+
+// MulRate nhân số tiền tệ với tỷ lệ (vd: phí = giá kèo × 2%), làm tròn đến 0.01 tệ
+// Trả về lỗi nếu kết quả vượt quá phạm vi lưu trữ (giống Parse)
+func (c CNY) MulRate(r Rate) (CNY, error) {
+	v, ok := mulDivRound(int64(c), int64(r), pow10[rateScale])
+	if !ok {
+		return 0, fmt.Errorf("số tiền quá lớn: %s × %s", c, r)
+	}
+	return CNY(v), nil
+}
+
+// ToVND quy đổi sang VND theo tỷ giá (VND/CNY), làm tròn đến đồng
+// Trả về lỗi nếu kết quả vượt quá phạm vi lưu trữ (giống Parse)
+func (c CNY) ToVND(r Rate) (VND, error) {
+	v, ok := mulDivRound(int64(c), int64(r), pow10[cnyScale+rateScale])
+	if !ok {
+		return 0, fmt.Errorf("số tiền quá lớn: %s tệ × tỷ giá %s", c, r)
+	}
+	return VND(v), nil
+}
+
+// Float64 - Giá trị gần đúng, chỉ dùng để hiển thị (vd: ô số trong file Excel)
+func (c CNY) Float64() float64 { return toFloat(int64(c), cnyScale) }
+
+// Float64 - Giá trị gần đúng, chỉ dùng để hiển thị
+func (v VND) Float64() float64 { return toFloat(int64(v), vndScale) }
+
+// Float64 - Giá trị gần đúng, chỉ dùng để hiển thị
+func (r Rate) Float64() float64 { return toFloat(int64(r), rateScale) }
+
+func (c CNY) String() string  { return formatDecimal(int64(c), cnyScale) }
+func (v VND) String() string  { return formatDecimal(int64(v), vndScale) }
+func (r Rate) String() string { return formatDecimal(int64(r), rateScale) }
+
+// JSON: ghi dạng số thập phân (12.34), đọc được cả số và chuỗi ("12.34")
+
+func (c CNY) MarshalJSON() ([]byte, error)  { return []byte(c.String()), nil }
+func (v VND) MarshalJSON() ([]byte, error)  { return []byte(v.String()), nil }
+func (r Rate) MarshalJSON() ([]byte, error) { return []byte(r.String()), nil }
+
+func (c *CNY) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalDecimal(data, cnyScale)
+	*c = CNY(v)
+	return err
+}
+
+func (v *VND) UnmarshalJSON(data []byte) error {
+	n, err := unmarshalDecimal(data, vndScale)
+	*v = VND(n)
+	return err
+}
+
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalDecimal(data, rateScale)
+	*r = Rate(v)
+	return err
+}
+
+// DB: ghi dạng chuỗi thập phân (NUMERIC chính xác), đọc từ NUMERIC/số nguyên
+// NULL được đọc thành 0; cột nullable dùng con trỏ (*CNY, *Rate)
+
+func (c CNY) Value() (driver.Value, error)  { return c.String(), nil }
+func (v VND) Value() (driver.Value, error)  { return v.String(), nil }
+func (r Rate) Value() (driver.Value, error) { return r.String(), nil }
+
+func (c *CNY) Scan(src interface{}) error {
+	v, err := scanDecimal(src, cnyScale)
+	*c = CNY(v)
+	return err
+}
+
+func (v *VND) Scan(src interface{}) error {
+	n, err := scanDecimal(src, vndScale)
+	*v = VND(n)
+	return err
+}
+
+func (r *Rate) Scan(src interface{}) error {
+	v, err := scanDecimal(src, rateScale)
+	*r = Rate(v)
+	return err
+}
+
+// parseDecimal đọc chuỗi thập phân thành số nguyên theo scale, làm tròn nửa lên (xa số 0)
+func parseDecimal(s string, scale int) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("số tiền rỗng")
+	}
+	if !decimalPattern.MatchString(s) {
+		return 0, fmt.Errorf("số tiền không hợp lệ: %q", s)
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, fmt.Errorf("số tiền không hợp lệ: %q", s)
+	}
+
+	// r × 10^scale, làm tròn nửa lên (xa số 0)
+	r.Mul(r, new(big.Rat).SetInt64(pow10[scale]))
+	num, den := r.Num(), r.Denom()
+	q, m := new(big.Int).QuoRem(num, den, new(big.Int))
+	if m.Sign() != 0 && new(big.Int).Mul(new(big.Int).Abs(m), big.NewInt(2)).Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(int64(num.Sign())))
+	}
+	if !q.IsInt64() {
+		return 0, fmt.Errorf("số tiền quá lớn: %q", s)
+	}
+	return q.Int64(), nil
+}
+
+// formatDecimal ghi số nguyên theo scale thành chuỗi thập phân (luôn đủ scale chữ số lẻ)
+func formatDecimal(v int64, scale int) string {
+	if scale == 0 {
+		return strconv.FormatInt(v, 10)
+	}
+	sign := ""
+	u := uint64(v)
+	if v < 0 {
+		sign = "-"
+		u = uint64(-v)
+	}
+	digits := strconv.FormatUint(u, 10)
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
+
+// mulDivRound tính a × b / div, làm tròn nửa lên (xa số 0)
+// ok = false nếu kết quả không vừa int64
+func mulDivRound(a, b, div int64) (v int64, ok bool) {
+	p := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+	q, m := new(big.Int).QuoRem(p, big.NewInt(div), new(big.Int))
+	if m.Sign() != 0 && new(big.Int).Mul(new(big.Int).Abs(m), big.NewInt(2)).Cmp(big.NewInt(div)) >= 0 {
+		q.Add(q, big.NewInt(int64(p.Sign())))
+	}
+	if !q.IsInt64() {
+		return 0, false
+	}
+	return q.Int64(), true
+}
+
+func toFloat(v int64, scale int) float64 {
+	f, _ := strconv.ParseFloat(formatDecimal(v, scale), 64)
+	return f
+}
+
+func unmarshalDecimal(data []byte, scale int) (int64, error) {
+	s := string(data)
+	if s == "null" {
+		return 0, nil
+	}
+	s = strings.Trim(s, `"`)
+	return parseDecimal(s, scale)
+}
+
+func scanDecimal(src interface{}, scale int) (int64, error) {
+	switch v := src.(type) {
+	case nil:
+		return 0, nil
+	case []byte:
+		return parseDecimal(string(v), scale)
+	case string:
+		return parseDecimal(v, scale)
+	case int64:
+		return parseDecimal(strconv.FormatInt(v, 10), scale)
+	case float64:
+		return parseDecimal(strconv.FormatFloat(v, 'f', -1, 64), scale)
+	default:
+		return 0, fmt.Errorf("không thể đọc số tiền từ kiểu %T", src)
+	}
+}
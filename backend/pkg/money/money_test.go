@@ -0,0 +1,203 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		parse   func(string) (int64, error)
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{name: "CNY chẵn", parse: parseCNY, input: "12", want: 1200},
+		{name: "CNY 2 chữ số lẻ", parse: parseCNY, input: "12.34", want: 1234},
+		{name: "CNY làm tròn lên", parse: parseCNY, input: "12.345", want: 1235},
+		{name: "CNY làm tròn xuống", parse: parseCNY, input: "12.344", want: 1234},
+		{name: "CNY âm làm tròn xa số 0", parse: parseCNY, input: "-12.345", want: -1235},
+		{name: "CNY âm làm tròn xuống", parse: parseCNY, input: "-12.344", want: -1234},
+		{name: "CNY có khoảng trắng", parse: parseCNY, input: " 1.5 ", want: 150},
+		{name: "CNY có dấu cộng", parse: parseCNY, input: "+1.5", want: 150},
+		{name: "VND làm tròn lên", parse: parseVND, input: "1000.5", want: 1001},
+		{name: "VND làm tròn xuống", parse: parseVND, input: "1000.49", want: 1000},
+		{name: "VND âm", parse: parseVND, input: "-1000.5", want: -1001},
+		{name: "Rate tỷ lệ phí", parse: parseRate, input: "0.02", want: 200},
+		{name: "Rate tỷ giá", parse: parseRate, input: "3550", want: 35500000},
+		{name: "Rate làm tròn 4 chữ số", parse: parseRate, input: "0.00005", want: 1},
+		{name: "chuỗi rỗng", parse: parseCNY, input: "  ", wantErr: true},
+		{name: "không phải số", parse: parseCNY, input: "abc", wantErr: true},
+		{name: "phân số", parse: parseCNY, input: "1/3", wantErr: true},
+		{name: "dạng mũ", parse: parseCNY, input: "1e3", wantErr: true},
+		{name: "dạng mũ viết hoa", parse: parseVND, input: "1E+06", wantErr: true},
+		{name: "hệ 16", parse: parseCNY, input: "0x10", wantErr: true},
+		{name: "hệ 2", parse: parseCNY, input: "0b11", wantErr: true},
+		{name: "hệ 8", parse: parseRate, input: "0o17", wantErr: true},
+		{name: "dấu gạch dưới", parse: parseVND, input: "1_000", wantErr: true},
+		{name: "thiếu phần nguyên", parse: parseCNY, input: ".5", wantErr: true},
+		{name: "thiếu phần lẻ", parse: parseCNY, input: "1.", wantErr: true},
+		{name: "hai dấu", parse: parseCNY, input: "--1", wantErr: true},
+		{name: "quá lớn", parse: parseCNY, input: "100000000000000000000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.parse(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %d, muốn lỗi", tt.input, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Parse(%q) = %d, %v, muốn %d", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func parseCNY(s string) (int64, error) {
+	v, err := ParseCNY(s)
+	return int64(v), err
+}
+
+func parseVND(s string) (int64, error) {
+	v, err := ParseVND(s)
+	return int64(v), err
+}
+
+func parseRate(s string) (int64, error) {
+	v, err := ParseRate(s)
+	return int64(v), err
+}
+
+func TestMulDivRound(t *testing.T) {
+	tests := []struct {
+		name   string
+		a, b   int64
+		div    int64
+		want   int64
+		wantOK bool
+	}{
+		{name: "chia hết", a: 10000, b: 200, div: 10000, want: 200, wantOK: true},
+		{name: "nửa làm tròn lên", a: 25, b: 1, div: 10, want: 3, wantOK: true},
+		{name: "dưới nửa làm tròn xuống", a: 24, b: 1, div: 10, want: 2, wantOK: true},
+		{name: "âm nửa làm tròn xa số 0", a: -25, b: 1, div: 10, want: -3, wantOK: true},
+		{name: "âm dưới nửa", a: -24, b: 1, div: 10, want: -2, wantOK: true},
+		{name: "hai số âm", a: -25, b: -1, div: 10, want: 3, wantOK: true},
+		{name: "tích vượt int64 nhưng kết quả vừa", a: math.MaxInt64, b: 10, div: 100, want: 922337203685477581, wantOK: true},
+		{name: "kết quả vượt int64", a: math.MaxInt64, b: 2, div: 1, wantOK: false},
+		{name: "kết quả âm vượt int64", a: math.MinInt64, b: 2, div: 1, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := mulDivRound(tt.a, tt.b, tt.div)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("mulDivRound(%d, %d, %d) = %d, %v, muốn %d, %v", tt.a, tt.b, tt.div, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMulRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  CNY
+		rate    Rate
+		want    CNY
+		wantErr bool
+	}{
+		{name: "phí 2%", amount: CNYFromInt(100), rate: 200, want: 200},
+		{name: "làm tròn đến 0.01 tệ", amount: 1234, rate: 600, want: 74}, // 12.34 × 6% = 0.7404
+		{name: "nửa làm tròn lên", amount: 125, rate: 200, want: 3},       // 1.25 × 2% = 0.025
+		{name: "số tiền âm", amount: -125, rate: 200, want: -3},           // -1.25 × 2% = -0.025
+		{name: "tỷ lệ 0", amount: CNYFromInt(100), rate: 0, want: 0},
+		{name: "tràn số", amount: CNY(math.MaxInt64), rate: RateFromInt(2), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.amount.MulRate(tt.rate)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("MulRate() = %s, muốn lỗi", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("%s.MulRate(%s) = %s, %v, muốn %s", tt.amount, tt.rate, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestToVND(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  CNY
+		rate    Rate
+		want    VND
+		wantErr bool
+	}{
+		{name: "tỷ giá chẵn", amount: CNYFromInt(100), rate: RateFromInt(3550), want: 355000},
+		{name: "làm tròn đến đồng", amount: 1, rate: RateFromInt(3550), want: 36}, // 0.01 × 3550 = 35.5
+		{name: "âm làm tròn xa số 0", amount: -1, rate: RateFromInt(3550), want: -36},
+		{name: "tỷ giá lẻ", amount: 1234, rate: 35501234, want: 43809}, // 12.34 × 3550.1234 = 43808.52...
+		{name: "tràn số", amount: CNY(math.MaxInt64), rate: RateFromInt(3550), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.amount.ToVND(tt.rate)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ToVND() = %s, muốn lỗi", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("%s.ToVND(%s) = %s, %v, muốn %s", tt.amount, tt.rate, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{CNY(1234).String(), "12.34"},
+		{CNY(5).String(), "0.05"},
+		{CNY(-5).String(), "-0.05"},
+		{CNY(0).String(), "0.00"},
+		{VND(-1000).String(), "-1000"},
+		{Rate(200).String(), "0.0200"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("String() = %q, muốn %q", tt.got, tt.want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		Amount CNY  `json:"amount"`
+		Rate   Rate `json:"rate"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount": "12.345", "rate": 0.02}`), &v); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if v.Amount != 1235 || v.Rate != 200 {
+		t.Errorf("Unmarshal() = %d, %d, muốn 1235, 200", v.Amount, v.Rate)
+	}
+	data, _ := json.Marshal(v)
+	if string(data) != `{"amount":12.35,"rate":0.0200}` {
+		t.Errorf("Marshal() = %s", data)
+	}
+}
@@ -0,0 +1,204 @@
+package pagination
+
+// Phân trang kiểu keyset (cursor) cho các API danh sách
+// Cursor là chuỗi base64 (opaque với client) chứa giá trị các cột sắp xếp của dòng biên
+// Thay vì OFFSET, trang tiếp theo được lấy bằng điều kiện (c1, c2, ...) > (v1, v2, ...)
+// nên không bị lệch khi có dòng mới được thêm vào và vẫn nhanh ở các trang sâu
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidCursor - Cursor không đọc được hoặc không khớp với kiểu sắp xếp hiện tại
+var ErrInvalidCursor = errors.New("Cursor không hợp lệ")
+
+// Cursor - Vị trí trong danh sách
+type Cursor struct {
+	Values   []interface{} `json:"v"`           // Giá trị các cột keyset của dòng biên
+	Backward bool          `json:"b,omitempty"` // true = lấy trang trước (prev_cursor)
+	Sort     string        `json:"s,omitempty"` // Kiểu sắp xếp lúc tạo cursor (để phát hiện cursor cũ)
+}
+
+// Request - Tham số phân trang của một request
+type Request struct {
+	Limit  int     // Số dòng mỗi trang (0 = không giới hạn, không trả cursor)
+	Offset int     // Chỉ dùng khi không có Cursor (tương thích ngược với limit/offset)
+	Cursor *Cursor // nil = trang đầu tiên (hoặc trang theo Offset)
+	Sort   string  // Kiểu sắp xếp hiện tại, vd: "stt:asc"
+}
+
+// Page - Thông tin phân trang trả về cho client
+type Page struct {
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
+	Limit      int     `json:"limit"`
+}
+
+// Encode mã hóa cursor thành chuỗi opaque
+func Encode(values []interface{}, backward bool, sort string) string {
+	data, _ := json.Marshal(Cursor{Values: values, Backward: backward, Sort: sort})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode giải mã cursor từ query parameter
+// sort: kiểu sắp xếp hiện tại, cursor tạo với kiểu sắp xếp khác sẽ bị từ chối
+// keyCount: số cột keyset mong đợi
+func Decode(token string, sort string, keyCount int) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if cursor.Sort != sort || len(cursor.Values) != keyCount {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// KeysetQuery tạo điều kiện WHERE và ORDER BY cho keyset pagination
+// columns: các cột sắp xếp (cột cuối phải là khóa duy nhất, vd: id) - tất cả phải NOT NULL
+// desc: chiều sắp xếp của danh sách
+// argIndex: chỉ số $ tiếp theo
+// Trả về điều kiện (rỗng nếu không có cursor), ORDER BY, args và chỉ số $ tiếp theo
+func KeysetQuery(columns []string, desc bool, cursor *Cursor, argIndex int) (string, string, []interface{}, int) {
+	// Lấy trang trước = đi ngược chiều sắp xếp, sau đó đảo lại kết quả
+	scanDesc := desc
+	if cursor != nil && cursor.Backward {
+		scanDesc = !desc
+	}
+
+	direction := "ASC"
+	operator := ">"
+	if scanDesc {
+		direction = "DESC"
+		operator = "<"
+	}
+
+	orderParts := make([]string, len(columns))
+	for i, column := range columns {
+		orderParts[i] = column + " " + direction
+	}
+	orderBy := " ORDER BY " + strings.Join(orderParts, ", ")
+
+	if cursor == nil {
+		return "", orderBy, nil, argIndex
+	}
+
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = fmt.Sprintf("$%d", argIndex)
+		argIndex++
+	}
+	condition := fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), operator, strings.Join(placeholders, ", "))
+
+	return condition, orderBy, cursor.Values, argIndex
+}
+
+// FetchLimit trả về số dòng cần lấy từ DB (lấy thêm 1 dòng để biết còn trang tiếp hay không)
+func (r Request) FetchLimit() int {
+	if r.Limit <= 0 {
+		return 0
+	}
+	return r.Limit + 1
+}
+
+// Paginate cắt dòng thừa, đảo lại thứ tự khi đi lùi và tạo next_cursor/prev_cursor
+// items: kết quả đã lấy với FetchLimit()
+// keyFn: trả về giá trị các cột keyset của một dòng (cùng thứ tự với columns trong KeysetQuery)
+func Paginate[T any](items []T, req Request, keyFn func(T) []interface{}) ([]T, Page) {
+	page := Page{Limit: req.Limit}
+	if req.Limit <= 0 {
+		return items, page
+	}
+
+	hasExtra := len(items) > req.Limit
+	if hasExtra {
+		items = items[:req.Limit]
+	}
+
+	backward := req.Cursor != nil && req.Cursor.Backward
+	if backward {
+		slices.Reverse(items)
+	}
+
+	var hasNext, hasPrev bool
+	if backward {
+		hasNext = true
+		hasPrev = hasExtra
+	} else {
+		hasNext = hasExtra
+		hasPrev = req.Cursor != nil || req.Offset > 0
+	}
+
+	if len(items) == 0 {
+		return items, page
+	}
+
+	if hasNext {
+		next := Encode(keyFn(items[len(items)-1]), false, req.Sort)
+		page.NextCursor = &next
+	}
+	if hasPrev {
+		prev := Encode(keyFn(items[0]), true, req.Sort)
+		page.PrevCursor = &prev
+	}
+
+	return items, page
+}
+
+// NewRequest tạo Request từ query parameter, giải mã cursor nếu có
+// Cursor yêu cầu limit > 0 (nếu không truyền limit thì dùng defaultLimit)
+func NewRequest(limit, offset int, token, sort string, keyCount, defaultLimit int) (Request, error) {
+	req := Request{Limit: limit, Offset: offset, Sort: sort}
+	if token == "" {
+		return req, nil
+	}
+
+	cursor, err := Decode(token, sort, keyCount)
+	if err != nil {
+		return req, err
+	}
+	req.Cursor = cursor
+	req.Offset = 0
+	if req.Limit <= 0 {
+		req.Limit = defaultLimit
+	}
+	return req, nil
+}
+
+// SQL tạo phần cuối câu query: điều kiện keyset, ORDER BY, LIMIT/OFFSET
+// hasWhere: query đã có WHERE chưa (để nối bằng AND)
+// Limit = 0 thì không thêm LIMIT (lấy tất cả)
+func (r Request) SQL(columns []string, desc bool, hasWhere bool, argIndex int) (string, []interface{}) {
+	condition, orderBy, args, argIndex := KeysetQuery(columns, desc, r.Cursor, argIndex)
+
+	clause := ""
+	if condition != "" {
+		if hasWhere {
+			clause += " AND " + condition
+		} else {
+			clause += " WHERE " + condition
+		}
+	}
+	clause += orderBy
+
+	if fetchLimit := r.FetchLimit(); fetchLimit > 0 {
+		clause += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+		args = append(args, fetchLimit, r.Offset)
+	}
+	return clause, args
+}
@@ -0,0 +1,157 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	token := Encode([]interface{}{"2026-10-01T00:00:00Z", "id-1"}, true, "thoi_gian_tao:desc")
+
+	cursor, err := Decode(token, "thoi_gian_tao:desc", 2)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	want := &Cursor{Values: []interface{}{"2026-10-01T00:00:00Z", "id-1"}, Backward: true, Sort: "thoi_gian_tao:desc"}
+	if !reflect.DeepEqual(cursor, want) {
+		t.Errorf("Decode() = %+v, muốn %+v", cursor, want)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	valid := Encode([]interface{}{float64(10), "id-1"}, false, "stt:asc")
+
+	tests := []struct {
+		name     string
+		token    string
+		sort     string
+		keyCount int
+	}{
+		{name: "không phải base64", token: "%%%", sort: "stt:asc", keyCount: 2},
+		{name: "không phải JSON", token: base64.RawURLEncoding.EncodeToString([]byte("abc")), sort: "stt:asc", keyCount: 2},
+		{name: "khác kiểu sắp xếp", token: valid, sort: "stt:desc", keyCount: 2},
+		{name: "sai số cột keyset", token: valid, sort: "stt:asc", keyCount: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := Decode(tt.token, tt.sort, tt.keyCount)
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("Decode() error = %v, muốn ErrInvalidCursor", err)
+			}
+			if cursor != nil {
+				t.Errorf("Decode() = %+v, muốn nil", cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeEmpty(t *testing.T) {
+	cursor, err := Decode("", "stt:asc", 2)
+	if err != nil || cursor != nil {
+		t.Fatalf("Decode(\"\") = %v, %v, muốn nil, nil", cursor, err)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	keyFn := func(n int) []interface{} { return []interface{}{float64(n)} }
+	cursorValue := func(t *testing.T, token *string, backward bool) interface{} {
+		t.Helper()
+		if token == nil {
+			return nil
+		}
+		cursor, err := Decode(*token, "stt:asc", 1)
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		if cursor.Backward != backward {
+			t.Errorf("cursor.Backward = %v, muốn %v", cursor.Backward, backward)
+		}
+		return cursor.Values[0]
+	}
+
+	tests := []struct {
+		name      string
+		items     []int
+		req       Request
+		wantItems []int
+		wantNext  interface{} // nil = không có next_cursor
+		wantPrev  interface{} // nil = không có prev_cursor
+	}{
+		{
+			name:      "không giới hạn thì không có cursor",
+			items:     []int{1, 2, 3},
+			req:       Request{Limit: 0, Sort: "stt:asc"},
+			wantItems: []int{1, 2, 3},
+		},
+		{
+			name:      "trang đầu còn trang sau",
+			items:     []int{1, 2, 3},
+			req:       Request{Limit: 2, Sort: "stt:asc"},
+			wantItems: []int{1, 2},
+			wantNext:  float64(2),
+		},
+		{
+			name:      "trang cuối",
+			items:     []int{1, 2},
+			req:       Request{Limit: 2, Sort: "stt:asc"},
+			wantItems: []int{1, 2},
+		},
+		{
+			name:      "trang theo offset có trang trước",
+			items:     []int{3, 4, 5},
+			req:       Request{Limit: 2, Offset: 2, Sort: "stt:asc"},
+			wantItems: []int{3, 4},
+			wantNext:  float64(4),
+			wantPrev:  float64(3),
+		},
+		{
+			name:      "đi tiếp bằng cursor",
+			items:     []int{3, 4},
+			req:       Request{Limit: 2, Cursor: &Cursor{Values: []interface{}{float64(2)}, Sort: "stt:asc"}, Sort: "stt:asc"},
+			wantItems: []int{3, 4},
+			wantPrev:  float64(3),
+		},
+		{
+			name:      "đi lùi đảo lại thứ tự, còn trang trước",
+			items:     []int{4, 3, 2},
+			req:       Request{Limit: 2, Cursor: &Cursor{Values: []interface{}{float64(5)}, Backward: true, Sort: "stt:asc"}, Sort: "stt:asc"},
+			wantItems: []int{3, 4},
+			wantNext:  float64(4),
+			wantPrev:  float64(3),
+		},
+		{
+			name:      "đi lùi về trang đầu",
+			items:     []int{2, 1},
+			req:       Request{Limit: 2, Cursor: &Cursor{Values: []interface{}{float64(3)}, Backward: true, Sort: "stt:asc"}, Sort: "stt:asc"},
+			wantItems: []int{1, 2},
+			wantNext:  float64(2),
+		},
+		{
+			name:      "trang rỗng",
+			items:     []int{},
+			req:       Request{Limit: 2, Cursor: &Cursor{Values: []interface{}{float64(9)}, Sort: "stt:asc"}, Sort: "stt:asc"},
+			wantItems: []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, page := Paginate(append([]int(nil), tt.items...), tt.req, keyFn)
+			if len(items) != len(tt.wantItems) || (len(items) > 0 && !reflect.DeepEqual(items, tt.wantItems)) {
+				t.Errorf("Paginate() items = %v, muốn %v", items, tt.wantItems)
+			}
+			if page.Limit != tt.req.Limit {
+				t.Errorf("page.Limit = %d, muốn %d", page.Limit, tt.req.Limit)
+			}
+			if got := cursorValue(t, page.NextCursor, false); got != tt.wantNext {
+				t.Errorf("next_cursor = %v, muốn %v", got, tt.wantNext)
+			}
+			if got := cursorValue(t, page.PrevCursor, true); got != tt.wantPrev {
+				t.Errorf("prev_cursor = %v, muốn %v", got, tt.wantPrev)
+			}
+		})
+	}
+}
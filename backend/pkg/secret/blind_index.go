@@ -0,0 +1,39 @@
+package secret
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// BlindIndex - Chỉ mục mù (HMAC-SHA256) cho dữ liệu đã mã hóa
+// Mã hóa phong bì dùng DEK ngẫu nhiên nên hai giá trị giống nhau cho ra hai chuỗi mã hóa khác nhau;
+// chỉ mục mù cho phép so sánh bằng nhau trong DB mà không cần giải mã
+// Key độc lập với keyring (đổi master key không làm thay đổi chỉ mục)
+type BlindIndex struct {
+	key []byte
+}
+
+// ParseBlindIndex đọc key chỉ mục mù từ chuỗi base64 chuẩn (32 byte)
+func ParseBlindIndex(encoded string) (*BlindIndex, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != keySize {
+		return nil, fmt.Errorf("key chỉ mục phải là %d byte mã hóa base64", keySize)
+	}
+	return &BlindIndex{key: key}, nil
+}
+
+// Hash tính chỉ mục của value (không phân biệt hoa thường, bỏ khoảng trắng đầu/cuối)
+// Chuỗi rỗng trả về "" (không có gì để so sánh)
+func (b *BlindIndex) Hash(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, b.key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
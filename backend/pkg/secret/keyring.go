@@ -0,0 +1,244 @@
+package secret
+
+// Mã hóa phong bì (envelope encryption) cho dữ liệu nhạy cảm lưu trong DB
+// Mỗi giá trị được mã hóa bằng một data key (DEK) ngẫu nhiên (AES-256-GCM),
+// DEK được mã hóa (wrap) bằng master key (KEK) lấy từ config
+// Định dạng lưu trữ: enc:v1:<key_id>:<DEK đã wrap>:<dữ liệu đã mã hóa> (base64)
+// Đổi master key (rotation): chỉ cần wrap lại DEK bằng key mới, không phải mã hóa lại dữ liệu
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	prefix  = "enc:v1:"
+	keySize = 32 // AES-256
+
+	// Masked - Giá trị hiển thị thay cho mật khẩu
+	Masked = "********"
+)
+
+var (
+	// ErrUnknownKey - Giá trị được mã hóa bằng master key không có trong keyring
+	ErrUnknownKey = errors.New("Không tìm thấy master key để giải mã")
+	// ErrMalformed - Chuỗi đã mã hóa không đúng định dạng hoặc đã bị sửa
+	ErrMalformed = errors.New("Dữ liệu mã hóa không hợp lệ")
+)
+
+var encoding = base64.RawStdEncoding
+
+// Keyring - Danh sách master key, key đầu tiên là key đang dùng để mã hóa
+// Các key cũ được giữ lại để giải mã dữ liệu chưa được rotate
+type Keyring struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+// ParseKeyring đọc keyring từ chuỗi cấu hình dạng "id1:base64key1,id2:base64key2"
+// Key đầu tiên là key đang dùng; mỗi key phải là 32 byte (base64 chuẩn)
+func ParseKeyring(spec string) (*Keyring, error) {
+	k := &Keyring{keys: map[string][]byte{}}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("key '%s' phải có dạng id:base64key", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != keySize {
+			return nil, fmt.Errorf("key '%s' phải là %d byte mã hóa base64", id, keySize)
+		}
+		if _, exists := k.keys[id]; exists {
+			return nil, fmt.Errorf("key '%s' bị lặp lại", id)
+		}
+
+		k.keys[id] = key
+		if k.activeID == "" {
+			k.activeID = id
+		}
+	}
+
+	if k.activeID == "" {
+		return nil, errors.New("keyring không có key nào")
+	}
+	return k, nil
+}
+
+// NewKeyring tạo keyring chỉ có một key (dùng cho môi trường dev/test)
+func NewKeyring(id string, key []byte) (*Keyring, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key phải là %d byte", keySize)
+	}
+	return &Keyring{activeID: id, keys: map[string][]byte{id: key}}, nil
+}
+
+// ActiveKeyID trả về ID của key đang dùng để mã hóa
+func (k *Keyring) ActiveKeyID() string {
+	return k.activeID
+}
+
+// IsEncrypted kiểm tra giá trị đã được mã hóa chưa (dữ liệu cũ có thể vẫn là plaintext)
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Encrypt mã hóa plaintext bằng DEK mới, wrap DEK bằng key đang dùng
+// Chuỗi rỗng được giữ nguyên (không có gì để bảo vệ)
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	dek := make([]byte, keySize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+
+	data, err := seal(dek, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+	return k.wrap(dek, data)
+}
+
+// Decrypt giải mã giá trị đã mã hóa
+// Giá trị chưa mã hóa (dữ liệu cũ trước khi bật mã hóa) được trả về nguyên vẹn
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	_, dek, data, err := k.unwrap(value)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dek, data, nil)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation kiểm tra giá trị có cần xử lý lại không (còn là plaintext hoặc dùng key cũ)
+func (k *Keyring) NeedsRotation(value string) bool {
+	if value == "" {
+		return false
+	}
+	if !IsEncrypted(value) {
+		return true
+	}
+	keyID, _, _ := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	return keyID != k.activeID
+}
+
+// Rotate đưa giá trị về key đang dùng: plaintext được mã hóa, giá trị dùng key cũ được wrap lại DEK
+// (dữ liệu không bị mã hóa lại). Giá trị đã dùng key hiện tại được giữ nguyên
+func (k *Keyring) Rotate(value string) (string, error) {
+	if !k.NeedsRotation(value) {
+		return value, nil
+	}
+	if !IsEncrypted(value) {
+		return k.Encrypt(value)
+	}
+
+	_, dek, data, err := k.unwrap(value)
+	if err != nil {
+		return "", err
+	}
+	return k.wrap(dek, data)
+}
+
+// wrap mã hóa DEK bằng key đang dùng và ghép thành chuỗi lưu trữ
+// key ID được dùng làm associated data để không thể đổi ID trong chuỗi
+func (k *Keyring) wrap(dek, data []byte) (string, error) {
+	wrapped, err := seal(k.keys[k.activeID], dek, []byte(k.activeID))
+	if err != nil {
+		return "", err
+	}
+	return prefix + k.activeID + ":" + encoding.EncodeToString(wrapped) + ":" + encoding.EncodeToString(data), nil
+}
+
+// unwrap tách chuỗi lưu trữ, giải mã DEK bằng master key tương ứng
+func (k *Keyring) unwrap(value string) (string, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, ErrMalformed
+	}
+
+	keyID := parts[0]
+	kek, ok := k.keys[keyID]
+	if !ok {
+		return "", nil, nil, fmt.Errorf("%w (key '%s')", ErrUnknownKey, keyID)
+	}
+
+	wrapped, err := encoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+	data, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+
+	dek, err := open(kek, wrapped, []byte(keyID))
+	if err != nil || len(dek) != keySize {
+		return "", nil, nil, ErrMalformed
+	}
+	return keyID, dek, data, nil
+}
+
+// seal mã hóa AES-GCM, kết quả = nonce || ciphertext
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open giải mã dữ liệu tạo bởi seal
+func open(key, sealed, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Mask che giá trị để hiển thị: giữ 2 ký tự đầu và 2 ký tự cuối, chuỗi ngắn (<= 4 ký tự) che toàn bộ
+// Phải khớp với cách che trong migration 017 (dữ liệu lịch sử cũ)
+func Mask(value string) string {
+	if value == "" {
+		return ""
+	}
+	runes := []rune(value)
+	if len(runes) <= 4 {
+		return "****"
+	}
+	return string(runes[:2]) + "****" + string(runes[len(runes)-2:])
+}
@@ -0,0 +1,194 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, keySize)
+}
+
+func testKeySpec(id string, b byte) string {
+	return id + ":" + base64.StdEncoding.EncodeToString(testKey(b))
+}
+
+func TestParseKeyring(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       string
+		wantActive string
+		wantErr    bool
+	}{
+		{name: "một key", spec: testKeySpec("k1", 1), wantActive: "k1"},
+		{name: "key đầu tiên là key đang dùng", spec: testKeySpec("k2", 2) + ", " + testKeySpec("k1", 1), wantActive: "k2"},
+		{name: "bỏ qua phần tử rỗng", spec: "," + testKeySpec("k1", 1) + ",", wantActive: "k1"},
+		{name: "chuỗi rỗng", spec: "", wantErr: true},
+		{name: "thiếu id", spec: ":" + base64.StdEncoding.EncodeToString(testKey(1)), wantErr: true},
+		{name: "thiếu dấu hai chấm", spec: "k1", wantErr: true},
+		{name: "không phải base64", spec: "k1:%%%", wantErr: true},
+		{name: "key sai độ dài", spec: "k1:" + base64.StdEncoding.EncodeToString([]byte("short")), wantErr: true},
+		{name: "key bị lặp", spec: testKeySpec("k1", 1) + "," + testKeySpec("k1", 2), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := ParseKeyring(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseKeyring(%q) error = nil, muốn lỗi", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseKeyring(%q) error = %v", tt.spec, err)
+			}
+			if k.ActiveKeyID() != tt.wantActive {
+				t.Errorf("ActiveKeyID() = %q, muốn %q", k.ActiveKeyID(), tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	k, err := NewKeyring("k1", testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, plaintext := range []string{"", "mật khẩu", "a:b:c"} {
+		encrypted, err := k.Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt(%q) error = %v", plaintext, err)
+		}
+		if plaintext == "" {
+			if encrypted != "" {
+				t.Errorf("Encrypt(\"\") = %q, muốn chuỗi rỗng", encrypted)
+			}
+			continue
+		}
+		if !strings.HasPrefix(encrypted, "enc:v1:k1:") {
+			t.Errorf("Encrypt(%q) = %q, muốn tiền tố enc:v1:k1:", plaintext, encrypted)
+		}
+		if strings.Contains(encrypted, plaintext) {
+			t.Errorf("Encrypt(%q) chứa plaintext", plaintext)
+		}
+		decrypted, err := k.Decrypt(encrypted)
+		if err != nil || decrypted != plaintext {
+			t.Errorf("Decrypt(Encrypt(%q)) = %q, %v", plaintext, decrypted, err)
+		}
+	}
+}
+
+func TestDecryptPlaintext(t *testing.T) {
+	k, _ := NewKeyring("k1", testKey(1))
+	got, err := k.Decrypt("dữ liệu cũ")
+	if err != nil || got != "dữ liệu cũ" {
+		t.Fatalf("Decrypt(plaintext) = %q, %v, muốn giữ nguyên", got, err)
+	}
+}
+
+func TestDecryptInvalid(t *testing.T) {
+	k, _ := NewKeyring("k1", testKey(1))
+	other, _ := NewKeyring("k1", testKey(9))
+	encrypted, _ := k.Encrypt("secret")
+	parts := strings.Split(strings.TrimPrefix(encrypted, prefix), ":")
+
+	tests := []struct {
+		name    string
+		value   string
+		keyring *Keyring
+		wantErr error
+	}{
+		{name: "thiếu phần", value: prefix + "k1:abc", keyring: k, wantErr: ErrMalformed},
+		{name: "thừa phần", value: encrypted + ":abc", keyring: k, wantErr: ErrMalformed},
+		{name: "key không có trong keyring", value: prefix + "k2:" + parts[1] + ":" + parts[2], keyring: k, wantErr: ErrUnknownKey},
+		{name: "DEK không phải base64", value: prefix + "k1:%%%:" + parts[2], keyring: k, wantErr: ErrMalformed},
+		{name: "dữ liệu không phải base64", value: prefix + "k1:" + parts[1] + ":%%%", keyring: k, wantErr: ErrMalformed},
+		{name: "sai master key", value: encrypted, keyring: other, wantErr: ErrMalformed},
+		{name: "dữ liệu bị sửa", value: prefix + "k1:" + parts[1] + ":" + encoding.EncodeToString(bytes.Repeat([]byte{0}, 40)), keyring: k, wantErr: ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.keyring.Decrypt(tt.value); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decrypt() error = %v, muốn %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRotate(t *testing.T) {
+	oldRing, _ := ParseKeyring(testKeySpec("k1", 1))
+	newRing, _ := ParseKeyring(testKeySpec("k2", 2) + "," + testKeySpec("k1", 1))
+
+	encryptedOld, _ := oldRing.Encrypt("secret")
+	encryptedNew, _ := newRing.Encrypt("secret")
+
+	tests := []struct {
+		name      string
+		value     string
+		wantRot   bool
+		wantSame  bool
+		wantPlain string
+	}{
+		{name: "chuỗi rỗng", value: "", wantSame: true},
+		{name: "plaintext được mã hóa", value: "secret", wantRot: true, wantPlain: "secret"},
+		{name: "key cũ được wrap lại", value: encryptedOld, wantRot: true, wantPlain: "secret"},
+		{name: "key hiện tại giữ nguyên", value: encryptedNew, wantSame: true, wantPlain: "secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newRing.NeedsRotation(tt.value); got != tt.wantRot {
+				t.Errorf("NeedsRotation() = %v, muốn %v", got, tt.wantRot)
+			}
+			rotated, err := newRing.Rotate(tt.value)
+			if err != nil {
+				t.Fatalf("Rotate() error = %v", err)
+			}
+			if tt.wantSame {
+				if rotated != tt.value {
+					t.Errorf("Rotate() = %q, muốn giữ nguyên %q", rotated, tt.value)
+				}
+			} else if !strings.HasPrefix(rotated, "enc:v1:k2:") {
+				t.Errorf("Rotate() = %q, muốn dùng key k2", rotated)
+			}
+			if newRing.NeedsRotation(rotated) {
+				t.Errorf("NeedsRotation(Rotate()) = true")
+			}
+			if tt.wantPlain != "" {
+				decrypted, err := newRing.Decrypt(rotated)
+				if err != nil || decrypted != tt.wantPlain {
+					t.Errorf("Decrypt(Rotate()) = %q, %v, muốn %q", decrypted, err, tt.wantPlain)
+				}
+			}
+		})
+	}
+
+	// Wrap lại DEK: phần dữ liệu đã mã hóa không đổi
+	rotated, _ := newRing.Rotate(encryptedOld)
+	if oldData, newData := encryptedOld[strings.LastIndex(encryptedOld, ":"):], rotated[strings.LastIndex(rotated, ":"):]; oldData != newData {
+		t.Errorf("Rotate() mã hóa lại dữ liệu, muốn chỉ wrap lại DEK")
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"abcd", "****"},
+		{"abcde", "ab****de"},
+		{"tàikhoản", "tà****ản"},
+	}
+	for _, tt := range tests {
+		if got := Mask(tt.value); got != tt.want {
+			t.Errorf("Mask(%q) = %q, muốn %q", tt.value, got, tt.want)
+		}
+	}
+}
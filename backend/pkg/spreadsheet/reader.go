@@ -0,0 +1,81 @@
+package spreadsheet
+
+// Đọc dữ liệu dạng bảng từ file CSV hoặc XLSX (sheet đầu tiên)
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Format - Định dạng file bảng tính
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ErrUnsupportedFormat - File không phải CSV/XLSX
+var ErrUnsupportedFormat = errors.New("Chỉ hỗ trợ file .csv hoặc .xlsx")
+
+// DetectFormat xác định định dạng theo đuôi file
+func DetectFormat(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// ReadRows đọc tất cả các dòng (kể cả dòng tiêu đề) từ file
+// XLSX: đọc sheet đầu tiên, giá trị số lấy dạng gốc (không theo định dạng hiển thị của ô)
+// CSV: hỗ trợ dấu phân cách "," hoặc ";" (Excel tiếng Việt thường xuất ";"), bỏ BOM UTF-8
+func ReadRows(r io.Reader, format string) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatXLSX:
+		return readXLSX(r)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+func readCSV(r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// Dòng đầu có nhiều ";" hơn "," thì dùng ";" làm dấu phân cách
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	return reader.ReadAll()
+}
+
+func readXLSX(r io.Reader) ([][]string, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("File XLSX không có sheet nào")
+	}
+
+	return f.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+}
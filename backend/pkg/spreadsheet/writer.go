@@ -0,0 +1,213 @@
+package spreadsheet
+
+// Ghi dữ liệu dạng bảng ra CSV hoặc XLSX theo kiểu stream (từng dòng, không giữ toàn bộ dữ liệu trong bộ nhớ)
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"fullstack-backend/pkg/money"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// TimeLayout - Định dạng thời gian khi ghi ra file
+const TimeLayout = "2006-01-02 15:04:05"
+
+// ErrMultipleSheets - CSV chỉ có một sheet
+var ErrMultipleSheets = errors.New("File CSV không hỗ trợ nhiều sheet, hãy dùng format=xlsx")
+
+// Writer - Ghi bảng tính theo từng dòng
+type Writer interface {
+	// NewSheet bắt đầu sheet mới và ghi dòng tiêu đề
+	NewSheet(name string, headers []string) error
+	// WriteRow ghi một dòng vào sheet hiện tại
+	// Giá trị hỗ trợ: string, số, bool, time.Time, *time.Time, nil
+	WriteRow(values []interface{}) error
+	// Close ghi phần còn lại ra output (XLSX chỉ được ghi ra output ở bước này)
+	Close() error
+}
+
+// NewWriter tạo Writer theo định dạng (FormatCSV hoặc FormatXLSX)
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{out: w}, nil
+	case FormatXLSX:
+		return &xlsxWriter{out: w, file: excelize.NewFile()}, nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// ContentType trả về Content-Type HTTP của định dạng
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// normalizeValue chuyển thời gian thành chuỗi, tiền tệ thành số, con trỏ nil thành ô trống
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case money.CNY:
+		return v.Float64()
+	case money.VND:
+		return v.Float64()
+	case money.Rate:
+		return v.Float64()
+	case time.Time:
+		return v.Format(TimeLayout)
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return v.Format(TimeLayout)
+	case *int:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *string:
+		if v == nil {
+			return nil
+		}
+		return *v
+	}
+	return value
+}
+
+// csvWriter - Ghi CSV (UTF-8 có BOM để Excel hiển thị đúng tiếng Việt)
+type csvWriter struct {
+	out    io.Writer
+	writer *csv.Writer
+}
+
+func (w *csvWriter) NewSheet(name string, headers []string) error {
+	if w.writer != nil {
+		return ErrMultipleSheets
+	}
+	if _, err := io.WriteString(w.out, "\xef\xbb\xbf"); err != nil {
+		return err
+	}
+	w.writer = csv.NewWriter(w.out)
+	return w.writer.Write(headers)
+}
+
+func (w *csvWriter) WriteRow(values []interface{}) error {
+	if w.writer == nil {
+		return errors.New("Chưa gọi NewSheet")
+	}
+	record := make([]string, len(values))
+	for i, value := range values {
+		switch v := normalizeValue(value).(type) {
+		case nil:
+			record[i] = ""
+		case string:
+			record[i] = v
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	if err := w.writer.Write(record); err != nil {
+		return err
+	}
+	// Flush từng dòng để dữ liệu được gửi ngay cho client
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvWriter) Close() error {
+	if w.writer == nil {
+		return nil
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// xlsxWriter - Ghi XLSX bằng StreamWriter của excelize
+// (dữ liệu lớn được excelize ghi tạm ra đĩa, không giữ toàn bộ trong bộ nhớ)
+type xlsxWriter struct {
+	out         io.Writer
+	file        *excelize.File
+	stream      *excelize.StreamWriter
+	row         int
+	sheetCount  int
+	headerStyle int
+}
+
+func (w *xlsxWriter) NewSheet(name string, headers []string) error {
+	if err := w.flushSheet(); err != nil {
+		return err
+	}
+
+	if w.sheetCount == 0 {
+		// Đổi tên sheet mặc định "Sheet1"
+		if err := w.file.SetSheetName(w.file.GetSheetName(0), name); err != nil {
+			return err
+		}
+		style, err := w.file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+		if err != nil {
+			return err
+		}
+		w.headerStyle = style
+	} else if _, err := w.file.NewSheet(name); err != nil {
+		return err
+	}
+	w.sheetCount++
+
+	stream, err := w.file.NewStreamWriter(name)
+	if err != nil {
+		return err
+	}
+	w.stream = stream
+	w.row = 1
+
+	headerCells := make([]interface{}, len(headers))
+	for i, header := range headers {
+		headerCells[i] = excelize.Cell{StyleID: w.headerStyle, Value: header}
+	}
+	return w.writeCells(headerCells)
+}
+
+func (w *xlsxWriter) WriteRow(values []interface{}) error {
+	if w.stream == nil {
+		return errors.New("Chưa gọi NewSheet")
+	}
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		cells[i] = normalizeValue(value)
+	}
+	return w.writeCells(cells)
+}
+
+func (w *xlsxWriter) writeCells(cells []interface{}) error {
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+	w.row++
+	return w.stream.SetRow(cell, cells)
+}
+
+func (w *xlsxWriter) flushSheet() error {
+	if w.stream == nil {
+		return nil
+	}
+	err := w.stream.Flush()
+	w.stream = nil
+	return err
+}
+
+func (w *xlsxWriter) Close() error {
+	defer w.file.Close()
+	if err := w.flushSheet(); err != nil {
+		return err
+	}
+	_, err := w.file.WriteTo(w.out)
+	return err
+}
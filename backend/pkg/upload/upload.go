@@ -0,0 +1,114 @@
+package upload
+
+// Lưu file upload (multipart) ra đĩa theo một Policy: kiểm tra loại file, dung lượng và đặt tên file ngẫu nhiên
+// Loại file được nhận diện từ nội dung (http.DetectContentType), không tin Content-Type/phần mở rộng do client gửi lên
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Policy - Quy định file được phép upload và nơi lưu
+type Policy struct {
+	Dir          string            // Thư mục lưu file (tự tạo nếu chưa có)
+	MaxSize      int64             // Dung lượng tối đa (bytes)
+	AllowedTypes map[string]string // Content type được phép -> phần mở rộng khi lưu (vd: "image/png" -> ".png")
+	TypeLabel    string            // Mô tả loại file được phép, dùng trong thông báo lỗi (vd: "ảnh (JPEG, PNG, GIF)")
+}
+
+// Error - File không hợp lệ (sai loại, quá lớn, rỗng), Message hiển thị được cho người dùng
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// SavedFile - File đã lưu
+type SavedFile struct {
+	Path        string // Đường dẫn trên đĩa (Dir/Name)
+	Name        string // Tên file đã lưu (ngẫu nhiên, không phải tên gốc)
+	ContentType string // Content type nhận diện từ nội dung
+	Size        int64
+}
+
+// Save kiểm tra file theo policy rồi lưu vào policy.Dir với tên "<prefix>_<timestamp>_<ngẫu nhiên><ext>"
+// Trả về *Error nếu file không hợp lệ
+func Save(file *multipart.FileHeader, policy Policy, prefix string) (*SavedFile, error) {
+	if file.Size <= 0 {
+		return nil, &Error{Message: "File rỗng"}
+	}
+	if file.Size > policy.MaxSize {
+		return nil, &Error{Message: fmt.Sprintf("File không được vượt quá %s", formatSize(policy.MaxSize))}
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	// Nhận diện loại file từ 512 byte đầu
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	contentType := http.DetectContentType(head[:n])
+	ext, ok := policy.AllowedTypes[contentType]
+	if !ok {
+		return nil, &Error{Message: "Chỉ chấp nhận file " + policy.TypeLabel}
+	}
+
+	if err := os.MkdirAll(policy.Dir, 0755); err != nil {
+		return nil, err
+	}
+	name, err := randomName(prefix, ext)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(policy.Dir, name)
+
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+	// Giới hạn số byte ghi (phòng trường hợp Size trong header sai)
+	written, err := io.Copy(dst, io.LimitReader(io.MultiReader(bytes.NewReader(head[:n]), src), policy.MaxSize+1))
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && written > policy.MaxSize {
+		err = &Error{Message: fmt.Sprintf("File không được vượt quá %s", formatSize(policy.MaxSize))}
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	return &SavedFile{Path: path, Name: name, ContentType: contentType, Size: written}, nil
+}
+
+func randomName(prefix, ext string) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + "_" + strconv.FormatInt(time.Now().Unix(), 10) + "_" + hex.EncodeToString(b) + ext, nil
+}
+
+func formatSize(size int64) string {
+	if size >= 1024*1024 && size%(1024*1024) == 0 {
+		return strconv.FormatInt(size/(1024*1024), 10) + "MB"
+	}
+	return strconv.FormatInt(size/1024, 10) + "KB"
+}